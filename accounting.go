@@ -0,0 +1,129 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccountingEntry describes one attempt sent to a third-party API, for cost attribution
+type AccountingEntry struct {
+	// Host is the host of the request URL
+	Host string
+	// Method is the request method
+	Method string
+	// Route is the URL path template, e.g. "/v1/users/{id}", see WithRoute
+	Route string
+	// Caller is the tag set on the request context with WithCaller, empty when none was set
+	Caller string
+	// Status is the response status code, zero when the attempt failed without a response
+	Status int
+	// RequestBytes and ResponseBytes count the body bytes sent and read
+	RequestBytes  int64
+	ResponseBytes int64
+	// Duration runs from sending the request to closing the response body
+	Duration time.Duration
+	// Err is the transport error of the attempt, if any
+	Err error
+}
+
+// callerKey is the context key of the caller tag set by WithCaller
+type callerKey struct{}
+
+// WithCaller tags requests sent with ctx as made on behalf of caller, e.g. an internal team or feature,
+// reported in AccountingEntry.Caller
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the caller tag set by WithCaller, empty when none was set
+func CallerFromContext(ctx context.Context) string {
+	caller, _ := ctx.Value(callerKey{}).(string)
+	return caller
+}
+
+// routeKey is the context key of the route template of a request
+type routeKey struct{}
+
+// routeFromContext returns the route template of a request, defaulting to the path of its URL
+func routeFromContext(req *http.Request) string {
+	if route, ok := req.Context().Value(routeKey{}).(string); ok {
+		return route
+	}
+	return req.URL.EscapedPath()
+}
+
+// routeTemplate returns the path of a request URL before its placeholders are filled
+func routeTemplate(rawURL string) string {
+	if i := strings.IndexAny(rawURL, "?#"); i >= 0 {
+		rawURL = rawURL[:i]
+	}
+	if _, rest, ok := strings.Cut(rawURL, "://"); ok {
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			return rest[i:]
+		}
+		return "/"
+	}
+	return "/" + strings.TrimPrefix(rawURL, "/")
+}
+
+// AccountingMiddleware calls hook once per attempt with its host, route, caller, status, bytes and duration,
+// so platform teams can attribute third-party API costs to the teams and features making the calls:
+//
+//	ctx = httpclient.WithCaller(ctx, "checkout")
+//	err := client.Get(ctx, "/v1/users/{id}", &user, httpclient.WithPathParam("id", id))
+//
+// The route is the URL template when the request uses path parameters or WithRoute, its path otherwise.
+// The hook runs when the response body is closed, or when the attempt fails; it must be safe for concurrent use.
+func AccountingMiddleware(hook func(entry AccountingEntry)) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			entry := AccountingEntry{
+				Host:   req.URL.Host,
+				Method: req.Method,
+				Route:  routeFromContext(req),
+				Caller: CallerFromContext(req.Context()),
+			}
+			if req.ContentLength > 0 {
+				entry.RequestBytes = req.ContentLength
+			}
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				entry.Err, entry.Duration = err, time.Since(start)
+				hook(entry)
+				return resp, err
+			}
+			entry.Status = resp.StatusCode
+			resp.Body = &accountingReader{ReadCloser: resp.Body, entry: entry, start: start, hook: hook}
+			return resp, nil
+		})
+	}
+}
+
+// accountingReader counts response body bytes, reporting the entry when closed
+type accountingReader struct {
+	io.ReadCloser
+	entry AccountingEntry
+	start time.Time
+	hook  func(entry AccountingEntry)
+	once  sync.Once
+}
+
+func (r *accountingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.entry.ResponseBytes += int64(n)
+	return n, err
+}
+
+func (r *accountingReader) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(func() {
+		r.entry.Duration = time.Since(r.start)
+		r.hook(r.entry)
+	})
+	return err
+}
@@ -0,0 +1,71 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAccountingMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/missing") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"pikachu"}`))
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var entries []AccountingEntry
+	client := &Client{BaseURL: server.URL, Middleware: []Middleware{AccountingMiddleware(func(entry AccountingEntry) {
+		mu.Lock()
+		defer mu.Unlock()
+		entries = append(entries, entry)
+	})}}
+	ctx := WithCaller(context.Background(), "pokedex-team")
+
+	var result map[string]string
+	if err := client.Post(ctx, "/pokemon/{id}?lang=en", map[string]int{"level": 5}, &result, WithPathParam("id", "25")); err != nil {
+		t.Fatalf("POST request failed: %v", err)
+	}
+	var status int
+	if err := client.Get(context.Background(), "/missing/1", nil, WithStatus(&status)); err != nil {
+		t.Fatalf("GET request failed: %v", err)
+	}
+	if err := client.Get(context.Background(), "/missing/2", nil, WithStatus(&status), WithRoute("/missing/{n}")); err != nil {
+		t.Fatalf("GET request failed: %v", err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	first := entries[0]
+	if first.Route != "/pokemon/{id}" || first.Caller != "pokedex-team" || first.Method != http.MethodPost || first.Host != strings.TrimPrefix(server.URL, "http://") {
+		t.Errorf("unexpected entry %+v", first)
+	}
+	if first.Status != http.StatusOK || first.RequestBytes != int64(len(`{"level":5}`)) || first.ResponseBytes != int64(len(`{"name":"pikachu"}`)) || first.Duration <= 0 {
+		t.Errorf("unexpected counters %+v", first)
+	}
+	if entries[1].Route != "/missing/1" || entries[1].Status != http.StatusNotFound || entries[1].Caller != "" {
+		t.Errorf("unexpected entry %+v", entries[1])
+	}
+	if entries[2].Route != "/missing/{n}" {
+		t.Errorf("expected route /missing/{n}, got %s", entries[2].Route)
+	}
+}
+
+func TestRouteTemplate(t *testing.T) {
+	for rawURL, want := range map[string]string{
+		"https://api.example.com/v1/users/{id}?x=1": "/v1/users/{id}",
+		"https://api.example.com":                   "/",
+		"users/{id}#top":                            "/users/{id}",
+	} {
+		if got := routeTemplate(rawURL); got != want {
+			t.Errorf("expected %s for %s, got %s", want, rawURL, got)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package httpclient
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrUnsupportedChallenge is returned when an Authenticator cannot answer a challenge, e.g. a Digest
+// challenge with an unknown algorithm
+var ErrUnsupportedChallenge = errors.New("unsupported authentication challenge")
+
+// DefaultMaxAuthRounds is how many challenges ChallengeMiddleware answers per request, enough for
+// the three legs of NTLM
+const DefaultMaxAuthRounds = 3
+
+// Challenge is an authentication challenge from a WWW-Authenticate or Proxy-Authenticate header
+type Challenge struct {
+	// Scheme is the authentication scheme, e.g. "Basic" or "Digest"
+	Scheme string
+	// Params holds the auth-params with lowercase names, e.g. "realm" and "nonce"
+	Params map[string]string
+	// Token68 is the opaque token of schemes such as Negotiate and NTLM
+	Token68 string
+}
+
+// Authenticator answers authentication challenges of one scheme
+type Authenticator interface {
+	// Scheme returns the authentication scheme answered, e.g. "Digest"
+	Scheme() string
+	// Authenticate returns the credentials answering challenge for req, i.e. the Authorization header value
+	Authenticate(req *http.Request, challenge Challenge) (string, error)
+}
+
+// ChallengeMiddleware answers 401 responses carrying a WWW-Authenticate header with the first authenticator,
+// in the given order, matching one of the offered schemes and replays the request with its credentials.
+//
+// A request is replayed once per challenge, or up to DefaultMaxAuthRounds times for multi-leg schemes whose
+// challenges carry a token, and only when its body can be replayed through GetBody. Only challenges of the
+// origin the request was sent to are answered, so credentials do not follow redirects to other origins.
+func ChallengeMiddleware(authenticators ...Authenticator) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return challengeTransport(next, http.StatusUnauthorized, "WWW-Authenticate", "Authorization", authenticators, true)
+	}
+}
+
+// challengeTransport answers responses with status carrying challenges in challengeHeader, sending the
+// credentials in authHeader, only for the origin of the request sent by the client when originOnly is set
+func challengeTransport(next http.RoundTripper, status int, challengeHeader, authHeader string, authenticators []Authenticator, originOnly bool) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+		if originOnly && !sentOrigin(req) {
+			return next.RoundTrip(req)
+		}
+
+		resp, err := next.RoundTrip(req)
+		var chosen Authenticator
+		for round := 0; err == nil && replayable && round < DefaultMaxAuthRounds && resp.StatusCode == status; round++ {
+			auth, challenge, ok := selectChallenge(resp.Header.Values(challengeHeader), authenticators, chosen)
+			// A bare challenge after answering means the credentials were rejected
+			if !ok || (chosen != nil && challenge.Token68 == "" && !strings.EqualFold(challenge.Params["stale"], "true")) {
+				break
+			}
+			chosen = auth
+
+			credentials, authErr := auth.Authenticate(req, challenge)
+			if authErr != nil {
+				_ = resp.Body.Close()
+				return nil, fmt.Errorf("failed to answer %s challenge: %w", auth.Scheme(), authErr)
+			}
+			authReq := req.Clone(req.Context())
+			if req.Body != nil && req.Body != http.NoBody {
+				body, bodyErr := req.GetBody()
+				if bodyErr != nil {
+					_ = resp.Body.Close()
+					return nil, bodyErr
+				}
+				authReq.Body = body
+			}
+			authReq.Header.Set(authHeader, credentials)
+
+			// Drain the body so the connection can be reused, which connection-bound schemes rely on
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			_ = resp.Body.Close()
+			resp, err = next.RoundTrip(authReq)
+		}
+		return resp, err
+	})
+}
+
+// sentOrigin reports whether req has the origin of the request sent by the client, rather than of a redirect.
+// Requests sent without a Client have no other origin.
+func sentOrigin(req *http.Request) bool {
+	stats, ok := req.Context().Value(requestStatsKey{}).(*requestStats)
+	return !ok || stats == nil || stats.url == nil || sameOrigin(stats.url, req.URL)
+}
+
+// selectChallenge returns the first of authenticators answering one of the challenges in values,
+// only considering chosen once a scheme was picked
+func selectChallenge(values []string, authenticators []Authenticator, chosen Authenticator) (Authenticator, Challenge, bool) {
+	var challenges []Challenge
+	for _, value := range values {
+		challenges = append(challenges, ParseChallenges(value)...)
+	}
+	for _, auth := range authenticators {
+		if chosen != nil && auth != chosen {
+			continue
+		}
+		for _, challenge := range challenges {
+			if strings.EqualFold(challenge.Scheme, auth.Scheme()) {
+				return auth, challenge, true
+			}
+		}
+	}
+	return nil, Challenge{}, false
+}
+
+// ParseChallenges parses the challenges of a WWW-Authenticate or Proxy-Authenticate header value
+// as described in RFC 7235, e.g. `Digest realm="api", nonce="abc", Basic realm="api"`
+func ParseChallenges(header string) []Challenge {
+	p := &challengeParser{s: header}
+	var challenges []Challenge
+	for {
+		p.skip(", \t")
+		if p.done() {
+			return challenges
+		}
+		scheme := p.token()
+		if scheme == "" {
+			// Skip a malformed character rather than giving up on the rest
+			p.i++
+			continue
+		}
+
+		challenge := Challenge{Scheme: scheme, Params: map[string]string{}}
+		for {
+			start := p.i
+			p.skip(", \t")
+			if p.done() {
+				break
+			}
+			if name, value, ok := p.param(); ok {
+				challenge.Params[strings.ToLower(name)] = value
+				continue
+			}
+			p.i = start
+			p.skip(" \t")
+			if token, ok := p.token68(); ok && len(challenge.Params) == 0 && challenge.Token68 == "" {
+				challenge.Token68 = token
+				continue
+			}
+			// Not a parameter, so it starts the next challenge
+			p.i = start
+			break
+		}
+		challenges = append(challenges, challenge)
+	}
+}
+
+type challengeParser struct {
+	s string
+	i int
+}
+
+func (p *challengeParser) done() bool {
+	return p.i >= len(p.s)
+}
+
+func (p *challengeParser) skip(chars string) {
+	for !p.done() && strings.IndexByte(chars, p.s[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+func (p *challengeParser) token() string {
+	start := p.i
+	for !p.done() && isTokenChar(p.s[p.i]) {
+		p.i++
+	}
+	return p.s[start:p.i]
+}
+
+// param parses name=value where value is a token or quoted string, leaving the position untouched otherwise
+func (p *challengeParser) param() (string, string, bool) {
+	start := p.i
+	name := p.token()
+	p.skip(" \t")
+	if name == "" || p.done() || p.s[p.i] != '=' {
+		p.i = start
+		return "", "", false
+	}
+	p.i++
+	p.skip(" \t")
+	if !p.done() && p.s[p.i] == '"' {
+		if value, ok := p.quoted(); ok {
+			return name, value, true
+		}
+	} else if value := p.token(); value != "" && (p.done() || p.s[p.i] != '=') {
+		return name, value, true
+	}
+	p.i = start
+	return "", "", false
+}
+
+func (p *challengeParser) quoted() (string, bool) {
+	var b strings.Builder
+	for p.i++; !p.done(); p.i++ {
+		switch c := p.s[p.i]; c {
+		case '"':
+			p.i++
+			return b.String(), true
+		case '\\':
+			if p.i+1 < len(p.s) {
+				p.i++
+				b.WriteByte(p.s[p.i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", false
+}
+
+// token68 parses a token68 followed by the end of the challenge
+func (p *challengeParser) token68() (string, bool) {
+	start := p.i
+	for !p.done() && (isTokenChar(p.s[p.i]) || p.s[p.i] == '/') {
+		p.i++
+	}
+	if p.i == start {
+		return "", false
+	}
+	for !p.done() && p.s[p.i] == '=' {
+		p.i++
+	}
+	token := p.s[start:p.i]
+	p.skip(" \t")
+	if !p.done() && p.s[p.i] != ',' {
+		p.i = start
+		return "", false
+	}
+	return token, true
+}
+
+func isTokenChar(c byte) bool {
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
+// BasicAuthenticator answers Basic challenges with a username and password. The credentials are sent in clear,
+// so challenges of plain HTTP requests are refused with ErrInsecureScheme unless AllowHTTP is set.
+type BasicAuthenticator struct {
+	Username string
+	Password string
+	// AllowHTTP answers challenges of plain HTTP requests too, e.g. of a local server or a proxy
+	AllowHTTP bool
+}
+
+// Scheme returns "Basic"
+func (a *BasicAuthenticator) Scheme() string {
+	return "Basic"
+}
+
+// Authenticate returns the base64 encoded credentials
+func (a *BasicAuthenticator) Authenticate(req *http.Request, _ Challenge) (string, error) {
+	if !a.AllowHTTP && !strings.EqualFold(req.URL.Scheme, "https") {
+		return "", fmt.Errorf("%w: refusing to send Basic credentials over %s", ErrInsecureScheme, req.URL.Scheme)
+	}
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(a.Username+":"+a.Password)), nil
+}
+
+// BearerAuthenticator answers Bearer challenges with a token from Source, invalidating the cached token
+// first when Source has an Invalidate method, as CachedCredentials does, so expired tokens are refreshed
+type BearerAuthenticator struct {
+	Source CredentialSource
+}
+
+// Scheme returns "Bearer"
+func (a *BearerAuthenticator) Scheme() string {
+	return "Bearer"
+}
+
+// Authenticate fetches a fresh token from Source
+func (a *BearerAuthenticator) Authenticate(req *http.Request, _ Challenge) (string, error) {
+	if invalidator, ok := a.Source.(interface{ Invalidate() }); ok {
+		invalidator.Invalidate()
+	}
+	cred, err := a.Source.Credential(req.Context())
+	if err != nil {
+		return "", fmt.Errorf("failed to get credential: %w", err)
+	}
+	return "Bearer " + cred.Token, nil
+}
+
+// DigestAuthenticator answers Digest challenges as described in RFC 7616, supporting the MD5 and SHA-256
+// algorithms, their -sess variants and the auth quality of protection
+type DigestAuthenticator struct {
+	Username string
+	Password string
+
+	// cnonce generates client nonces, replaced in tests
+	cnonce func() string
+}
+
+// Scheme returns "Digest"
+func (a *DigestAuthenticator) Scheme() string {
+	return "Digest"
+}
+
+// Authenticate computes the digest response for req
+func (a *DigestAuthenticator) Authenticate(req *http.Request, challenge Challenge) (string, error) {
+	algorithm := challenge.Params["algorithm"]
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+	var newHash func() hash.Hash
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("%w: digest algorithm %s", ErrUnsupportedChallenge, algorithm)
+	}
+	digest := func(parts ...string) string {
+		h := newHash()
+		_, _ = io.WriteString(h, strings.Join(parts, ":"))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	var qop string
+	if offered, ok := challenge.Params["qop"]; ok {
+		for _, option := range strings.Split(offered, ",") {
+			if strings.TrimSpace(option) == "auth" {
+				qop = "auth"
+			}
+		}
+		if qop == "" {
+			return "", fmt.Errorf("%w: digest qop %s", ErrUnsupportedChallenge, offered)
+		}
+	}
+
+	realm, nonce := challenge.Params["realm"], challenge.Params["nonce"]
+	cnonce := a.newCnonce()
+	const nc = "00000001"
+	uri := req.URL.RequestURI()
+
+	ha1 := digest(a.Username, realm, a.Password)
+	if strings.HasSuffix(strings.ToUpper(algorithm), "-SESS") {
+		ha1 = digest(ha1, nonce, cnonce)
+	}
+	ha2 := digest(req.Method, uri)
+	var response string
+	if qop != "" {
+		response = digest(ha1, nonce, nc, cnonce, qop, ha2)
+	} else {
+		response = digest(ha1, nonce, ha2)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `Digest username=%s, realm=%s, nonce=%s, uri=%s, algorithm=%s, response=%s`,
+		quote(a.Username), quote(realm), quote(nonce), quote(uri), algorithm, quote(response))
+	if opaque, ok := challenge.Params["opaque"]; ok {
+		fmt.Fprintf(&b, ", opaque=%s", quote(opaque))
+	}
+	if qop != "" {
+		fmt.Fprintf(&b, ", qop=%s, nc=%s, cnonce=%s", qop, nc, quote(cnonce))
+	}
+	return b.String(), nil
+}
+
+func (a *DigestAuthenticator) newCnonce() string {
+	if a.cnonce != nil {
+		return a.cnonce()
+	}
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// quote returns s as an HTTP quoted string
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// NegotiateAuthenticator answers Negotiate challenges with raw tokens from a security context,
+// see the negotiate package for SPNEGO framing over Kerberos
+type NegotiateAuthenticator struct {
+	// Token returns the base64 decoded token answering the server token, empty on the first leg
+	Token func(req *http.Request, serverToken []byte) ([]byte, error)
+}
+
+// Scheme returns "Negotiate"
+func (a *NegotiateAuthenticator) Scheme() string {
+	return "Negotiate"
+}
+
+// Authenticate exchanges the challenge token for the next client token
+func (a *NegotiateAuthenticator) Authenticate(req *http.Request, challenge Challenge) (string, error) {
+	var serverToken []byte
+	if challenge.Token68 != "" {
+		var err error
+		if serverToken, err = base64.StdEncoding.DecodeString(challenge.Token68); err != nil {
+			return "", fmt.Errorf("failed to decode negotiate token: %w", err)
+		}
+	}
+	token, err := a.Token(req, serverToken)
+	if err != nil {
+		return "", err
+	}
+	return "Negotiate " + base64.StdEncoding.EncodeToString(token), nil
+}
@@ -0,0 +1,220 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseChallenges(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []Challenge
+	}{
+		{`Basic realm="pokedex"`, []Challenge{{Scheme: "Basic", Params: map[string]string{"realm": "pokedex"}}}},
+		{
+			`Digest realm="api", qop="auth, auth-int", Nonce=abc, Basic realm="a \"b\""`,
+			[]Challenge{
+				{Scheme: "Digest", Params: map[string]string{"realm": "api", "qop": "auth, auth-int", "nonce": "abc"}},
+				{Scheme: "Basic", Params: map[string]string{"realm": `a "b"`}},
+			},
+		},
+		{
+			`Negotiate, NTLM TlRMTVNTUAACAAAA+/==, Bearer`,
+			[]Challenge{
+				{Scheme: "Negotiate", Params: map[string]string{}},
+				{Scheme: "NTLM", Params: map[string]string{}, Token68: "TlRMTVNTUAACAAAA+/=="},
+				{Scheme: "Bearer", Params: map[string]string{}},
+			},
+		},
+		{`Bearer error="invalid_token", error_description="expired"`, []Challenge{
+			{Scheme: "Bearer", Params: map[string]string{"error": "invalid_token", "error_description": "expired"}},
+		}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := ParseChallenges(tt.header); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseChallenges(%q): expected %+v, got %+v", tt.header, tt.want, got)
+		}
+	}
+}
+
+func TestChallengeMiddleware(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("basic", func(t *testing.T) {
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			if user, password, ok := r.BasicAuth(); !ok || user != "ash" || password != "pikachu" {
+				w.Header().Set("WWW-Authenticate", `Digest realm="pokedex", nonce="abc", algorithm=UNKNOWN, Basic realm="pokedex"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer server.Close()
+
+		client := &Client{Middleware: []Middleware{ChallengeMiddleware(&BasicAuthenticator{Username: "ash", Password: "pikachu", AllowHTTP: true})}}
+		if err := client.Post(ctx, server.URL, map[string]string{"name": "pikachu"}, nil); err != nil {
+			t.Fatalf("POST request failed: %v", err)
+		}
+		if attempts != 2 {
+			t.Errorf("expected 2 attempts, got %d", attempts)
+		}
+
+		// Rejected credentials are not replayed again
+		atomic.StoreInt32(&attempts, 0)
+		client = &Client{Middleware: []Middleware{ChallengeMiddleware(&BasicAuthenticator{Username: "ash", Password: "raichu", AllowHTTP: true})}}
+		if err := client.Get(ctx, server.URL, nil); err == nil {
+			t.Error("expected error with wrong password")
+		}
+		if attempts != 2 {
+			t.Errorf("expected 2 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("basic over plain http", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, _, ok := r.BasicAuth(); ok {
+				t.Error("expected no credentials over plain http")
+			}
+			w.Header().Set("WWW-Authenticate", `Basic realm="pokedex"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		client := &Client{Middleware: []Middleware{ChallengeMiddleware(&BasicAuthenticator{Username: "ash", Password: "pikachu"})}}
+		if err := client.Get(ctx, server.URL, nil); !errors.Is(err, ErrInsecureScheme) {
+			t.Errorf("expected ErrInsecureScheme, got %v", err)
+		}
+	})
+
+	t.Run("cross-origin redirect", func(t *testing.T) {
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "" {
+				t.Errorf("expected no credentials on another origin, got %q", r.Header.Get("Authorization"))
+			}
+			w.Header().Set("WWW-Authenticate", `Basic realm="phishing"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer other.Close()
+		server := httptest.NewServer(http.RedirectHandler(other.URL, http.StatusFound))
+		defer server.Close()
+
+		client := &Client{Middleware: []Middleware{ChallengeMiddleware(&BasicAuthenticator{Username: "ash", Password: "pikachu", AllowHTTP: true})}}
+		var httpErr *HTTPError
+		if err := client.Get(ctx, server.URL, nil); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected 401 HTTPError, got %v", err)
+		}
+	})
+
+	t.Run("bearer refresh", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer token-2" {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer server.Close()
+
+		var fetches int32
+		source := NewCachedCredentials(CredentialSourceFunc(func(ctx context.Context) (*Credential, error) {
+			n := atomic.AddInt32(&fetches, 1)
+			return &Credential{Token: "token-" + string(rune('0'+n))}, nil
+		}), 0)
+		client := &Client{Middleware: []Middleware{
+			CredentialMiddleware(source, nil),
+			ChallengeMiddleware(&BearerAuthenticator{Source: source}),
+		}}
+		if err := client.Get(ctx, server.URL, nil); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if fetches != 2 {
+			t.Errorf("expected 2 fetches, got %d", fetches)
+		}
+	})
+
+	t.Run("multi-leg", func(t *testing.T) {
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			switch r.Header.Get("Authorization") {
+			case "":
+				w.Header().Set("WWW-Authenticate", "Negotiate")
+			case "Negotiate " + base64.StdEncoding.EncodeToString([]byte("hello")):
+				w.Header().Set("WWW-Authenticate", "Negotiate "+base64.StdEncoding.EncodeToString([]byte("challenge")))
+			case "Negotiate " + base64.StdEncoding.EncodeToString([]byte("answer:challenge")):
+				_, _ = w.Write([]byte(`{}`))
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		client := &Client{Middleware: []Middleware{ChallengeMiddleware(&NegotiateAuthenticator{
+			Token: func(req *http.Request, serverToken []byte) ([]byte, error) {
+				if serverToken == nil {
+					return []byte("hello"), nil
+				}
+				return []byte("answer:" + string(serverToken)), nil
+			},
+		})}}
+		if err := client.Get(ctx, server.URL, nil); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if attempts != 3 {
+			t.Errorf("expected 3 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("unsupported challenge", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("WWW-Authenticate", `Digest realm="pokedex", nonce="abc", qop="auth-int"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		client := &Client{Middleware: []Middleware{ChallengeMiddleware(&DigestAuthenticator{Username: "ash"})}}
+		if err := client.Get(ctx, server.URL, nil); !errors.Is(err, ErrUnsupportedChallenge) {
+			t.Errorf("expected ErrUnsupportedChallenge, got %v", err)
+		}
+	})
+}
+
+func TestDigestAuthenticator(t *testing.T) {
+	// Example from RFC 7616 section 3.9.1
+	challenge := Challenge{Scheme: "Digest", Params: map[string]string{
+		"realm":  "http-auth@example.org",
+		"qop":    "auth, auth-int",
+		"nonce":  "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+		"opaque": "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS",
+	}}
+	auth := &DigestAuthenticator{Username: "Mufasa", Password: "Circle of Life", cnonce: func() string {
+		return "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+	}}
+	req := httptest.NewRequest(http.MethodGet, "http://www.example.org/dir/index.html", nil)
+
+	for algorithm, response := range map[string]string{
+		"MD5":     "8ca523f5e9506fed4657c9700eebdbec",
+		"SHA-256": "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1",
+	} {
+		challenge.Params["algorithm"] = algorithm
+		credentials, err := auth.Authenticate(req, challenge)
+		if err != nil {
+			t.Fatalf("Authenticate failed: %v", err)
+		}
+		got := ParseChallenges(credentials)
+		if len(got) != 1 || got[0].Params["response"] != response || got[0].Params["uri"] != "/dir/index.html" ||
+			got[0].Params["qop"] != "auth" || got[0].Params["nc"] != "00000001" || got[0].Params["opaque"] != challenge.Params["opaque"] {
+			t.Errorf("%s: unexpected credentials %s", algorithm, credentials)
+		}
+	}
+}
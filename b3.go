@@ -0,0 +1,155 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// B3 is a Zipkin trace context propagated in B3 headers
+type B3 struct {
+	// TraceID is the 16 or 32 hex character trace identifier
+	TraceID string
+	// SpanID is the 16 hex character identifier of the current span
+	SpanID string
+	// ParentSpanID is the identifier of the parent span, empty for root spans
+	ParentSpanID string
+	// Sampled is the sampling decision, nil defers it to the receiver
+	Sampled *bool
+	// Debug forces sampling and flags the trace for debugging
+	Debug bool
+}
+
+// b3Key is the context key of the trace context set by WithB3
+type b3Key struct{}
+
+// WithB3 returns a context carrying the trace context of the current span, propagated by B3Middleware
+func WithB3(ctx context.Context, b3 B3) context.Context {
+	return context.WithValue(ctx, b3Key{}, b3)
+}
+
+// B3FromContext returns the trace context set by WithB3
+func B3FromContext(ctx context.Context) (B3, bool) {
+	b3, ok := ctx.Value(b3Key{}).(B3)
+	return b3, ok
+}
+
+// ParseB3 extracts the trace context of incoming request headers, in the multi-header X-B3-* form or the
+// single b3 header form, so servers can continue the trace in their outgoing calls:
+//
+//	if b3, ok := httpclient.ParseB3(r.Header); ok {
+//	    ctx = httpclient.WithB3(ctx, b3)
+//	}
+func ParseB3(header http.Header) (B3, bool) {
+	if single := header.Get("b3"); single != "" {
+		return parseSingleB3(single)
+	}
+	b3 := B3{
+		TraceID:      header.Get("X-B3-TraceId"),
+		SpanID:       header.Get("X-B3-SpanId"),
+		ParentSpanID: header.Get("X-B3-ParentSpanId"),
+		Debug:        header.Get("X-B3-Flags") == "1",
+	}
+	switch header.Get("X-B3-Sampled") {
+	case "1", "true":
+		b3.Sampled = boolPtr(true)
+	case "0", "false":
+		b3.Sampled = boolPtr(false)
+	}
+	return b3, b3.TraceID != "" && b3.SpanID != ""
+}
+
+// parseSingleB3 parses the b3 header, {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}
+func parseSingleB3(value string) (B3, bool) {
+	fields := strings.Split(value, "-")
+	var b3 B3
+	if len(fields) == 1 {
+		// A sampling decision only, without trace
+		return b3, false
+	}
+	b3.TraceID, b3.SpanID = fields[0], fields[1]
+	if len(fields) > 2 {
+		switch fields[2] {
+		case "1":
+			b3.Sampled = boolPtr(true)
+		case "0":
+			b3.Sampled = boolPtr(false)
+		case "d":
+			b3.Debug = true
+		}
+	}
+	if len(fields) > 3 {
+		b3.ParentSpanID = fields[3]
+	}
+	return b3, b3.TraceID != "" && b3.SpanID != ""
+}
+
+// B3Middleware propagates the trace context of the request context, set with WithB3, in B3 headers for
+// Zipkin-style tracing. Every attempt is a child span of the context span with a new span ID, and requests
+// without trace context are left alone. With singleHeader, the compact b3 header is sent instead of the
+// X-B3-* headers.
+func B3Middleware(singleHeader bool) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			parent, ok := B3FromContext(req.Context())
+			if !ok || req.Header.Get("X-B3-TraceId") != "" || req.Header.Get("b3") != "" {
+				return next.RoundTrip(req)
+			}
+			child := parent
+			child.SpanID, child.ParentSpanID = newSpanID(), parent.SpanID
+
+			req = req.Clone(req.Context())
+			if singleHeader {
+				req.Header.Set("b3", child.singleHeader())
+			} else {
+				child.setHeaders(req.Header)
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+func (b3 B3) setHeaders(header http.Header) {
+	header.Set("X-B3-TraceId", b3.TraceID)
+	header.Set("X-B3-SpanId", b3.SpanID)
+	if b3.ParentSpanID != "" {
+		header.Set("X-B3-ParentSpanId", b3.ParentSpanID)
+	}
+	switch {
+	case b3.Debug:
+		header.Set("X-B3-Flags", "1")
+	case b3.Sampled != nil && *b3.Sampled:
+		header.Set("X-B3-Sampled", "1")
+	case b3.Sampled != nil:
+		header.Set("X-B3-Sampled", "0")
+	}
+}
+
+func (b3 B3) singleHeader() string {
+	value := b3.TraceID + "-" + b3.SpanID
+	switch {
+	case b3.Debug:
+		value += "-d"
+	case b3.Sampled != nil && *b3.Sampled:
+		value += "-1"
+	case b3.Sampled != nil:
+		value += "-0"
+	}
+	if b3.ParentSpanID != "" && (b3.Debug || b3.Sampled != nil) {
+		value += "-" + b3.ParentSpanID
+	}
+	return value
+}
+
+// newSpanID returns a random 64-bit span identifier in hex
+func newSpanID() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
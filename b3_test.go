@@ -0,0 +1,72 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestB3Middleware(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	sampled := true
+	parent := B3{TraceID: "463ac35c9f6413ad48485a3953bb6124", SpanID: "a2fb4a1d1a96d312", Sampled: &sampled}
+	ctx := WithB3(context.Background(), parent)
+
+	t.Run("multi header", func(t *testing.T) {
+		client := &Client{Middleware: []Middleware{B3Middleware(false)}}
+		if err := client.Get(ctx, server.URL, nil); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if received.Get("X-B3-TraceId") != parent.TraceID || received.Get("X-B3-ParentSpanId") != parent.SpanID || received.Get("X-B3-Sampled") != "1" {
+			t.Errorf("unexpected headers %v", received)
+		}
+		if span := received.Get("X-B3-SpanId"); len(span) != 16 || span == parent.SpanID {
+			t.Errorf("expected a new child span ID, got %q", span)
+		}
+
+		b3, ok := ParseB3(received)
+		if !ok || b3.TraceID != parent.TraceID || b3.ParentSpanID != parent.SpanID || b3.Sampled == nil || !*b3.Sampled {
+			t.Errorf("unexpected parsed trace context %+v", b3)
+		}
+	})
+
+	t.Run("single header", func(t *testing.T) {
+		client := &Client{Middleware: []Middleware{B3Middleware(true)}}
+		if err := client.Get(ctx, server.URL, nil); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if received.Get("X-B3-TraceId") != "" {
+			t.Errorf("expected no multi headers, got %v", received)
+		}
+		b3, ok := ParseB3(received)
+		if !ok || b3.TraceID != parent.TraceID || b3.ParentSpanID != parent.SpanID || b3.Sampled == nil || !*b3.Sampled {
+			t.Errorf("unexpected b3 header %q", received.Get("b3"))
+		}
+	})
+
+	t.Run("without trace context", func(t *testing.T) {
+		client := &Client{Middleware: []Middleware{B3Middleware(false)}}
+		if err := client.Get(context.Background(), server.URL, nil); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if _, ok := ParseB3(received); ok {
+			t.Errorf("expected no B3 headers, got %v", received)
+		}
+	})
+}
+
+func TestParseB3(t *testing.T) {
+	b3, ok := ParseB3(http.Header{"B3": {"80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-d"}})
+	if !ok || !b3.Debug || b3.ParentSpanID != "" {
+		t.Errorf("unexpected trace context %+v", b3)
+	}
+	if _, ok := ParseB3(http.Header{"B3": {"0"}}); ok {
+		t.Error("expected a sampling-only header to carry no trace context")
+	}
+}
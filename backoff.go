@@ -0,0 +1,113 @@
+package httpclient
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Backoff computes the delay before the next attempt. attempt is the number of attempts made so far,
+// starting at 1, and resp or err describe the last one.
+type Backoff interface {
+	NextDelay(attempt int, resp *http.Response, err error) time.Duration
+}
+
+// BackoffFunc adapts a function to the Backoff interface
+type BackoffFunc func(attempt int, resp *http.Response, err error) time.Duration
+
+// NextDelay calls f(attempt, resp, err)
+func (f BackoffFunc) NextDelay(attempt int, resp *http.Response, err error) time.Duration {
+	return f(attempt, resp, err)
+}
+
+// Defaults of the built-in backoffs
+const (
+	DefaultBackoffBase = 100 * time.Millisecond
+	DefaultBackoffMax  = 5 * time.Second
+)
+
+// ExponentialBackoff multiplies the delay by Multiplier after every attempt, optionally with full jitter
+type ExponentialBackoff struct {
+	// Base is the first delay, defaults to DefaultBackoffBase
+	Base time.Duration
+	// Max caps the delay, defaults to DefaultBackoffMax
+	Max time.Duration
+	// Multiplier grows the delay, defaults to 2
+	Multiplier float64
+	// Jitter picks a random delay between zero and the computed one, spreading retries of many clients
+	Jitter bool
+}
+
+// NextDelay implements Backoff
+func (b ExponentialBackoff) NextDelay(attempt int, _ *http.Response, _ error) time.Duration {
+	base, max := backoffBounds(b.Base, b.Max)
+	multiplier := b.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+	delay := capDelay(float64(base)*math.Pow(multiplier, float64(attempt-1)), max)
+	if b.Jitter {
+		delay = time.Duration(rand.Int63n(int64(delay) + 1))
+	}
+	return delay
+}
+
+// LinearBackoff adds Step to the delay after every attempt
+type LinearBackoff struct {
+	// Step is the first delay and the increment, defaults to DefaultBackoffBase
+	Step time.Duration
+	// Max caps the delay, defaults to DefaultBackoffMax
+	Max time.Duration
+}
+
+// NextDelay implements Backoff
+func (b LinearBackoff) NextDelay(attempt int, _ *http.Response, _ error) time.Duration {
+	step, max := backoffBounds(b.Step, b.Max)
+	return capDelay(float64(step)*float64(attempt), max)
+}
+
+// ConstantBackoff waits the same delay before every retry
+type ConstantBackoff time.Duration
+
+// NextDelay implements Backoff
+func (b ConstantBackoff) NextDelay(int, *http.Response, error) time.Duration {
+	return time.Duration(b)
+}
+
+// DecorrelatedJitterBackoff picks a random delay between Base and three times the previous upper bound,
+// the "decorrelated jitter" curve, which spreads retries well while still growing. As a Backoff is shared
+// by concurrent requests, the previous bound is derived from the attempt number rather than remembered.
+type DecorrelatedJitterBackoff struct {
+	// Base is the minimum delay, defaults to DefaultBackoffBase
+	Base time.Duration
+	// Max caps the delay, defaults to DefaultBackoffMax
+	Max time.Duration
+}
+
+// NextDelay implements Backoff
+func (b DecorrelatedJitterBackoff) NextDelay(attempt int, _ *http.Response, _ error) time.Duration {
+	base, max := backoffBounds(b.Base, b.Max)
+	upper := capDelay(float64(base)*math.Pow(3, float64(attempt)), max)
+	if upper <= base {
+		return upper
+	}
+	return base + time.Duration(rand.Int63n(int64(upper-base)+1))
+}
+
+func backoffBounds(base, max time.Duration) (time.Duration, time.Duration) {
+	if base <= 0 {
+		base = DefaultBackoffBase
+	}
+	if max <= 0 {
+		max = DefaultBackoffMax
+	}
+	return base, max
+}
+
+func capDelay(delay float64, max time.Duration) time.Duration {
+	if delay > float64(max) || math.IsInf(delay, 0) || math.IsNaN(delay) {
+		return max
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,83 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	t.Run("exponential", func(t *testing.T) {
+		b := ExponentialBackoff{Base: 100 * time.Millisecond, Max: time.Second}
+		want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+		for i, w := range want {
+			if got := b.NextDelay(i+1, nil, nil); got != w {
+				t.Errorf("attempt %d: expected %v, got %v", i+1, w, got)
+			}
+		}
+	})
+
+	t.Run("exponential with jitter", func(t *testing.T) {
+		b := ExponentialBackoff{Base: 100 * time.Millisecond, Jitter: true}
+		for i := 0; i < 100; i++ {
+			if got := b.NextDelay(3, nil, nil); got < 0 || got > 400*time.Millisecond {
+				t.Fatalf("expected delay within [0, 400ms], got %v", got)
+			}
+		}
+	})
+
+	t.Run("linear", func(t *testing.T) {
+		b := LinearBackoff{Step: 50 * time.Millisecond, Max: 120 * time.Millisecond}
+		want := []time.Duration{50 * time.Millisecond, 100 * time.Millisecond, 120 * time.Millisecond}
+		for i, w := range want {
+			if got := b.NextDelay(i+1, nil, nil); got != w {
+				t.Errorf("attempt %d: expected %v, got %v", i+1, w, got)
+			}
+		}
+	})
+
+	t.Run("constant", func(t *testing.T) {
+		if got := ConstantBackoff(time.Second).NextDelay(7, nil, nil); got != time.Second {
+			t.Errorf("expected 1s, got %v", got)
+		}
+	})
+
+	t.Run("decorrelated jitter", func(t *testing.T) {
+		b := DecorrelatedJitterBackoff{Base: 100 * time.Millisecond, Max: 2 * time.Second}
+		for attempt := 1; attempt <= 5; attempt++ {
+			for i := 0; i < 50; i++ {
+				got := b.NextDelay(attempt, nil, nil)
+				if got < 100*time.Millisecond || got > 2*time.Second {
+					t.Fatalf("attempt %d: expected delay within [100ms, 2s], got %v", attempt, got)
+				}
+			}
+		}
+	})
+
+	t.Run("used by retries", func(t *testing.T) {
+		server, _ := flakyServer(t, 2, http.StatusBadGateway)
+		var attempts []int
+		client := &Client{MaxRetries: 2, Backoff: BackoffFunc(func(attempt int, resp *http.Response, err error) time.Duration {
+			attempts = append(attempts, attempt)
+			return 0
+		})}
+		// flakyServer sends Retry-After, strip it so the backoff is consulted
+		client.Middleware = []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				resp, err := next.RoundTrip(req)
+				if resp != nil {
+					resp.Header.Del("Retry-After")
+				}
+				return resp, err
+			})
+		}}
+
+		if err := client.Get(context.Background(), server.URL, nil); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+			t.Errorf("expected backoff for attempts [1 2], got %v", attempts)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultBatchConcurrency is the number of requests Batch runs in parallel when Client.BatchConcurrency is not set
+const DefaultBatchConcurrency = 10
+
+// BatchRequest describes a single request executed by Batch
+type BatchRequest struct {
+	Method  string
+	URL     string
+	Body    interface{}
+	Result  interface{}
+	Options []Option
+	// Err is set to the outcome of the request once Batch returns
+	Err error
+}
+
+// Batch executes requests concurrently with at most BatchConcurrency requests in flight.
+//
+// Responses are unmarshalled into each request's Result and failures are stored in its Err field.
+// Requests that have not started when ctx is done are not sent and fail with the context error.
+// The returned error is a *MultiError listing the failures, nil when every request succeeded.
+func (c *Client) Batch(ctx context.Context, requests ...*BatchRequest) error {
+	workers := c.BatchConcurrency
+	if workers <= 0 {
+		workers = DefaultBatchConcurrency
+	}
+	if workers > len(requests) {
+		workers = len(requests)
+	}
+
+	jobs := make(chan *BatchRequest)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for req := range jobs {
+				if err := ctx.Err(); err != nil {
+					req.Err = err
+					continue
+				}
+				req.Err = c.Do(ctx, req.Method, req.URL, req.Body, req.Result, req.Options...)
+			}
+		}()
+	}
+	for _, req := range requests {
+		jobs <- req
+	}
+	close(jobs)
+	wg.Wait()
+
+	var failures []*BatchError
+	for i, req := range requests {
+		if req.Err != nil {
+			failures = append(failures, &BatchError{Index: i, Method: req.Method, URL: req.URL, Err: req.Err})
+		}
+	}
+	if len(failures) > 0 {
+		return &MultiError{Errors: failures, Total: len(requests)}
+	}
+	return nil
+}
+
+// BatchError is the failure of one request of a batch
+type BatchError struct {
+	// Index is the position of the request in the batch
+	Index  int
+	Method string
+	URL    string
+	Err    error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("request %d (%s %s): %v", e.Index, e.Method, e.URL, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the status of the HTTPError wrapped by the failure, zero when the request failed without
+// a response or the status was accepted, e.g. with WithStatus
+func (e *BatchError) StatusCode() int {
+	var httpErr *HTTPError
+	if errors.As(e.Err, &httpErr) {
+		return httpErr.StatusCode
+	}
+	return 0
+}
+
+// MultiError is returned by Batch when requests failed, keeping each failure with its position and URL
+//
+//	var multi *httpclient.MultiError
+//	if errors.As(err, &multi) {
+//	    for _, failure := range multi.ByStatusClass(5) {
+//	        log.Printf("retry later: %s", failure.URL)
+//	    }
+//	}
+type MultiError struct {
+	// Errors are the failures in request order
+	Errors []*BatchError
+	// Total is the number of requests in the batch
+	Total int
+}
+
+func (e *MultiError) Error() string {
+	return fmt.Sprintf("%d of %d batch requests failed, first error: %v", len(e.Errors), e.Total, e.Errors[0])
+}
+
+// Unwrap returns the first failure, so errors.Is and errors.As match it
+func (e *MultiError) Unwrap() error {
+	return e.Errors[0]
+}
+
+// Filter returns the failures for which keep returns true
+func (e *MultiError) Filter(keep func(failure *BatchError) bool) []*BatchError {
+	var failures []*BatchError
+	for _, failure := range e.Errors {
+		if keep(failure) {
+			failures = append(failures, failure)
+		}
+	}
+	return failures
+}
+
+// ByStatusClass returns the failures with an HTTP status in the given class, e.g. 4 for 4xx errors;
+// class 0 returns the failures without a response, such as network errors and cancellations
+func (e *MultiError) ByStatusClass(class int) []*BatchError {
+	return e.Filter(func(failure *BatchError) bool {
+		return failure.StatusCode()/100 == class
+	})
+}
@@ -0,0 +1,123 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Batch(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+			return
+		case "/broken":
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"method":"` + r.Method + `","path":"` + r.URL.Path + `"}`))
+	}))
+	defer server.Close()
+
+	t.Run("runs heterogeneous requests with bounded concurrency", func(t *testing.T) {
+		client := &Client{BatchConcurrency: 2}
+
+		results := make([]map[string]string, 6)
+		requests := make([]*BatchRequest, 6)
+		for i := range requests {
+			method := http.MethodGet
+			var body interface{}
+			if i%2 == 1 {
+				method = http.MethodPost
+				body = map[string]int{"index": i}
+			}
+			requests[i] = &BatchRequest{Method: method, URL: server.URL + "/pokemon", Body: body, Result: &results[i]}
+		}
+
+		if err := client.Batch(context.Background(), requests...); err != nil {
+			t.Fatalf("batch failed: %v", err)
+		}
+
+		if results[1]["method"] != http.MethodPost {
+			t.Errorf("expected method POST, got '%s'", results[1]["method"])
+		}
+		if max := atomic.LoadInt32(&maxInFlight); max > 2 {
+			t.Errorf("expected at most 2 requests in flight, got %d", max)
+		}
+	})
+
+	t.Run("collects per-request errors", func(t *testing.T) {
+		client := &Client{}
+		ok := &BatchRequest{Method: http.MethodGet, URL: server.URL + "/pokemon"}
+		missing := &BatchRequest{Method: http.MethodGet, URL: server.URL + "/missing"}
+
+		if err := client.Batch(context.Background(), ok, missing); err == nil {
+			t.Error("expected batch error")
+		}
+		if ok.Err != nil {
+			t.Errorf("unexpected error: %v", ok.Err)
+		}
+		if missing.Err == nil {
+			t.Error("expected error for missing resource")
+		}
+	})
+
+	t.Run("cancelled context skips requests", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		req := &BatchRequest{Method: http.MethodGet, URL: server.URL + "/pokemon"}
+		_ = (&Client{}).Batch(ctx, req)
+		if req.Err != context.Canceled {
+			t.Errorf("expected context.Canceled, got %v", req.Err)
+		}
+	})
+	t.Run("multi error", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		requests := []*BatchRequest{
+			{Method: http.MethodGet, URL: server.URL + "/pokemon"},
+			{Method: http.MethodGet, URL: server.URL + "/missing"},
+			{Method: http.MethodGet, URL: server.URL + "/broken"},
+		}
+		err := (&Client{}).Batch(context.Background(), requests...)
+		var multi *MultiError
+		if !errors.As(err, &multi) {
+			t.Fatalf("expected MultiError, got %v", err)
+		}
+		if multi.Total != 3 || len(multi.Errors) != 2 || multi.Errors[0].Index != 1 || multi.Errors[0].URL != server.URL+"/missing" {
+			t.Errorf("unexpected failures %+v", multi.Errors)
+		}
+		if client := multi.ByStatusClass(4); len(client) != 1 || client[0].StatusCode() != http.StatusNotFound {
+			t.Errorf("expected one 4xx failure, got %v", client)
+		}
+		if server := multi.ByStatusClass(5); len(server) != 1 || server[0].Index != 2 {
+			t.Errorf("expected one 5xx failure, got %v", server)
+		}
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+			t.Errorf("expected first failure to be matched, got %v", err)
+		}
+
+		err = (&Client{}).Batch(ctx, &BatchRequest{Method: http.MethodGet, URL: server.URL + "/pokemon"})
+		if !errors.As(err, &multi) || len(multi.ByStatusClass(0)) != 1 || !errors.Is(err, context.Canceled) {
+			t.Errorf("expected cancelled failure without status, got %v", err)
+		}
+	})
+}
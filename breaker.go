@@ -0,0 +1,236 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for requests rejected by an open CircuitBreaker
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets requests through and counts failures
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects requests until OpenTimeout elapses
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe request through, closing the circuit when it succeeds
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitCounts are the counters of a CircuitBreaker
+type CircuitCounts struct {
+	// ConsecutiveFailures is the current run of failures, reset by a success
+	ConsecutiveFailures int
+	// Successes, Failures and Rejected count requests since the breaker was created
+	Successes int64
+	Failures  int64
+	Rejected  int64
+}
+
+// CircuitBreaker stops sending requests to a failing dependency, failing fast with ErrCircuitOpen instead.
+// Use it through its Middleware; with retries every attempt is counted.
+//
+//	breaker := &httpclient.CircuitBreaker{OnStateChange: func(from, to httpclient.CircuitState) {
+//	    log.Printf("payments circuit %s -> %s", from, to)
+//	}}
+//	client := &httpclient.Client{Middleware: []httpclient.Middleware{breaker.Middleware()}}
+type CircuitBreaker struct {
+	// FailureThreshold is the number of consecutive failures opening the circuit, defaults to 5
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before a probe is allowed, defaults to 30s
+	OpenTimeout time.Duration
+	// IsFailure classifies attempts, defaults to transport errors and status 500 and above. By default attempts
+	// canceled by the caller, or whose context is done, are not counted at all.
+	IsFailure func(resp *http.Response, err error) bool
+	// OnStateChange is called after every state transition, outside the breaker lock
+	OnStateChange func(from, to CircuitState)
+
+	mu       sync.Mutex
+	state    CircuitState
+	counts   CircuitCounts
+	openedAt time.Time
+	probing  bool
+	// generation changes with every transition, so outcomes of attempts admitted before are not applied
+	generation uint64
+}
+
+// admission is the state an attempt was let through in
+type admission struct {
+	generation uint64
+	probe      bool
+}
+
+// State returns the current state, reporting an open circuit whose timeout elapsed as half-open
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.openTimeout() {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// Counts returns a snapshot of the counters
+func (b *CircuitBreaker) Counts() CircuitCounts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.counts
+}
+
+// Reset closes the circuit and clears the consecutive failures
+func (b *CircuitBreaker) Reset() {
+	b.mu.Lock()
+	from := b.state
+	b.transition(CircuitClosed)
+	b.mu.Unlock()
+	b.notify(from, CircuitClosed)
+}
+
+// Middleware returns a Middleware guarding requests with the breaker
+func (b *CircuitBreaker) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			admitted, err := b.allow()
+			if err != nil {
+				closeBody(req)
+				return nil, err
+			}
+			resp, err := next.RoundTrip(req)
+			if b.IsFailure == nil && canceledByCaller(req, err) {
+				b.release(admitted)
+			} else {
+				b.record(admitted, b.isFailure(resp, err))
+			}
+			return resp, err
+		})
+	}
+}
+
+// allow lets an attempt through, returning the admission its outcome is recorded with
+func (b *CircuitBreaker) allow() (admission, error) {
+	b.mu.Lock()
+	from := b.state
+	switch {
+	case b.state == CircuitOpen && time.Since(b.openedAt) >= b.openTimeout():
+		b.transition(CircuitHalfOpen)
+		b.probing = true
+	case b.state == CircuitHalfOpen && !b.probing:
+		b.probing = true
+	case b.state != CircuitClosed:
+		b.counts.Rejected++
+		b.mu.Unlock()
+		return admission{}, ErrCircuitOpen
+	}
+	admitted := admission{generation: b.generation, probe: b.state == CircuitHalfOpen}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	return admitted, nil
+}
+
+// record counts the outcome of an attempt, only changing the state when the attempt was admitted in the
+// current one: failures open a closed circuit past the threshold, and the probe closes or re-opens a
+// half-open circuit
+func (b *CircuitBreaker) record(admitted admission, failed bool) {
+	b.mu.Lock()
+	from := b.state
+	current := admitted.generation == b.generation
+	if failed {
+		b.counts.Failures++
+	} else {
+		b.counts.Successes++
+	}
+	switch {
+	case !current:
+	case admitted.probe && failed:
+		b.counts.ConsecutiveFailures++
+		b.transition(CircuitOpen)
+	case admitted.probe:
+		b.transition(CircuitClosed)
+	case failed:
+		b.counts.ConsecutiveFailures++
+		if b.counts.ConsecutiveFailures >= b.failureThreshold() {
+			b.transition(CircuitOpen)
+		}
+	default:
+		b.counts.ConsecutiveFailures = 0
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// release ends an attempt whose outcome says nothing about the dependency, letting another probe through
+func (b *CircuitBreaker) release(admitted admission) {
+	b.mu.Lock()
+	if admitted.probe && admitted.generation == b.generation {
+		b.probing = false
+	}
+	b.mu.Unlock()
+}
+
+// transition moves to state, starting a new generation; the caller holds b.mu
+func (b *CircuitBreaker) transition(state CircuitState) {
+	b.state = state
+	b.generation++
+	b.probing = false
+	switch state {
+	case CircuitOpen:
+		b.openedAt = time.Now()
+	case CircuitClosed:
+		b.counts.ConsecutiveFailures = 0
+	}
+}
+
+func (b *CircuitBreaker) notify(from, to CircuitState) {
+	if from != to && b.OnStateChange != nil {
+		b.OnStateChange(from, to)
+	}
+}
+
+func (b *CircuitBreaker) isFailure(resp *http.Response, err error) bool {
+	if b.IsFailure != nil {
+		return b.IsFailure(resp, err)
+	}
+	return err != nil || resp.StatusCode >= 500
+}
+
+// canceledByCaller reports whether the attempt failed because its caller gave up, not because of the dependency
+func canceledByCaller(req *http.Request, err error) bool {
+	return err != nil && (errors.Is(err, context.Canceled) || req.Context().Err() != nil)
+}
+
+func (b *CircuitBreaker) failureThreshold() int {
+	if b.FailureThreshold > 0 {
+		return b.FailureThreshold
+	}
+	return 5
+}
+
+func (b *CircuitBreaker) openTimeout() time.Duration {
+	if b.OpenTimeout > 0 {
+		return b.OpenTimeout
+	}
+	return 30 * time.Second
+}
@@ -0,0 +1,145 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var failing int32 = 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var transitions []string
+	breaker := &CircuitBreaker{
+		FailureThreshold: 2,
+		OpenTimeout:      20 * time.Millisecond,
+		OnStateChange: func(from, to CircuitState) {
+			mu.Lock()
+			defer mu.Unlock()
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	}
+	client := &Client{Middleware: []Middleware{breaker.Middleware()}}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_ = client.Get(ctx, server.URL, nil)
+	}
+	if breaker.State() != CircuitOpen {
+		t.Fatalf("expected open circuit, got %s", breaker.State())
+	}
+
+	if err := client.Get(ctx, server.URL, nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	counts := breaker.Counts()
+	if counts.Failures != 2 || counts.Rejected != 1 || counts.ConsecutiveFailures != 2 {
+		t.Errorf("unexpected counts: %+v", counts)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if breaker.State() != CircuitHalfOpen {
+		t.Errorf("expected half-open circuit, got %s", breaker.State())
+	}
+
+	atomic.StoreInt32(&failing, 0)
+	if err := client.Get(ctx, server.URL, nil); err != nil {
+		t.Fatalf("probe request failed: %v", err)
+	}
+	if breaker.State() != CircuitClosed {
+		t.Errorf("expected closed circuit, got %s", breaker.State())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"closed->open", "open->half-open", "half-open->closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("expected transitions %v, got %v", want, transitions)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("expected transitions %v, got %v", want, transitions)
+			break
+		}
+	}
+}
+
+func TestCircuitBreaker_CallerCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(100 * time.Millisecond):
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	breaker := &CircuitBreaker{FailureThreshold: 3}
+	client := &Client{Middleware: []Middleware{breaker.Middleware()}}
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		if err := client.Get(ctx, server.URL, nil); err == nil {
+			t.Fatal("expected timeout error")
+		}
+		cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = client.Get(ctx, server.URL, nil)
+
+	if breaker.State() != CircuitClosed {
+		t.Errorf("expected closed circuit, got %s", breaker.State())
+	}
+	if counts := breaker.Counts(); counts.Failures != 0 || counts.ConsecutiveFailures != 0 {
+		t.Errorf("expected no failures counted, got %+v", counts)
+	}
+}
+
+func TestCircuitBreaker_StaleOutcomes(t *testing.T) {
+	breaker := &CircuitBreaker{FailureThreshold: 1, OpenTimeout: 20 * time.Millisecond}
+	slow, err := breaker.allow()
+	if err != nil {
+		t.Fatalf("allow failed: %v", err)
+	}
+	failing, _ := breaker.allow()
+	breaker.record(failing, true)
+	openedAt := breaker.openedAt
+
+	// Outcomes of attempts admitted while closed neither close nor refresh the open circuit
+	breaker.record(slow, false)
+	breaker.record(slow, true)
+	if breaker.State() != CircuitOpen || !breaker.openedAt.Equal(openedAt) {
+		t.Fatalf("expected the circuit to stay open since %v, got %s since %v", openedAt, breaker.State(), breaker.openedAt)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	probe, err := breaker.allow()
+	if err != nil {
+		t.Fatalf("probe not allowed: %v", err)
+	}
+	breaker.record(failing, false)
+	if _, err := breaker.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected a single probe, got %v", err)
+	}
+	if breaker.State() != CircuitHalfOpen {
+		t.Errorf("expected half-open circuit until the probe ends, got %s", breaker.State())
+	}
+	breaker.record(probe, false)
+	if breaker.State() != CircuitClosed {
+		t.Errorf("expected the probe to close the circuit, got %s", breaker.State())
+	}
+}
@@ -0,0 +1,92 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledBufferSize keeps buffers grown by unusually large bodies out of the pool
+const maxPooledBufferSize = 256 << 10
+
+// jsonBuffer is a pooled buffer with a JSON encoder writing into it, so the encoder is reused as well
+type jsonBuffer struct {
+	bytes.Buffer
+	enc *json.Encoder
+}
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := &jsonBuffer{}
+		buf.enc = json.NewEncoder(&buf.Buffer)
+		return buf
+	},
+}
+
+func getBuffer() *jsonBuffer {
+	return bufferPool.Get().(*jsonBuffer)
+}
+
+func putBuffer(buf *jsonBuffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// encodeJSON marshals v into a pooled buffer like json.Marshal, without the trailing newline of json.Encoder
+func encodeJSON(v interface{}) (*jsonBuffer, error) {
+	buf := getBuffer()
+	if err := buf.enc.Encode(v); err != nil {
+		putBuffer(buf)
+		return nil, err
+	}
+	buf.Truncate(buf.Len() - 1)
+	return buf, nil
+}
+
+// pooledBody shares a pooled buffer between the readers of a request body. Every reader and the owner
+// hold a reference, and the buffer returns to the pool once all of them are released, because the
+// transport may still be reading the body after the response arrived.
+type pooledBody struct {
+	buf  *jsonBuffer
+	refs int32
+}
+
+func newPooledBody(buf *jsonBuffer) *pooledBody {
+	return &pooledBody{buf: buf, refs: 1}
+}
+
+// reader returns a new reader over the body, failing when the buffer was already released
+func (p *pooledBody) reader() (io.ReadCloser, error) {
+	for {
+		refs := atomic.LoadInt32(&p.refs)
+		if refs <= 0 {
+			return nil, errors.New("request body already released")
+		}
+		if atomic.CompareAndSwapInt32(&p.refs, refs, refs+1) {
+			return &pooledReader{Reader: bytes.NewReader(p.buf.Bytes()), body: p}, nil
+		}
+	}
+}
+
+func (p *pooledBody) release() {
+	if atomic.AddInt32(&p.refs, -1) == 0 {
+		putBuffer(p.buf)
+	}
+}
+
+type pooledReader struct {
+	*bytes.Reader
+	body *pooledBody
+	once sync.Once
+}
+
+func (r *pooledReader) Close() error {
+	r.once.Do(r.body.release)
+	return nil
+}
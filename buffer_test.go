@@ -0,0 +1,218 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestClient_BufferPool(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/echo", http.StatusTemporaryRedirect)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	for _, disabled := range []bool{false, true} {
+		client := &Client{DisableBufferPool: disabled}
+
+		t.Run(fmt.Sprintf("concurrent requests disabled=%v", disabled), func(t *testing.T) {
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					var result map[string]int
+					if err := client.Post(ctx, server.URL+"/echo", map[string]int{"id": i}, &result); err != nil {
+						t.Errorf("POST request failed: %v", err)
+						return
+					}
+					if result["id"] != i {
+						t.Errorf("expected id %d, got %d", i, result["id"])
+					}
+				}(i)
+			}
+			wg.Wait()
+		})
+
+		t.Run(fmt.Sprintf("body resent on redirect disabled=%v", disabled), func(t *testing.T) {
+			var result map[string]string
+			if err := client.Post(ctx, server.URL+"/redirect", map[string]string{"name": "pikachu"}, &result); err != nil {
+				t.Fatalf("POST request failed: %v", err)
+			}
+			if result["name"] != "pikachu" {
+				t.Errorf("expected name 'pikachu', got '%s'", result["name"])
+			}
+		})
+	}
+
+	t.Run("matches json.Marshal", func(t *testing.T) {
+		v := map[string]interface{}{"html": "<b>&</b>", "n": 1.5}
+		buf, err := encodeJSON(v)
+		if err != nil {
+			t.Fatalf("encoding failed: %v", err)
+		}
+		defer putBuffer(buf)
+		want, _ := json.Marshal(v)
+		if buf.String() != string(want) {
+			t.Errorf("expected %s, got %s", want, buf.String())
+		}
+	})
+}
+
+func TestClient_RequestBody(t *testing.T) {
+	type seen struct {
+		contentLength    int64
+		transferEncoding []string
+		body             string
+	}
+	var last seen
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		last = seen{r.ContentLength, r.TransferEncoding, string(body)}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		client *Client
+		method string
+		body   interface{}
+		want   seen
+	}{
+		{"no body", &Client{}, http.MethodGet, nil, seen{0, nil, ""}},
+		{"raw bytes", &Client{}, http.MethodPost, []byte(`{"a":1}`), seen{7, nil, `{"a":1}`}},
+		{"marshalled", &Client{}, http.MethodPut, map[string]int{"a": 1}, seen{7, nil, `{"a":1}`}},
+		{"custom marshal", &Client{MarshalFunc: func(v any) ([]byte, error) { return json.Marshal(v) }},
+			http.MethodPatch, map[string]int{"a": 1}, seen{7, nil, `{"a":1}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.client.Do(ctx, tt.method, server.URL, tt.body, nil); err != nil {
+				t.Fatalf("%s request failed: %v", tt.method, err)
+			}
+			if last.contentLength != tt.want.contentLength || len(last.transferEncoding) != 0 || last.body != tt.want.body {
+				t.Errorf("expected %+v, got %+v", tt.want, last)
+			}
+		})
+	}
+
+	t.Run("get has nil body", func(t *testing.T) {
+		req, release, err := (&Client{}).buildRequest(ctx, http.MethodGet, server.URL, nil, buildOptions())
+		if err != nil {
+			t.Fatalf("building request failed: %v", err)
+		}
+		defer release()
+		if req.Body != nil {
+			t.Errorf("expected nil body, got %T", req.Body)
+		}
+	})
+}
+
+func TestClient_DecodeStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/trailing":
+			_, _ = w.Write([]byte(`{"id":25} {"id":26}`))
+		case "/empty":
+		default:
+			_, _ = w.Write([]byte(" {\"id\":25,\"name\":\"pikachu\"}\n"))
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	client := &Client{}
+
+	t.Run("decodes", func(t *testing.T) {
+		var result map[string]interface{}
+		if err := client.Get(ctx, server.URL, &result); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if result["name"] != "pikachu" {
+			t.Errorf("expected name 'pikachu', got '%v'", result["name"])
+		}
+	})
+
+	t.Run("rejects trailing data", func(t *testing.T) {
+		var result map[string]interface{}
+		if err := client.Get(ctx, server.URL+"/trailing", &result); err == nil {
+			t.Error("expected error for trailing data")
+		}
+	})
+
+	t.Run("rejects empty body", func(t *testing.T) {
+		var result map[string]interface{}
+		if err := client.Get(ctx, server.URL+"/empty", &result); err == nil {
+			t.Error("expected error for empty body")
+		}
+	})
+}
+
+func TestClient_WithoutBodyBuffering(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/html":
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("<html>bad gateway</html>"))
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+		default:
+			_, _ = w.Write([]byte(`{"id":25,"name":"pikachu"}`))
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	client := &Client{}
+
+	t.Run("decodes captured status", func(t *testing.T) {
+		var status int
+		var result map[string]string
+		if err := client.Get(ctx, server.URL+"/missing", &result, WithStatus(&status), WithoutBodyBuffering()); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if status != http.StatusNotFound || result["error"] != "not found" {
+			t.Errorf("expected 404 with error, got %d and %v", status, result)
+		}
+	})
+
+	t.Run("returns decoding errors of captured status", func(t *testing.T) {
+		var status int
+		var result map[string]string
+		if err := client.Get(ctx, server.URL+"/html", &result, WithStatus(&status)); err != nil {
+			t.Errorf("expected buffered request to ignore the decoding error, got %v", err)
+		}
+		if err := client.Get(ctx, server.URL+"/html", &result, WithStatus(&status), WithoutBodyBuffering()); err == nil {
+			t.Error("expected decoding error")
+		}
+	})
+
+	t.Run("errors stay buffered", func(t *testing.T) {
+		var httpErr *HTTPError
+		err := client.Get(ctx, server.URL+"/missing", nil, WithoutBodyBuffering())
+		if !errors.As(err, &httpErr) || string(httpErr.Body) != `{"error":"not found"}` {
+			t.Errorf("expected HTTPError with body, got %v", err)
+		}
+	})
+
+	t.Run("rejects whole body options", func(t *testing.T) {
+		var result map[string]interface{}
+		err := client.Get(ctx, server.URL, &result, WithEnvelope("data"), WithoutBodyBuffering())
+		if !errors.Is(err, ErrBodyBufferingRequired) {
+			t.Errorf("expected ErrBodyBufferingRequired, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestByteRangesResponse(t *testing.T) {
+	const content = "0123456789abcdefghijklmnopqrstuvwxyz"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ignored" {
+			r.Header.Del("Range")
+		}
+		http.ServeContent(w, r, "alphabet.txt", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	client := &Client{}
+	fetch := func(t *testing.T, path string, ranges ...ByteRange) map[ContentRange]string {
+		parts := map[ContentRange]string{}
+		result := &ByteRangesResponse{OnPart: func(part *RangePart) error {
+			data, err := io.ReadAll(part)
+			parts[part.Range] = string(data)
+			return err
+		}}
+		if err := client.Get(context.Background(), server.URL+path, result, WithRanges(ranges...)); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		return parts
+	}
+
+	t.Run("multiple ranges", func(t *testing.T) {
+		parts := fetch(t, "/", ByteRange{Start: 0, End: 3}, ByteRange{Start: 30, End: -1})
+		if len(parts) != 2 || parts[ContentRange{0, 3, 36}] != "0123" || parts[ContentRange{30, 35, 36}] != "uvwxyz" {
+			t.Errorf("unexpected parts %v", parts)
+		}
+	})
+
+	t.Run("single range", func(t *testing.T) {
+		parts := fetch(t, "/", ByteRange{Start: 10, End: 12})
+		if len(parts) != 1 || parts[ContentRange{10, 12, 36}] != "abc" {
+			t.Errorf("unexpected parts %v", parts)
+		}
+	})
+
+	t.Run("range ignored", func(t *testing.T) {
+		parts := fetch(t, "/ignored", ByteRange{Start: 10, End: 12})
+		if len(parts) != 1 || parts[ContentRange{0, 35, 36}] != content {
+			t.Errorf("unexpected parts %v", parts)
+		}
+	})
+}
+
+func TestParseContentRange(t *testing.T) {
+	if r, err := ParseContentRange("bytes 0-99/*"); err != nil || r != (ContentRange{0, 99, -1}) {
+		t.Errorf("expected 0-99 of unknown size, got %+v, %v", r, err)
+	}
+	for _, value := range []string{"bytes */100", "items 0-1/2", "bytes 5-1/10"} {
+		if _, err := ParseContentRange(value); err == nil {
+			t.Errorf("expected error for %q", value)
+		}
+	}
+}
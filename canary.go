@@ -0,0 +1,165 @@
+package httpclient
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultCanaryMinRequests is the number of canary requests needed before Canary.MaxFailureRate applies
+const DefaultCanaryMinRequests = 20
+
+// RouteStats counts the outcomes of the requests sent to one route of a Canary
+type RouteStats struct {
+	Requests int64
+	// Failures counts transport errors and responses with status 500 and above
+	Failures int64
+	// Latency is the total time spent waiting for responses
+	Latency time.Duration
+}
+
+// FailureRate returns Failures divided by Requests, zero without requests
+func (s RouteStats) FailureRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Failures) / float64(s.Requests)
+}
+
+// AverageLatency returns the mean response time, zero without requests
+func (s RouteStats) AverageLatency() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.Latency / time.Duration(s.Requests)
+}
+
+// CanaryStats holds the statistics of both routes of a Canary
+type CanaryStats struct {
+	Primary RouteStats
+	Canary  RouteStats
+}
+
+// Canary routes a weighted share of requests to a new base URL, supporting gradual migrations to a new
+// API endpoint. Requests keep their URL on the primary route; on the canary route the scheme and host are
+// replaced with those of BaseURL and its path is prepended. Each attempt is routed on its own, so a retry
+// may switch routes.
+//
+//	canary := &httpclient.Canary{BaseURL: "https://v2.api.example.com", Weight: 0.05, MaxFailureRate: 0.2}
+//	client := &httpclient.Client{Middleware: []httpclient.Middleware{canary.Middleware()}}
+type Canary struct {
+	// BaseURL receives the canary share of the requests
+	BaseURL string
+	// Weight is the share in [0, 1] of requests routed to BaseURL
+	Weight float64
+	// MaxFailureRate disables the canary once its failure rate exceeds it, zero disables the check
+	MaxFailureRate float64
+	// MinRequests is the number of canary requests before MaxFailureRate applies, defaults to DefaultCanaryMinRequests
+	MinRequests int64
+	// OnDisable is called when MaxFailureRate trips the kill switch
+	OnDisable func(stats CanaryStats)
+	// Rand returns random numbers in [0, 1), defaults to math/rand.Float64
+	Rand func() float64
+
+	mu       sync.Mutex
+	disabled bool
+	stats    CanaryStats
+}
+
+// Disable is the kill switch routing every request to the primary route
+func (c *Canary) Disable() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.disabled = true
+}
+
+// Enable routes Weight of the requests to the canary again and resets the statistics
+func (c *Canary) Enable() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.disabled = false
+	c.stats = CanaryStats{}
+}
+
+// Enabled reports whether requests are routed to the canary
+func (c *Canary) Enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.disabled
+}
+
+// Stats returns the statistics of both routes
+func (c *Canary) Stats() CanaryStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Middleware returns a Middleware routing requests passed to next
+func (c *Canary) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			canary := c.pick()
+			if canary {
+				base, err := url.Parse(c.BaseURL)
+				if err != nil {
+					closeBody(req)
+					return nil, fmt.Errorf("failed to parse canary base URL: %w", err)
+				}
+				req = req.Clone(req.Context())
+				rebase(req, base)
+			}
+
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			c.record(canary, time.Since(start), err != nil || resp.StatusCode >= 500)
+			return resp, err
+		})
+	}
+}
+
+func (c *Canary) pick() bool {
+	if c.Weight <= 0 || !c.Enabled() {
+		return false
+	}
+	random := rand.Float64
+	if c.Rand != nil {
+		random = c.Rand
+	}
+	return random() < c.Weight
+}
+
+func (c *Canary) record(canary bool, latency time.Duration, failed bool) {
+	c.mu.Lock()
+	route := &c.stats.Primary
+	if canary {
+		route = &c.stats.Canary
+	}
+	route.Requests++
+	route.Latency += latency
+	if failed {
+		route.Failures++
+	}
+
+	minRequests := c.MinRequests
+	if minRequests <= 0 {
+		minRequests = DefaultCanaryMinRequests
+	}
+	trip := canary && !c.disabled && c.MaxFailureRate > 0 &&
+		c.stats.Canary.Requests >= minRequests && c.stats.Canary.FailureRate() > c.MaxFailureRate
+	if trip {
+		c.disabled = true
+	}
+	stats := c.stats
+	c.mu.Unlock()
+
+	if trip && c.OnDisable != nil {
+		c.OnDisable(stats)
+	}
+}
@@ -0,0 +1,86 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCanary(t *testing.T) {
+	v1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`"v1` + r.URL.Path + `"`))
+	}))
+	defer v1.Close()
+	var v2Status = http.StatusOK
+	v2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(v2Status)
+		_, _ = w.Write([]byte(`"v2` + r.URL.Path + `"`))
+	}))
+	defer v2.Close()
+
+	// Every fourth request goes to the canary
+	var n int
+	canary := &Canary{BaseURL: v2.URL + "/next", Weight: 0.25, Rand: func() float64 {
+		n++
+		if n%4 == 0 {
+			return 0.1
+		}
+		return 0.9
+	}}
+	c := &Client{Middleware: []Middleware{canary.Middleware()}}
+	ctx := context.Background()
+
+	t.Run("weighted routing", func(t *testing.T) {
+		routes := map[string]int{}
+		for i := 0; i < 8; i++ {
+			var route string
+			if err := c.Get(ctx, v1.URL+"/pokemon", &route); err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			routes[route]++
+		}
+		if routes["v1/pokemon"] != 6 || routes["v2/next/pokemon"] != 2 {
+			t.Errorf("expected 6 primary and 2 canary requests, got %v", routes)
+		}
+		stats := canary.Stats()
+		if stats.Primary.Requests != 6 || stats.Canary.Requests != 2 || stats.Canary.Failures != 0 {
+			t.Errorf("expected route stats, got %+v", stats)
+		}
+	})
+
+	t.Run("kill switch", func(t *testing.T) {
+		canary.Disable()
+		for i := 0; i < 4; i++ {
+			var route string
+			if err := c.Get(ctx, v1.URL+"/pokemon", &route); err != nil || route != "v1/pokemon" {
+				t.Fatalf("expected primary route, got %q and %v", route, err)
+			}
+		}
+		canary.Enable()
+		if stats := canary.Stats(); stats.Primary.Requests != 0 {
+			t.Errorf("expected stats reset on Enable, got %+v", stats)
+		}
+	})
+
+	t.Run("max failure rate", func(t *testing.T) {
+		v2Status = http.StatusBadGateway
+		var disabled CanaryStats
+		canary.MaxFailureRate = 0.5
+		canary.MinRequests = 2
+		canary.OnDisable = func(stats CanaryStats) { disabled = stats }
+
+		for i := 0; i < 16; i++ {
+			_ = c.Get(ctx, v1.URL+"/pokemon", nil)
+		}
+		if canary.Enabled() {
+			t.Fatalf("expected the canary to be disabled")
+		}
+		if disabled.Canary.Requests != 2 || disabled.Canary.FailureRate() != 1 {
+			t.Errorf("expected OnDisable after 2 failed canary requests, got %+v", disabled)
+		}
+		if stats := canary.Stats(); stats.Canary.Requests != 2 || stats.Primary.Requests != 14 {
+			t.Errorf("expected the remaining requests on the primary route, got %+v", stats)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrInjectedFault is returned by ChaosTransport for injected transport failures
+var ErrInjectedFault = errors.New("injected fault")
+
+// ChaosTransport is an http.RoundTripper injecting failures, error statuses, latency and truncated bodies
+// into requests, so retry and circuit breaker configurations can be exercised in tests and staging
+type ChaosTransport struct {
+	// Transport sends requests that are not failed, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+	// FailureRate is the probability in [0, 1] of failing a request with ErrInjectedFault
+	FailureRate float64
+	// StatusRate is the probability in [0, 1] of replying with one of StatusCodes without sending the request
+	StatusRate float64
+	// StatusCodes are the injected status codes, defaults to 503
+	StatusCodes []int
+	// Latency is added before every request
+	Latency time.Duration
+	// LatencyJitter adds a random extra delay up to its value
+	LatencyJitter time.Duration
+	// TruncateRate is the probability in [0, 1] of cutting a response body in half,
+	// reading it then fails with io.ErrUnexpectedEOF
+	TruncateRate float64
+	// Hosts restricts faults to requests to matching hosts when not empty, using the patterns of
+	// Client.AllowedHosts; requests to other hosts are sent untouched
+	Hosts []string
+	// Rand returns random numbers in [0, 1), defaults to math/rand.Float64
+	Rand func() float64
+}
+
+// Middleware returns a Middleware injecting the configured faults in front of next
+func (c *ChaosTransport) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		chaos := *c
+		chaos.Transport = next
+		return &chaos
+	}
+}
+
+// RoundTrip sends req through the transport, injecting faults on the way
+func (c *ChaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := c.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if !c.targets(req) {
+		return transport.RoundTrip(req)
+	}
+
+	if delay := c.delay(); delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			closeBody(req)
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	if c.roll(c.FailureRate) {
+		closeBody(req)
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Redacted(), ErrInjectedFault)
+	}
+	if c.roll(c.StatusRate) {
+		closeBody(req)
+		return c.statusResponse(req), nil
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil || !c.roll(c.TruncateRate) {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body[:len(body)/2]), errReader{io.ErrUnexpectedEOF}))
+	return resp, nil
+}
+
+// targets reports whether faults are injected into req
+func (c *ChaosTransport) targets(req *http.Request) bool {
+	if len(c.Hosts) == 0 {
+		return true
+	}
+	host := strings.ToLower(req.URL.Hostname())
+	for _, pattern := range c.Hosts {
+		if matchHost(pattern, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *ChaosTransport) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	random := c.Rand
+	if random == nil {
+		random = rand.Float64
+	}
+	return random() < rate
+}
+
+func (c *ChaosTransport) delay() time.Duration {
+	delay := c.Latency
+	if c.LatencyJitter > 0 {
+		random := c.Rand
+		if random == nil {
+			random = rand.Float64
+		}
+		delay += time.Duration(random() * float64(c.LatencyJitter))
+	}
+	return delay
+}
+
+func (c *ChaosTransport) statusResponse(req *http.Request) *http.Response {
+	status := http.StatusServiceUnavailable
+	if len(c.StatusCodes) > 0 {
+		random := c.Rand
+		if random == nil {
+			random = rand.Float64
+		}
+		status = c.StatusCodes[int(random()*float64(len(c.StatusCodes)))%len(c.StatusCodes)]
+	}
+	body := `{"error":"injected fault"}`
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
+// errReader always fails with err
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
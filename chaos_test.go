@@ -0,0 +1,88 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestChaosTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"pikachu","id":25}`))
+	}))
+	defer server.Close()
+
+	always := func() float64 { return 0 }
+
+	t.Run("failure", func(t *testing.T) {
+		client := &Client{Middleware: []Middleware{(&ChaosTransport{FailureRate: 0.5, Rand: always}).Middleware()}}
+		err := client.Get(context.Background(), server.URL, nil)
+		if !errors.Is(err, ErrInjectedFault) {
+			t.Errorf("expected ErrInjectedFault, got %v", err)
+		}
+	})
+
+	t.Run("status", func(t *testing.T) {
+		client := &Client{Middleware: []Middleware{(&ChaosTransport{StatusRate: 1, StatusCodes: []int{429}, Rand: always}).Middleware()}}
+		var status int
+		if err := client.Get(context.Background(), server.URL, nil, WithStatus(&status)); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if status != http.StatusTooManyRequests {
+			t.Errorf("expected status 429, got %d", status)
+		}
+	})
+
+	t.Run("hosts", func(t *testing.T) {
+		chaos := &ChaosTransport{FailureRate: 1, Hosts: []string{"*.staging.internal"}, Rand: always}
+		client := &Client{Middleware: []Middleware{chaos.Middleware()}}
+		if err := client.Get(context.Background(), server.URL, nil); err != nil {
+			t.Errorf("expected requests to other hosts to be untouched, got %v", err)
+		}
+		err := client.Get(context.Background(), "http://pokeapi.staging.internal/pokemon", nil)
+		if !errors.Is(err, ErrInjectedFault) {
+			t.Errorf("expected ErrInjectedFault, got %v", err)
+		}
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		client := &Client{Middleware: []Middleware{(&ChaosTransport{Latency: time.Second}).Middleware()}}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		err := client.Get(ctx, server.URL, nil)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		chaos := &ChaosTransport{TruncateRate: 1, Rand: always}
+		resp, err := (&http.Client{Transport: chaos}).Get(server.URL)
+		if err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+		}
+		if len(body) != 13 {
+			t.Errorf("expected 13 bytes, got %d", len(body))
+		}
+	})
+
+	t.Run("no faults", func(t *testing.T) {
+		client := &Client{Middleware: []Middleware{(&ChaosTransport{}).Middleware()}}
+		var result map[string]interface{}
+		if err := client.Get(context.Background(), server.URL, &result); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if result["name"] != "pikachu" {
+			t.Errorf("expected name 'pikachu', got '%v'", result["name"])
+		}
+	})
+}
@@ -39,114 +39,368 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // Client wraps http.Client with JSON utilities
 type Client struct {
 	// Client is the underlying HTTP client used for requests, defaults to http.DefaultClient
 	Client *http.Client
+	// BaseURL is prepended to request URLs without a scheme, e.g. "https://api.example.com/v1"
+	BaseURL string
 	// MarshalFunc is used to marshal Go values into JSON, defaults to json.Marshal
 	MarshalFunc func(v any) ([]byte, error)
 	// UnmarshalFunc is used to unmarshal JSON data into a Go value, defaults to json.Unmarshal
 	UnmarshalFunc func(data []byte, v any) error
+	// ErrorDecoder turns HTTPErrors into typed errors such as *RPCError, see DecodeRPCStatus;
+	// returning nil keeps the HTTPError
+	ErrorDecoder func(err *HTTPError) error
+	// UserAgent is sent in the User-Agent header unless a request sets its own, defaults to DefaultUserAgent
+	UserAgent string
+	// Locale is a language tag such as "fr-CA" sent in Accept-Language unless a request sets its own
+	Locale string
+	// LocaleHeader is an additional header receiving the locale, e.g. "X-Locale" for APIs ignoring Accept-Language
+	LocaleHeader string
+	// DisableCompression requests identity encoded responses instead of the gzip negotiated by net/http,
+	// e.g. behind proxies mangling compressed bodies or when the exact Content-Length is needed
+	DisableCompression bool
+	// SensitiveHeaders are removed from redirected requests that leave the original origin,
+	// defaults to DefaultSensitiveHeaders; set to an empty non-nil slice to keep all headers
+	SensitiveHeaders []string
+	// AllowedHosts restricts requests, including redirects, to matching hosts when not empty;
+	// "*.example.com" matches any subdomain of example.com
+	AllowedHosts []string
+	// DeniedHosts rejects requests to matching hosts, taking precedence over AllowedHosts
+	DeniedHosts []string
+	// RequireHTTPS rejects plain HTTP requests and redirects with ErrInsecureScheme
+	RequireHTTPS bool
+	// MaxRedirects is the maximum number of redirects followed, zero keeps the policy of the HTTP client
+	MaxRedirects int
+	// MaxResponseBytes fails responses with larger bodies with ErrResponseTooLarge, zero means no limit
+	MaxResponseBytes int64
+	// SpillThreshold buffers marshalled request bodies larger than this many bytes in a temporary file instead
+	// of memory, removed once the request is done; zero disables spilling. It has no effect with MarshalFunc.
+	SpillThreshold int64
+	// SpillDir is the directory of the temporary files of SpillThreshold, defaults to os.TempDir()
+	SpillDir string
+	// DisableBufferPool stops reusing pooled buffers for marshalled request bodies and response reads,
+	// which can help when debugging memory issues
+	DisableBufferPool bool
+	// MaxRetries is the number of times a failed attempt is retried, zero disables retries
+	MaxRetries int
+	// RetryPolicy selects the attempts that are retried, defaults to StatusRetryPolicy{}
+	RetryPolicy RetryPolicy
+	// Backoff computes the delay between retries when the response has no Retry-After header,
+	// defaults to ExponentialBackoff{}
+	Backoff Backoff
+	// MaxRetryAfter is the longest Retry-After delay waited for before retrying, responses asking for longer
+	// are returned as is, defaults to DefaultMaxRetryAfter
+	MaxRetryAfter time.Duration
+	// HostProfiles override the timeout, headers, rate limit and retry settings for requests to matching
+	// hosts, keyed by host patterns like AllowedHosts; exact hosts take precedence over wildcards
+	HostProfiles map[string]*HostProfile
+	// Scheduler limits concurrent requests and dispatches waiting ones by priority, nil means no limit
+	Scheduler *Scheduler
+	// Middleware wraps the transport of every request, the first middleware being the outermost
+	Middleware []Middleware
+	// DeadlineHeader is a header receiving the time each attempt has left before the context deadline or
+	// the client timeout, e.g. DeadlineHeaderMillis or DeadlineHeaderGRPC, so servers can shed work that
+	// will not complete in time; empty disables it
+	DeadlineHeader string
+	// Envelope is the top-level field of successful responses holding the decoded value, e.g. "data" for
+	// APIs answering {"data": ...}; requests override it with WithEnvelope and WithoutEnvelope. It applies to
+	// JSON results, including Resource items and ForEachElement, but not to GraphQL, Twirp and FollowLink,
+	// nor to ResponseDecoders of other formats
+	Envelope string
+	// RequestTransformers rewrite marshalled and []byte request bodies before they are sent, before the
+	// transformers of the request; see RequestTransformer
+	RequestTransformers []RequestTransformer
+	// ResponseTransformers rewrite the raw body of successful responses before they are decoded, before the
+	// transformers of the request; see ResponseTransformer
+	ResponseTransformers []ResponseTransformer
+	// OnError is called with the request and error of every request failing once its retries are exhausted,
+	// e.g. to report API failures to an error tracker; it is not called for requests that cannot be built
+	OnError func(req *http.Request, err error)
+	// OnDeprecation is called with the responses announcing the deprecation or sunset of the requested
+	// resource, e.g. LogDeprecation, so upcoming API shutdowns are noticed before they break anything
+	OnDeprecation func(req *http.Request, d Deprecation)
+	// OnWarning is called with every Warning header value of responses, e.g. LogWarning, so degraded
+	// upstream behavior such as stale cached responses is visible
+	OnWarning func(req *http.Request, w Warning)
+	// FailureDump writes the redacted request and response of failed requests to files, nil disables dumps
+	FailureDump *FailureDump
+	// BatchConcurrency is the maximum number of requests Batch runs in parallel, defaults to DefaultBatchConcurrency
+	BatchConcurrency int
+
+	life atomic.Value // *lifecycle, see Shutdown
 }
 
 // Get performs a GET request and unmarshals JSON response
 func (c *Client) Get(ctx context.Context, url string, result interface{}, opts ...Option) error {
-	options := buildOptions(opts...)
+	return c.Do(ctx, http.MethodGet, url, nil, result, opts...)
+}
 
-	req, err := c.buildRequest(ctx, http.MethodGet, url, nil, options)
-	if err != nil {
-		return fmt.Errorf("failed to create GET request: %w", err)
-	}
+// Post performs a POST request with JSON body and unmarshals JSON response
+func (c *Client) Post(ctx context.Context, url string, body interface{}, result interface{}, opts ...Option) error {
+	return c.Do(ctx, http.MethodPost, url, body, result, opts...)
+}
 
-	client := c.getClient(options)
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to make GET request: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
+// Patch performs a PATCH request with JSON body and unmarshals JSON response
+func (c *Client) Patch(ctx context.Context, url string, body interface{}, result interface{}, opts ...Option) error {
+	return c.Do(ctx, http.MethodPatch, url, body, result, opts...)
+}
 
-	return c.parseResponse(resp, result, options)
+// Put performs a PUT request with JSON body and unmarshals JSON response
+func (c *Client) Put(ctx context.Context, url string, body interface{}, result interface{}, opts ...Option) error {
+	return c.Do(ctx, http.MethodPut, url, body, result, opts...)
 }
 
-// Post performs a POST request with JSON body and unmarshals JSON response
-func (c *Client) Post(ctx context.Context, url string, body interface{}, result interface{}, opts ...Option) error {
-	options := buildOptions(opts...)
+// Delete performs a DELETE request and unmarshals JSON response
+func (c *Client) Delete(ctx context.Context, url string, result interface{}, opts ...Option) error {
+	return c.Do(ctx, http.MethodDelete, url, nil, result, opts...)
+}
 
-	req, err := c.buildRequest(ctx, http.MethodPost, url, body, options)
-	if err != nil {
-		return fmt.Errorf("failed to create POST request: %w", err)
+// Do performs a request with any HTTP method, sending body as JSON when it is not nil and
+// unmarshalling the JSON response into result when it is not nil.
+// Bodies of type []byte are sent as is and io.Reader bodies are streamed, closing them when they are
+// io.ReadClosers. The Content-Type header defaults to application/json for requests with a body,
+// or the ContentType of bodies such as MultipartRelated. Results implementing ResponseDecoder decode
+// successful responses themselves.
+func (c *Client) Do(ctx context.Context, method, url string, body interface{}, result interface{}, opts ...Option) error {
+	start := time.Now()
+	stats := &requestStats{}
+	life := c.lifecycle()
+	if err := life.begin(); err != nil {
+		return c.requestError(method, url, start, stats, fmt.Errorf("failed to make %s request: %w", method, err))
 	}
-	req.Header.Set("Content-Type", "application/json")
+	defer life.end()
+	options := buildOptions(opts...)
+	defer releaseOptions(options)
 
-	client := c.getClient(options)
-	resp, err := client.Do(req)
+	ctx = context.WithValue(ctx, requestStatsKey{}, stats)
+	if c.mayTimeOut(ctx, options) {
+		ctx, stats.tracer = withTracer(ctx, start)
+	}
+	var err error
+	if c.Scheduler != nil {
+		var release func()
+		if release, err = c.Scheduler.acquire(ctx, options.Priority); err == nil {
+			err = c.do(ctx, method, url, body, result, options)
+			release()
+		}
+	} else {
+		err = c.do(ctx, method, url, body, result, options)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to make POST request: %w", err)
+		err = c.requestError(method, url, start, stats, err)
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	return c.parseResponse(resp, result, options)
+	if err != nil && options.Fallback != nil {
+		return options.Fallback(ctx, err)
+	}
+	return err
 }
 
-// Patch performs a PATCH request with JSON body and unmarshals JSON response
-func (c *Client) Patch(ctx context.Context, url string, body interface{}, result interface{}, opts ...Option) error {
-	options := buildOptions(opts...)
-
-	req, err := c.buildRequest(ctx, http.MethodPatch, url, body, options)
+// do sends a single request, including its retries
+func (c *Client) do(ctx context.Context, method, url string, body interface{}, result interface{}, options *Options) error {
+	if options.RetryNonIdempotent {
+		ctx = context.WithValue(ctx, retryNonIdempotentKey{}, true)
+	}
+	if options.BeforeRetry != nil {
+		ctx = context.WithValue(ctx, beforeRetryKey{}, &beforeRetry{
+			hook: options.BeforeRetry, body: body, transformers: c.requestTransformers(options),
+			contentDigest: options.ContentDigest, contentMD5: options.ContentMD5,
+		})
+	}
+	if options.Route != "" {
+		ctx = context.WithValue(ctx, routeKey{}, options.Route)
+	} else if len(options.PathParams) > 0 {
+		ctx = context.WithValue(ctx, routeKey{}, routeTemplate(url))
+	}
+	req, release, err := c.buildRequest(ctx, method, url, body, options)
 	if err != nil {
-		return fmt.Errorf("failed to create PATCH request: %w", err)
+		return fmt.Errorf("failed to create %s request: %w", method, err)
+	}
+	defer release()
+	c.setDefaultHeaders(req, body, options)
+	if err := setContentDigests(req, options); err != nil {
+		return err
+	}
+
+	if profile := c.hostProfile(req.URL.Hostname()); profile != nil {
+		for key, value := range profile.Headers {
+			if req.Header.Get(key) == "" {
+				req.Header.Set(key, value)
+			}
+		}
+		if profile.Timeout > 0 {
+			ctx, cancel := context.WithTimeout(req.Context(), profile.Timeout)
+			defer cancel()
+			req = req.WithContext(ctx)
+		}
 	}
-	req.Header.Set("Content-Type", "application/json")
 
+	stats, _ := ctx.Value(requestStatsKey{}).(*requestStats)
+	if stats != nil {
+		stats.url = req.URL
+	}
+	var capture *failureCapture
+	if c.FailureDump != nil {
+		capture = c.FailureDump.capture(req)
+	}
 	client := c.getClient(options)
 	resp, err := client.Do(req)
+	redactURLError(err, req.URL)
+	// Without retryTransport the request is sent once
+	if stats != nil && !c.needsRetries() {
+		atomic.StoreInt32(&stats.attempts, 1)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to make PATCH request: %w", err)
+		err = fmt.Errorf("failed to make %s request: %w", method, err)
+		c.reportError(req, nil, capture, err)
+		return err
 	}
 	defer func() { _ = resp.Body.Close() }()
+	if options.VerifyDigest {
+		verifyContentDigests(resp)
+	}
+	if capture != nil {
+		resp.Body = capture.tee(resp.Body)
+	}
 
-	return c.parseResponse(resp, result, options)
+	if err := c.parseResponse(resp, result, options); err != nil {
+		c.reportError(req, resp, capture, err)
+		return err
+	}
+	if options.FollowLocation != nil {
+		return c.followLocation(ctx, resp, options)
+	}
+	return nil
 }
 
-// Put performs a PUT request with JSON body and unmarshals JSON response
-func (c *Client) Put(ctx context.Context, url string, body interface{}, result interface{}, opts ...Option) error {
-	options := buildOptions(opts...)
-
-	req, err := c.buildRequest(ctx, http.MethodPut, url, body, options)
-	if err != nil {
-		return fmt.Errorf("failed to create PUT request: %w", err)
+// mayTimeOut reports whether the request of ctx has a deadline or timeout, whose failures report RequestTimings
+func (c *Client) mayTimeOut(ctx context.Context, options *Options) bool {
+	if _, ok := ctx.Deadline(); ok {
+		return true
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	client := c.getClient(options)
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to make PUT request: %w", err)
+	client := http.DefaultClient
+	if options.Client != nil {
+		client = options.Client
+	} else if c.Client != nil {
+		client = c.Client
 	}
-	defer func() { _ = resp.Body.Close() }()
+	if client.Timeout > 0 {
+		return true
+	}
+	for _, profile := range c.HostProfiles {
+		if profile.Timeout > 0 {
+			return true
+		}
+	}
+	return false
+}
 
-	return c.parseResponse(resp, result, options)
+// requestError wraps err into a RequestError, redacting the URL of the request or, when it could not be built, rawURL
+func (c *Client) requestError(method, rawURL string, start time.Time, stats *requestStats, err error) error {
+	end := time.Now()
+	requestErr := &RequestError{Method: method, Attempts: int(atomic.LoadInt32(&stats.attempts)), Elapsed: end.Sub(start), Err: err}
+	if stats.tracer != nil && errorCategory(err) == ErrTimeout {
+		requestErr.Timings = stats.tracer.timings(end)
+	}
+	if stats.url != nil {
+		requestErr.URL = redactedURL(stats.url)
+	} else if u, parseErr := url.Parse(c.requestURL(rawURL, nil)); parseErr == nil {
+		requestErr.URL = redactedURL(u)
+	}
+	return requestErr
 }
 
-// Delete performs a DELETE request and unmarshals JSON response
-func (c *Client) Delete(ctx context.Context, url string, result interface{}, opts ...Option) error {
-	options := buildOptions(opts...)
+// reportError dumps a failed request to FailureDump and passes it to OnError, resp is nil for transport errors
+func (c *Client) reportError(req *http.Request, resp *http.Response, capture *failureCapture, err error) {
+	if c.FailureDump != nil {
+		c.FailureDump.write(req, resp, capture, err)
+	}
+	if c.OnError != nil {
+		c.OnError(req, err)
+	}
+}
 
-	req, err := c.buildRequest(ctx, http.MethodDelete, url, nil, options)
+// followLocation fetches the resource at the Location of a 201 or 202 response into options.FollowLocation,
+// with the headers of the original request. It bypasses the scheduler, whose slot the request still holds.
+func (c *Client) followLocation(ctx context.Context, resp *http.Response, options *Options) error {
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
+		return nil
+	}
+	location, err := resp.Location()
+	if err == http.ErrNoLocation {
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create DELETE request: %w", err)
+		return fmt.Errorf("failed to parse Location header: %w", err)
+	}
+	headers := options.Headers
+	if !sameOrigin(resp.Request.URL, location) {
+		// Like redirects, a Location on another origin does not get the credentials of the request
+		headers = make(map[string]string, len(options.Headers))
+		for key, value := range options.Headers {
+			headers[key] = value
+		}
+		for _, sensitive := range c.sensitiveHeaders() {
+			for key := range headers {
+				if strings.EqualFold(key, sensitive) {
+					delete(headers, key)
+				}
+			}
+		}
+	}
+	follow := buildOptions(WithHeaders(headers))
+	defer releaseOptions(follow)
+	// The followed request is reported as part of the original one
+	ctx = context.WithValue(ctx, requestStatsKey{}, (*requestStats)(nil))
+	if err := c.do(ctx, http.MethodGet, location.String(), nil, options.FollowLocation, follow); err != nil {
+		return fmt.Errorf("failed to follow Location %s: %w", location, err)
 	}
+	return nil
+}
 
-	client := c.getClient(options)
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to make DELETE request: %w", err)
+// setDefaultHeaders fills in the headers the request did not set explicitly
+func (c *Client) setDefaultHeaders(req *http.Request, body interface{}, options *Options) {
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		contentType := "application/json"
+		if typer, ok := body.(contentTyper); ok {
+			contentType = typer.ContentType()
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+	if _, ok := req.Header["User-Agent"]; !ok {
+		req.Header.Set("User-Agent", c.userAgent())
+	}
+	// net/http only asks for gzip when the request has no Accept-Encoding
+	if (c.DisableCompression || options.IdentityEncoding) && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "identity")
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	return c.parseResponse(resp, result, options)
+	locale := options.Locale
+	if locale == "" {
+		locale = c.Locale
+	}
+	if locale != "" {
+		if req.Header.Get("Accept-Language") == "" {
+			req.Header.Set("Accept-Language", locale)
+		}
+		if c.LocaleHeader != "" && req.Header.Get(c.LocaleHeader) == "" {
+			req.Header.Set(c.LocaleHeader, locale)
+		}
+	}
+}
+
+func (c *Client) userAgent() string {
+	if c.UserAgent != "" {
+		return c.UserAgent
+	}
+	return DefaultUserAgent
 }
 
 // parseResponse reads and unmarshals JSON response
@@ -155,15 +409,160 @@ func (c *Client) parseResponse(resp *http.Response, result interface{}, options
 	if options.Status != nil {
 		*options.Status = resp.StatusCode
 	}
+	if options.ResponseCookies != nil {
+		*options.ResponseCookies = resp.Cookies()
+	}
+	if options.ETag != nil {
+		*options.ETag = resp.Header.Get("ETag")
+	}
+	if options.ServerTiming != nil {
+		*options.ServerTiming = ParseServerTiming(resp.Header.Values("Server-Timing")...)
+	}
+	if options.Warnings != nil || c.OnWarning != nil {
+		warnings := ParseWarnings(resp.Header.Values("Warning")...)
+		if c.OnWarning != nil && resp.Request != nil {
+			for _, warning := range warnings {
+				c.OnWarning(resp.Request, warning)
+			}
+		}
+		if options.Warnings != nil {
+			*options.Warnings = warnings
+		}
+	}
+	if options.Deprecation != nil || c.OnDeprecation != nil {
+		deprecation := ParseDeprecation(resp.Header)
+		if deprecation == nil {
+			deprecation = &Deprecation{}
+		} else if c.OnDeprecation != nil && resp.Request != nil {
+			c.OnDeprecation(resp.Request, *deprecation)
+		}
+		if options.Deprecation != nil {
+			*options.Deprecation = *deprecation
+		}
+	}
+	if options.NotModified != nil {
+		*options.NotModified = resp.StatusCode == http.StatusNotModified
+	}
+	// A 304 has no body, leave the result as it was
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
+	failed := statusFailed(resp.StatusCode, options)
+
+	if decoder, ok := result.(ResponseDecoder); ok && !failed {
+		r := *resp
+		if c.MaxResponseBytes > 0 {
+			r.Body = io.NopCloser(&maxBytesReader{r: resp.Body, limit: c.MaxResponseBytes, n: c.MaxResponseBytes})
+		}
+		var err error
+		if unwrapper, ok := decoder.(envelopeDecoder); ok && c.envelope(options) != "" {
+			err = unwrapper.decodeEnvelope(&r, c.envelope(options))
+		} else {
+			err = decoder.DecodeResponse(&r)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		if options.Trailer != nil {
+			*options.Trailer = resp.Trailer
+		}
+		return nil
+	}
+
+	// Without status capture and custom decoding, successful responses are decoded straight from the body;
+	// digests are only verified once the whole body was read, which the decoder does not guarantee.
+	// WithoutBodyBuffering also streams captured error statuses, whose decoding errors are then returned.
+	unbuffered := options.NoBodyBuffering && !failed
+	if unbuffered {
+		if err := c.checkUnbuffered(result, options); err != nil {
+			return err
+		}
+	}
+	streamable := (options.Status == nil || unbuffered) && !failed && options.Validator == nil && !options.VerifyDigest &&
+		len(c.ResponseTransformers) == 0 && len(options.ResponseTransformers) == 0
+	if result == nil && len(options.Fields) == 0 && unbuffered {
+		if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+		if options.Trailer != nil {
+			*options.Trailer = resp.Trailer
+		}
+		return nil
+	}
+	if result == nil && len(options.Fields) > 0 && streamable {
+		err := c.projectStream(resp.Body, options.Fields)
+		if options.Trailer != nil {
+			*options.Trailer = resp.Trailer
+		}
+		return err
+	}
+	if result != nil && streamable && c.UnmarshalFunc == nil && c.envelope(options) == "" && len(options.RawFields) == 0 &&
+		len(options.Fields) == 0 {
+		err := c.decodeStream(resp.Body, result)
+		if options.Trailer != nil {
+			*options.Trailer = resp.Trailer
+		}
+		return err
+	}
+
+	// json.Unmarshal never retains its input, so the buffer can be reused afterwards;
+	// custom UnmarshalFuncs might, so they get a buffer of their own
+	buf := new(bytes.Buffer)
+	if c.UnmarshalFunc == nil && !c.DisableBufferPool {
+		pooled := getBuffer()
+		defer putBuffer(pooled)
+		buf = &pooled.Buffer
+	}
+	if err := c.readBody(buf, resp.Body); err != nil {
 		return fmt.Errorf("failed to read response body: %w", err)
 	}
+	body := buf.Bytes()
+	// Trailers are only known once the body has been read
+	if options.Trailer != nil {
+		*options.Trailer = resp.Trailer
+	}
 
 	// Return error for non-OK status codes unless Status pointer is provided
-	if options.Status == nil && resp.StatusCode >= 400 {
-		return fmt.Errorf("HTTP error: %s, body: %s", resp.Status, string(body))
+	if failed {
+		httpErr := &HTTPError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Header:     resp.Header,
+			Body:       append([]byte(nil), body...),
+		}
+		if c.ErrorDecoder != nil {
+			if err := c.ErrorDecoder(httpErr); err != nil {
+				return err
+			}
+		}
+		return httpErr
+	}
+
+	if resp.StatusCode < 400 {
+		var err error
+		if body, err = c.transformResponse(resp, body, options); err != nil {
+			return fmt.Errorf("failed to transform response: %w", err)
+		}
+	}
+
+	if options.Validator != nil && resp.StatusCode < 400 && len(body) > 0 {
+		if err := options.Validator.ValidateJSON(body); err != nil {
+			return fmt.Errorf("failed to validate response: %w", err)
+		}
+	}
+
+	if len(options.Fields) > 0 && resp.StatusCode < 400 && len(body) > 0 {
+		if err := c.project(bytes.NewReader(body), options.Fields); err != nil {
+			return fmt.Errorf("failed to project response: %w", err)
+		}
+	}
+
+	if resp.StatusCode < 400 && len(body) > 0 {
+		var err error
+		if body, err = c.splitFields(body, result, options); err != nil {
+			return fmt.Errorf("failed to unwrap response: %w", err)
+		}
 	}
 
 	if result != nil {
@@ -180,42 +579,302 @@ func (c *Client) parseResponse(resp *http.Response, result interface{}, options
 	return nil
 }
 
+// checkUnbuffered rejects the options of a WithoutBodyBuffering request that need the whole body in memory
+func (c *Client) checkUnbuffered(result interface{}, options *Options) error {
+	var option string
+	switch {
+	case options.Validator != nil:
+		option = "WithValidator"
+	case options.VerifyDigest:
+		option = "WithVerifyDigest"
+	case len(c.ResponseTransformers) > 0 || len(options.ResponseTransformers) > 0:
+		option = "response transformers"
+	case len(options.RawFields) > 0:
+		option = "WithRawField"
+	case result != nil && len(options.Fields) > 0:
+		option = "WithField with a result"
+	case result != nil && c.envelope(options) != "":
+		option = "an envelope"
+	case result != nil && c.UnmarshalFunc != nil:
+		option = "a custom UnmarshalFunc"
+	default:
+		return nil
+	}
+	return fmt.Errorf("%w: %s needs the whole response body", ErrBodyBufferingRequired, option)
+}
+
+// statusFailed reports whether status fails the request with an HTTPError
+func statusFailed(status int, options *Options) bool {
+	if len(options.ExpectedStatus) > 0 {
+		for _, expected := range options.ExpectedStatus {
+			if status == expected {
+				return false
+			}
+		}
+		return true
+	}
+	return options.Status == nil && status >= 400
+}
+
+// decodeStream decodes the JSON value in r into result without buffering the whole body,
+// rejecting trailing data like json.Unmarshal and draining r so the connection can be reused
+func (c *Client) decodeStream(r io.Reader, result interface{}) error {
+	body := &errorTrackingReader{r: r}
+	if c.MaxResponseBytes > 0 {
+		body.r = &maxBytesReader{r: r, limit: c.MaxResponseBytes, n: c.MaxResponseBytes}
+	}
+
+	dec := json.NewDecoder(body)
+	if err := dec.Decode(result); err != nil {
+		if body.err != nil {
+			return fmt.Errorf("failed to read response body: %w", body.err)
+		}
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("failed to unmarshal JSON response: %w", err)
+	}
+
+	var chunk [512]byte
+	rest := io.MultiReader(dec.Buffered(), body)
+	for {
+		n, err := rest.Read(chunk[:])
+		for _, b := range chunk[:n] {
+			if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+				return fmt.Errorf("failed to unmarshal JSON response: invalid character %q after top-level value", b)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+	}
+}
+
+// errorTrackingReader remembers the first read error other than io.EOF
+type errorTrackingReader struct {
+	r   io.Reader
+	err error
+}
+
+func (t *errorTrackingReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if err != nil && err != io.EOF && t.err == nil {
+		t.err = err
+	}
+	return n, err
+}
+
+// maxBytesReader fails with ErrResponseTooLarge once more than limit bytes are read
+type maxBytesReader struct {
+	r     io.Reader
+	limit int64
+	n     int64
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.n < 0 {
+		return 0, fmt.Errorf("%w: limit is %d bytes", ErrResponseTooLarge, m.limit)
+	}
+	if int64(len(p)) > m.n+1 {
+		p = p[:m.n+1]
+	}
+	n, err := m.r.Read(p)
+	m.n -= int64(n)
+	if m.n < 0 {
+		return n, fmt.Errorf("%w: limit is %d bytes", ErrResponseTooLarge, m.limit)
+	}
+	return n, err
+}
+
+// readBody reads r into buf, enforcing MaxResponseBytes
+func (c *Client) readBody(buf *bytes.Buffer, r io.Reader) error {
+	if c.MaxResponseBytes <= 0 {
+		_, err := buf.ReadFrom(r)
+		return err
+	}
+	if _, err := buf.ReadFrom(io.LimitReader(r, c.MaxResponseBytes+1)); err != nil {
+		return err
+	}
+	if int64(buf.Len()) > c.MaxResponseBytes {
+		return fmt.Errorf("%w: limit is %d bytes", ErrResponseTooLarge, c.MaxResponseBytes)
+	}
+	return nil
+}
+
 func (c *Client) getClient(options *Options) *http.Client {
-	if options.Client != nil {
-		return options.Client
+	base := options.Client
+	if base == nil {
+		base = c.Client
 	}
-	if c.Client == nil {
-		return http.DefaultClient
+	if base == nil {
+		base = http.DefaultClient
 	}
-	return c.Client
+
+	// Shallow copy so the redirect policy can be wrapped without mutating the caller's client
+	client := *base
+	client.CheckRedirect = c.checkRedirect(base.CheckRedirect)
+	if c.needsGuard() {
+		client.Transport = c.guard(client.Transport)
+	}
+	if len(c.Middleware) > 0 {
+		client.Transport = chainMiddleware(client.Transport, c.Middleware)
+	}
+	// Outside of middleware so signatures cover the header, inside retries so every attempt has its own
+	if header := c.deadlineHeader(options); header != "" {
+		client.Transport = deadlineTransport(client.Transport, header, client.Timeout)
+	}
+	if c.needsProfiles() {
+		client.Transport = c.profileTransport(client.Transport)
+	}
+	// Retries wrap the middleware so every attempt goes through it
+	if c.needsRetries() {
+		client.Transport = c.retryTransport(client.Transport)
+	}
+	return &client
 }
 
-// buildRequest creates an HTTP request with the given method, URL, and body
-func (c *Client) buildRequest(ctx context.Context, method, url string, body interface{}, options *Options) (*http.Request, error) {
+// buildRequest creates an HTTP request with the given method, URL, and body. The returned release
+// function must be called once the request is done to recycle a pooled body buffer.
+func (c *Client) buildRequest(ctx context.Context, method, url string, body interface{}, options *Options) (*http.Request, func(), error) {
+	release := func() {}
+	url = c.requestURL(url, options.PathParams)
+
 	var bodyBytes []byte
+	var bodyReader io.Reader
+	var pooled *pooledBody
+	var spilled *spilledBody
+	// Transformed bodies are marshalled in memory, pooled and spilled bodies are not rewritten
+	transformers := c.requestTransformers(options)
 	if body != nil {
 		if b, ok := body.([]byte); ok {
 			bodyBytes = b
+		} else if r, ok := body.(io.Reader); ok {
+			bodyReader = r
+		} else if c.MarshalFunc == nil && c.SpillThreshold > 0 && transformers == nil {
+			var err error
+			spilled, bodyBytes, err = c.spillJSON(body)
+			if err != nil {
+				return nil, release, fmt.Errorf("failed to marshal request body: %w", err)
+			}
+			if spilled != nil {
+				release = spilled.release
+			}
+		} else if c.MarshalFunc == nil && !c.DisableBufferPool && transformers == nil {
+			buf, err := encodeJSON(body)
+			if err != nil {
+				return nil, release, fmt.Errorf("failed to marshal request body: %w", err)
+			}
+			pooled = newPooledBody(buf)
+			release = pooled.release
 		} else {
 			var err error
 			bodyBytes, err = c.marshal(body)
 			if err != nil {
-				return nil, fmt.Errorf("failed to marshal request body: %w", err)
+				return nil, release, fmt.Errorf("failed to marshal request body: %w", err)
 			}
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(bodyBytes))
+	var req *http.Request
+	var err error
+	if pooled != nil {
+		req, err = http.NewRequestWithContext(ctx, method, url, nil)
+		if err == nil {
+			req.Body, _ = pooled.reader()
+			req.ContentLength = int64(pooled.buf.Len())
+			req.GetBody = pooled.reader
+		}
+	} else if spilled != nil {
+		req, err = http.NewRequestWithContext(ctx, method, url, nil)
+		if err == nil {
+			req.Body, _ = spilled.reader()
+			req.ContentLength = spilled.size
+			req.GetBody = spilled.reader
+		}
+	} else if bodyReader != nil {
+		req, err = http.NewRequestWithContext(ctx, method, url, bodyReader)
+		if sized, ok := bodyReader.(contentLengther); ok && err == nil {
+			if req.ContentLength = sized.ContentLength(); req.ContentLength == 0 {
+				req.Body = http.NoBody
+			}
+		}
+	} else if body != nil {
+		// bytes.Reader lets net/http set ContentLength and GetBody without copying bodyBytes
+		req, err = http.NewRequestWithContext(ctx, method, url, bytes.NewReader(bodyBytes))
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, url, nil)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		release()
+		return nil, func() {}, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Apply headers from options
 	for key, value := range options.Headers {
 		req.Header.Set(key, value)
 	}
+	for _, cookie := range options.Cookies {
+		req.AddCookie(cookie)
+	}
+	if options.RequestTrailer != nil && req.Body != nil && req.Body != http.NoBody {
+		// Trailers are only sent with chunked encoding, which an unknown length selects
+		req.Trailer = options.RequestTrailer
+		req.ContentLength = -1
+	}
 
-	return req, nil
+	// Merge query parameters from options with those already in the URL
+	if len(options.Query) > 0 {
+		query := req.URL.Query()
+		for key, values := range options.Query {
+			for _, value := range values {
+				query.Add(key, value)
+			}
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+
+	if transformers != nil && body != nil && bodyReader == nil {
+		if bodyBytes, err = transformRequest(req, bodyBytes, transformers); err != nil {
+			release()
+			return nil, func() {}, fmt.Errorf("failed to transform request body: %w", err)
+		}
+		setBytesBody(req, bodyBytes)
+	}
+
+	return req, release, nil
+}
+
+// clone returns a copy of c whose slices can be modified without affecting c
+func (c *Client) clone() *Client {
+	// Creating the lifecycle first orders its creation by a concurrent request before the copy
+	c.lifecycle()
+	clone := *c
+	clone.life = atomic.Value{}
+	clone.SensitiveHeaders = cloneSlice(c.SensitiveHeaders)
+	clone.AllowedHosts = cloneSlice(c.AllowedHosts)
+	clone.DeniedHosts = cloneSlice(c.DeniedHosts)
+	clone.Middleware = cloneSlice(c.Middleware)
+	clone.ResponseTransformers = cloneSlice(c.ResponseTransformers)
+	clone.RequestTransformers = cloneSlice(c.RequestTransformers)
+	if c.HostProfiles != nil {
+		// Profiles are shared so their rate limiters keep counting across copies
+		clone.HostProfiles = make(map[string]*HostProfile, len(c.HostProfiles))
+		for host, profile := range c.HostProfiles {
+			clone.HostProfiles[host] = profile
+		}
+	}
+	return &clone
+}
+
+// cloneSlice copies s, keeping nil and empty slices apart since some fields give them different meanings
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
 }
 
 func (c *Client) marshal(v any) ([]byte, error) {
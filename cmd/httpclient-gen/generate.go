@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/llkhacquan/httpclient/openapi"
+)
+
+// generator accumulates the generated declarations of a package
+type generator struct {
+	doc *openapi.Document
+	buf bytes.Buffer
+	// pending holds inline object schemas that still need a named type
+	pending []namedSchema
+	named   map[string]bool
+}
+
+type namedSchema struct {
+	name   string
+	schema *openapi.Schema
+}
+
+// generate returns the formatted source of a client package for doc
+func generate(doc *openapi.Document, pkg string) ([]byte, error) {
+	g := &generator{doc: doc, named: map[string]bool{}}
+
+	g.writeClient()
+
+	names := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g.named[exportedName(name)] = true
+	}
+	for _, name := range names {
+		g.writeModel(exportedName(name), doc.Components.Schemas[name])
+	}
+
+	for _, op := range doc.Operations() {
+		if err := g.writeOperation(op); err != nil {
+			return nil, err
+		}
+	}
+
+	for len(g.pending) > 0 {
+		next := g.pending[0]
+		g.pending = g.pending[1:]
+		g.writeModel(next.name, next.schema)
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by httpclient-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "// Package %s is a client for the %s API.\n", pkg, doc.Info.Title)
+	fmt.Fprintf(&src, "package %s\n\n", pkg)
+	src.WriteString("import (\n\t\"context\"\n")
+	body := g.buf.String()
+	for _, imp := range []struct{ pkg, use string }{
+		{"fmt", "fmt."}, {"net/url", "url."}, {"time", "time."},
+	} {
+		if strings.Contains(body, imp.use) {
+			fmt.Fprintf(&src, "\t%q\n", imp.pkg)
+		}
+	}
+	src.WriteString("\n\t\"github.com/llkhacquan/httpclient\"\n)\n\n")
+	src.WriteString(body)
+
+	code, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return code, nil
+}
+
+func (g *generator) writeClient() {
+	g.printf("// Client calls the %s API\n", g.doc.Info.Title)
+	g.printf("type Client struct {\n")
+	g.printf("// HTTP performs the requests, defaults to the httpclient default client\n")
+	g.printf("HTTP *httpclient.Client\n")
+	g.printf("// BaseURL is prepended to every operation path\n")
+	g.printf("BaseURL string\n}\n\n")
+
+	g.printf("// NewClient returns a Client sending requests to baseURL\n")
+	g.printf("func NewClient(baseURL string, client *httpclient.Client) *Client {\n")
+	g.printf("return &Client{HTTP: client, BaseURL: baseURL}\n}\n\n")
+
+	g.printf("func (c *Client) do(ctx context.Context, method, path string, body, result interface{}, opts []httpclient.Option) error {\n")
+	g.printf("if c.HTTP == nil {\nreturn httpclient.Do(ctx, method, c.BaseURL+path, body, result, opts...)\n}\n")
+	g.printf("return c.HTTP.Do(ctx, method, c.BaseURL+path, body, result, opts...)\n}\n\n")
+}
+
+func (g *generator) writeModel(name string, schema *openapi.Schema) {
+	if schema.Description != "" {
+		g.printf("// %s %s\n", name, lowerFirst(schema.Description))
+	} else {
+		g.printf("// %s is generated from the %s schema\n", name, name)
+	}
+
+	if schema.Ref == "" && (schema.Type == "object" || schema.Type == "") && len(schema.Properties) > 0 {
+		g.printf("type %s struct {\n", name)
+		props := make([]string, 0, len(schema.Properties))
+		for prop := range schema.Properties {
+			props = append(props, prop)
+		}
+		sort.Strings(props)
+		for _, prop := range props {
+			field := exportedName(prop)
+			typ := g.goType(schema.Properties[prop], name+field)
+			tag := prop
+			if !contains(schema.Required, prop) {
+				tag += ",omitempty"
+				// omitempty has no effect on structs, make them optional through a pointer
+				if !basicTypes[typ] && !strings.HasPrefix(typ, "[]") && !strings.HasPrefix(typ, "map[") {
+					typ = "*" + typ
+				}
+			}
+			g.printf("%s %s `json:%q`\n", field, typ, tag)
+		}
+		g.printf("}\n\n")
+		return
+	}
+	g.printf("type %s %s\n\n", name, g.goType(schema, name+"Value"))
+}
+
+func (g *generator) writeOperation(op openapi.OperationRef) error {
+	name := exportedName(op.Operation.OperationID)
+	if op.Operation.OperationID == "" {
+		name = exportedName(strings.ToLower(op.Method) + " " + op.Path)
+	}
+
+	var pathParams, queryParams, headerParams []*openapi.Parameter
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			pathParams = append(pathParams, p)
+		case "query":
+			queryParams = append(queryParams, p)
+		case "header":
+			headerParams = append(headerParams, p)
+		}
+	}
+
+	args := []string{"ctx context.Context"}
+	for _, p := range pathParams {
+		args = append(args, fmt.Sprintf("%s %s", paramName(p.Name), g.goType(p.Schema, name+exportedName(p.Name))))
+	}
+
+	paramsType := name + "Params"
+	if len(queryParams)+len(headerParams) > 0 {
+		g.writeParams(paramsType, name, append(queryParams, headerParams...))
+		args = append(args, "params *"+paramsType)
+	}
+
+	hasBody := false
+	if op.Operation.RequestBody != nil {
+		if schema := openapi.JSONSchema(op.Operation.RequestBody.Content); schema != nil {
+			args = append(args, "body "+g.goType(schema, name+"Request"))
+			hasBody = true
+		}
+	}
+	args = append(args, "opts ...httpclient.Option")
+
+	resultType := ""
+	if schema := g.successSchema(op.Operation); schema != nil {
+		resultType = g.goType(schema, name+"Response")
+	}
+
+	path, err := pathExpr(op.Path, pathParams)
+	if err != nil {
+		return fmt.Errorf("operation %s: %w", name, err)
+	}
+
+	g.printf("// %s calls %s %s\n", name, op.Method, op.Path)
+	if op.Operation.Summary != "" {
+		g.printf("//\n// %s\n", op.Operation.Summary)
+	}
+	if resultType != "" {
+		g.printf("func (c *Client) %s(%s) (%s, error) {\n", name, strings.Join(args, ", "), resultType)
+		g.printf("var result %s\n", resultType)
+	} else {
+		g.printf("func (c *Client) %s(%s) error {\n", name, strings.Join(args, ", "))
+	}
+
+	if len(queryParams)+len(headerParams) > 0 {
+		g.printf("if params != nil {\n")
+		if len(queryParams) > 0 {
+			g.printf("query := url.Values{}\n")
+			for _, p := range queryParams {
+				g.writeParamValue(p, "query.Add(%q, fmt.Sprint(%s))\n")
+			}
+			g.printf("opts = append(opts, httpclient.WithQuery(query))\n")
+		}
+		for _, p := range headerParams {
+			g.writeParamValue(p, "opts = append(opts, httpclient.WithHeader(%q, fmt.Sprint(%s)))\n")
+		}
+		g.printf("}\n")
+	}
+
+	bodyArg := "nil"
+	if hasBody {
+		bodyArg = "body"
+	}
+	if resultType != "" {
+		g.printf("err := c.do(ctx, %q, %s, %s, &result, opts)\n", op.Method, path, bodyArg)
+		g.printf("return result, err\n}\n\n")
+	} else {
+		g.printf("return c.do(ctx, %q, %s, %s, nil, opts)\n}\n\n", op.Method, path, bodyArg)
+	}
+	return nil
+}
+
+func (g *generator) writeParams(typeName, opName string, params []*openapi.Parameter) {
+	g.printf("// %s holds the optional query and header parameters of %s\n", typeName, opName)
+	g.printf("type %s struct {\n", typeName)
+	for _, p := range params {
+		typ := g.goType(p.Schema, opName+exportedName(p.Name))
+		if !strings.HasPrefix(typ, "[]") {
+			typ = "*" + typ
+		}
+		g.printf("%s %s\n", exportedName(p.Name), typ)
+	}
+	g.printf("}\n\n")
+}
+
+// writeParamValue emits stmt for every value of p found in params, stmt receives the parameter name and value expression
+func (g *generator) writeParamValue(p *openapi.Parameter, stmt string) {
+	field := "params." + exportedName(p.Name)
+	if p.Schema != nil && p.Schema.Type == "array" {
+		g.printf("for _, v := range %s {\n", field)
+		g.printf(stmt, p.Name, "v")
+		g.printf("}\n")
+		return
+	}
+	g.printf("if %s != nil {\n", field)
+	g.printf(stmt, p.Name, "*"+field)
+	g.printf("}\n")
+}
+
+// successSchema returns the JSON schema of the first 2xx response, or nil
+func (g *generator) successSchema(op *openapi.Operation) *openapi.Schema {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") && op.Responses[code] != nil {
+			if schema := openapi.JSONSchema(op.Responses[code].Content); schema != nil {
+				return schema
+			}
+		}
+	}
+	return nil
+}
+
+// goType returns the Go type of schema, registering inline objects under hint
+func (g *generator) goType(schema *openapi.Schema, hint string) string {
+	if schema == nil {
+		return "interface{}"
+	}
+	if schema.Ref != "" {
+		name, err := openapi.RefName(schema.Ref)
+		if err != nil {
+			return "interface{}"
+		}
+		return exportedName(name)
+	}
+
+	switch schema.Type {
+	case "string":
+		switch schema.Format {
+		case "date-time":
+			return "time.Time"
+		case "byte", "binary":
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		if schema.Format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case "number":
+		if schema.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.goType(schema.Items, hint+"Item")
+	case "object", "":
+		if len(schema.Properties) > 0 {
+			if !g.named[hint] {
+				g.named[hint] = true
+				g.pending = append(g.pending, namedSchema{name: hint, schema: schema})
+			}
+			return hint
+		}
+		if schema.AdditionalProperties != nil {
+			return "map[string]" + g.goType(schema.AdditionalProperties, hint+"Value")
+		}
+		if schema.Type == "object" {
+			return "map[string]interface{}"
+		}
+	}
+	return "interface{}"
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// pathExpr returns a Go expression building path with escaped path parameters
+func pathExpr(path string, params []*openapi.Parameter) (string, error) {
+	var parts []string
+	for path != "" {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			parts = append(parts, fmt.Sprintf("%q", path))
+			break
+		}
+		end := strings.IndexByte(path[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated path parameter in %q", path)
+		}
+		if start > 0 {
+			parts = append(parts, fmt.Sprintf("%q", path[:start]))
+		}
+
+		name := path[start+1 : start+end]
+		found := false
+		for _, p := range params {
+			if p.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("path parameter %q is not declared", name)
+		}
+		parts = append(parts, fmt.Sprintf("url.PathEscape(fmt.Sprint(%s))", paramName(name)))
+		path = path[start+end+1:]
+	}
+	if len(parts) == 0 {
+		return `""`, nil
+	}
+	return strings.Join(parts, " + "), nil
+}
+
+var basicTypes = map[string]bool{
+	"string": true, "int32": true, "int64": true, "float32": true, "float64": true, "bool": true, "interface{}": true,
+}
+
+var initialisms = map[string]bool{
+	"api": true, "http": true, "id": true, "json": true, "uri": true, "url": true, "uuid": true,
+}
+
+var keywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true, "default": true,
+	"defer": true, "else": true, "fallthrough": true, "for": true, "func": true, "go": true, "goto": true,
+	"if": true, "import": true, "interface": true, "map": true, "package": true, "range": true,
+	"return": true, "select": true, "struct": true, "switch": true, "type": true, "var": true,
+	"ctx": true, "opts": true, "params": true, "body": true, "result": true, "err": true,
+}
+
+// words splits s on separators and lower to upper case transitions
+func words(s string) []string {
+	var out []string
+	var cur []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(cur) > 0 {
+				out = append(out, string(cur))
+				cur = nil
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && len(cur) > 0 && unicode.IsLower(runes[i-1]) {
+			out = append(out, string(cur))
+			cur = nil
+		}
+		cur = append(cur, r)
+	}
+	if len(cur) > 0 {
+		out = append(out, string(cur))
+	}
+	return out
+}
+
+// exportedName converts s into an exported Go identifier
+func exportedName(s string) string {
+	var b strings.Builder
+	for _, w := range words(s) {
+		if initialisms[strings.ToLower(w)] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	name := b.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "N" + name
+	}
+	return name
+}
+
+// paramName converts s into an unexported Go identifier usable as a function parameter
+func paramName(s string) string {
+	ws := words(s)
+	if len(ws) == 0 {
+		return "param"
+	}
+	name := strings.ToLower(ws[0]) + exportedName(strings.Join(ws[1:], " "))
+	if len(ws) == 1 {
+		name = strings.ToLower(ws[0])
+	}
+	if keywords[name] || unicode.IsDigit([]rune(name)[0]) {
+		name += "Param"
+	}
+	return name
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	if len(r) > 1 && unicode.IsUpper(r[1]) {
+		return s
+	}
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
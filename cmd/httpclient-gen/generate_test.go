@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/llkhacquan/httpclient/openapi"
+)
+
+func TestGenerate(t *testing.T) {
+	doc, err := openapi.Load("testdata/petstore.json")
+	if err != nil {
+		t.Fatalf("loading spec failed: %v", err)
+	}
+
+	code, err := generate(doc, "petstore")
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	src := string(code)
+
+	expected := []string{
+		"package petstore",
+		"type Pet struct {",
+		"Owner  *PetOwner  `json:\"owner,omitempty\"`",
+		"type Pets []Pet",
+		"func (c *Client) ListPets(ctx context.Context, params *ListPetsParams, opts ...httpclient.Option) (Pets, error) {",
+		"func (c *Client) CreatePet(ctx context.Context, body NewPet, opts ...httpclient.Option) (Pet, error) {",
+		"func (c *Client) ShowPetByID(ctx context.Context, petID int64, opts ...httpclient.Option) (Pet, error) {",
+		`"/pets/"+url.PathEscape(fmt.Sprint(petID))`,
+		"func (c *Client) DeletePetsPetID(ctx context.Context, petID int64, opts ...httpclient.Option) error {",
+	}
+	for _, want := range expected {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated code is missing %q", want)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		in, exported, param string
+	}{
+		{"petId", "PetID", "petID"},
+		{"X-Request-ID", "XRequestID", "xRequestID"},
+		{"list_all_pets", "ListAllPets", "listAllPets"},
+		{"type", "Type", "typeParam"},
+	}
+	for _, tt := range tests {
+		if got := exportedName(tt.in); got != tt.exported {
+			t.Errorf("exportedName(%q): expected %q, got %q", tt.in, tt.exported, got)
+		}
+		if got := paramName(tt.in); got != tt.param {
+			t.Errorf("paramName(%q): expected %q, got %q", tt.in, tt.param, got)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Command httpclient-gen generates a typed Go client from an OpenAPI 3 JSON document.
+//
+// The generated package contains one struct per component schema and one method per operation
+// on a Client type that delegates to httpclient.Client:
+//
+//	go run github.com/llkhacquan/httpclient/cmd/httpclient-gen -spec petstore.json -package petstore -o petstore/client.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/llkhacquan/httpclient/openapi"
+)
+
+func main() {
+	spec := flag.String("spec", "", "path to the OpenAPI 3 JSON document")
+	pkg := flag.String("package", "api", "name of the generated package")
+	out := flag.String("o", "", "output file, defaults to stdout")
+	flag.Parse()
+
+	if *spec == "" {
+		fmt.Fprintln(os.Stderr, "httpclient-gen: -spec is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*spec, *pkg, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "httpclient-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(spec, pkg, out string) error {
+	doc, err := openapi.Load(spec)
+	if err != nil {
+		return err
+	}
+
+	code, err := generate(doc, pkg)
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	if err := os.WriteFile(out, code, 0o644); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// selectPath returns the value at a jq style path such as ".results[0].name", "." selects v itself
+func selectPath(v interface{}, path string) (interface{}, error) {
+	if !strings.HasPrefix(path, ".") {
+		return nil, fmt.Errorf("path %q must start with '.'", path)
+	}
+
+	rest := path[1:]
+	for rest != "" {
+		switch {
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated index in path %q", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid index %q in path %q", rest[1:end], path)
+			}
+			list, ok := v.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot index %T with [%d]", v, index)
+			}
+			if index < 0 {
+				index += len(list)
+			}
+			if index < 0 || index >= len(list) {
+				return nil, nil
+			}
+			v = list[index]
+			rest = rest[end+1:]
+		case rest[0] == '.':
+			rest = rest[1:]
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			object, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot select field %q of %T", key, v)
+			}
+			v = object[key]
+			rest = rest[end:]
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,278 @@
+// Command httpclient sends JSON requests from the command line using the httpclient package.
+//
+// Usage:
+//
+//	httpclient <get|post|put|patch|delete> URL [flags]
+//	httpclient replay FILE [flags]
+//
+// Examples:
+//
+//	httpclient get https://pokeapi.co/api/v2/pokemon/pikachu --jq .name
+//	httpclient post https://httpbin.org/post -d '{"pokemon":"pikachu"}' -H 'X-Trace: 1'
+//	httpclient post https://httpbin.org/post -d '{"pokemon":"pikachu"}' --retries 3 --retry-non-idempotent
+//	httpclient replay /var/tmp/failures/20261016T150405.123Z-0001-POST-api.example.com.http --target https://staging.example.com
+//
+// The replay command re-sends a request of a failure dump, HAR file or cassette, printing the response status
+// before its body.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/llkhacquan/httpclient"
+	"github.com/llkhacquan/httpclient/httpclienttest"
+)
+
+var methods = map[string]string{
+	"get":    http.MethodGet,
+	"post":   http.MethodPost,
+	"put":    http.MethodPut,
+	"patch":  http.MethodPatch,
+	"delete": http.MethodDelete,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// headerFlags collects repeated -H "Key: Value" flags
+type headerFlags []string
+
+func (h *headerFlags) String() string { return strings.Join(*h, ", ") }
+
+func (h *headerFlags) Set(value string) error {
+	if !strings.Contains(value, ":") {
+		return fmt.Errorf("header %q must have the form 'Key: Value'", value)
+	}
+	*h = append(*h, value)
+	return nil
+}
+
+type config struct {
+	method             string
+	url                string
+	replay             bool
+	target             string
+	index              int
+	headers            headerFlags
+	data               string
+	jq                 string
+	bearer             string
+	user               string
+	timeout            time.Duration
+	retries            int
+	retryNonIdempotent bool
+	status             bool
+	compact            bool
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	cfg, err := parseArgs(args, stderr)
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(stderr, "httpclient: %v\n", err)
+		}
+		return 2
+	}
+
+	if err := execute(cfg, stdout); err != nil {
+		fmt.Fprintf(stderr, "httpclient: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func parseArgs(args []string, stderr io.Writer) (*config, error) {
+	usage := "usage: httpclient <get|post|put|patch|delete> URL [flags]\n       httpclient replay FILE [flags]"
+	if len(args) == 0 {
+		return nil, errors.New(usage)
+	}
+	cfg := &config{replay: strings.ToLower(args[0]) == "replay"}
+	method, ok := methods[strings.ToLower(args[0])]
+	if !ok && !cfg.replay {
+		return nil, fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+	cfg.method = method
+
+	fs := flag.NewFlagSet("httpclient "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Var(&cfg.headers, "H", "request header 'Key: Value', may be repeated")
+	if cfg.replay {
+		fs.StringVar(&cfg.target, "target", "", "scheme and host replacing those of the replayed request, e.g. https://staging.example.com")
+		fs.IntVar(&cfg.index, "index", 0, "index of the replayed request in HAR files and cassettes")
+	} else {
+		fs.StringVar(&cfg.data, "d", "", "request body, '@file' reads it from a file and '@-' from stdin")
+		fs.BoolVar(&cfg.status, "status", false, "print the response status code and accept non-2xx responses")
+		fs.IntVar(&cfg.retries, "retries", 0, "number of times a failed request is retried")
+		fs.BoolVar(&cfg.retryNonIdempotent, "retry-non-idempotent", false, "retry POST and PATCH requests too")
+	}
+	fs.StringVar(&cfg.jq, "jq", "", "print only the value at a path such as .results[0].name")
+	fs.StringVar(&cfg.bearer, "bearer", "", "bearer token sent in the Authorization header")
+	fs.StringVar(&cfg.user, "user", "", "basic auth credentials 'user:password'")
+	fs.DurationVar(&cfg.timeout, "timeout", 30*time.Second, "request timeout")
+	fs.BoolVar(&cfg.compact, "compact", false, "print compact instead of indented JSON")
+
+	// Flags may appear before or after the URL
+	var positional []string
+	for {
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()
+	}
+	if len(positional) != 1 {
+		what := "URL"
+		if cfg.replay {
+			what = "file"
+		}
+		return nil, fmt.Errorf("expected exactly one %s\n%s", what, usage)
+	}
+	cfg.url = positional[0]
+	return cfg, nil
+}
+
+func execute(cfg *config, stdout io.Writer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+
+	var opts []httpclient.Option
+	for _, h := range cfg.headers {
+		kv := strings.SplitN(h, ":", 2)
+		opts = append(opts, httpclient.WithHeader(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])))
+	}
+	if cfg.bearer != "" {
+		opts = append(opts, httpclient.WithHeader("Authorization", "Bearer "+cfg.bearer))
+	}
+	if cfg.user != "" {
+		opts = append(opts, httpclient.WithHeader("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(cfg.user))))
+	}
+	if cfg.replay {
+		return replay(ctx, cfg, opts, stdout)
+	}
+	var status int
+	if cfg.status {
+		opts = append(opts, httpclient.WithStatus(&status))
+	}
+	if cfg.retryNonIdempotent {
+		opts = append(opts, httpclient.WithRetryNonIdempotent())
+	}
+
+	var body interface{}
+	if cfg.data != "" {
+		data, err := readData(cfg.data)
+		if err != nil {
+			return err
+		}
+		body = data
+	}
+
+	client := &httpclient.Client{
+		MaxRetries: cfg.retries,
+		// Accept empty bodies such as 204 No Content responses
+		UnmarshalFunc: func(data []byte, v any) error {
+			if len(bytes.TrimSpace(data)) == 0 {
+				return nil
+			}
+			return json.Unmarshal(data, v)
+		},
+	}
+
+	var result json.RawMessage
+	if err := client.Do(ctx, cfg.method, cfg.url, body, &result, opts...); err != nil {
+		return err
+	}
+
+	if cfg.status {
+		fmt.Fprintln(stdout, status)
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return printJSON(stdout, result, cfg.jq, cfg.compact)
+}
+
+// replay re-sends a request loaded from cfg.url, printing the response status and body
+func replay(ctx context.Context, cfg *config, opts []httpclient.Option, stdout io.Writer) error {
+	requests, err := httpclienttest.LoadRequests(cfg.url)
+	if err != nil {
+		return err
+	}
+	if cfg.index < 0 || cfg.index >= len(requests) {
+		return fmt.Errorf("request index %d out of range, %s holds %d requests", cfg.index, cfg.url, len(requests))
+	}
+	replayer := &httpclienttest.Replayer{Target: cfg.target}
+	resp, err := replayer.Replay(ctx, requests[cfg.index], opts...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, resp.StatusCode)
+	if resp.Body == "" {
+		return nil
+	}
+	if json.Valid([]byte(resp.Body)) {
+		return printJSON(stdout, json.RawMessage(resp.Body), cfg.jq, cfg.compact)
+	}
+	_, err = fmt.Fprintln(stdout, resp.Body)
+	return err
+}
+
+func readData(data string) ([]byte, error) {
+	switch {
+	case data == "@-":
+		return io.ReadAll(os.Stdin)
+	case strings.HasPrefix(data, "@"):
+		return os.ReadFile(data[1:])
+	}
+	return []byte(data), nil
+}
+
+func printJSON(w io.Writer, raw json.RawMessage, path string, compact bool) error {
+	if path != "" {
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+		selected, err := selectPath(v, path)
+		if err != nil {
+			return err
+		}
+		// Print strings without quotes, like jq -r
+		if s, ok := selected.(string); ok {
+			_, err := fmt.Fprintln(w, s)
+			return err
+		}
+		if raw, err = json.Marshal(selected); err != nil {
+			return err
+		}
+	}
+
+	var out bytes.Buffer
+	var err error
+	if compact {
+		err = json.Compact(&out, raw)
+	} else {
+		err = json.Indent(&out, raw, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to format response: %w", err)
+	}
+	out.WriteByte('\n')
+	_, err = out.WriteTo(w)
+	return err
+}
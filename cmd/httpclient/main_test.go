@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var mu sync.Mutex
+	flaky := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			// Fails the first request of each key
+			mu.Lock()
+			failed := flaky[r.URL.Query().Get("key")]
+			flaky[r.URL.Query().Get("key")] = true
+			mu.Unlock()
+			if !failed {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`{"name":"pikachu"}`))
+		case "/pokemon/pikachu":
+			_, _ = w.Write([]byte(`{"id":25,"name":"pikachu","types":[{"type":{"name":"electric"}}]}`))
+		case "/echo":
+			body, _ := io.ReadAll(r.Body)
+			_, _ = w.Write([]byte(`{"trace":"` + r.Header.Get("X-Trace") + `","json":` + string(body) + `}`))
+		case "/gone":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	har := filepath.Join(t.TempDir(), "session.har")
+	err := os.WriteFile(har, []byte(`{"log":{"entries":[
+		{"request":{"method":"GET","url":"https://api.example.com/pokemon/pikachu","headers":[]}},
+		{"request":{"method":"POST","url":"https://api.example.com/echo","headers":[{"name":"X-Trace","value":"har"}],
+			"postData":{"text":"{\"level\":7}"}}}]}}`), 0o600)
+	if err != nil {
+		t.Fatalf("writing HAR failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		code     int
+		expected string
+	}{
+		{"jq selects string", []string{"get", server.URL + "/pokemon/pikachu", "--jq", ".name"}, 0, "pikachu\n"},
+		{"jq selects nested value", []string{"get", "--jq", ".types[0].type", server.URL + "/pokemon/pikachu", "--compact"}, 0, "{\"name\":\"electric\"}\n"},
+		{"post with header", []string{"post", server.URL + "/echo", "-d", `{"level":5}`, "-H", "X-Trace: abc", "--jq", ".json.level"}, 0, "5\n"},
+		{"status capture", []string{"get", server.URL + "/missing", "--status", "--compact"}, 0, "404\n{\"error\":\"not found\"}\n"},
+		{"empty response", []string{"delete", server.URL + "/gone"}, 0, ""},
+		{"http error", []string{"get", server.URL + "/missing"}, 1, ""},
+		{"retries", []string{"get", server.URL + "/flaky?key=get", "--retries", "1", "--jq", ".name"}, 0, "pikachu\n"},
+		{"no retries", []string{"get", server.URL + "/flaky?key=none", "--jq", ".name"}, 1, ""},
+		{"post not retried", []string{"post", server.URL + "/flaky?key=post", "-d", "{}", "--retries", "1"}, 1, ""},
+		{"post retried", []string{"post", server.URL + "/flaky?key=post-retried", "-d", "{}", "--retries", "1", "--retry-non-idempotent", "--jq", ".name"}, 0, "pikachu\n"},
+		{"unknown command", []string{"fetch", server.URL}, 2, ""},
+		{"replay", []string{"replay", har, "--target", server.URL, "--jq", ".name"}, 0, "200\npikachu\n"},
+		{"replay index", []string{"replay", har, "--index", "1", "--target", server.URL, "--compact"}, 0, "200\n{\"trace\":\"har\",\"json\":{\"level\":7}}\n"},
+		{"replay out of range", []string{"replay", har, "--index", "2"}, 1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, &stdout, &stderr)
+			if code != tt.code {
+				t.Fatalf("expected exit code %d, got %d (stderr: %s)", tt.code, code, stderr.String())
+			}
+			if stdout.String() != tt.expected {
+				t.Errorf("expected output %q, got %q", tt.expected, stdout.String())
+			}
+		})
+	}
+}
+
+func TestSelectPath(t *testing.T) {
+	v := map[string]interface{}{"results": []interface{}{map[string]interface{}{"name": "bulbasaur"}}}
+
+	got, err := selectPath(v, ".results[-1].name")
+	if err != nil {
+		t.Fatalf("selectPath failed: %v", err)
+	}
+	if got != "bulbasaur" {
+		t.Errorf("expected 'bulbasaur', got '%v'", got)
+	}
+
+	if _, err := selectPath(v, ".results.name"); err == nil || !strings.Contains(err.Error(), "cannot select") {
+		t.Errorf("expected field selection error, got %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Credential is a secret obtained from a CredentialSource at request time
+type Credential struct {
+	// Token is an API key or token
+	Token string
+	// Certificate is a client TLS certificate
+	Certificate *tls.Certificate
+	// ExpiresAt is when the credential stops being valid, zero when unknown
+	ExpiresAt time.Time
+}
+
+// CredentialSource supplies credentials at request time so secrets never sit in plain struct fields,
+// see the credentials/vault and credentials/awssm packages for adapters
+type CredentialSource interface {
+	Credential(ctx context.Context) (*Credential, error)
+}
+
+// CredentialSourceFunc adapts a function to the CredentialSource interface
+type CredentialSourceFunc func(ctx context.Context) (*Credential, error)
+
+// Credential calls f(ctx)
+func (f CredentialSourceFunc) Credential(ctx context.Context) (*Credential, error) {
+	return f(ctx)
+}
+
+const (
+	// DefaultCredentialTTL is how long CachedCredentials keeps a credential without expiry
+	DefaultCredentialTTL = 5 * time.Minute
+	// DefaultCredentialFetchTimeout bounds the fetches of CachedCredentials when FetchTimeout is not set
+	DefaultCredentialFetchTimeout = 30 * time.Second
+)
+
+// CachedCredentials caches the credential of Source, fetching a new one when the TTL elapses,
+// shortly before the credential expires or after Invalidate, which lets secrets rotate.
+//
+// Concurrent requests needing a new credential share a single fetch, which is not canceled when one of them
+// gives up; each request still stops waiting when its own context is done.
+type CachedCredentials struct {
+	// Source supplies the credentials
+	Source CredentialSource
+	// TTL is how long a credential is cached, defaults to DefaultCredentialTTL
+	TTL time.Duration
+	// RefreshBefore fetches a new credential this long before ExpiresAt, defaults to 1 minute
+	RefreshBefore time.Duration
+	// FetchTimeout bounds each fetch from Source, defaults to DefaultCredentialFetchTimeout
+	FetchTimeout time.Duration
+
+	mu        sync.Mutex
+	cached    *Credential
+	fetchedAt time.Time
+	fetching  *credentialFetch
+}
+
+// credentialFetch is a fetch from Source shared by the requests waiting for it
+type credentialFetch struct {
+	done chan struct{}
+	cred *Credential
+	err  error
+}
+
+// NewCachedCredentials returns a cache over source with the given TTL, zero meaning DefaultCredentialTTL
+func NewCachedCredentials(source CredentialSource, ttl time.Duration) *CachedCredentials {
+	return &CachedCredentials{Source: source, TTL: ttl}
+}
+
+// Credential returns the cached credential, fetching a new one from Source when needed
+func (c *CachedCredentials) Credential(ctx context.Context) (*Credential, error) {
+	c.mu.Lock()
+	if c.cached != nil && c.fresh(time.Now()) {
+		cred := c.cached
+		c.mu.Unlock()
+		return cred, nil
+	}
+	fetch := c.fetching
+	if fetch == nil {
+		fetch = &credentialFetch{done: make(chan struct{})}
+		c.fetching = fetch
+		go c.fetch(detachedContext{ctx}, fetch)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-fetch.done:
+		if fetch.err != nil {
+			return nil, fmt.Errorf("failed to fetch credential: %w", fetch.err)
+		}
+		return fetch.cred, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch gets a credential from Source for the requests waiting on f, caching it unless the cache was
+// invalidated meanwhile
+func (c *CachedCredentials) fetch(ctx context.Context, f *credentialFetch) {
+	timeout := c.FetchTimeout
+	if timeout <= 0 {
+		timeout = DefaultCredentialFetchTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cred, err := c.Source.Credential(ctx)
+
+	c.mu.Lock()
+	if c.fetching == f {
+		if err == nil {
+			c.cached, c.fetchedAt = cred, time.Now()
+		}
+		c.fetching = nil
+	}
+	c.mu.Unlock()
+
+	f.cred, f.err = cred, err
+	close(f.done)
+}
+
+// detachedContext keeps the values of a context, such as trace spans, without its deadline and cancellation
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
+
+func (c *CachedCredentials) fresh(now time.Time) bool {
+	ttl := c.TTL
+	if ttl <= 0 {
+		ttl = DefaultCredentialTTL
+	}
+	if now.Sub(c.fetchedAt) >= ttl {
+		return false
+	}
+	refreshBefore := c.RefreshBefore
+	if refreshBefore <= 0 {
+		refreshBefore = time.Minute
+	}
+	return c.cached.ExpiresAt.IsZero() || now.Before(c.cached.ExpiresAt.Add(-refreshBefore))
+}
+
+// Invalidate drops the cached credential so the next request fetches a new one, without waiting for
+// a fetch started before
+func (c *CachedCredentials) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cached, c.fetching = nil, nil
+}
+
+// GetClientCertificate returns the certificate of the current credential, it fits
+// tls.Config.GetClientCertificate so rotated client certificates are used for new connections
+func (c *CachedCredentials) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	cred, err := c.Credential(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if cred.Certificate == nil {
+		return nil, errors.New("credential has no certificate")
+	}
+	return cred.Certificate, nil
+}
+
+// BearerToken sends the credential token in the Authorization header
+func BearerToken(req *http.Request, cred *Credential) {
+	req.Header.Set("Authorization", "Bearer "+cred.Token)
+}
+
+// APIKeyHeader returns a function sending the credential token in the named header
+func APIKeyHeader(name string) func(req *http.Request, cred *Credential) {
+	return func(req *http.Request, cred *Credential) {
+		req.Header.Set(name, cred.Token)
+	}
+}
+
+// CredentialMiddleware applies a credential from source to every request, apply defaults to BearerToken.
+// When the server answers 401 and source has an Invalidate method, as CachedCredentials does,
+// the cached credential is dropped so the next request picks up a rotated secret.
+func CredentialMiddleware(source CredentialSource, apply func(req *http.Request, cred *Credential)) Middleware {
+	if apply == nil {
+		apply = BearerToken
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			cred, err := source.Credential(req.Context())
+			if err != nil {
+				closeBody(req)
+				return nil, fmt.Errorf("failed to get credential: %w", err)
+			}
+			req = req.Clone(req.Context())
+			apply(req, cred)
+
+			resp, err := next.RoundTrip(req)
+			if err == nil && resp.StatusCode == http.StatusUnauthorized {
+				if invalidator, ok := source.(interface{ Invalidate() }); ok {
+					invalidator.Invalidate()
+				}
+			}
+			return resp, err
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Package awssm reads credentials from AWS Secrets Manager.
+//
+//	source := &awssm.Source{Region: "eu-west-1", SecretID: "prod/payments/api", Field: "api_key"}
+//	client := &httpclient.Client{Middleware: []httpclient.Middleware{
+//	    httpclient.CredentialMiddleware(httpclient.NewCachedCredentials(source, 10*time.Minute), nil),
+//	}}
+package awssm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/llkhacquan/httpclient"
+	"github.com/llkhacquan/httpclient/internal/sigv4"
+)
+
+// Source is an httpclient.CredentialSource reading a secret with GetSecretValue
+type Source struct {
+	// Region is the AWS region, defaults to the AWS_REGION environment variable
+	Region string
+	// SecretID is the name or ARN of the secret
+	SecretID string
+	// VersionStage selects the secret version, defaults to AWSCURRENT
+	VersionStage string
+	// Field selects a key of a JSON secret, the whole SecretString is used when empty
+	Field string
+	// AccessKeyID, SecretAccessKey and SessionToken sign requests, they default to the
+	// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Endpoint overrides the Secrets Manager URL, e.g. for VPC endpoints or local emulators
+	Endpoint string
+	// HTTPClient sends requests, defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+type getSecretValueRequest struct {
+	SecretID     string `json:"SecretId"`
+	VersionStage string `json:"VersionStage,omitempty"`
+}
+
+type getSecretValueResponse struct {
+	SecretString string `json:"SecretString"`
+}
+
+// Credential reads the secret from Secrets Manager
+func (s *Source) Credential(ctx context.Context) (*httpclient.Credential, error) {
+	region := s.Region
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + region + ".amazonaws.com/"
+	}
+	creds := sigv4.Credentials{AccessKeyID: s.AccessKeyID, SecretAccessKey: s.SecretAccessKey, SessionToken: s.SessionToken}
+	if creds.AccessKeyID == "" {
+		creds = sigv4.Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+	}
+
+	client := &httpclient.Client{
+		Client:     s.HTTPClient,
+		Middleware: []httpclient.Middleware{signer(creds, region)},
+	}
+	var resp getSecretValueResponse
+	err := client.Post(ctx, endpoint, getSecretValueRequest{SecretID: s.SecretID, VersionStage: s.VersionStage}, &resp,
+		httpclient.WithHeader("Content-Type", "application/x-amz-json-1.1"),
+		httpclient.WithHeader("X-Amz-Target", "secretsmanager.GetSecretValue"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s: %w", s.SecretID, err)
+	}
+
+	if s.Field == "" {
+		return &httpclient.Credential{Token: resp.SecretString}, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(resp.SecretString), &fields); err != nil {
+		return nil, fmt.Errorf("secret %s is not JSON: %w", s.SecretID, err)
+	}
+	value, ok := fields[s.Field].(string)
+	if !ok {
+		return nil, fmt.Errorf("secret %s has no string field %q", s.SecretID, s.Field)
+	}
+	return &httpclient.Credential{Token: value}, nil
+}
+
+// signer signs requests with SigV4 for the secretsmanager service
+func signer(creds sigv4.Credentials, region string) httpclient.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			var body []byte
+			if req.Body != nil {
+				var err error
+				body, err = io.ReadAll(req.Body)
+				_ = req.Body.Close()
+				if err != nil {
+					return nil, fmt.Errorf("failed to read request body: %w", err)
+				}
+			}
+			req = req.Clone(req.Context())
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			sigv4.Sign(req, body, creds, region, "secretsmanager", time.Now())
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+var _ httpclient.CredentialSource = (*Source)(nil)
@@ -0,0 +1,45 @@
+package awssm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			t.Errorf("expected X-Amz-Target GetSecretValue, got '%s'", r.Header.Get("X-Amz-Target"))
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			t.Errorf("expected SigV4 Authorization, got '%s'", r.Header.Get("Authorization"))
+		}
+		var req getSecretValueRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.SecretID != "prod/api" {
+			t.Errorf("expected SecretId 'prod/api', got '%s'", req.SecretID)
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		_, _ = w.Write([]byte(`{"Name":"prod/api","SecretString":"{\"api_key\":\"s3cr3t\"}"}`))
+	}))
+	defer server.Close()
+
+	source := &Source{
+		Region:          "eu-west-1",
+		SecretID:        "prod/api",
+		Field:           "api_key",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		Endpoint:        server.URL,
+	}
+	cred, err := source.Credential(context.Background())
+	if err != nil {
+		t.Fatalf("reading credential failed: %v", err)
+	}
+	if cred.Token != "s3cr3t" {
+		t.Errorf("expected token 's3cr3t', got '%s'", cred.Token)
+	}
+}
@@ -0,0 +1,94 @@
+// Package vault reads credentials from a HashiCorp Vault KV version 2 secrets engine.
+//
+//	source := &vault.Source{Address: "https://vault:8200", Token: os.Getenv("VAULT_TOKEN"), Path: "payments/api", Field: "api_key"}
+//	client := &httpclient.Client{Middleware: []httpclient.Middleware{
+//	    httpclient.CredentialMiddleware(httpclient.NewCachedCredentials(source, 10*time.Minute), nil),
+//	}}
+package vault
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+// Source is an httpclient.CredentialSource reading a secret from Vault
+type Source struct {
+	// Address is the Vault server URL, defaults to the VAULT_ADDR environment variable
+	Address string
+	// Token authenticates to Vault, defaults to the VAULT_TOKEN environment variable
+	Token string
+	// Mount is the mount path of the KV engine, defaults to "secret"
+	Mount string
+	// Path is the secret path within the mount
+	Path string
+	// Field is the secret field holding the token
+	Field string
+	// CertField and KeyField name fields holding a PEM client certificate and key, both optional
+	CertField string
+	KeyField  string
+	// Client sends requests to Vault, defaults to a zero httpclient.Client
+	Client *httpclient.Client
+}
+
+type secretResponse struct {
+	LeaseDuration int `json:"lease_duration"`
+	Data          struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+}
+
+// Credential reads the secret from Vault
+func (s *Source) Credential(ctx context.Context) (*httpclient.Credential, error) {
+	address := s.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	token := s.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	mount := s.Mount
+	if mount == "" {
+		mount = "secret"
+	}
+	client := s.Client
+	if client == nil {
+		client = &httpclient.Client{}
+	}
+
+	url := strings.TrimSuffix(address, "/") + "/v1/" + strings.Trim(mount, "/") + "/data/" + strings.TrimPrefix(s.Path, "/")
+	var resp secretResponse
+	if err := client.Get(ctx, url, &resp, httpclient.WithHeader("X-Vault-Token", token)); err != nil {
+		return nil, fmt.Errorf("failed to read vault secret %s: %w", s.Path, err)
+	}
+
+	cred := &httpclient.Credential{}
+	if resp.LeaseDuration > 0 {
+		cred.ExpiresAt = time.Now().Add(time.Duration(resp.LeaseDuration) * time.Second)
+	}
+	if s.Field != "" {
+		value, ok := resp.Data.Data[s.Field].(string)
+		if !ok {
+			return nil, fmt.Errorf("vault secret %s has no string field %q", s.Path, s.Field)
+		}
+		cred.Token = value
+	}
+	if s.CertField != "" {
+		certPEM, _ := resp.Data.Data[s.CertField].(string)
+		keyPEM, _ := resp.Data.Data[s.KeyField].(string)
+		cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate from vault secret %s: %w", s.Path, err)
+		}
+		cred.Certificate = &cert
+	}
+	return cred, nil
+}
+
+var _ httpclient.CredentialSource = (*Source)(nil)
@@ -0,0 +1,39 @@
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/payments/api" {
+			t.Errorf("expected path /v1/secret/data/payments/api, got %s", r.URL.Path)
+		}
+		if r.Header.Get("X-Vault-Token") != "root" {
+			t.Errorf("expected X-Vault-Token 'root', got '%s'", r.Header.Get("X-Vault-Token"))
+		}
+		_, _ = w.Write([]byte(`{"lease_duration":0,"data":{"data":{"api_key":"s3cr3t"},"metadata":{"version":3}}}`))
+	}))
+	defer server.Close()
+
+	t.Run("reads field", func(t *testing.T) {
+		source := &Source{Address: server.URL, Token: "root", Path: "payments/api", Field: "api_key"}
+		cred, err := source.Credential(context.Background())
+		if err != nil {
+			t.Fatalf("reading credential failed: %v", err)
+		}
+		if cred.Token != "s3cr3t" {
+			t.Errorf("expected token 's3cr3t', got '%s'", cred.Token)
+		}
+	})
+
+	t.Run("missing field", func(t *testing.T) {
+		source := &Source{Address: server.URL, Token: "root", Path: "payments/api", Field: "password"}
+		if _, err := source.Credential(context.Background()); err == nil {
+			t.Error("expected error for missing field")
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCredentialMiddleware(t *testing.T) {
+	var current atomic.Value
+	current.Store("token-1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+current.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var fetches int32
+	source := NewCachedCredentials(CredentialSourceFunc(func(ctx context.Context) (*Credential, error) {
+		atomic.AddInt32(&fetches, 1)
+		return &Credential{Token: current.Load().(string)}, nil
+	}), 0)
+	client := &Client{Middleware: []Middleware{CredentialMiddleware(source, nil)}}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := client.Get(ctx, server.URL, nil); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("expected 1 fetch, got %d", fetches)
+	}
+
+	// Rotate the secret: the first request fails and invalidates the cache
+	current.Store("token-2")
+	if err := client.Get(ctx, server.URL, nil); err == nil {
+		t.Error("expected error with stale credential")
+	}
+	if err := client.Get(ctx, server.URL, nil); err != nil {
+		t.Fatalf("GET request after rotation failed: %v", err)
+	}
+	if fetches != 2 {
+		t.Errorf("expected 2 fetches, got %d", fetches)
+	}
+}
+
+func TestCachedCredentials(t *testing.T) {
+	var fetches int32
+	release := make(chan struct{})
+	cache := NewCachedCredentials(CredentialSourceFunc(func(ctx context.Context) (*Credential, error) {
+		atomic.AddInt32(&fetches, 1)
+		select {
+		case <-release:
+			return &Credential{Token: "token"}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}), 0)
+
+	// The first caller gives up while the fetch is slow, the others get the credential of the same fetch
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := cache.Credential(ctx)
+		canceled <- err
+	}()
+	for atomic.LoadInt32(&fetches) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	var wg sync.WaitGroup
+	tokens := make(chan string, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cred, err := cache.Credential(context.Background())
+			if err != nil {
+				t.Errorf("Credential failed: %v", err)
+				return
+			}
+			tokens <- cred.Token
+		}()
+	}
+	cancel()
+	if err := <-canceled; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	close(release)
+	wg.Wait()
+	close(tokens)
+
+	for token := range tokens {
+		if token != "token" {
+			t.Errorf("expected token, got %q", token)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("expected 1 shared fetch, got %d", fetches)
+	}
+	if cred, err := cache.Credential(context.Background()); err != nil || cred.Token != "token" || fetches != 1 {
+		t.Errorf("expected cached token, got %v and %v after %d fetches", cred, err, fetches)
+	}
+}
@@ -0,0 +1,151 @@
+// Package datadog starts Datadog APM client spans for requests and injects the Datadog propagation headers,
+// without depending on dd-trace-go. A few lines adapt dd-trace-go spans to the Span interface:
+//
+//	type ddSpan struct{ ddtrace.Span }
+//
+//	func (s ddSpan) Inject(header http.Header) error {
+//	    return tracer.Inject(s.Context(), tracer.HTTPHeadersCarrier(header))
+//	}
+//	func (s ddSpan) Finish(err error) { s.Span.Finish(tracer.WithError(err)) }
+//
+//	client := &httpclient.Client{Middleware: []httpclient.Middleware{datadog.Middleware(datadog.Config{
+//	    Service: "payments-client",
+//	    StartSpan: func(ctx context.Context, operation string) datadog.Span {
+//	        span, _ := tracer.StartSpanFromContext(ctx, operation)
+//	        return ddSpan{span}
+//	    },
+//	})}}
+//
+// Services without a tracer can still propagate a trace they received with SpanContext.Inject.
+package datadog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+// DefaultOperation is the operation name of client spans, as used by dd-trace-go's net/http integration
+const DefaultOperation = "http.request"
+
+// Span is a client span, as implemented by dd-trace-go spans with the adapter shown in the package documentation
+type Span interface {
+	// SetTag sets a span tag
+	SetTag(key string, value interface{})
+	// Inject writes the propagation headers of the span into header
+	Inject(header http.Header) error
+	// Finish ends the span, flagging it as an error when err is not nil
+	Finish(err error)
+}
+
+// Config configures the Middleware
+type Config struct {
+	// StartSpan starts a child span of the span of ctx, it is required
+	StartSpan func(ctx context.Context, operation string) Span
+	// Operation is the span operation name, defaults to DefaultOperation
+	Operation string
+	// Service is the service name of the spans, e.g. "payments-client", the tracer default when empty
+	Service string
+	// Resource names the resource of a request, defaults to its method and path
+	Resource func(req *http.Request) string
+	// IsError reports whether a response status flags the span as an error, defaults to status 500 and above
+	IsError func(status int) bool
+}
+
+// Middleware starts a client span for every attempt, tagged with the method, URL without query, host and status,
+// and injects its propagation headers into the request
+func Middleware(cfg Config) httpclient.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			span := cfg.StartSpan(req.Context(), cfg.operation())
+			span.SetTag("span.type", "http")
+			span.SetTag("span.kind", "client")
+			span.SetTag("component", "llkhacquan/httpclient")
+			span.SetTag("resource.name", cfg.resource(req))
+			span.SetTag("http.method", req.Method)
+			span.SetTag("http.url", redactedURL(req))
+			span.SetTag("out.host", req.URL.Hostname())
+			if cfg.Service != "" {
+				span.SetTag("service.name", cfg.Service)
+			}
+
+			req = req.Clone(req.Context())
+			if err := span.Inject(req.Header); err != nil {
+				span.SetTag("propagation.error", err.Error())
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				span.Finish(err)
+				return resp, err
+			}
+			span.SetTag("http.status_code", strconv.Itoa(resp.StatusCode))
+			if cfg.isError(resp.StatusCode) {
+				span.Finish(fmt.Errorf("%d: %s", resp.StatusCode, http.StatusText(resp.StatusCode)))
+			} else {
+				span.Finish(nil)
+			}
+			return resp, nil
+		})
+	}
+}
+
+func (cfg Config) operation() string {
+	if cfg.Operation != "" {
+		return cfg.Operation
+	}
+	return DefaultOperation
+}
+
+func (cfg Config) resource(req *http.Request) string {
+	if cfg.Resource != nil {
+		return cfg.Resource(req)
+	}
+	return req.Method + " " + req.URL.Path
+}
+
+func (cfg Config) isError(status int) bool {
+	if cfg.IsError != nil {
+		return cfg.IsError(status)
+	}
+	return status >= 500
+}
+
+// redactedURL returns the request URL without query string and credentials, which may hold secrets
+func redactedURL(req *http.Request) string {
+	u := *req.URL
+	u.User, u.RawQuery, u.Fragment = nil, "", ""
+	return u.String()
+}
+
+// SpanContext is a Datadog trace context, e.g. received from an upstream service
+type SpanContext struct {
+	// TraceID is the lower 64 bits of the trace ID
+	TraceID uint64
+	// TraceIDHigh is the upper 64 bits of a 128-bit trace ID, zero for 64-bit trace IDs
+	TraceIDHigh uint64
+	// SpanID is the ID of the span the request is a child of
+	SpanID uint64
+	// SamplingPriority is the sampling decision, nil defers it to the receiver
+	SamplingPriority *int
+	// Origin is the origin product of the trace, e.g. "synthetics"
+	Origin string
+}
+
+// Inject writes the x-datadog-* propagation headers of the context into header
+func (c SpanContext) Inject(header http.Header) {
+	header.Set("X-Datadog-Trace-Id", strconv.FormatUint(c.TraceID, 10))
+	header.Set("X-Datadog-Parent-Id", strconv.FormatUint(c.SpanID, 10))
+	if c.SamplingPriority != nil {
+		header.Set("X-Datadog-Sampling-Priority", strconv.Itoa(*c.SamplingPriority))
+	}
+	if c.Origin != "" {
+		header.Set("X-Datadog-Origin", c.Origin)
+	}
+	if c.TraceIDHigh != 0 {
+		header.Set("X-Datadog-Tags", fmt.Sprintf("_dd.p.tid=%016x", c.TraceIDHigh))
+	}
+}
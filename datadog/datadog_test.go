@@ -0,0 +1,124 @@
+package datadog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+type fakeSpan struct {
+	operation string
+	tags      map[string]interface{}
+	finished  bool
+	err       error
+}
+
+func (s *fakeSpan) SetTag(key string, value interface{}) { s.tags[key] = value }
+
+func (s *fakeSpan) Inject(header http.Header) error {
+	header.Set("X-Datadog-Trace-Id", "1234")
+	header.Set("X-Datadog-Parent-Id", "5678")
+	return nil
+}
+
+func (s *fakeSpan) Finish(err error) { s.finished, s.err = true, err }
+
+func TestMiddleware(t *testing.T) {
+	var traceIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceIDs = append(traceIDs, r.Header.Get("X-Datadog-Trace-Id"))
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var spans []*fakeSpan
+	client := &httpclient.Client{Middleware: []httpclient.Middleware{Middleware(Config{
+		Service: "pokedex-client",
+		StartSpan: func(ctx context.Context, operation string) Span {
+			span := &fakeSpan{operation: operation, tags: map[string]interface{}{}}
+			spans = append(spans, span)
+			return span
+		},
+	})}}
+
+	t.Run("success", func(t *testing.T) {
+		spans, traceIDs = nil, nil
+		if err := client.Get(context.Background(), server.URL+"/pokemon?token=secret", nil); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if len(spans) != 1 || !spans[0].finished || spans[0].err != nil {
+			t.Fatalf("expected one finished span without error, got %+v", spans)
+		}
+		span := spans[0]
+		if span.operation != DefaultOperation {
+			t.Errorf("expected operation %s, got %s", DefaultOperation, span.operation)
+		}
+		if span.tags["resource.name"] != "GET /pokemon" {
+			t.Errorf("expected resource GET /pokemon, got %v", span.tags["resource.name"])
+		}
+		if span.tags["http.url"] != server.URL+"/pokemon" {
+			t.Errorf("expected url without query, got %v", span.tags["http.url"])
+		}
+		if span.tags["http.status_code"] != "204" || span.tags["service.name"] != "pokedex-client" {
+			t.Errorf("expected status and service tags, got %v", span.tags)
+		}
+		if len(traceIDs) != 1 || traceIDs[0] != "1234" {
+			t.Errorf("expected injected trace id, got %v", traceIDs)
+		}
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		spans = nil
+		err := client.Get(context.Background(), server.URL+"/broken", nil)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if len(spans) != 1 || spans[0].err == nil {
+			t.Fatalf("expected one span finished with error, got %+v", spans)
+		}
+	})
+
+	t.Run("transport error", func(t *testing.T) {
+		spans = nil
+		err := client.Get(context.Background(), "http://127.0.0.1:1/pokemon", nil)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if len(spans) == 0 || spans[len(spans)-1].err == nil {
+			t.Fatalf("expected span finished with error, got %+v", spans)
+		}
+	})
+}
+
+func TestSpanContext_Inject(t *testing.T) {
+	priority := 2
+	header := http.Header{}
+	SpanContext{TraceID: 42, TraceIDHigh: 0xabc, SpanID: 7, SamplingPriority: &priority, Origin: "synthetics"}.Inject(header)
+
+	expected := map[string]string{
+		"X-Datadog-Trace-Id":          "42",
+		"X-Datadog-Parent-Id":         "7",
+		"X-Datadog-Sampling-Priority": strconv.Itoa(priority),
+		"X-Datadog-Origin":            "synthetics",
+		"X-Datadog-Tags":              "_dd.p.tid=0000000000000abc",
+	}
+	for key, want := range expected {
+		if got := header.Get(key); got != want {
+			t.Errorf("expected %s %q, got %q", key, want, got)
+		}
+	}
+
+	header = http.Header{}
+	SpanContext{TraceID: 1, SpanID: 2}.Inject(header)
+	if header.Get("X-Datadog-Sampling-Priority") != "" || header.Get("X-Datadog-Tags") != "" {
+		t.Errorf("expected no optional headers, got %v", header)
+	}
+}
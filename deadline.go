@@ -0,0 +1,79 @@
+package httpclient
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers commonly used to propagate deadlines, see Client.DeadlineHeader
+const (
+	// DeadlineHeaderMillis carries the remaining time in milliseconds, e.g. "1500"
+	DeadlineHeaderMillis = "X-Request-Timeout-Ms"
+	// DeadlineHeaderGRPC carries the remaining time in the grpc-timeout format, e.g. "1500m"
+	DeadlineHeaderGRPC = "Grpc-Timeout"
+)
+
+// deadlineHeader returns the header propagating the deadline of the request, empty for none
+func (c *Client) deadlineHeader(options *Options) string {
+	if options.DeadlineHeader != "" {
+		return options.DeadlineHeader
+	}
+	return c.DeadlineHeader
+}
+
+// deadlineTransport sets header to the time every attempt has left before the deadline of its context or
+// the client timeout starting now, whichever comes first; requests without either are sent unchanged
+func deadlineTransport(next http.RoundTripper, header string, timeout time.Duration) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	var clientDeadline time.Time
+	if timeout > 0 {
+		clientDeadline = time.Now().Add(timeout)
+	}
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		deadline, ok := req.Context().Deadline()
+		if !clientDeadline.IsZero() && (!ok || clientDeadline.Before(deadline)) {
+			deadline, ok = clientDeadline, true
+		}
+		if !ok {
+			return next.RoundTrip(req)
+		}
+
+		remaining := time.Until(deadline)
+		value := strconv.FormatInt(int64((remaining+time.Millisecond-1)/time.Millisecond), 10)
+		if remaining <= 0 {
+			value = "0"
+		}
+		if strings.EqualFold(header, DeadlineHeaderGRPC) {
+			value = encodeGRPCTimeout(remaining)
+		}
+		// RoundTrippers must not modify the request they are given
+		req = req.Clone(req.Context())
+		req.Header.Set(header, value)
+		return next.RoundTrip(req)
+	})
+}
+
+// encodeGRPCTimeout formats d as a grpc-timeout value of at most 8 digits in the finest unit possible,
+// rounding up like gRPC implementations
+func encodeGRPCTimeout(d time.Duration) string {
+	if d <= 0 {
+		return "0n"
+	}
+	units := []struct {
+		unit   time.Duration
+		suffix string
+	}{
+		{time.Nanosecond, "n"}, {time.Microsecond, "u"}, {time.Millisecond, "m"},
+		{time.Second, "S"}, {time.Minute, "M"}, {time.Hour, "H"},
+	}
+	for _, u := range units {
+		if value := (d + u.unit - 1) / u.unit; value <= 99999999 {
+			return strconv.FormatInt(int64(value), 10) + u.suffix
+		}
+	}
+	return "99999999H"
+}
@@ -0,0 +1,87 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDeadlineHeader(t *testing.T) {
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		if len(headers) == 1 && r.URL.Path == "/flaky" {
+			time.Sleep(20 * time.Millisecond)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	millis := func(h http.Header) int {
+		ms, err := strconv.Atoi(h.Get(DeadlineHeaderMillis))
+		if err != nil {
+			t.Fatalf("parsing %s failed: %v", DeadlineHeaderMillis, err)
+		}
+		return ms
+	}
+
+	t.Run("context deadline per attempt", func(t *testing.T) {
+		headers = nil
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		client := &Client{DeadlineHeader: DeadlineHeaderMillis, MaxRetries: 1, Backoff: ConstantBackoff(0)}
+		if err := client.Get(ctx, server.URL+"/flaky", nil); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if len(headers) != 2 {
+			t.Fatalf("expected 2 attempts, got %d", len(headers))
+		}
+		first, second := millis(headers[0]), millis(headers[1])
+		if first > 1000 || first < 900 || second >= first {
+			t.Errorf("expected decreasing remaining time under 1000ms, got %d then %d", first, second)
+		}
+	})
+
+	t.Run("client timeout and grpc format", func(t *testing.T) {
+		headers = nil
+		client := &Client{Client: &http.Client{Timeout: 2 * time.Second}, DeadlineHeader: DeadlineHeaderMillis}
+		if err := client.Get(context.Background(), server.URL, nil, WithDeadlinePropagationHeader(DeadlineHeaderGRPC)); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		value := headers[0].Get(DeadlineHeaderGRPC)
+		if len(value) < 2 || value[len(value)-1] != 'u' || headers[0].Get(DeadlineHeaderMillis) != "" {
+			t.Errorf("expected grpc-timeout in microseconds only, got %q", value)
+		}
+	})
+
+	t.Run("no deadline", func(t *testing.T) {
+		headers = nil
+		if err := (&Client{DeadlineHeader: DeadlineHeaderMillis}).Get(context.Background(), server.URL, nil); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if _, ok := headers[0][DeadlineHeaderMillis]; ok {
+			t.Errorf("expected no header without deadline, got %q", headers[0].Get(DeadlineHeaderMillis))
+		}
+	})
+}
+
+func TestEncodeGRPCTimeout(t *testing.T) {
+	tests := []struct {
+		d        time.Duration
+		expected string
+	}{
+		{0, "0n"},
+		{50 * time.Millisecond, "50000000n"},
+		{1500 * time.Millisecond, "1500000u"},
+		{time.Duration(1500500500), "1500501u"},
+		{30 * time.Hour, "108000S"},
+	}
+	for _, tt := range tests {
+		if got := encodeGRPCTimeout(tt.d); got != tt.expected {
+			t.Errorf("expected %s for %v, got %s", tt.expected, tt.d, got)
+		}
+	}
+}
@@ -1,30 +1,85 @@
 package httpclient
 
-import "context"
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
 
-var defaultClient = &Client{}
+// The default client is replaced as a whole, never modified in place, so package-level functions
+// can load it without locking while SetDefault and UpdateDefault run concurrently
+var (
+	defaultClient   atomic.Value // *Client
+	defaultClientMu sync.Mutex   // serializes UpdateDefault
+)
+
+// Default returns the client used by the package-level functions. It is shared and must not be
+// modified, use UpdateDefault to change its configuration.
+func Default() *Client {
+	if c, ok := defaultClient.Load().(*Client); ok {
+		return c
+	}
+	return zeroClient
+}
+
+// zeroClient is the default client until SetDefault is called
+var zeroClient = &Client{}
+
+// SetDefault replaces the client used by the package-level functions, a nil client restores the zero
+// value Client. It is safe to call concurrently with requests, which keep using the client they started with.
+func SetDefault(c *Client) {
+	if c == nil {
+		c = zeroClient
+	}
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+	defaultClient.Store(c)
+}
+
+// UpdateDefault applies update to a copy of the default client and installs the copy,
+// so concurrent updates are not lost and in-flight requests are unaffected
+func UpdateDefault(update func(c *Client)) {
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+	c := Default().clone()
+	update(c)
+	defaultClient.Store(c)
+}
+
+// Use appends middleware to the default client, e.g. logging or metrics for programs using the
+// package-level functions. Like Client.Middleware, the first middleware ever added is outermost.
+func Use(mw ...Middleware) {
+	UpdateDefault(func(c *Client) {
+		c.Middleware = append(c.Middleware, mw...)
+	})
+}
 
 // Get performs a GET request using the default client
 func Get(ctx context.Context, url string, result interface{}, opts ...Option) error {
-	return defaultClient.Get(ctx, url, result, opts...)
+	return Default().Get(ctx, url, result, opts...)
 }
 
 // Post performs a POST request using the default client
 func Post(ctx context.Context, url string, body interface{}, result interface{}, opts ...Option) error {
-	return defaultClient.Post(ctx, url, body, result, opts...)
+	return Default().Post(ctx, url, body, result, opts...)
 }
 
 // Patch performs a PATCH request using the default client
 func Patch(ctx context.Context, url string, body interface{}, result interface{}, opts ...Option) error {
-	return defaultClient.Patch(ctx, url, body, result, opts...)
+	return Default().Patch(ctx, url, body, result, opts...)
 }
 
 // Put performs a PUT request using the default client
 func Put(ctx context.Context, url string, body interface{}, result interface{}, opts ...Option) error {
-	return defaultClient.Put(ctx, url, body, result, opts...)
+	return Default().Put(ctx, url, body, result, opts...)
 }
 
 // Delete performs a DELETE request using the default client
 func Delete(ctx context.Context, url string, result interface{}, opts ...Option) error {
-	return defaultClient.Delete(ctx, url, result, opts...)
+	return Default().Delete(ctx, url, result, opts...)
+}
+
+// Do performs a request with any HTTP method using the default client
+func Do(ctx context.Context, method, url string, body interface{}, result interface{}, opts ...Option) error {
+	return Default().Do(ctx, method, url, body, result, opts...)
 }
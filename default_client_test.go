@@ -3,6 +3,8 @@ package httpclient
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 )
 
@@ -97,3 +99,75 @@ func TestDefaultClientFunctions(t *testing.T) {
 		}
 	})
 }
+
+func TestSetDefault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"agent":"` + r.Header.Get("X-Agent") + `"}`))
+	}))
+	defer server.Close()
+	defer SetDefault(nil)
+
+	agent := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				req = req.Clone(req.Context())
+				req.Header.Set("X-Agent", name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	t.Run("concurrent updates", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				UpdateDefault(func(c *Client) { c.Middleware = append(c.Middleware, agent("a")) })
+			}()
+			go func() {
+				defer wg.Done()
+				if err := Get(context.Background(), server.URL, nil); err != nil {
+					t.Errorf("GET request failed: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if n := len(Default().Middleware); n != 10 {
+			t.Errorf("expected 10 middleware, got %d", n)
+		}
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		previous := Default()
+		SetDefault(&Client{Middleware: []Middleware{agent("b")}})
+
+		var result map[string]string
+		if err := Get(context.Background(), server.URL, &result); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if result["agent"] != "b" {
+			t.Errorf("expected agent 'b', got '%s'", result["agent"])
+		}
+		if len(previous.Middleware) != 10 {
+			t.Errorf("expected previous client unchanged, got %d middleware", len(previous.Middleware))
+		}
+	})
+
+	t.Run("use", func(t *testing.T) {
+		SetDefault(nil)
+		Use(agent("c"))
+
+		var result map[string]string
+		if err := Get(context.Background(), server.URL, &result); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if result["agent"] != "c" {
+			t.Errorf("expected agent 'c', got '%s'", result["agent"])
+		}
+		if len(zeroClient.Middleware) != 0 {
+			t.Errorf("expected zero client unchanged, got %d middleware", len(zeroClient.Middleware))
+		}
+	})
+}
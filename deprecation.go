@@ -0,0 +1,87 @@
+package httpclient
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Deprecation describes the Deprecation (RFC 9745) and Sunset (RFC 8594) headers of a response announcing
+// that the requested resource is going away
+type Deprecation struct {
+	// Deprecated reports whether the response has a Deprecation header
+	Deprecated bool
+	// Since is the date of the deprecation, zero when the header has none, e.g. "true" in early drafts
+	Since time.Time
+	// Sunset is the date the resource is expected to stop responding, zero without a Sunset header
+	Sunset time.Time
+	// Link is the target of a Link header with the deprecation or sunset relation, documenting the change
+	Link string
+}
+
+// ParseDeprecation returns the Deprecation announced by header, nil when it has neither a Deprecation nor
+// a Sunset header
+func ParseDeprecation(header http.Header) *Deprecation {
+	deprecation, sunset := header.Get("Deprecation"), header.Get("Sunset")
+	if deprecation == "" && sunset == "" {
+		return nil
+	}
+
+	d := &Deprecation{Deprecated: deprecation != ""}
+	if strings.HasPrefix(deprecation, "@") {
+		if seconds, err := strconv.ParseInt(deprecation[1:], 10, 64); err == nil {
+			d.Since = time.Unix(seconds, 0).UTC()
+		}
+	} else if since, err := http.ParseTime(deprecation); err == nil {
+		d.Since = since
+	}
+	if at, err := http.ParseTime(sunset); err == nil {
+		d.Sunset = at
+	}
+	for _, value := range header.Values("Link") {
+		for _, link := range splitQuoted(value, ',') {
+			params := splitQuoted(link, ';')
+			target := strings.TrimSpace(params[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range params[1:] {
+				name, rel, _ := strings.Cut(param, "=")
+				if !strings.EqualFold(strings.TrimSpace(name), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(unquote(strings.TrimSpace(rel))) {
+					if (strings.EqualFold(rel, "deprecation") || strings.EqualFold(rel, "sunset")) && d.Link == "" {
+						d.Link = target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+	return d
+}
+
+// String describes the deprecation, e.g. "deprecated since 2024-07-01, sunset on 2025-01-01, see https://..."
+func (d Deprecation) String() string {
+	var parts []string
+	switch {
+	case d.Deprecated && !d.Since.IsZero():
+		parts = append(parts, "deprecated since "+d.Since.UTC().Format("2006-01-02"))
+	case d.Deprecated:
+		parts = append(parts, "deprecated")
+	}
+	if !d.Sunset.IsZero() {
+		parts = append(parts, "sunset on "+d.Sunset.UTC().Format("2006-01-02"))
+	}
+	if d.Link != "" {
+		parts = append(parts, "see "+d.Link)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// LogDeprecation is a Client.OnDeprecation callback logging the deprecated request with the standard logger
+func LogDeprecation(req *http.Request, d Deprecation) {
+	log.Printf("httpclient: %s %s is %s", req.Method, redactedURL(req.URL), d)
+}
@@ -0,0 +1,79 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseDeprecation(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   *Deprecation
+	}{
+		{"none", http.Header{}, nil},
+		{"structured date", http.Header{
+			"Deprecation": {"@1719792000"},
+			"Sunset":      {"Wed, 01 Jan 2025 00:00:00 GMT"},
+			"Link":        {`<https://api.example.com/v2>; rel="successor-version", <https://docs.example.com/v1>; rel="deprecation"; type="text/html"`},
+		}, &Deprecation{
+			Deprecated: true,
+			Since:      time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+			Sunset:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			Link:       "https://docs.example.com/v1",
+		}},
+		{"boolean draft", http.Header{"Deprecation": {"true"}}, &Deprecation{Deprecated: true}},
+		{"sunset only", http.Header{"Sunset": {"Wed, 01 Jan 2025 00:00:00 GMT"}}, &Deprecation{Sunset: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseDeprecation(tt.header)
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+			if got != nil && (got.Deprecated != tt.want.Deprecated || !got.Since.Equal(tt.want.Since) ||
+				!got.Sunset.Equal(tt.want.Sunset) || got.Link != tt.want.Link) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+
+	d := Deprecation{Deprecated: true, Since: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), Link: "https://docs.example.com"}
+	if expected := "deprecated since 2024-07-01, see https://docs.example.com"; d.String() != expected {
+		t.Errorf("expected %q, got %q", expected, d.String())
+	}
+}
+
+func TestClient_OnDeprecation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1" {
+			w.Header().Set("Deprecation", "@1719792000")
+			w.Header().Set("Sunset", "Wed, 01 Jan 2025 00:00:00 GMT")
+		}
+	}))
+	defer server.Close()
+
+	var reported []string
+	client := &Client{OnDeprecation: func(req *http.Request, d Deprecation) {
+		reported = append(reported, req.URL.Path+": "+d.String())
+	}}
+	var d Deprecation
+	if err := client.Get(context.Background(), server.URL+"/v1", nil, WithDeprecation(&d)); err != nil {
+		t.Fatalf("GET request failed: %v", err)
+	}
+	if !d.Deprecated || d.Sunset.Year() != 2025 {
+		t.Errorf("expected captured deprecation, got %+v", d)
+	}
+	if err := client.Get(context.Background(), server.URL+"/v2", nil, WithDeprecation(&d)); err != nil {
+		t.Fatalf("GET request failed: %v", err)
+	}
+	if d.Deprecated {
+		t.Errorf("expected zero deprecation, got %+v", d)
+	}
+	if len(reported) != 1 || reported[0] != "/v1: deprecated since 2024-07-01, sunset on 2025-01-01" {
+		t.Errorf("expected one report for /v1, got %v", reported)
+	}
+}
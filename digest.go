@@ -0,0 +1,187 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrDigestMismatch is returned when reading a response body whose content does not match its digest headers
+var ErrDigestMismatch = errors.New("content digest mismatch")
+
+// digestAlgorithms are the Content-Digest algorithms of RFC 9530 that are computed and verified
+var digestAlgorithms = map[string]func() hash.Hash{
+	"sha-256": sha256.New,
+	"sha-512": sha512.New,
+}
+
+// setContentDigests sets the Content-Digest and Content-MD5 headers requested by options. Bodies that can be
+// replayed are hashed up front; streamed bodies are hashed as they are sent and their digests sent as trailers.
+func setContentDigests(req *http.Request, options *Options) error {
+	if (!options.ContentDigest && !options.ContentMD5) || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	digests := newRequestDigests(options.ContentDigest, options.ContentMD5)
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		_, err = io.Copy(digests, body)
+		_ = body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		digests.set(req.Header)
+		return nil
+	}
+
+	// The trailers declared by WithRequestTrailers are copied once the body is read, when their values are final
+	trailer := http.Header{}
+	for key := range req.Trailer {
+		trailer[key] = nil
+	}
+	if digests.sha256 != nil {
+		trailer["Content-Digest"] = nil
+	}
+	if digests.md5 != nil {
+		trailer["Content-Md5"] = nil
+	}
+	req.Body = &digestingBody{ReadCloser: req.Body, digests: digests, trailer: trailer, declared: req.Trailer}
+	req.Trailer = trailer
+	req.ContentLength = -1
+	return nil
+}
+
+// setBodyDigests sets the Content-Digest and Content-MD5 headers of the body data, when enabled
+func setBodyDigests(header http.Header, data []byte, contentDigest, contentMD5 bool) {
+	if !contentDigest && !contentMD5 {
+		return
+	}
+	digests := newRequestDigests(contentDigest, contentMD5)
+	_, _ = digests.Write(data)
+	digests.set(header)
+}
+
+func newRequestDigests(contentDigest, contentMD5 bool) *requestDigests {
+	digests := &requestDigests{}
+	if contentDigest {
+		digests.sha256 = sha256.New()
+	}
+	if contentMD5 {
+		digests.md5 = md5.New()
+	}
+	return digests
+}
+
+// requestDigests hashes a request body with the algorithms selected by the request options
+type requestDigests struct {
+	sha256 hash.Hash
+	md5    hash.Hash
+}
+
+func (d *requestDigests) Write(p []byte) (int, error) {
+	if d.sha256 != nil {
+		d.sha256.Write(p)
+	}
+	if d.md5 != nil {
+		d.md5.Write(p)
+	}
+	return len(p), nil
+}
+
+// set writes the digests of the data hashed so far into header
+func (d *requestDigests) set(header http.Header) {
+	if d.sha256 != nil {
+		header["Content-Digest"] = []string{"sha-256=:" + base64.StdEncoding.EncodeToString(d.sha256.Sum(nil)) + ":"}
+	}
+	if d.md5 != nil {
+		header["Content-Md5"] = []string{base64.StdEncoding.EncodeToString(d.md5.Sum(nil))}
+	}
+}
+
+// digestingBody hashes a streamed request body, filling its trailer at the end
+type digestingBody struct {
+	io.ReadCloser
+	digests  *requestDigests
+	trailer  http.Header
+	declared http.Header
+}
+
+func (b *digestingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	_, _ = b.digests.Write(p[:n])
+	if err == io.EOF {
+		for key, values := range b.declared {
+			b.trailer[key] = values
+		}
+		b.digests.set(b.trailer)
+	}
+	return n, err
+}
+
+// verifyContentDigests wraps the body of resp to check it against its Content-Digest and Content-MD5 headers.
+// Bodies decompressed by the transport are left alone, since the digests cover the encoded content.
+func verifyContentDigests(resp *http.Response) {
+	if resp.Uncompressed || resp.Body == nil || resp.Body == http.NoBody {
+		return
+	}
+	var checks []digestCheck
+	for _, value := range resp.Header.Values("Content-Digest") {
+		for _, member := range strings.Split(value, ",") {
+			algorithm, encoded, ok := strings.Cut(strings.TrimSpace(member), "=")
+			newHash, supported := digestAlgorithms[strings.ToLower(algorithm)]
+			if !ok || !supported {
+				continue
+			}
+			if want, err := base64.StdEncoding.DecodeString(strings.Trim(encoded, ":")); err == nil {
+				checks = append(checks, digestCheck{name: algorithm, hash: newHash(), want: want})
+			}
+		}
+	}
+	if value := resp.Header.Get("Content-MD5"); value != "" {
+		if want, err := base64.StdEncoding.DecodeString(value); err == nil {
+			checks = append(checks, digestCheck{name: "md5", hash: md5.New(), want: want})
+		}
+	}
+	if len(checks) > 0 {
+		resp.Body = &verifyingBody{ReadCloser: resp.Body, checks: checks}
+	}
+}
+
+// digestCheck is a digest advertised by a response
+type digestCheck struct {
+	name string
+	hash hash.Hash
+	want []byte
+}
+
+// verifyingBody hashes a response body, failing the last read when a digest does not match
+type verifyingBody struct {
+	io.ReadCloser
+	checks []digestCheck
+}
+
+func (b *verifyingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	for _, check := range b.checks {
+		check.hash.Write(p[:n])
+	}
+	if err == io.EOF {
+		for _, check := range b.checks {
+			if !bytes.Equal(check.hash.Sum(nil), check.want) {
+				return n, fmt.Errorf("%w: %s", ErrDigestMismatch, check.name)
+			}
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,98 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestContentDigest(t *testing.T) {
+	sha := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+	}
+	md := func(s string) string {
+		sum := md5.Sum([]byte(s))
+		return base64.StdEncoding.EncodeToString(sum[:])
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		digest, contentMD5 := r.Header.Get("Content-Digest"), r.Header.Get("Content-MD5")
+		if digest == "" {
+			digest, contentMD5 = r.Trailer.Get("Content-Digest"), r.Trailer.Get("Content-MD5")
+		}
+		if digest != sha(string(body)) || contentMD5 != md(string(body)) {
+			t.Errorf("unexpected digests %q and %q for %s", digest, contentMD5, body)
+		}
+
+		const response = `{"name":"pikachu"}`
+		switch r.URL.Path {
+		case "/flaky":
+			if string(body) == `{"nonce":1}` {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/corrupted":
+			w.Header().Set("Content-Digest", "sha-512=:AAAA:, "+sha(`{"name":"raichu"}`))
+		default:
+			w.Header().Set("Content-Digest", "unknown=:AAAA:, "+sha(response))
+			w.Header().Set("Content-MD5", md(response))
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client := &Client{}
+	ctx := context.Background()
+
+	t.Run("marshalled body", func(t *testing.T) {
+		var result map[string]string
+		if err := client.Post(ctx, server.URL, map[string]int{"id": 25}, &result, WithContentDigest(), WithContentMD5(), WithVerifyDigest()); err != nil {
+			t.Fatalf("POST request failed: %v", err)
+		}
+		if result["name"] != "pikachu" {
+			t.Errorf("expected pikachu, got %v", result)
+		}
+	})
+
+	t.Run("streamed body", func(t *testing.T) {
+		trailer := http.Header{"X-Checksum": nil}
+		err := client.Post(ctx, server.URL, io.NopCloser(strings.NewReader("raw upload")), nil,
+			WithContentDigest(), WithContentMD5(), WithRequestTrailers(trailer))
+		if err != nil {
+			t.Fatalf("POST request failed: %v", err)
+		}
+	})
+
+	t.Run("corrupted response", func(t *testing.T) {
+		var result map[string]string
+		err := client.Post(ctx, server.URL+"/corrupted", []byte(`{}`), &result, WithContentDigest(), WithContentMD5(), WithVerifyDigest())
+		if !errors.Is(err, ErrDigestMismatch) {
+			t.Errorf("expected ErrDigestMismatch, got %v", err)
+		}
+	})
+	t.Run("body rewritten before retries", func(t *testing.T) {
+		client := &Client{MaxRetries: 1}
+		body := map[string]int{"nonce": 1}
+		err := client.Put(ctx, server.URL+"/flaky", body, nil, WithContentDigest(), WithContentMD5(),
+			WithBeforeRetry(func(int, interface{}) error {
+				body["nonce"]++
+				return nil
+			}))
+		if err != nil {
+			t.Fatalf("PUT request failed: %v", err)
+		}
+		if body["nonce"] != 2 {
+			t.Errorf("expected 1 retry, got nonce %d", body["nonce"])
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// maxDoHResponseSize is the largest DNS message that fits the two byte length prefix
+const maxDoHResponseSize = 65535
+
+// NewDoHResolver returns a resolver that sends DNS queries to a DNS-over-HTTPS endpoint (RFC 8484),
+// such as "https://1.1.1.1/dns-query". The endpoint itself is reached with client, which defaults to
+// http.DefaultClient; use an IP literal endpoint to avoid depending on the system resolver.
+//
+// The resolver is typically plugged into the dialer of the transport used by a Client:
+//
+//	dialer := &net.Dialer{Resolver: httpclient.NewDoHResolver("https://1.1.1.1/dns-query", nil)}
+//	client := &httpclient.Client{
+//	    Client: &http.Client{Transport: &http.Transport{DialContext: dialer.DialContext}},
+//	}
+func NewDoHResolver(endpoint string, client *http.Client) *net.Resolver {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return &dohConn{ctx: ctx, endpoint: endpoint, client: client}, nil
+		},
+	}
+}
+
+// dohConn is a net.Conn speaking the DNS over TCP framing to the Go resolver,
+// forwarding each complete query to the DoH endpoint
+type dohConn struct {
+	ctx      context.Context
+	endpoint string
+	client   *http.Client
+
+	query    bytes.Buffer
+	response bytes.Reader
+	deadline time.Time
+}
+
+// Write buffers length-prefixed DNS queries written by the resolver
+func (c *dohConn) Write(b []byte) (int, error) {
+	return c.query.Write(b)
+}
+
+// Read sends the buffered query on first use and returns the length-prefixed answer
+func (c *dohConn) Read(b []byte) (int, error) {
+	if c.response.Size() == 0 {
+		if err := c.exchange(); err != nil {
+			return 0, err
+		}
+	}
+	return c.response.Read(b)
+}
+
+func (c *dohConn) exchange() error {
+	msg := c.query.Bytes()
+	if len(msg) < 2 {
+		return errors.New("incomplete DNS query")
+	}
+	msg = msg[2:]
+
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(msg))
+	if err != nil {
+		return fmt.Errorf("failed to create DoH request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make DoH request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("DoH error: %s", resp.Status)
+	}
+
+	answer, err := io.ReadAll(io.LimitReader(resp.Body, maxDoHResponseSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read DoH response: %w", err)
+	}
+	if len(answer) > maxDoHResponseSize {
+		return errors.New("DoH response too large")
+	}
+
+	framed := make([]byte, 2+len(answer))
+	framed[0], framed[1] = byte(len(answer)>>8), byte(len(answer))
+	copy(framed[2:], answer)
+	c.response.Reset(framed)
+	return nil
+}
+
+func (c *dohConn) Close() error                       { return nil }
+func (c *dohConn) LocalAddr() net.Addr                { return dohAddr{} }
+func (c *dohConn) RemoteAddr() net.Addr               { return dohAddr{} }
+func (c *dohConn) SetDeadline(t time.Time) error      { c.deadline = t; return nil }
+func (c *dohConn) SetReadDeadline(t time.Time) error  { c.deadline = t; return nil }
+func (c *dohConn) SetWriteDeadline(t time.Time) error { return nil }
+
+type dohAddr struct{}
+
+func (dohAddr) Network() string { return "doh" }
+func (dohAddr) String() string  { return "doh" }
@@ -0,0 +1,65 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// dnsAnswer builds a minimal response to query answering A questions with 192.0.2.1
+func dnsAnswer(query []byte) []byte {
+	end := 12
+	for query[end] != 0 {
+		end += int(query[end]) + 1
+	}
+	end += 5 // terminating zero label, QTYPE and QCLASS
+	isA := query[end-4] == 0 && query[end-3] == 1
+
+	resp := append([]byte{}, query[:2]...)
+	resp = append(resp, 0x81, 0x80, 0, 1, 0, 0, 0, 0, 0, 0)
+	resp = append(resp, query[12:end]...)
+	if isA {
+		resp[7] = 1
+		resp = append(resp, 0xc0, 0x0c, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 192, 0, 2, 1)
+	}
+	return resp
+}
+
+func TestNewDoHResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/dns-message" {
+			http.Error(w, "bad content type", http.StatusUnsupportedMediaType)
+			return
+		}
+		query, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/dns-message")
+		_, _ = w.Write(dnsAnswer(query))
+	}))
+	defer server.Close()
+
+	t.Run("resolves through DoH endpoint", func(t *testing.T) {
+		resolver := NewDoHResolver(server.URL, server.Client())
+		addrs, err := resolver.LookupHost(context.Background(), "pokeapi.example")
+		if err != nil {
+			t.Fatalf("lookup failed: %v", err)
+		}
+
+		if len(addrs) != 1 || addrs[0] != "192.0.2.1" {
+			t.Errorf("expected [192.0.2.1], got %v", addrs)
+		}
+	})
+
+	t.Run("endpoint error fails lookup", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}))
+		defer failing.Close()
+
+		resolver := NewDoHResolver(failing.URL, failing.Client())
+		if _, err := resolver.LookupHost(context.Background(), "pokeapi.example"); err == nil {
+			t.Error("expected lookup to fail")
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package httpclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxFailureDumps is the number of files a FailureDump writes when MaxFiles is zero
+const DefaultMaxFailureDumps = 100
+
+// DefaultMaxDumpBodyBytes is the number of body bytes a FailureDump keeps when MaxBodyBytes is zero
+const DefaultMaxDumpBodyBytes = 64 << 10
+
+// FailureDump writes the request and response of every failed request, failing with an error status once its
+// retries are exhausted or failing to decode, to a file of Dir, so failures can be reproduced without verbose
+// logging. Sensitive headers and URL user info are redacted:
+//
+//	client := &httpclient.Client{FailureDump: &httpclient.FailureDump{Dir: "/var/tmp/payments-failures"}}
+//
+// Files are named after the time, method and host of the request, e.g.
+// 20261016T150405.123Z-0001-POST-api.example.com.http. Files that cannot be written are skipped.
+type FailureDump struct {
+	// Dir is the directory receiving the dumps, created when missing; it is required
+	Dir string
+	// MaxFiles is the maximum number of files written by the process, defaults to DefaultMaxFailureDumps
+	MaxFiles int
+	// MaxBodyBytes truncates request and response bodies, defaults to DefaultMaxDumpBodyBytes
+	MaxBodyBytes int64
+	// RedactHeaders are the headers whose values are redacted, defaults to DefaultSensitiveHeaders and Set-Cookie
+	RedactHeaders []string
+
+	mu      sync.Mutex
+	written int
+}
+
+// Written returns the number of files written so far
+func (d *FailureDump) Written() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.written
+}
+
+// failureCapture keeps the start of the request and response bodies of a request in case it fails
+type failureCapture struct {
+	limit       int64
+	requestBody []byte
+	streamed    bool
+	response    bytes.Buffer
+}
+
+// capture reads the start of the request body when it can be replayed
+func (d *FailureDump) capture(req *http.Request) *failureCapture {
+	capture := &failureCapture{limit: d.maxBodyBytes()}
+	if req.Body == nil || req.Body == http.NoBody {
+		return capture
+	}
+	if req.GetBody == nil {
+		capture.streamed = true
+		return capture
+	}
+	if body, err := req.GetBody(); err == nil {
+		capture.requestBody, _ = io.ReadAll(io.LimitReader(body, capture.limit))
+		_ = body.Close()
+	}
+	return capture
+}
+
+// tee copies the start of a response body into the capture as it is read
+func (c *failureCapture) tee(body io.ReadCloser) io.ReadCloser {
+	return &teeBody{ReadCloser: body, capture: c}
+}
+
+type teeBody struct {
+	io.ReadCloser
+	capture *failureCapture
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := b.capture.limit - int64(b.capture.response.Len()); room > 0 {
+		if int64(n) < room {
+			room = int64(n)
+		}
+		b.capture.response.Write(p[:room])
+	}
+	return n, err
+}
+
+// write dumps a failed request and its response, which is nil for transport errors
+func (d *FailureDump) write(req *http.Request, resp *http.Response, capture *failureCapture, failure error) {
+	d.mu.Lock()
+	if d.written >= d.maxFiles() {
+		d.mu.Unlock()
+		return
+	}
+	d.written++
+	seq := d.written
+	d.mu.Unlock()
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s %s failed at %s\n", req.Method, req.URL.Redacted(), time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(&buf, "# %s\n\n", strings.ReplaceAll(failure.Error(), "\n", " "))
+
+	fmt.Fprintf(&buf, "%s %s HTTP/1.1\r\n", req.Method, req.URL.RequestURI())
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	fmt.Fprintf(&buf, "Host: %s\r\n", host)
+	d.writeHeader(&buf, req.Header)
+	buf.WriteString("\r\n")
+	switch {
+	case capture.streamed:
+		buf.WriteString("# streamed body not captured\n")
+	default:
+		d.writeBody(&buf, capture.requestBody, req.ContentLength)
+	}
+
+	if resp != nil {
+		fmt.Fprintf(&buf, "\n%s %s\r\n", resp.Proto, resp.Status)
+		d.writeHeader(&buf, resp.Header)
+		buf.WriteString("\r\n")
+		d.writeBody(&buf, capture.response.Bytes(), resp.ContentLength)
+	}
+
+	if err := os.MkdirAll(d.Dir, 0o700); err != nil {
+		return
+	}
+	name := fmt.Sprintf("%s-%04d-%s-%s.http", time.Now().UTC().Format("20060102T150405.000Z"), seq, req.Method, safeFileName(req.URL.Host))
+	_ = os.WriteFile(filepath.Join(d.Dir, name), buf.Bytes(), 0o600)
+}
+
+// writeHeader writes header sorted by name, redacting sensitive values
+func (d *FailureDump) writeHeader(buf *bytes.Buffer, header http.Header) {
+	redact := d.RedactHeaders
+	if redact == nil {
+		redact = append(cloneSlice(DefaultSensitiveHeaders), "Set-Cookie")
+	}
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			for _, sensitive := range redact {
+				if strings.EqualFold(name, sensitive) {
+					value = "[REDACTED]"
+				}
+			}
+			fmt.Fprintf(buf, "%s: %s\r\n", name, value)
+		}
+	}
+}
+
+// writeBody writes a captured body, noting when it was truncated
+func (d *FailureDump) writeBody(buf *bytes.Buffer, body []byte, length int64) {
+	buf.Write(body)
+	if int64(len(body)) >= d.maxBodyBytes() && length != int64(len(body)) {
+		fmt.Fprintf(buf, "\n# body truncated to %d bytes\n", len(body))
+	}
+}
+
+func (d *FailureDump) maxFiles() int {
+	if d.MaxFiles > 0 {
+		return d.MaxFiles
+	}
+	return DefaultMaxFailureDumps
+}
+
+func (d *FailureDump) maxBodyBytes() int64 {
+	if d.MaxBodyBytes > 0 {
+		return d.MaxBodyBytes
+	}
+	return DefaultMaxDumpBodyBytes
+}
+
+// safeFileName replaces the characters of s that are not safe in file names
+func safeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,115 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFailureDump(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/broken":
+			w.Header().Set("Set-Cookie", "session=secret")
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`{"error":"upstream timeout"}`))
+		case "/garbage":
+			_, _ = w.Write([]byte(`{"name":`))
+		default:
+			_, _ = w.Write([]byte(`{"name":"pikachu"}`))
+		}
+	}))
+	defer server.Close()
+
+	readDumps := func(t *testing.T, dir string) []string {
+		t.Helper()
+		entries, err := os.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatalf("reading dumps failed: %v", err)
+		}
+		var dumps []string
+		for _, entry := range entries {
+			data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				t.Fatalf("reading dump failed: %v", err)
+			}
+			dumps = append(dumps, string(data))
+		}
+		return dumps
+	}
+
+	t.Run("error status", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "failures")
+		client := &Client{FailureDump: &FailureDump{Dir: dir}}
+		err := client.Post(context.Background(), server.URL+"/broken?page=2", map[string]string{"name": "pikachu"}, nil,
+			WithHeader("Authorization", "Bearer secret"), WithHeader("X-Request-Id", "42"))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+
+		dumps := readDumps(t, dir)
+		if len(dumps) != 1 {
+			t.Fatalf("expected 1 dump, got %d", len(dumps))
+		}
+		for _, want := range []string{
+			"POST /broken?page=2 HTTP/1.1",
+			"Authorization: [REDACTED]",
+			"X-Request-Id: 42",
+			`{"name":"pikachu"}`,
+			"HTTP/1.1 502 Bad Gateway",
+			`{"error":"upstream timeout"}`,
+		} {
+			if !strings.Contains(dumps[0], want) {
+				t.Errorf("expected dump to contain %q, got:\n%s", want, dumps[0])
+			}
+		}
+		if strings.Contains(dumps[0], "Bearer secret") || strings.Contains(dumps[0], "session=secret") {
+			t.Errorf("expected credentials to be redacted, got:\n%s", dumps[0])
+		}
+	})
+
+	t.Run("decode error", func(t *testing.T) {
+		dir := t.TempDir()
+		client := &Client{FailureDump: &FailureDump{Dir: dir}}
+		var result map[string]string
+		if err := client.Get(context.Background(), server.URL+"/garbage", &result); err == nil {
+			t.Fatal("expected error")
+		}
+		dumps := readDumps(t, dir)
+		if len(dumps) != 1 || !strings.Contains(dumps[0], "HTTP/1.1 200 OK") || !strings.Contains(dumps[0], `{"name":`) {
+			t.Errorf("expected dump of the undecodable response, got %v", dumps)
+		}
+	})
+
+	t.Run("success", func(t *testing.T) {
+		dir := t.TempDir()
+		client := &Client{FailureDump: &FailureDump{Dir: dir}}
+		var result map[string]string
+		if err := client.Get(context.Background(), server.URL+"/pokemon", &result); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if dumps := readDumps(t, dir); len(dumps) != 0 {
+			t.Errorf("expected no dump, got %d", len(dumps))
+		}
+	})
+
+	t.Run("capped", func(t *testing.T) {
+		dir := t.TempDir()
+		dump := &FailureDump{Dir: dir, MaxFiles: 2, MaxBodyBytes: 8}
+		client := &Client{FailureDump: dump}
+		for i := 0; i < 5; i++ {
+			_ = client.Get(context.Background(), server.URL+"/broken", nil)
+		}
+		dumps := readDumps(t, dir)
+		if len(dumps) != 2 || dump.Written() != 2 {
+			t.Fatalf("expected 2 dumps, got %d", len(dumps))
+		}
+		if !strings.Contains(dumps[0], "# body truncated to 8 bytes") {
+			t.Errorf("expected truncated body, got:\n%s", dumps[0])
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ForEachElement returns a result decoding a response holding a top-level JSON array one element at a time,
+// calling fn with each element instead of materializing the whole collection:
+//
+//	err := client.Get(ctx, "/pokemon", httpclient.ForEachElement(func(p Pokemon) error {
+//	    return store.Save(p)
+//	}))
+//
+// An error returned by fn stops the decoding and is returned by the request. Elements are decoded with
+// encoding/json whatever the UnmarshalFunc of the client, and a null response calls fn zero times.
+func ForEachElement[T any](fn func(element T) error) ResponseDecoder {
+	return forEach[T]{open: '[', fn: func(_ string, element T) error { return fn(element) }}
+}
+
+// ForEachEntry is like ForEachElement for a response holding a top-level JSON object, calling fn with the key
+// and value of each member in document order
+func ForEachEntry[T any](fn func(key string, value T) error) ResponseDecoder {
+	return forEach[T]{open: '{', fn: fn}
+}
+
+// forEach decodes the members of the top-level array or object opened by open
+type forEach[T any] struct {
+	open json.Delim
+	fn   func(key string, value T) error
+}
+
+// DecodeResponse implements ResponseDecoder
+func (f forEach[T]) DecodeResponse(resp *http.Response) error {
+	return f.decode(json.NewDecoder(resp.Body))
+}
+
+// decodeEnvelope implements envelopeDecoder, skipping the other fields of the envelope without decoding them
+func (f forEach[T]) decodeEnvelope(resp *http.Response, envelope string) error {
+	dec := json.NewDecoder(resp.Body)
+	if token, err := dec.Token(); err != nil {
+		return err
+	} else if token != json.Delim('{') {
+		return fmt.Errorf("expected { at the top level of the response, got %v", token)
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if token == envelope {
+			return f.decode(dec)
+		}
+		if err := skipValue(dec); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("no %q field in response", envelope)
+}
+
+// decode calls fn with the members of the next value of dec
+func (f forEach[T]) decode(dec *json.Decoder) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil
+	}
+	if token != f.open {
+		return fmt.Errorf("expected %v at the top level of the response, got %v", f.open, token)
+	}
+
+	for i := 0; dec.More(); i++ {
+		var key string
+		if f.open == '{' {
+			if token, err = dec.Token(); err != nil {
+				return err
+			}
+			key = token.(string)
+		}
+		var value T
+		if err := dec.Decode(&value); err != nil {
+			return fmt.Errorf("failed to decode element %d: %w", i, err)
+		}
+		if err := f.fn(key, value); err != nil {
+			return err
+		}
+	}
+	// Closing delimiter
+	_, err = dec.Token()
+	return err
+}
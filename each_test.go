@@ -0,0 +1,83 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestForEach(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/list":
+			_, _ = w.Write([]byte(`[{"name":"bulbasaur"},{"name":"ivysaur"},{"name":"venusaur"}]`))
+		case "/map":
+			_, _ = w.Write([]byte(`{"bulbasaur":1,"ivysaur":2}`))
+		default:
+			_, _ = w.Write([]byte(`null`))
+		}
+	}))
+	defer server.Close()
+
+	type pokemon struct {
+		Name string `json:"name"`
+	}
+
+	t.Run("elements", func(t *testing.T) {
+		var names []string
+		err := (&Client{}).Get(context.Background(), server.URL+"/list", ForEachElement(func(p pokemon) error {
+			names = append(names, p.Name)
+			return nil
+		}))
+		if err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if len(names) != 3 || names[2] != "venusaur" {
+			t.Errorf("expected 3 names, got %v", names)
+		}
+	})
+
+	t.Run("entries", func(t *testing.T) {
+		var keys []string
+		var total int
+		err := (&Client{}).Get(context.Background(), server.URL+"/map", ForEachEntry(func(key string, id int) error {
+			keys = append(keys, key)
+			total += id
+			return nil
+		}))
+		if err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if len(keys) != 2 || keys[0] != "bulbasaur" || total != 3 {
+			t.Errorf("expected ordered keys and total 3, got %v and %d", keys, total)
+		}
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		err := (&Client{}).Get(context.Background(), server.URL+"/list", ForEachElement(func(pokemon) error {
+			calls++
+			return stop
+		}))
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Errorf("expected stop after one call, got %v after %d", err, calls)
+		}
+	})
+
+	t.Run("null and mismatch", func(t *testing.T) {
+		if err := (&Client{}).Get(context.Background(), server.URL+"/null", ForEachElement(func(pokemon) error {
+			t.Errorf("unexpected call")
+			return nil
+		})); err != nil {
+			t.Errorf("expected no error for null, got %v", err)
+		}
+		if err := (&Client{}).Get(context.Background(), server.URL+"/map", ForEachElement(func(pokemon) error {
+			return nil
+		})); err == nil {
+			t.Errorf("expected error for an object, got nil")
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// requestURL replaces path parameters in rawURL and prefixes it with BaseURL when it has no scheme
+func (c *Client) requestURL(rawURL string, params map[string]string) string {
+	for name, value := range params {
+		rawURL = strings.ReplaceAll(rawURL, "{"+name+"}", url.PathEscape(value))
+	}
+	if c.BaseURL == "" || strings.Contains(rawURL, "://") {
+		return rawURL
+	}
+	return strings.TrimSuffix(c.BaseURL, "/") + "/" + strings.TrimPrefix(rawURL, "/")
+}
+
+// Endpoint describes an API operation once, its method, path template, accepted statuses and types,
+// so calls only provide parameters:
+//
+//	type GetPokemonRequest struct {
+//	    Name     string `path:"name"`
+//	    Language string `query:"lang,omitempty"`
+//	}
+//	var GetPokemon = httpclient.NewEndpoint[GetPokemonRequest, Pokemon]("GET", "/pokemon/{name}")
+//
+//	pokemon, err := GetPokemon.Call(ctx, client, GetPokemonRequest{Name: "pikachu"})
+//
+// Fields of Req tagged `path:"name"` fill the {name} placeholders and fields tagged `query:"name"` become
+// query parameters, optionally with ",omitempty". For methods other than GET, HEAD, DELETE and OPTIONS,
+// Req is also sent as the JSON body, so parameter-only fields should be tagged `json:"-"`.
+type Endpoint[Req any, Resp any] struct {
+	// Method is the HTTP method
+	Method string
+	// Path is appended to Client.BaseURL, or a full URL, with {name} placeholders
+	Path string
+	// ExpectedStatus lists the accepted status codes, defaults to any status below 400
+	ExpectedStatus []int
+	// Options are applied to every call before the options of the call
+	Options []Option
+}
+
+// NewEndpoint returns an Endpoint for method and path
+func NewEndpoint[Req any, Resp any](method, path string, opts ...Option) *Endpoint[Req, Resp] {
+	return &Endpoint[Req, Resp]{Method: method, Path: path, Options: opts}
+}
+
+// Call sends req to the endpoint and decodes the response, a nil client uses the default client
+func (e *Endpoint[Req, Resp]) Call(ctx context.Context, c *Client, req Req, opts ...Option) (Resp, error) {
+	var resp Resp
+	if c == nil {
+		c = Default()
+	}
+
+	params, query := requestParams(req)
+	path := e.Path
+	for name, value := range params {
+		path = strings.ReplaceAll(path, "{"+name+"}", url.PathEscape(value))
+	}
+	if start := strings.Index(path, "{"); start >= 0 && strings.Contains(path[start:], "}") {
+		return resp, fmt.Errorf("missing path parameter in %s", path)
+	}
+
+	all := make([]Option, 0, len(e.Options)+len(opts)+2)
+	all = append(all, e.Options...)
+	if len(query) > 0 {
+		all = append(all, WithQuery(query))
+	}
+	if len(e.ExpectedStatus) > 0 {
+		all = append(all, WithExpectedStatus(e.ExpectedStatus...))
+	}
+	all = append(all, opts...)
+
+	var body interface{}
+	switch e.Method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions:
+	default:
+		body = req
+	}
+	err := c.Do(ctx, e.Method, path, body, &resp, all...)
+	return resp, err
+}
+
+// requestParams collects the path and query parameters of the tagged fields of req
+func requestParams(req interface{}) (map[string]string, url.Values) {
+	v := reflect.ValueOf(req)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	params := map[string]string{}
+	query := url.Values{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := v.Field(i)
+		if name, ok := field.Tag.Lookup("path"); ok {
+			params[name] = fmt.Sprint(value.Interface())
+		}
+		if tag, ok := field.Tag.Lookup("query"); ok {
+			name, omitEmpty := tag, false
+			if i := strings.Index(tag, ","); i >= 0 {
+				name, omitEmpty = tag[:i], tag[i+1:] == "omitempty"
+			}
+			if omitEmpty && value.IsZero() {
+				continue
+			}
+			if value.Kind() == reflect.Slice && value.Type().Elem().Kind() != reflect.Uint8 {
+				for j := 0; j < value.Len(); j++ {
+					query.Add(name, fmt.Sprint(value.Index(j).Interface()))
+				}
+				continue
+			}
+			query.Add(name, fmt.Sprint(value.Interface()))
+		}
+	}
+	return params, query
+}
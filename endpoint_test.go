@@ -0,0 +1,98 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"method": r.Method,
+			"path":   r.URL.EscapedPath(),
+			"query":  r.URL.RawQuery,
+			"body":   string(body),
+		})
+	}))
+	defer server.Close()
+
+	type echo struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+		Query  string `json:"query"`
+		Body   string `json:"body"`
+	}
+	type getRequest struct {
+		Name     string   `path:"name"`
+		Language string   `query:"lang,omitempty"`
+		Fields   []string `query:"fields"`
+	}
+	type createRequest struct {
+		Trainer string `path:"trainer" json:"-"`
+		Name    string `json:"name"`
+	}
+
+	c := &Client{BaseURL: server.URL + "/api/"}
+	ctx := context.Background()
+
+	t.Run("path and query parameters", func(t *testing.T) {
+		get := NewEndpoint[getRequest, echo](http.MethodGet, "/pokemon/{name}")
+		resp, err := get.Call(ctx, c, getRequest{Name: "mr mime", Fields: []string{"id", "name"}})
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		if resp.Path != "/api/pokemon/mr%20mime" {
+			t.Errorf("expected escaped path, got %s", resp.Path)
+		}
+		if resp.Query != "fields=id&fields=name" {
+			t.Errorf("expected query without empty lang, got %s", resp.Query)
+		}
+		if resp.Body != "" {
+			t.Errorf("expected no body for GET, got %s", resp.Body)
+		}
+	})
+
+	t.Run("body and expected status", func(t *testing.T) {
+		create := &Endpoint[*createRequest, echo]{Method: http.MethodPost, Path: "/trainers/{trainer}/pokemon", ExpectedStatus: []int{http.StatusCreated}}
+		resp, err := create.Call(ctx, c, &createRequest{Trainer: "ash", Name: "pikachu"})
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		if resp.Path != "/api/trainers/ash/pokemon" || resp.Body != `{"name":"pikachu"}` {
+			t.Errorf("expected path and body, got %+v", resp)
+		}
+
+		update := &Endpoint[*createRequest, echo]{Method: http.MethodPut, Path: "/trainers/{trainer}/pokemon", ExpectedStatus: []int{http.StatusCreated}}
+		var httpErr *HTTPError
+		if _, err := update.Call(ctx, c, &createRequest{Trainer: "ash"}); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusOK {
+			t.Errorf("expected HTTPError for unexpected 200, got %v", err)
+		}
+	})
+
+	t.Run("missing path parameter", func(t *testing.T) {
+		get := NewEndpoint[struct{}, echo](http.MethodGet, "/pokemon/{name}")
+		if _, err := get.Call(ctx, c, struct{}{}); err == nil {
+			t.Errorf("expected missing path parameter error")
+		}
+	})
+
+	t.Run("path parameter options", func(t *testing.T) {
+		var resp echo
+		err := c.Get(ctx, "pokemon/{name}/{form}", &resp, WithPathParams(map[string]string{"name": "pikachu"}), WithPathParam("form", "a/b"))
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if resp.Path != "/api/pokemon/pikachu/a%2Fb" {
+			t.Errorf("expected substituted path, got %s", resp.Path)
+		}
+	})
+}
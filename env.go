@@ -0,0 +1,202 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables read by NewFromEnv
+const (
+	EnvTimeout          = "HTTPCLIENT_TIMEOUT"
+	EnvProxy            = "HTTPCLIENT_PROXY"
+	EnvMaxRetries       = "HTTPCLIENT_MAX_RETRIES"
+	EnvMaxRedirects     = "HTTPCLIENT_MAX_REDIRECTS"
+	EnvMaxResponseBytes = "HTTPCLIENT_MAX_RESPONSE_BYTES"
+	EnvUserAgent        = "HTTPCLIENT_USER_AGENT"
+	EnvRequireHTTPS     = "HTTPCLIENT_REQUIRE_HTTPS"
+	EnvAllowedHosts     = "HTTPCLIENT_ALLOWED_HOSTS"
+	EnvDeniedHosts      = "HTTPCLIENT_DENIED_HOSTS"
+)
+
+// Environment variables read by ChaosFromEnv
+const (
+	EnvChaosHosts       = "HTTPCLIENT_CHAOS_HOSTS"
+	EnvChaosFailureRate = "HTTPCLIENT_CHAOS_FAILURE_RATE"
+	EnvChaosStatusRate  = "HTTPCLIENT_CHAOS_STATUS_RATE"
+	EnvChaosStatusCodes = "HTTPCLIENT_CHAOS_STATUS_CODES"
+	EnvChaosLatency     = "HTTPCLIENT_CHAOS_LATENCY"
+	EnvChaosJitter      = "HTTPCLIENT_CHAOS_LATENCY_JITTER"
+)
+
+// NewFromEnv returns a Client configured from HTTPCLIENT_* environment variables, unset variables
+// keep the zero value defaults:
+//   - HTTPCLIENT_TIMEOUT: overall timeout as a duration, e.g. "10s"
+//   - HTTPCLIENT_PROXY: proxy URL for every request, overriding HTTP_PROXY and HTTPS_PROXY
+//   - HTTPCLIENT_MAX_RETRIES, HTTPCLIENT_MAX_REDIRECTS, HTTPCLIENT_MAX_RESPONSE_BYTES: integers
+//   - HTTPCLIENT_USER_AGENT: default User-Agent
+//   - HTTPCLIENT_REQUIRE_HTTPS: boolean, e.g. "true" or "1"
+//   - HTTPCLIENT_ALLOWED_HOSTS, HTTPCLIENT_DENIED_HOSTS: comma separated host patterns
+//
+// Faults configured with the HTTPCLIENT_CHAOS_* variables of ChaosFromEnv are injected by the last middleware.
+func NewFromEnv() (*Client, error) {
+	return newFromEnv(os.Getenv)
+}
+
+func newFromEnv(getenv func(string) string) (*Client, error) {
+	c := &Client{UserAgent: getenv(EnvUserAgent)}
+
+	var err error
+	if c.MaxRetries, err = envInt(getenv, EnvMaxRetries); err != nil {
+		return nil, err
+	}
+	if c.MaxRedirects, err = envInt(getenv, EnvMaxRedirects); err != nil {
+		return nil, err
+	}
+	maxResponseBytes, err := envInt(getenv, EnvMaxResponseBytes)
+	if err != nil {
+		return nil, err
+	}
+	c.MaxResponseBytes = int64(maxResponseBytes)
+	if value := getenv(EnvRequireHTTPS); value != "" {
+		if c.RequireHTTPS, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", EnvRequireHTTPS, err)
+		}
+	}
+	c.AllowedHosts = envList(getenv, EnvAllowedHosts)
+	c.DeniedHosts = envList(getenv, EnvDeniedHosts)
+	chaos, err := chaosFromEnv(getenv)
+	if err != nil {
+		return nil, err
+	}
+	if chaos != nil {
+		c.Middleware = []Middleware{chaos.Middleware()}
+	}
+
+	timeout, proxy := getenv(EnvTimeout), getenv(EnvProxy)
+	if timeout == "" && proxy == "" {
+		return c, nil
+	}
+
+	client := &http.Client{}
+	if timeout != "" {
+		if client.Timeout, err = time.ParseDuration(timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", EnvTimeout, err)
+		}
+	}
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", EnvProxy, err)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(proxyURL)
+		client.Transport = transport
+	}
+	c.Client = client
+	return c, nil
+}
+
+// ChaosFromEnv returns the fault injection configured by HTTPCLIENT_CHAOS_* environment variables, or nil
+// when no fault is configured, so resilience can be exercised in staging without code changes:
+//
+//	if chaos, err := httpclient.ChaosFromEnv(); err != nil {
+//	    return err
+//	} else if chaos != nil {
+//	    client.Middleware = append(client.Middleware, chaos.Middleware())
+//	}
+//
+// The variables are:
+//   - HTTPCLIENT_CHAOS_HOSTS: comma separated host patterns receiving faults, required with any fault
+//   - HTTPCLIENT_CHAOS_FAILURE_RATE, HTTPCLIENT_CHAOS_STATUS_RATE: probabilities in [0, 1], e.g. "0.05"
+//   - HTTPCLIENT_CHAOS_STATUS_CODES: comma separated injected statuses, defaults to 503
+//   - HTTPCLIENT_CHAOS_LATENCY, HTTPCLIENT_CHAOS_LATENCY_JITTER: durations added to requests, e.g. "200ms"
+func ChaosFromEnv() (*ChaosTransport, error) {
+	return chaosFromEnv(os.Getenv)
+}
+
+func chaosFromEnv(getenv func(string) string) (*ChaosTransport, error) {
+	chaos := &ChaosTransport{Hosts: envList(getenv, EnvChaosHosts)}
+	var err error
+	if chaos.FailureRate, err = envRate(getenv, EnvChaosFailureRate); err != nil {
+		return nil, err
+	}
+	if chaos.StatusRate, err = envRate(getenv, EnvChaosStatusRate); err != nil {
+		return nil, err
+	}
+	for _, code := range envList(getenv, EnvChaosStatusCodes) {
+		status, err := strconv.Atoi(code)
+		if err != nil || status < 100 || status > 599 {
+			return nil, fmt.Errorf("failed to parse %s: invalid status %q", EnvChaosStatusCodes, code)
+		}
+		chaos.StatusCodes = append(chaos.StatusCodes, status)
+	}
+	if chaos.Latency, err = envDuration(getenv, EnvChaosLatency); err != nil {
+		return nil, err
+	}
+	if chaos.LatencyJitter, err = envDuration(getenv, EnvChaosJitter); err != nil {
+		return nil, err
+	}
+
+	if chaos.FailureRate == 0 && chaos.StatusRate == 0 && chaos.Latency == 0 && chaos.LatencyJitter == 0 {
+		return nil, nil
+	}
+	// Faults are never injected everywhere by accident, e.g. by a variable leaking into production
+	if len(chaos.Hosts) == 0 {
+		return nil, fmt.Errorf("%s is required to inject faults", EnvChaosHosts)
+	}
+	return chaos, nil
+}
+
+func envRate(getenv func(string) string, key string) (float64, error) {
+	value := getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", key, err)
+	}
+	if rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("failed to parse %s: %v is not in [0, 1]", key, rate)
+	}
+	return rate, nil
+}
+
+func envDuration(getenv func(string) string, key string) (time.Duration, error) {
+	value := getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", key, err)
+	}
+	return d, nil
+}
+
+func envInt(getenv func(string) string, key string) (int, error) {
+	value := getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", key, err)
+	}
+	return n, nil
+}
+
+func envList(getenv func(string) string, key string) []string {
+	var list []string
+	for _, item := range strings.Split(getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
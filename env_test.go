@@ -0,0 +1,127 @@
+package httpclient
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewFromEnv(t *testing.T) {
+	t.Run("unset", func(t *testing.T) {
+		c, err := newFromEnv(func(string) string { return "" })
+		if err != nil {
+			t.Fatalf("newFromEnv failed: %v", err)
+		}
+		if !reflect.DeepEqual(c, &Client{}) {
+			t.Errorf("expected zero value client, got %+v", c)
+		}
+	})
+
+	t.Run("all variables", func(t *testing.T) {
+		t.Setenv(EnvTimeout, "5s")
+		t.Setenv(EnvProxy, "http://proxy.internal:3128")
+		t.Setenv(EnvMaxRetries, "3")
+		t.Setenv(EnvMaxRedirects, "2")
+		t.Setenv(EnvMaxResponseBytes, "1024")
+		t.Setenv(EnvUserAgent, "pokedex/1.0")
+		t.Setenv(EnvRequireHTTPS, "true")
+		t.Setenv(EnvAllowedHosts, "pokeapi.co, *.pokeapi.co")
+		t.Setenv(EnvDeniedHosts, "evil.pokeapi.co")
+
+		c, err := NewFromEnv()
+		if err != nil {
+			t.Fatalf("NewFromEnv failed: %v", err)
+		}
+		if c.Client.Timeout != 5*time.Second {
+			t.Errorf("expected timeout 5s, got %v", c.Client.Timeout)
+		}
+		req, _ := http.NewRequest(http.MethodGet, "https://pokeapi.co", nil)
+		proxy, err := c.Client.Transport.(*http.Transport).Proxy(req)
+		if err != nil || proxy == nil || proxy.Host != "proxy.internal:3128" {
+			t.Errorf("expected proxy.internal:3128, got %v (%v)", proxy, err)
+		}
+		if c.MaxRetries != 3 || c.MaxRedirects != 2 || c.MaxResponseBytes != 1024 {
+			t.Errorf("expected limits 3/2/1024, got %d/%d/%d", c.MaxRetries, c.MaxRedirects, c.MaxResponseBytes)
+		}
+		if c.UserAgent != "pokedex/1.0" || !c.RequireHTTPS {
+			t.Errorf("expected user agent and RequireHTTPS, got %q and %v", c.UserAgent, c.RequireHTTPS)
+		}
+		if !reflect.DeepEqual(c.AllowedHosts, []string{"pokeapi.co", "*.pokeapi.co"}) {
+			t.Errorf("expected allowed hosts, got %v", c.AllowedHosts)
+		}
+		if !reflect.DeepEqual(c.DeniedHosts, []string{"evil.pokeapi.co"}) {
+			t.Errorf("expected denied hosts, got %v", c.DeniedHosts)
+		}
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		for key, value := range map[string]string{
+			EnvTimeout:      "soon",
+			EnvMaxRetries:   "many",
+			EnvRequireHTTPS: "maybe",
+			EnvProxy:        "://proxy",
+		} {
+			env := map[string]string{key: value}
+			if _, err := newFromEnv(func(k string) string { return env[k] }); err == nil {
+				t.Errorf("expected error for %s=%q", key, value)
+			}
+		}
+	})
+}
+
+func TestChaosFromEnv(t *testing.T) {
+	t.Run("unset", func(t *testing.T) {
+		chaos, err := chaosFromEnv(func(string) string { return "" })
+		if err != nil || chaos != nil {
+			t.Errorf("expected no chaos, got %+v (%v)", chaos, err)
+		}
+	})
+
+	t.Run("all variables", func(t *testing.T) {
+		t.Setenv(EnvChaosHosts, "staging.pokeapi.co, *.staging.internal")
+		t.Setenv(EnvChaosFailureRate, "0.01")
+		t.Setenv(EnvChaosStatusRate, "0.05")
+		t.Setenv(EnvChaosStatusCodes, "500,503")
+		t.Setenv(EnvChaosLatency, "200ms")
+		t.Setenv(EnvChaosJitter, "50ms")
+
+		chaos, err := ChaosFromEnv()
+		if err != nil {
+			t.Fatalf("ChaosFromEnv failed: %v", err)
+		}
+		expected := &ChaosTransport{
+			Hosts:         []string{"staging.pokeapi.co", "*.staging.internal"},
+			FailureRate:   0.01,
+			StatusRate:    0.05,
+			StatusCodes:   []int{500, 503},
+			Latency:       200 * time.Millisecond,
+			LatencyJitter: 50 * time.Millisecond,
+		}
+		if !reflect.DeepEqual(chaos, expected) {
+			t.Errorf("expected %+v, got %+v", expected, chaos)
+		}
+
+		c, err := NewFromEnv()
+		if err != nil {
+			t.Fatalf("NewFromEnv failed: %v", err)
+		}
+		if len(c.Middleware) != 1 {
+			t.Errorf("expected chaos middleware, got %d middleware", len(c.Middleware))
+		}
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		for _, env := range []map[string]string{
+			{EnvChaosStatusRate: "0.5"},
+			{EnvChaosHosts: "staging.pokeapi.co", EnvChaosStatusRate: "5%"},
+			{EnvChaosHosts: "staging.pokeapi.co", EnvChaosFailureRate: "1.5"},
+			{EnvChaosHosts: "staging.pokeapi.co", EnvChaosStatusCodes: "oops", EnvChaosStatusRate: "0.1"},
+			{EnvChaosHosts: "staging.pokeapi.co", EnvChaosLatency: "slow"},
+		} {
+			if _, err := chaosFromEnv(func(k string) string { return env[k] }); err == nil {
+				t.Errorf("expected error for %v", env)
+			}
+		}
+	})
+}
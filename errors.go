@@ -0,0 +1,169 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ErrPreconditionFailed matches HTTPErrors with status 412, returned when an If-Match precondition fails
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// Categories of transport errors, matched by errors.Is on the errors returned by requests, so callers can tell a
+// slow server from a down one or from misconfigured certificates
+var (
+	// ErrTimeout matches requests that ran out of time, by deadline, Client.Timeout or a network timeout
+	ErrTimeout = errors.New("timeout")
+	// ErrConnection matches failures to connect to the server or connections lost during the exchange
+	ErrConnection = errors.New("connection error")
+	// ErrDNS matches failures to resolve the host of the request
+	ErrDNS = errors.New("DNS error")
+	// ErrTLS matches TLS handshake failures, such as untrusted or expired certificates
+	ErrTLS = errors.New("TLS error")
+)
+
+// ErrBodyBufferingRequired is returned by requests sent WithoutBodyBuffering with options reading the whole body
+var ErrBodyBufferingRequired = errors.New("response body buffering required")
+
+// HTTPError is returned for responses with status 400 and above when WithStatus is not used
+type HTTPError struct {
+	// StatusCode is the response status code, e.g. 404
+	StatusCode int
+	// Status is the response status line, e.g. "404 Not Found"
+	Status string
+	// Header holds the response headers
+	Header http.Header
+	// Body is the response body
+	Body []byte
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP error: %s, body: %s", e.Status, string(e.Body))
+}
+
+// Is lets errors.Is match status sentinels such as ErrPreconditionFailed
+func (e *HTTPError) Is(target error) bool {
+	return target == ErrPreconditionFailed && e.StatusCode == http.StatusPreconditionFailed
+}
+
+// RequestError wraps every error returned by a Client request with the call that failed, so logs identify it
+// without wrapping each call; errors.Is and errors.As see through it to the cause, e.g. an *HTTPError
+type RequestError struct {
+	// Method is the request method, e.g. "GET"
+	Method string
+	// URL is the request URL without user info and with its query values redacted
+	URL string
+	// Attempts is the number of attempts made, zero when the request failed before being sent
+	Attempts int
+	// Elapsed is the time from the call to the failure, including scheduling and retries
+	Elapsed time.Duration
+	// Timings tell where the time went when the request timed out, nil otherwise
+	Timings *RequestTimings
+	// Err is the cause of the failure
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	attempts := "attempts"
+	if e.Attempts == 1 {
+		attempts = "attempt"
+	}
+	if e.Timings != nil {
+		return fmt.Sprintf("%s %s failed after %d %s in %s (%s): %v", e.Method, e.URL, e.Attempts, attempts,
+			e.Elapsed.Round(time.Millisecond), e.Timings, e.Err)
+	}
+	return fmt.Sprintf("%s %s failed after %d %s in %s: %v", e.Method, e.URL, e.Attempts, attempts, e.Elapsed.Round(time.Millisecond), e.Err)
+}
+
+// Unwrap returns the cause of the failure
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// Is lets errors.Is match the category of the failure, one of ErrTimeout, ErrConnection, ErrDNS and ErrTLS
+func (e *RequestError) Is(target error) bool {
+	switch target {
+	case ErrTimeout, ErrConnection, ErrDNS, ErrTLS:
+		return errorCategory(e.Err) == target
+	}
+	return false
+}
+
+// errorCategory classifies a transport error, returning nil for errors of other kinds such as HTTPErrors.
+// DNS and TLS failures take precedence over timeouts, a DNS lookup timing out being a DNS problem.
+func errorCategory(err error) error {
+	var dnsErr *net.DNSError
+	var recordHeader tls.RecordHeaderError
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalidCertificate x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	var netErr net.Error
+	var opErr *net.OpError
+	// Errors of net/http only tell TLS alerts and lost connections apart by their message or io.EOF
+	var urlErr *url.Error
+	transport := errors.As(err, &urlErr)
+	switch {
+	case errors.As(err, &dnsErr):
+		return ErrDNS
+	case errors.As(err, &recordHeader), errors.As(err, &unknownAuthority), errors.As(err, &invalidCertificate),
+		errors.As(err, &hostname), transport && strings.Contains(urlErr.Err.Error(), "tls: "):
+		return ErrTLS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrTimeout
+	case errors.As(err, &opErr), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET),
+		transport && (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)):
+		return ErrConnection
+	}
+	return nil
+}
+
+// requestStatsKey carries the *requestStats of the request sent by Do
+type requestStatsKey struct{}
+
+// requestStats records what RequestError reports about a request while it is sent
+type requestStats struct {
+	attempts int32
+	url      *url.URL
+	tracer   *requestTracer
+}
+
+// countAttempt counts an attempt of the request of ctx
+func countAttempt(ctx context.Context) {
+	if stats, ok := ctx.Value(requestStatsKey{}).(*requestStats); ok && stats != nil {
+		atomic.AddInt32(&stats.attempts, 1)
+	}
+}
+
+// redactURLError redacts the URL that the *url.Error returned by http.Client repeats, with its query
+func redactURLError(err error, u *url.URL) {
+	if urlErr, ok := err.(*url.Error); ok {
+		urlErr.URL = redactedURL(u)
+	}
+}
+
+// redactedURL returns u without user info and fragment, with the values of its query parameters redacted
+// since they often carry API keys and tokens
+func redactedURL(u *url.URL) string {
+	redacted := *u
+	redacted.User, redacted.Fragment = nil, ""
+	if query := u.Query(); len(query) > 0 {
+		keys := make([]string, 0, len(query))
+		for key := range query {
+			keys = append(keys, url.QueryEscape(key)+"=REDACTED")
+		}
+		sort.Strings(keys)
+		redacted.RawQuery = strings.Join(keys, "&")
+	}
+	return redacted.String()
+}
@@ -0,0 +1,174 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"not found"}`))
+	}))
+	defer server.Close()
+
+	err := (&Client{}).Get(context.Background(), server.URL, nil)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("expected *HTTPError, got %v", err)
+	}
+	if httpErr.StatusCode != http.StatusNotFound || string(httpErr.Body) != `{"error":"not found"}` {
+		t.Errorf("unexpected error fields: %+v", httpErr)
+	}
+	if httpErr.Error() != `HTTP error: 404 Not Found, body: {"error":"not found"}` {
+		t.Errorf("unexpected error message: %s", httpErr)
+	}
+	if errors.Is(err, ErrPreconditionFailed) {
+		t.Error("expected 404 not to match ErrPreconditionFailed")
+	}
+}
+
+func TestWithIfMatch(t *testing.T) {
+	var mu sync.Mutex
+	version, name := 1, "pikachu"
+	etag := func() string { return `"v` + string(rune('0'+version)) + `"` }
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodPut {
+			if r.Header.Get("If-Match") != etag() {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			version++
+			name = "raichu"
+		}
+		w.Header().Set("ETag", etag())
+		_, _ = w.Write([]byte(`{"name":"` + name + `"}`))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	client := &Client{}
+
+	var current string
+	var result map[string]string
+	if err := client.Get(ctx, server.URL, &result, WithETag(&current)); err != nil {
+		t.Fatalf("GET request failed: %v", err)
+	}
+	if current != `"v1"` {
+		t.Fatalf("expected ETag \"v1\", got %s", current)
+	}
+
+	t.Run("matching etag", func(t *testing.T) {
+		var updated string
+		if err := client.Put(ctx, server.URL, map[string]string{"name": "raichu"}, &result, WithIfMatch(current), WithETag(&updated)); err != nil {
+			t.Fatalf("PUT request failed: %v", err)
+		}
+		if updated != `"v2"` {
+			t.Errorf("expected ETag \"v2\", got %s", updated)
+		}
+	})
+
+	t.Run("stale etag", func(t *testing.T) {
+		err := client.Put(ctx, server.URL, map[string]string{"name": "raichu"}, &result, WithIfMatch(current))
+		if !errors.Is(err, ErrPreconditionFailed) {
+			t.Errorf("expected ErrPreconditionFailed, got %v", err)
+		}
+	})
+}
+
+func TestRequestError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	t.Run("retried", func(t *testing.T) {
+		client := &Client{BaseURL: server.URL, MaxRetries: 2, Backoff: ConstantBackoff(0)}
+		err := client.Get(context.Background(), "/pokemon?api_key=secret&limit=5", nil)
+		var requestErr *RequestError
+		if !errors.As(err, &requestErr) {
+			t.Fatalf("expected *RequestError, got %v", err)
+		}
+		if requestErr.Method != http.MethodGet || requestErr.Attempts != 3 || requestErr.Elapsed <= 0 {
+			t.Errorf("expected GET with 3 attempts, got %+v", requestErr)
+		}
+		if expected := server.URL + "/pokemon?api_key=REDACTED&limit=REDACTED"; requestErr.URL != expected {
+			t.Errorf("expected URL %s, got %s", expected, requestErr.URL)
+		}
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("expected wrapped HTTPError, got %v", err)
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Errorf("expected redacted message, got %s", err)
+		}
+	})
+
+	t.Run("transport error", func(t *testing.T) {
+		err := (&Client{}).Get(context.Background(), "http://127.0.0.1:1/?token=secret", nil)
+		var requestErr *RequestError
+		if !errors.As(err, &requestErr) || requestErr.Attempts != 1 {
+			t.Fatalf("expected *RequestError after one attempt, got %v", err)
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Errorf("expected redacted message, got %s", err)
+		}
+	})
+
+	t.Run("not sent", func(t *testing.T) {
+		err := (&Client{}).Post(context.Background(), "http://example.com", make(chan int), nil)
+		var requestErr *RequestError
+		if !errors.As(err, &requestErr) || requestErr.Attempts != 0 {
+			t.Errorf("expected *RequestError without attempts, got %v", err)
+		}
+	})
+}
+
+func TestErrorCategories(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer slow.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer secure.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("tls: handshake failure upstream"))
+	}))
+	defer failing.Close()
+
+	tests := []struct {
+		name     string
+		client   *Client
+		url      string
+		expected error
+	}{
+		{"timeout", &Client{Client: &http.Client{Timeout: 10 * time.Millisecond}}, slow.URL, ErrTimeout},
+		{"connection", &Client{}, "http://127.0.0.1:1", ErrConnection},
+		{"dns", &Client{}, "http://pokemon.invalid", ErrDNS},
+		{"tls", &Client{}, secure.URL, ErrTLS},
+		{"http error", &Client{}, failing.URL, nil},
+	}
+	categories := []error{ErrTimeout, ErrConnection, ErrDNS, ErrTLS}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.client.Get(context.Background(), tt.url, nil)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, category := range categories {
+				if errors.Is(err, category) != (category == tt.expected) {
+					t.Errorf("expected errors.Is(err, %v) to be %v, got %v", category, category == tt.expected, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// projectStream projects the JSON document in r like project while it is read, then drains r so the
+// connection can be reused
+func (c *Client) projectStream(r io.Reader, fields map[string]interface{}) error {
+	body := &errorTrackingReader{r: r}
+	if c.MaxResponseBytes > 0 {
+		body.r = &maxBytesReader{r: r, limit: c.MaxResponseBytes, n: c.MaxResponseBytes}
+	}
+	if err := c.project(body, fields); err != nil {
+		if body.err != nil {
+			return fmt.Errorf("failed to read response body: %w", body.err)
+		}
+		return fmt.Errorf("failed to project response: %w", err)
+	}
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	return nil
+}
+
+// fieldProjection collects the values matching a WithField path
+type fieldProjection struct {
+	path   []string
+	target interface{}
+	many   bool
+	values []json.RawMessage
+}
+
+// project decodes the values at the paths of fields from the JSON document in r into their targets, skipping
+// everything else token by token so that unselected parts of the document are never materialized
+func (c *Client) project(r io.Reader, fields map[string]interface{}) error {
+	projections := make([]*fieldProjection, 0, len(fields))
+	for path, target := range fields {
+		segments := strings.Split(path, ".")
+		projection := &fieldProjection{path: segments, target: target}
+		for _, segment := range segments {
+			projection.many = projection.many || segment == "#"
+		}
+		projections = append(projections, projection)
+	}
+
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := walkFields(dec, projections, 0); err != nil {
+		return err
+	}
+
+	for _, projection := range projections {
+		if len(projection.values) == 0 {
+			continue
+		}
+		value := []byte(projection.values[len(projection.values)-1])
+		if projection.many {
+			value = append(append([]byte("["), bytes.Join(bytesOf(projection.values), []byte(","))...), ']')
+		}
+		if err := c.unmarshal(value, projection.target); err != nil {
+			return fmt.Errorf("failed to decode field %q: %w", strings.Join(projection.path, "."), err)
+		}
+	}
+	return nil
+}
+
+// walkFields reads the next value of dec, recording it in the projections whose whole path matches and
+// descending into objects and arrays matched by the first depth segments of some projection. A value that is
+// both recorded and descended into, e.g. for the paths "items" and "items.#.id", is walked again once decoded.
+func walkFields(dec *json.Decoder, projections []*fieldProjection, depth int) error {
+	var matched bool
+	longer := make([]*fieldProjection, 0, len(projections))
+	for _, projection := range projections {
+		if len(projection.path) == depth {
+			matched = true
+		} else {
+			longer = append(longer, projection)
+		}
+	}
+	if matched {
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		for _, projection := range projections {
+			if len(projection.path) == depth {
+				projection.values = append(projection.values, value)
+			}
+		}
+		if len(longer) == 0 {
+			return nil
+		}
+		inner := json.NewDecoder(bytes.NewReader(value))
+		inner.UseNumber()
+		return walkFields(inner, longer, depth)
+	}
+
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return nil
+	}
+	for i := 0; dec.More(); i++ {
+		key := strconv.Itoa(i)
+		if delim == '{' {
+			if token, err = dec.Token(); err != nil {
+				return err
+			}
+			key = token.(string)
+		}
+		next := make([]*fieldProjection, 0, len(projections))
+		for _, projection := range projections {
+			segment := projection.path[depth]
+			if segment == key || (segment == "#" && delim == '[') {
+				next = append(next, projection)
+			}
+		}
+		if len(next) == 0 {
+			err = skipValue(dec)
+		} else {
+			err = walkFields(dec, next, depth+1)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// Closing delimiter
+	_, err = dec.Token()
+	return err
+}
+
+// skipValue reads the next value of dec without keeping it
+func skipValue(dec *json.Decoder) error {
+	nested := 0
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			nested++
+		case json.Delim('}'), json.Delim(']'):
+			nested--
+		}
+		if nested == 0 {
+			return nil
+		}
+	}
+}
+
+func bytesOf(values []json.RawMessage) [][]byte {
+	out := make([][]byte, len(values))
+	for i, value := range values {
+		out[i] = value
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithField(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"count":3,"items":[{"id":1,"name":"bulbasaur","tags":["seed"]},{"name":"ivysaur","id":2},` +
+			`{"id":3,"name":"venusaur","stats":{"hp":80}}],"next":{"cursor":"abc"}}`))
+	}))
+	defer server.Close()
+
+	t.Run("streamed", func(t *testing.T) {
+		var ids []int
+		var count int
+		var cursor, second string
+		var missing = "untouched"
+		err := (&Client{}).Get(context.Background(), server.URL, nil,
+			WithField("items.#.id", &ids), WithField("count", &count), WithField("next.cursor", &cursor),
+			WithField("items.1.name", &second), WithField("items.#.stats.mp", &missing))
+		if err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if len(ids) != 3 || ids[0] != 1 || ids[1] != 2 || ids[2] != 3 {
+			t.Errorf("expected ids [1 2 3], got %v", ids)
+		}
+		if count != 3 || cursor != "abc" || second != "ivysaur" {
+			t.Errorf("expected count 3, cursor 'abc' and 'ivysaur', got %d, '%s' and '%s'", count, cursor, second)
+		}
+		if missing != "untouched" {
+			t.Errorf("expected unmatched target untouched, got '%s'", missing)
+		}
+	})
+
+	t.Run("with result", func(t *testing.T) {
+		var names []string
+		var page struct {
+			Count int `json:"count"`
+		}
+		err := (&Client{}).Get(context.Background(), server.URL, &page, WithField("items.#.name", &names))
+		if err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if page.Count != 3 || len(names) != 3 || names[2] != "venusaur" {
+			t.Errorf("expected count 3 and 3 names, got %d and %v", page.Count, names)
+		}
+	})
+
+	t.Run("overlapping paths", func(t *testing.T) {
+		var items []map[string]interface{}
+		var ids []int
+		var hp int
+		err := (&Client{}).Get(context.Background(), server.URL, nil,
+			WithField("items", &items), WithField("items.#.id", &ids), WithField("items.2.stats.hp", &hp))
+		if err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if len(items) != 3 || len(ids) != 3 || ids[2] != 3 || hp != 80 {
+			t.Errorf("expected 3 items, ids [1 2 3] and hp 80, got %d items, %v and %d", len(items), ids, hp)
+		}
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		var ids []string
+		if err := (&Client{}).Get(context.Background(), server.URL, nil, WithField("items.#.id", &ids)); err == nil {
+			t.Errorf("expected decode error, got nil")
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// Future is the pending result of an asynchronous request, decoded into a T:
+//
+//	pikachu := httpclient.GetAsync[Pokemon](ctx, client, "https://pokeapi.co/api/v2/pokemon/pikachu")
+//	eevee := httpclient.GetAsync[Pokemon](ctx, client, "https://pokeapi.co/api/v2/pokemon/eevee")
+//	p1, err1 := pikachu.Await(ctx)
+//	p2, err2 := eevee.Await(ctx)
+type Future[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// DoAsync sends a request in a new goroutine and returns a Future resolving with the decoded response,
+// a nil client uses the default client
+func DoAsync[T any](ctx context.Context, c *Client, method, url string, body interface{}, opts ...Option) *Future[T] {
+	if c == nil {
+		c = Default()
+	}
+	f := &Future[T]{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		f.err = c.Do(ctx, method, url, body, &f.value, opts...)
+	}()
+	return f
+}
+
+// GetAsync sends a GET request in the background, see DoAsync
+func GetAsync[T any](ctx context.Context, c *Client, url string, opts ...Option) *Future[T] {
+	return DoAsync[T](ctx, c, http.MethodGet, url, nil, opts...)
+}
+
+// PostAsync sends a POST request in the background, see DoAsync
+func PostAsync[T any](ctx context.Context, c *Client, url string, body interface{}, opts ...Option) *Future[T] {
+	return DoAsync[T](ctx, c, http.MethodPost, url, body, opts...)
+}
+
+// Done is closed once the request completed
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Await waits for the request to complete and returns its decoded value and error,
+// or the zero value and the context error if ctx is done first. The request keeps running
+// after ctx is done unless the context it was started with is canceled too.
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// AwaitAll waits for every future and returns the first error in argument order
+func AwaitAll[T any](ctx context.Context, futures ...*Future[T]) ([]T, error) {
+	values := make([]T, len(futures))
+	var first error
+	for i, f := range futures {
+		value, err := f.Await(ctx)
+		values[i] = value
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return values, first
+}
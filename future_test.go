@@ -0,0 +1,64 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFuture(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"` + strings.TrimPrefix(r.URL.Path, "/") + `"}`))
+	}))
+	defer server.Close()
+
+	type pokemon struct {
+		Name string `json:"name"`
+	}
+	ctx := context.Background()
+
+	t.Run("fan out", func(t *testing.T) {
+		values, err := AwaitAll(ctx,
+			GetAsync[pokemon](ctx, nil, server.URL+"/pikachu"),
+			PostAsync[pokemon](ctx, &Client{}, server.URL+"/eevee", map[string]int{"level": 5}),
+		)
+		if err != nil {
+			t.Fatalf("AwaitAll failed: %v", err)
+		}
+		if values[0].Name != "pikachu" || values[1].Name != "eevee" {
+			t.Errorf("expected pikachu and eevee, got %+v", values)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		f := GetAsync[pokemon](ctx, nil, server.URL+"/missing")
+		<-f.Done()
+		var httpErr *HTTPError
+		if _, err := f.Await(ctx); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+			t.Errorf("expected a 404 HTTPError, got %v", err)
+		}
+	})
+
+	t.Run("await canceled", func(t *testing.T) {
+		f := GetAsync[pokemon](ctx, nil, server.URL+"/slow")
+		waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		if _, err := f.Await(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+		if value, err := f.Await(ctx); err != nil || value.Name != "slow" {
+			t.Errorf("expected the request to complete, got %+v and %v", value, err)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// GraphQLLocation points at the position in the query an error refers to
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphQLError is a single entry of the errors array of a GraphQL response
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Locations  []GraphQLLocation      `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e GraphQLError) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	path := make([]string, len(e.Path))
+	for i, p := range e.Path {
+		path[i] = fmt.Sprint(p)
+	}
+	return fmt.Sprintf("%s (path: %s)", e.Message, strings.Join(path, "."))
+}
+
+// GraphQLErrors is returned when a GraphQL response contains errors, use errors.As to inspect them
+type GraphQLErrors []GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return "GraphQL error: " + strings.Join(messages, "; ")
+}
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors GraphQLErrors   `json:"errors"`
+}
+
+// GraphQL sends query with variables as a standard GraphQL POST request and unmarshals the
+// data field of the response into result.
+//
+// Errors reported by the server are returned as GraphQLErrors. Partial data returned alongside
+// errors is still unmarshalled into result.
+func (c *Client) GraphQL(ctx context.Context, url, query string, variables map[string]interface{}, result interface{}, opts ...Option) error {
+	var response graphQLResponse
+	// The data and errors of the response are its own envelope
+	opts = append(opts[:len(opts):len(opts)], WithoutEnvelope())
+	if err := c.Post(ctx, url, graphQLRequest{Query: query, Variables: variables}, &response, opts...); err != nil {
+		return err
+	}
+
+	if result != nil && len(response.Data) > 0 && string(response.Data) != "null" {
+		if err := c.unmarshal(response.Data, result); err != nil {
+			return fmt.Errorf("failed to unmarshal GraphQL data: %w", err)
+		}
+	}
+
+	if len(response.Errors) > 0 {
+		return response.Errors
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_GraphQL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if req.Variables["name"] == "missingno" {
+			_, _ = w.Write([]byte(`{"data":{"pokemon":null},"errors":[{"message":"pokemon not found","path":["pokemon"]}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"pokemon":{"id":25,"name":"` + req.Variables["name"].(string) + `"}}}`))
+	}))
+	defer server.Close()
+
+	client := &Client{}
+	query := `query($name: String!) { pokemon(name: $name) { id name } }`
+
+	t.Run("decodes data", func(t *testing.T) {
+		var result struct {
+			Pokemon Pokemon `json:"pokemon"`
+		}
+		err := client.GraphQL(context.Background(), server.URL, query, map[string]interface{}{"name": "pikachu"}, &result)
+		if err != nil {
+			t.Fatalf("GraphQL request failed: %v", err)
+		}
+
+		if result.Pokemon.Name != "pikachu" {
+			t.Errorf("expected name 'pikachu', got '%s'", result.Pokemon.Name)
+		}
+	})
+
+	t.Run("surfaces GraphQL errors", func(t *testing.T) {
+		var result map[string]interface{}
+		err := client.GraphQL(context.Background(), server.URL, query, map[string]interface{}{"name": "missingno"}, &result)
+
+		var gqlErrs GraphQLErrors
+		if !errors.As(err, &gqlErrs) {
+			t.Fatalf("expected GraphQLErrors, got %v", err)
+		}
+		if gqlErrs[0].Message != "pokemon not found" {
+			t.Errorf("expected message 'pokemon not found', got '%s'", gqlErrs[0].Message)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrHostNotAllowed is returned for requests to hosts rejected by Client.AllowedHosts or Client.DeniedHosts
+	ErrHostNotAllowed = errors.New("host not allowed")
+	// ErrInsecureScheme is returned for plain HTTP requests when Client.RequireHTTPS is set
+	ErrInsecureScheme = errors.New("insecure scheme")
+)
+
+// hostAllowed reports whether host passes the allow and deny lists, denied hosts taking precedence
+func (c *Client) hostAllowed(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range c.DeniedHosts {
+		if matchHost(pattern, host) {
+			return false
+		}
+	}
+	if len(c.AllowedHosts) == 0 {
+		return true
+	}
+	for _, pattern := range c.AllowedHosts {
+		if matchHost(pattern, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchHost matches host against pattern, where "*.example.com" matches any subdomain of example.com
+// but not example.com itself and "*" matches every host
+func matchHost(pattern, host string) bool {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasPrefix(pattern, "*."):
+		return strings.HasSuffix(host, pattern[1:])
+	}
+	return host == pattern
+}
+
+// needsGuard reports whether requests must go through guard
+func (c *Client) needsGuard() bool {
+	return c.RequireHTTPS || len(c.AllowedHosts) > 0 || len(c.DeniedHosts) > 0
+}
+
+// guard rejects requests with an insecure scheme or to disallowed hosts before they reach transport,
+// which covers redirects and any middleware rewriting the request URL
+func (c *Client) guard(transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if c.RequireHTTPS && !strings.EqualFold(req.URL.Scheme, "https") {
+			closeBody(req)
+			return nil, fmt.Errorf("%w: %s", ErrInsecureScheme, req.URL.Scheme)
+		}
+		if !c.hostAllowed(req.URL.Hostname()) {
+			closeBody(req)
+			return nil, fmt.Errorf("%w: %s", ErrHostNotAllowed, req.URL.Hostname())
+		}
+		return transport.RoundTrip(req)
+	})
+}
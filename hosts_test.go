@@ -0,0 +1,66 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_AllowedHosts(t *testing.T) {
+	t.Run("matching", func(t *testing.T) {
+		c := &Client{
+			AllowedHosts: []string{"api.example.com", "*.pokeapi.co"},
+			DeniedHosts:  []string{"admin.pokeapi.co"},
+		}
+		tests := map[string]bool{
+			"api.example.com":    true,
+			"API.Example.com.":   true,
+			"evil.example.com":   false,
+			"v2.pokeapi.co":      true,
+			"a.b.pokeapi.co":     true,
+			"pokeapi.co":         false,
+			"admin.pokeapi.co":   false,
+			"pokeapi.co.evil.io": false,
+		}
+		for host, allowed := range tests {
+			if got := c.hostAllowed(host); got != allowed {
+				t.Errorf("expected %s allowed=%v, got %v", host, allowed, got)
+			}
+		}
+	})
+
+	t.Run("rejects requests", func(t *testing.T) {
+		c := &Client{AllowedHosts: []string{"api.example.com"}}
+		err := c.Get(context.Background(), "http://127.0.0.1:1/", nil)
+		if !errors.Is(err, ErrHostNotAllowed) {
+			t.Errorf("expected ErrHostNotAllowed, got %v", err)
+		}
+	})
+
+	t.Run("rejects redirects", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "http://localhost:1/internal", http.StatusFound)
+		}))
+		defer server.Close()
+
+		c := &Client{AllowedHosts: []string{"127.0.0.1"}}
+		err := c.Get(context.Background(), server.URL, nil)
+		if !errors.Is(err, ErrHostNotAllowed) {
+			t.Errorf("expected ErrHostNotAllowed, got %v", err)
+		}
+	})
+
+	t.Run("denied hosts only", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer server.Close()
+
+		c := &Client{DeniedHosts: []string{"localhost"}}
+		if err := c.Get(context.Background(), server.URL, nil); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,312 @@
+package httpclienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+// Mode controls whether a Cassette records real traffic or replays recorded interactions
+type Mode int
+
+const (
+	// ModeAuto replays when the cassette file exists and records otherwise,
+	// setting HTTPCLIENTTEST_RECORD=1 forces recording
+	ModeAuto Mode = iota
+	// ModeRecord always sends real requests and overwrites the cassette on Save
+	ModeRecord
+	// ModeReplay only answers from the cassette and fails for unknown requests
+	ModeReplay
+)
+
+// RedactedValue replaces the values of redacted headers in cassettes
+const RedactedValue = "REDACTED"
+
+// DefaultRedactedHeaders are redacted when Cassette.RedactHeaders is not set
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// RecordedRequest is the request half of an Interaction
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+	// Incomplete tells why the body is unknown, e.g. for failure dumps with truncated bodies
+	Incomplete string `json:"incomplete,omitempty"`
+}
+
+// RecordedResponse is the response half of an Interaction
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Interaction is a recorded request and response pair
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// Matcher reports whether a recorded request answers req, body is the already read request body
+type Matcher func(req *http.Request, body []byte, recorded *RecordedRequest) bool
+
+// MatchMethodAndURL matches requests with the same method and full URL
+func MatchMethodAndURL(req *http.Request, _ []byte, recorded *RecordedRequest) bool {
+	return req.Method == recorded.Method && req.URL.String() == recorded.URL
+}
+
+// MatchBody matches requests with identical bodies, comparing JSON bodies semantically
+func MatchBody(_ *http.Request, body []byte, recorded *RecordedRequest) bool {
+	if string(body) == recorded.Body {
+		return true
+	}
+	var a, b interface{}
+	if json.Unmarshal(body, &a) != nil || json.Unmarshal([]byte(recorded.Body), &b) != nil {
+		return false
+	}
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return bytes.Equal(ja, jb)
+}
+
+// MatchHeaders returns a Matcher comparing the given request headers
+func MatchHeaders(names ...string) Matcher {
+	return func(req *http.Request, _ []byte, recorded *RecordedRequest) bool {
+		for _, name := range names {
+			if req.Header.Get(name) != recorded.Header.Get(name) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// MatchAll combines matchers, all of which must match
+func MatchAll(matchers ...Matcher) Matcher {
+	return func(req *http.Request, body []byte, recorded *RecordedRequest) bool {
+		for _, m := range matchers {
+			if !m(req, body, recorded) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Cassette is an http.RoundTripper recording real interactions to a file and replaying them
+// deterministically afterwards, so tests can run offline.
+type Cassette struct {
+	// Transport sends real requests while recording, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+	// Matcher selects the recorded interaction answering a request,
+	// defaults to matching method, URL and body
+	Matcher Matcher
+	// RedactHeaders lists request and response headers whose values are redacted when saving,
+	// defaults to DefaultRedactedHeaders
+	RedactHeaders []string
+
+	path      string
+	recording bool
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         []bool
+}
+
+// NewCassette opens the cassette stored at path, loading its interactions unless recording
+func NewCassette(path string, mode Mode) (*Cassette, error) {
+	c := &Cassette{path: path}
+
+	switch mode {
+	case ModeRecord:
+		c.recording = true
+	case ModeAuto:
+		_, err := os.Stat(path)
+		c.recording = os.IsNotExist(err) || os.Getenv("HTTPCLIENTTEST_RECORD") == "1"
+	}
+	if c.recording {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var file cassetteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette: %w", err)
+	}
+	c.interactions = file.Interactions
+	c.used = make([]bool, len(file.Interactions))
+	return c, nil
+}
+
+// UseCassette opens the cassette at path in ModeAuto for the duration of the test, saving it on cleanup,
+// and returns a client using it as transport
+func UseCassette(t testing.TB, path string) (*httpclient.Client, *Cassette) {
+	t.Helper()
+	c, err := NewCassette(path, ModeAuto)
+	if err != nil {
+		t.Fatalf("httpclienttest: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := c.Save(); err != nil {
+			t.Errorf("httpclienttest: %v", err)
+		}
+	})
+	return c.Client(), c
+}
+
+type cassetteFile struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Recording reports whether the cassette sends real requests
+func (c *Cassette) Recording() bool {
+	return c.recording
+}
+
+// RoundTrip records or replays req depending on the cassette mode
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("httpclienttest: failed to read request body: %w", err)
+		}
+	}
+
+	if c.recording {
+		return c.record(req, body)
+	}
+	return c.replay(req, body)
+}
+
+func (c *Cassette) record(req *http.Request, body []byte) (*http.Response, error) {
+	transport := c.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = http.NoBody
+	if len(body) > 0 {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	out.ContentLength = int64(len(body))
+	resp, err := transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("httpclienttest: failed to read response body: %w", err)
+	}
+
+	c.mu.Lock()
+	c.interactions = append(c.interactions, &Interaction{
+		Request:  RecordedRequest{Method: req.Method, URL: req.URL.String(), Header: c.redact(req.Header), Body: string(body)},
+		Response: RecordedResponse{StatusCode: resp.StatusCode, Header: c.redact(resp.Header), Body: string(respBody)},
+	})
+	c.used = append(c.used, true)
+	c.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	resp.ContentLength = int64(len(respBody))
+	return resp, nil
+}
+
+func (c *Cassette) replay(req *http.Request, body []byte) (*http.Response, error) {
+	matcher := c.Matcher
+	if matcher == nil {
+		matcher = MatchAll(MatchMethodAndURL, MatchBody)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Prefer interactions not replayed yet so repeated requests are answered in recording order
+	found := -1
+	for i, in := range c.interactions {
+		if matcher(req, body, &in.Request) {
+			if !c.used[i] {
+				found = i
+				break
+			}
+			if found < 0 {
+				found = i
+			}
+		}
+	}
+	if found < 0 {
+		return nil, fmt.Errorf("httpclienttest: no recorded interaction for %s %s in %s", req.Method, req.URL, c.path)
+	}
+	c.used[found] = true
+
+	recorded := c.interactions[found].Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (c *Cassette) redact(header http.Header) http.Header {
+	redacted := header.Clone()
+	names := c.RedactHeaders
+	if names == nil {
+		names = DefaultRedactedHeaders
+	}
+	for _, name := range names {
+		if values := redacted.Values(name); len(values) > 0 {
+			redacted.Set(name, RedactedValue)
+		}
+	}
+	return redacted
+}
+
+// Save writes recorded interactions to the cassette file, it does nothing when replaying
+func (c *Cassette) Save() error {
+	if !c.recording {
+		return nil
+	}
+
+	c.mu.Lock()
+	data, err := json.MarshalIndent(cassetteFile{Interactions: c.interactions}, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(c.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// Client returns an httpclient.Client using the cassette as transport
+func (c *Cassette) Client() *httpclient.Client {
+	return &httpclient.Client{Client: &http.Client{Transport: c}}
+}
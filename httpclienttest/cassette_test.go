@@ -0,0 +1,71 @@
+package httpclienttest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+func TestCassette(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		_, _ = w.Write([]byte(`{"id":25,"name":"pikachu"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassettes", "pokemon.json")
+	auth := httpclient.WithHeader("Authorization", "Bearer secret")
+
+	t.Run("records on first run", func(t *testing.T) {
+		c, err := NewCassette(path, ModeAuto)
+		if err != nil {
+			t.Fatalf("opening cassette failed: %v", err)
+		}
+		if !c.Recording() {
+			t.Fatal("expected cassette to record")
+		}
+
+		var p pokemon
+		if err := c.Client().Get(context.Background(), server.URL+"/pokemon/25", &p, auth); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if err := c.Save(); err != nil {
+			t.Fatalf("saving cassette failed: %v", err)
+		}
+
+		data, _ := os.ReadFile(path)
+		if strings.Contains(string(data), "secret") {
+			t.Error("expected Authorization header to be redacted")
+		}
+	})
+
+	t.Run("replays without server", func(t *testing.T) {
+		c, err := NewCassette(path, ModeAuto)
+		if err != nil {
+			t.Fatalf("opening cassette failed: %v", err)
+		}
+
+		var p pokemon
+		if err := c.Client().Get(context.Background(), server.URL+"/pokemon/25", &p, auth); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if p.Name != "pikachu" {
+			t.Errorf("expected name 'pikachu', got '%s'", p.Name)
+		}
+		if n := atomic.LoadInt32(&hits); n != 1 {
+			t.Errorf("expected server to be hit once, got %d", n)
+		}
+
+		if err := c.Client().Get(context.Background(), server.URL+"/pokemon/26", &p); err == nil {
+			t.Error("expected error for unrecorded request")
+		}
+	})
+}
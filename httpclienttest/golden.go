@@ -0,0 +1,181 @@
+package httpclienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+// Golden is a middleware snapshotting every outgoing request to a golden file and comparing
+// later runs against it, catching unintended wire-format changes during refactors.
+//
+// Requests are canonicalized before comparison: query parameters and headers are sorted, values of
+// DefaultRedactedHeaders are redacted and JSON bodies are indented with sorted keys. Set
+// HTTPCLIENTTEST_UPDATE_GOLDEN=1 to rewrite the golden files.
+//
+//	golden := httpclienttest.NewGolden(t, "testdata/golden")
+//	client := &httpclient.Client{Client: server.Client(), Middleware: []httpclient.Middleware{golden.Middleware}}
+type Golden struct {
+	// Update rewrites golden files instead of comparing, defaults to HTTPCLIENTTEST_UPDATE_GOLDEN=1
+	Update bool
+	// IgnoreHeaders lists headers left out of snapshots, e.g. ones carrying timestamps or request IDs,
+	// defaults to User-Agent whose version changes between releases
+	IgnoreHeaders []string
+
+	t   testing.TB
+	dir string
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewGolden returns a Golden storing the snapshots of test t under dir
+func NewGolden(t testing.TB, dir string) *Golden {
+	return &Golden{
+		Update: os.Getenv("HTTPCLIENTTEST_UPDATE_GOLDEN") == "1",
+		t:      t,
+		dir:    dir,
+	}
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// Middleware snapshots requests before passing them to next, use it as an httpclient.Middleware
+func (g *Golden) Middleware(next http.RoundTripper) http.RoundTripper {
+	return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		var body []byte
+		if req.Body != nil {
+			var err error
+			body, err = io.ReadAll(req.Body)
+			_ = req.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("httpclienttest: failed to read request body: %w", err)
+			}
+			req = req.Clone(req.Context())
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		g.mu.Lock()
+		g.seq++
+		name := fmt.Sprintf("%s_%03d.golden", unsafeFileChars.ReplaceAllString(g.t.Name(), "_"), g.seq)
+		g.mu.Unlock()
+
+		g.check(filepath.Join(g.dir, name), g.canonicalize(req, body))
+		return next.RoundTrip(req)
+	})
+}
+
+func (g *Golden) check(path string, got []byte) {
+	g.t.Helper()
+
+	if g.Update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			g.t.Errorf("httpclienttest: failed to create golden directory: %v", err)
+			return
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			g.t.Errorf("httpclienttest: failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		g.t.Errorf("httpclienttest: failed to read golden file, run with HTTPCLIENTTEST_UPDATE_GOLDEN=1 to create it: %v", err)
+		return
+	}
+	if !bytes.Equal(want, got) {
+		g.t.Errorf("httpclienttest: request does not match %s:\n%s", path, diffLines(string(want), string(got)))
+	}
+}
+
+func (g *Golden) canonicalize(req *http.Request, body []byte) []byte {
+	var b bytes.Buffer
+
+	u := *req.URL
+	u.RawQuery = u.Query().Encode()
+	fmt.Fprintf(&b, "%s %s\n", req.Method, u.String())
+
+	ignore := g.IgnoreHeaders
+	if ignore == nil {
+		ignore = []string{"User-Agent"}
+	}
+	ignored := make(map[string]bool, len(ignore))
+	for _, name := range ignore {
+		ignored[http.CanonicalHeaderKey(name)] = true
+	}
+	redacted := make(map[string]bool, len(DefaultRedactedHeaders))
+	for _, name := range DefaultRedactedHeaders {
+		redacted[http.CanonicalHeaderKey(name)] = true
+	}
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		if !ignored[http.CanonicalHeaderKey(name)] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			if redacted[http.CanonicalHeaderKey(name)] {
+				value = RedactedValue
+			}
+			fmt.Fprintf(&b, "%s: %s\n", name, value)
+		}
+	}
+
+	if len(body) > 0 {
+		b.WriteByte('\n')
+		var v interface{}
+		if json.Unmarshal(body, &v) == nil {
+			// Re-marshalling sorts object keys
+			indented, _ := json.MarshalIndent(v, "", "  ")
+			body = indented
+		}
+		b.Write(body)
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// diffLines returns the lines of want and got that differ, prefixed with - and +
+func diffLines(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	n := len(wantLines)
+	if len(gotLines) > n {
+		n = len(gotLines)
+	}
+
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w == g {
+			continue
+		}
+		if i < len(wantLines) {
+			fmt.Fprintf(&b, "-%s\n", w)
+		}
+		if i < len(gotLines) {
+			fmt.Fprintf(&b, "+%s\n", g)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,61 @@
+package httpclienttest
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+func TestGolden(t *testing.T) {
+	m := NewMock()
+	m.On("POST", "/orders").Reply(http.StatusCreated, []byte(`{}`)).AnyTimes()
+	dir := t.TempDir()
+
+	send := func(tb testing.TB, update bool, order map[string]interface{}) {
+		golden := NewGolden(tb, dir)
+		golden.Update = update
+		golden.IgnoreHeaders = []string{"User-Agent", "X-Request-Id"}
+		client := &httpclient.Client{Client: m.HTTPClient(), Middleware: []httpclient.Middleware{golden.Middleware}}
+		err := client.Post(context.Background(), "https://shop.example/orders?b=2&a=1", order, nil,
+			httpclient.WithHeader("Authorization", "Bearer secret"),
+			httpclient.WithHeader("X-Request-Id", "random"))
+		if err != nil {
+			t.Fatalf("POST request failed: %v", err)
+		}
+	}
+
+	t.Run("writes canonical snapshots", func(t *testing.T) {
+		send(t, true, map[string]interface{}{"quantity": 2, "pokemon": "pikachu"})
+
+		data, err := os.ReadFile(filepath.Join(dir, "TestGolden_writes_canonical_snapshots_001.golden"))
+		if err != nil {
+			t.Fatalf("reading golden file failed: %v", err)
+		}
+		want := "POST https://shop.example/orders?a=1&b=2\n" +
+			"Authorization: REDACTED\n" +
+			"Content-Type: application/json\n" +
+			"\n{\n  \"pokemon\": \"pikachu\",\n  \"quantity\": 2\n}\n"
+		if string(data) != want {
+			t.Errorf("expected snapshot:\n%s\ngot:\n%s", want, data)
+		}
+	})
+
+	t.Run("detects changes", func(t *testing.T) {
+		rec := &recordingT{TB: t}
+		send(rec, true, map[string]interface{}{"pokemon": "pikachu"})
+		send(rec, false, map[string]interface{}{"pokemon": "pikachu"})
+		if len(rec.errors) != 0 {
+			t.Fatalf("expected no failures, got %v", rec.errors)
+		}
+
+		send(rec, false, map[string]interface{}{"pokemon": "raichu"})
+		if len(rec.errors) != 1 || !strings.Contains(rec.errors[0], `+  "pokemon": "raichu"`) {
+			t.Errorf("expected a diff, got %v", rec.errors)
+		}
+	})
+}
@@ -0,0 +1,186 @@
+// Package httpclienttest provides utilities for testing code built on httpclient without
+// reaching real servers.
+//
+// Mock is a transport with an expectation API:
+//
+//	m := httpclienttest.NewMock()
+//	m.On("GET", "/pokemon/25").ReplyJSON(200, pokemon)
+//	client := m.Client()
+//	// ... exercise code using client
+//	m.AssertExpectations(t)
+package httpclienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+// Mock is an http.RoundTripper answering requests from registered expectations
+type Mock struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	unexpected   []string
+}
+
+// NewMock returns a Mock without expectations
+func NewMock() *Mock {
+	return &Mock{}
+}
+
+// Expectation describes an expected request and the reply sent for it
+type Expectation struct {
+	method  string
+	pattern string
+	status  int
+	header  http.Header
+	body    []byte
+	err     error
+	times   int
+	calls   int
+}
+
+// On registers an expectation for method and pattern and returns it for configuring the reply.
+//
+// A pattern starting with "/" matches the request path, or the path and query when it contains
+// a "?"; any other pattern is compared with the full request URL. Each expectation is expected
+// exactly once unless changed with Times or AnyTimes, and replies 200 with an empty body by default.
+func (m *Mock) On(method, pattern string) *Expectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := &Expectation{method: strings.ToUpper(method), pattern: pattern, status: http.StatusOK, header: http.Header{}, times: 1}
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// Reply sets the status code and raw body sent for the expectation
+func (e *Expectation) Reply(status int, body []byte) *Expectation {
+	e.status = status
+	e.body = body
+	return e
+}
+
+// ReplyJSON sets the status code and a JSON body marshalled from v
+func (e *Expectation) ReplyJSON(status int, v interface{}) *Expectation {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("httpclienttest: failed to marshal reply: %v", err))
+	}
+	e.header.Set("Content-Type", "application/json")
+	return e.Reply(status, body)
+}
+
+// ReplyError makes the transport fail with err, simulating a network error
+func (e *Expectation) ReplyError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// ReplyHeader sets a response header
+func (e *Expectation) ReplyHeader(key, value string) *Expectation {
+	e.header.Set(key, value)
+	return e
+}
+
+// Times sets how many calls are expected
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// AnyTimes allows any number of calls, including none
+func (e *Expectation) AnyTimes() *Expectation {
+	e.times = -1
+	return e
+}
+
+func (e *Expectation) matches(req *http.Request) bool {
+	if e.method != req.Method {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(e.pattern, "/") && strings.Contains(e.pattern, "?"):
+		return e.pattern == req.URL.RequestURI()
+	case strings.HasPrefix(e.pattern, "/"):
+		return e.pattern == req.URL.Path
+	}
+	return e.pattern == req.URL.String()
+}
+
+func (e *Expectation) String() string {
+	return e.method + " " + e.pattern
+}
+
+// RoundTrip answers req with the first matching expectation that still accepts calls
+func (m *Mock) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		_ = req.Body.Close()
+	}
+
+	m.mu.Lock()
+	var match *Expectation
+	for _, e := range m.expectations {
+		if e.matches(req) && (e.times < 0 || e.calls < e.times) {
+			match = e
+			match.calls++
+			break
+		}
+	}
+	if match == nil {
+		call := req.Method + " " + req.URL.String()
+		m.unexpected = append(m.unexpected, call)
+		m.mu.Unlock()
+		return nil, fmt.Errorf("httpclienttest: unexpected request %s", call)
+	}
+	m.mu.Unlock()
+
+	if match.err != nil {
+		return nil, match.err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", match.status, http.StatusText(match.status)),
+		StatusCode:    match.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        match.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(match.body)),
+		ContentLength: int64(len(match.body)),
+		Request:       req,
+	}, nil
+}
+
+// HTTPClient returns an http.Client using the mock as transport
+func (m *Mock) HTTPClient() *http.Client {
+	return &http.Client{Transport: m}
+}
+
+// Client returns an httpclient.Client using the mock as transport
+func (m *Mock) Client() *httpclient.Client {
+	return &httpclient.Client{Client: m.HTTPClient()}
+}
+
+// AssertExpectations reports expectations that were not met and requests that matched no expectation
+func (m *Mock) AssertExpectations(t testing.TB) {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range m.expectations {
+		if e.times >= 0 && e.calls != e.times {
+			t.Errorf("httpclienttest: expected %s to be called %d times, got %d", e, e.times, e.calls)
+		}
+	}
+	for _, call := range m.unexpected {
+		t.Errorf("httpclienttest: unexpected request %s", call)
+	}
+}
@@ -0,0 +1,85 @@
+package httpclienttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+// recordingT captures failures reported through testing.TB
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+type pokemon struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestMock(t *testing.T) {
+	t.Run("replies to expected requests", func(t *testing.T) {
+		m := NewMock()
+		m.On("GET", "/pokemon/25").ReplyJSON(http.StatusOK, pokemon{ID: 25, Name: "pikachu"})
+		m.On("POST", "/pokemon").Reply(http.StatusCreated, []byte(`{"id":152}`)).Times(2)
+
+		client := m.Client()
+		var p pokemon
+		if err := client.Get(context.Background(), "https://pokeapi.co/pokemon/25", &p); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if p.Name != "pikachu" {
+			t.Errorf("expected name 'pikachu', got '%s'", p.Name)
+		}
+
+		for i := 0; i < 2; i++ {
+			var status int
+			if err := client.Post(context.Background(), "https://pokeapi.co/pokemon", pokemon{Name: "chikorita"}, &p, httpclient.WithStatus(&status)); err != nil {
+				t.Fatalf("POST request failed: %v", err)
+			}
+			if status != http.StatusCreated {
+				t.Errorf("expected status 201, got %d", status)
+			}
+		}
+
+		m.AssertExpectations(t)
+	})
+
+	t.Run("reports unmet and unexpected requests", func(t *testing.T) {
+		m := NewMock()
+		m.On("GET", "/pokemon/1")
+		m.On("GET", "/pokemon?limit=5").AnyTimes()
+
+		err := m.Client().Get(context.Background(), "https://pokeapi.co/pokemon/2", nil)
+		if err == nil {
+			t.Fatal("expected error for unexpected request")
+		}
+
+		rec := &recordingT{}
+		m.AssertExpectations(rec)
+		if len(rec.errors) != 2 {
+			t.Errorf("expected 2 failures, got %v", rec.errors)
+		}
+	})
+
+	t.Run("simulates transport errors", func(t *testing.T) {
+		m := NewMock()
+		boom := errors.New("connection reset")
+		m.On("GET", "https://pokeapi.co/pokemon/25").ReplyError(boom)
+
+		err := m.Client().Get(context.Background(), "https://pokeapi.co/pokemon/25", nil)
+		if !errors.Is(err, boom) {
+			t.Errorf("expected transport error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,231 @@
+package httpclienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+// PactSpecificationVersion is the version of the Pact specification written by Pact
+const PactSpecificationVersion = "2.0.0"
+
+// Pact is a middleware capturing request and response pairs as consumer contracts in the Pact format,
+// so contracts published to a broker derive from real client usage in tests:
+//
+//	pact := httpclienttest.NewPact("pokedex", "pokeapi")
+//	client := &httpclient.Client{Client: server.Client(), Middleware: []httpclient.Middleware{pact.Middleware}}
+//	pact.Describe("a request for pikachu", "pikachu exists")
+//	// ... exercise code using client
+//	err := pact.WriteFile("pacts")
+//
+// Only the headers listed in RequestHeaders and ResponseHeaders are part of the contract, so volatile
+// headers such as dates and user agents do not make provider verification fail.
+type Pact struct {
+	Consumer string
+	Provider string
+	// RequestHeaders lists the request headers recorded, defaults to Content-Type
+	RequestHeaders []string
+	// ResponseHeaders lists the response headers recorded, defaults to Content-Type
+	ResponseHeaders []string
+
+	mu           sync.Mutex
+	interactions []PactInteraction
+	description  string
+	state        string
+}
+
+// PactInteraction is a recorded request and response pair with its description
+type PactInteraction struct {
+	Description   string       `json:"description"`
+	ProviderState string       `json:"providerState,omitempty"`
+	Request       PactRequest  `json:"request"`
+	Response      PactResponse `json:"response"`
+}
+
+// PactRequest is the request half of a PactInteraction
+type PactRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Query   string            `json:"query,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+// PactResponse is the response half of a PactInteraction
+type PactResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+type pactFile struct {
+	Consumer     pactParticipant   `json:"consumer"`
+	Provider     pactParticipant   `json:"provider"`
+	Interactions []PactInteraction `json:"interactions"`
+	Metadata     pactMetadata      `json:"metadata"`
+}
+
+type pactParticipant struct {
+	Name string `json:"name"`
+}
+
+type pactMetadata struct {
+	PactSpecification struct {
+		Version string `json:"version"`
+	} `json:"pactSpecification"`
+}
+
+// NewPact returns a Pact recording the contract between consumer and provider
+func NewPact(consumer, provider string) *Pact {
+	return &Pact{Consumer: consumer, Provider: provider}
+}
+
+// Describe sets the description and provider state of the next recorded interaction. Without it,
+// interactions are described by their method and path.
+func (p *Pact) Describe(description, providerState string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.description, p.state = description, providerState
+}
+
+// Middleware records requests passed to next and their responses, use it as an httpclient.Middleware
+func (p *Pact) Middleware(next http.RoundTripper) http.RoundTripper {
+	return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		reqBody, err := readAndReplace(&req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("httpclienttest: failed to read request body: %w", err)
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		respBody, err := readAndReplace(&resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("httpclienttest: failed to read response body: %w", err)
+		}
+
+		interaction := PactInteraction{
+			Request: PactRequest{
+				Method:  req.Method,
+				Path:    req.URL.EscapedPath(),
+				Query:   req.URL.RawQuery,
+				Headers: pactHeaders(req.Header, p.RequestHeaders),
+				Body:    pactBody(reqBody),
+			},
+			Response: PactResponse{
+				Status:  resp.StatusCode,
+				Headers: pactHeaders(resp.Header, p.ResponseHeaders),
+				Body:    pactBody(respBody),
+			},
+		}
+		p.record(interaction)
+		return resp, nil
+	})
+}
+
+func (p *Pact) record(interaction PactInteraction) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	description := p.description
+	if description == "" {
+		description = interaction.Request.Method + " " + interaction.Request.Path
+	}
+	// Descriptions identify interactions and must be unique
+	unique := description
+	for n := 2; p.hasDescription(unique); n++ {
+		unique = fmt.Sprintf("%s (%d)", description, n)
+	}
+	interaction.Description = unique
+	interaction.ProviderState = p.state
+	p.description, p.state = "", ""
+	p.interactions = append(p.interactions, interaction)
+}
+
+func (p *Pact) hasDescription(description string) bool {
+	for _, in := range p.interactions {
+		if in.Description == description {
+			return true
+		}
+	}
+	return false
+}
+
+// Interactions returns the recorded interactions
+func (p *Pact) Interactions() []PactInteraction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PactInteraction(nil), p.interactions...)
+}
+
+// WriteFile writes the contract to "<consumer>-<provider>.json" in dir
+func (p *Pact) WriteFile(dir string) error {
+	file := pactFile{
+		Consumer:     pactParticipant{Name: p.Consumer},
+		Provider:     pactParticipant{Name: p.Provider},
+		Interactions: p.Interactions(),
+	}
+	file.Metadata.PactSpecification.Version = PactSpecificationVersion
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal pact: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create pact directory: %w", err)
+	}
+	name := unsafeFileChars.ReplaceAllString(p.Consumer+"-"+p.Provider, "_") + ".json"
+	if err := os.WriteFile(filepath.Join(dir, name), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write pact: %w", err)
+	}
+	return nil
+}
+
+// readAndReplace reads *body and replaces it with a reader over the same bytes
+func readAndReplace(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	_ = (*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+func pactHeaders(header http.Header, names []string) map[string]string {
+	if names == nil {
+		names = []string{"Content-Type"}
+	}
+	headers := map[string]string{}
+	for _, name := range names {
+		if values := header.Values(name); len(values) > 0 {
+			headers[http.CanonicalHeaderKey(name)] = strings.Join(values, ", ")
+		}
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+// pactBody keeps JSON bodies structured and other bodies as strings
+func pactBody(body []byte) interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+	var v interface{}
+	if json.Unmarshal(body, &v) == nil {
+		return v
+	}
+	return string(body)
+}
@@ -0,0 +1,83 @@
+package httpclienttest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+func TestPact(t *testing.T) {
+	stubs := NewStubs().
+		Stub("GET", "/pokemon/*", http.StatusOK, map[string]string{"name": "pikachu"}).
+		Stub("POST", "/teams", http.StatusCreated, map[string]int{"id": 7})
+	pact := NewPact("pokedex", "pokeapi")
+	client := &httpclient.Client{Client: stubs.HTTPClient(), Middleware: []httpclient.Middleware{pact.Middleware}}
+	ctx := context.Background()
+
+	pact.Describe("a request for pikachu", "pikachu exists")
+	var pokemon map[string]string
+	if err := client.Get(ctx, "https://pokeapi.co/pokemon/pikachu?lang=en", &pokemon); err != nil {
+		t.Fatalf("GET request failed: %v", err)
+	}
+	if pokemon["name"] != "pikachu" {
+		t.Errorf("expected the response to reach the caller, got %v", pokemon)
+	}
+	for i := 0; i < 2; i++ {
+		if err := client.Post(ctx, "https://pokeapi.co/teams", map[string]string{"name": "red"}, nil); err != nil {
+			t.Fatalf("POST request failed: %v", err)
+		}
+	}
+
+	dir := t.TempDir()
+	if err := pact.WriteFile(dir); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "pokedex-pokeapi.json"))
+	if err != nil {
+		t.Fatalf("failed to read pact: %v", err)
+	}
+	var file struct {
+		Consumer     struct{ Name string } `json:"consumer"`
+		Provider     struct{ Name string } `json:"provider"`
+		Interactions []PactInteraction     `json:"interactions"`
+		Metadata     struct {
+			PactSpecification struct{ Version string } `json:"pactSpecification"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("failed to parse pact: %v", err)
+	}
+
+	if file.Consumer.Name != "pokedex" || file.Provider.Name != "pokeapi" || file.Metadata.PactSpecification.Version != "2.0.0" {
+		t.Errorf("expected participants and metadata, got %s", data)
+	}
+	if len(file.Interactions) != 3 {
+		t.Fatalf("expected 3 interactions, got %d", len(file.Interactions))
+	}
+	get := file.Interactions[0]
+	if get.Description != "a request for pikachu" || get.ProviderState != "pikachu exists" {
+		t.Errorf("expected description and state, got %q and %q", get.Description, get.ProviderState)
+	}
+	if get.Request.Path != "/pokemon/pikachu" || get.Request.Query != "lang=en" || get.Request.Headers != nil {
+		t.Errorf("expected request without headers, got %+v", get.Request)
+	}
+	if get.Response.Status != http.StatusOK || get.Response.Body.(map[string]interface{})["name"] != "pikachu" {
+		t.Errorf("expected response body, got %+v", get.Response)
+	}
+
+	post, again := file.Interactions[1], file.Interactions[2]
+	if post.Description != "POST /teams" || again.Description != "POST /teams (2)" || post.ProviderState != "" {
+		t.Errorf("expected unique default descriptions, got %q and %q", post.Description, again.Description)
+	}
+	if post.Request.Headers["Content-Type"] != "application/json" || post.Request.Body.(map[string]interface{})["name"] != "red" {
+		t.Errorf("expected JSON request, got %+v", post.Request)
+	}
+	if post.Response.Status != http.StatusCreated {
+		t.Errorf("expected status 201, got %d", post.Response.Status)
+	}
+}
@@ -0,0 +1,161 @@
+package httpclienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+// Call is a request captured by a Recorder
+type Call struct {
+	Method string
+	URL    string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// Decode unmarshals the JSON request body into v
+func (c Call) Decode(v interface{}) error {
+	return json.Unmarshal(c.Body, v)
+}
+
+// BodyMatcher checks a recorded request body, returning a description of the mismatch or nil
+type BodyMatcher func(body []byte) error
+
+// JSONBody matches bodies that are JSON equal to v
+func JSONBody(v interface{}) BodyMatcher {
+	return func(body []byte) error {
+		want, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to marshal expected body: %w", err)
+		}
+		var a, b interface{}
+		if err := json.Unmarshal(body, &a); err != nil {
+			return fmt.Errorf("body is not JSON: %s", body)
+		}
+		_ = json.Unmarshal(want, &b)
+		got, _ := json.Marshal(a)
+		normalized, _ := json.Marshal(b)
+		if !bytes.Equal(got, normalized) {
+			return fmt.Errorf("expected body %s, got %s", normalized, got)
+		}
+		return nil
+	}
+}
+
+// BodyContains matches bodies containing s
+func BodyContains(s string) BodyMatcher {
+	return func(body []byte) error {
+		if !strings.Contains(string(body), s) {
+			return fmt.Errorf("expected body to contain %q, got %s", s, body)
+		}
+		return nil
+	}
+}
+
+// Recorder captures every request sent through its middleware:
+//
+//	rec := httpclienttest.NewRecorder()
+//	client := &httpclient.Client{Middleware: []httpclient.Middleware{rec.Middleware}}
+//	// ... exercise code using client
+//	rec.AssertCalled(t, "POST", "/orders", httpclienttest.JSONBody(order))
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+// NewRecorder returns an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Middleware records requests before passing them to next, use it as an httpclient.Middleware
+func (r *Recorder) Middleware(next http.RoundTripper) http.RoundTripper {
+	return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		var body []byte
+		if req.Body != nil {
+			var err error
+			body, err = io.ReadAll(req.Body)
+			_ = req.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("httpclienttest: failed to read request body: %w", err)
+			}
+			req = req.Clone(req.Context())
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		r.mu.Lock()
+		r.calls = append(r.calls, Call{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Path:   req.URL.Path,
+			Header: req.Header.Clone(),
+			Body:   body,
+		})
+		r.mu.Unlock()
+
+		return next.RoundTrip(req)
+	})
+}
+
+// Calls returns the recorded requests in the order they were sent
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call{}, r.calls...)
+}
+
+// Reset forgets all recorded requests
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+// AssertCalled reports an error unless a request with method and path was recorded whose body satisfies all matchers
+func (r *Recorder) AssertCalled(t testing.TB, method, path string, matchers ...BodyMatcher) {
+	t.Helper()
+
+	var mismatches []string
+	for _, call := range r.Calls() {
+		if call.Method != method || call.Path != path {
+			continue
+		}
+		matched := true
+		for _, m := range matchers {
+			if err := m(call.Body); err != nil {
+				mismatches = append(mismatches, err.Error())
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return
+		}
+	}
+
+	if len(mismatches) > 0 {
+		t.Errorf("httpclienttest: %s %s was called but no body matched:\n%s", method, path, strings.Join(mismatches, "\n"))
+		return
+	}
+	t.Errorf("httpclienttest: expected %s %s to be called", method, path)
+}
+
+// AssertNotCalled reports an error if a request with method and path was recorded
+func (r *Recorder) AssertNotCalled(t testing.TB, method, path string) {
+	t.Helper()
+	for _, call := range r.Calls() {
+		if call.Method == method && call.Path == path {
+			t.Errorf("httpclienttest: expected %s %s not to be called", method, path)
+			return
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package httpclienttest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+func TestRecorder(t *testing.T) {
+	m := NewMock()
+	m.On("POST", "/orders").Reply(http.StatusCreated, []byte(`{}`)).AnyTimes()
+
+	rec := NewRecorder()
+	client := &httpclient.Client{Client: m.HTTPClient(), Middleware: []httpclient.Middleware{rec.Middleware}}
+
+	order := map[string]interface{}{"pokemon": "pikachu", "quantity": 2}
+	if err := client.Post(context.Background(), "https://shop.example/orders", order, nil,
+		httpclient.WithHeader("X-Trace", "abc")); err != nil {
+		t.Fatalf("POST request failed: %v", err)
+	}
+
+	t.Run("captures requests", func(t *testing.T) {
+		calls := rec.Calls()
+		if len(calls) != 1 {
+			t.Fatalf("expected 1 call, got %d", len(calls))
+		}
+		if calls[0].Header.Get("X-Trace") != "abc" {
+			t.Errorf("expected X-Trace 'abc', got '%s'", calls[0].Header.Get("X-Trace"))
+		}
+
+		var decoded map[string]interface{}
+		if err := calls[0].Decode(&decoded); err != nil {
+			t.Fatalf("decoding body failed: %v", err)
+		}
+		if decoded["pokemon"] != "pikachu" {
+			t.Errorf("expected pokemon 'pikachu', got '%v'", decoded["pokemon"])
+		}
+	})
+
+	t.Run("assertions", func(t *testing.T) {
+		rec.AssertCalled(t, "POST", "/orders", JSONBody(order), BodyContains("pikachu"))
+		rec.AssertNotCalled(t, "DELETE", "/orders")
+
+		failing := &recordingT{}
+		rec.AssertCalled(failing, "POST", "/orders", JSONBody(map[string]int{"quantity": 3}))
+		rec.AssertCalled(failing, "GET", "/orders")
+		if len(failing.errors) != 2 {
+			t.Errorf("expected 2 failures, got %v", failing.errors)
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package httpclienttest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+// ErrIncompleteRequest is returned when replaying a request whose body was truncated or not captured
+var ErrIncompleteRequest = errors.New("recorded request is incomplete")
+
+// LoadRequests reads the requests stored in a file written by httpclient.FailureDump, a HAR file or a
+// cassette, detecting the format from the content
+func LoadRequests(path string) ([]RecordedRequest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read requests: %w", err)
+	}
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return parseJSONRequests(trimmed)
+	}
+	req, err := parseDump(data)
+	if err != nil {
+		return nil, err
+	}
+	return []RecordedRequest{*req}, nil
+}
+
+// harFile is the subset of HAR 1.2 describing requests
+type harFile struct {
+	Log *struct {
+		Entries []struct {
+			Request struct {
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+				PostData *struct {
+					Text string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// parseJSONRequests reads the requests of a HAR file or a cassette
+func parseJSONRequests(data []byte) ([]RecordedRequest, error) {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("failed to parse requests: %w", err)
+	}
+	if har.Log == nil {
+		var cassette cassetteFile
+		if err := json.Unmarshal(data, &cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette: %w", err)
+		}
+		requests := make([]RecordedRequest, len(cassette.Interactions))
+		for i, in := range cassette.Interactions {
+			requests[i] = in.Request
+		}
+		return requests, nil
+	}
+
+	requests := make([]RecordedRequest, len(har.Log.Entries))
+	for i, entry := range har.Log.Entries {
+		req := RecordedRequest{Method: entry.Request.Method, URL: entry.Request.URL, Header: http.Header{}}
+		for _, h := range entry.Request.Headers {
+			// HTTP/2 pseudo-headers such as :authority are not headers of the replayed request
+			if !strings.HasPrefix(h.Name, ":") {
+				req.Header.Add(h.Name, h.Value)
+			}
+		}
+		if entry.Request.PostData != nil {
+			req.Body = entry.Request.PostData.Text
+		}
+		requests[i] = req
+	}
+	return requests, nil
+}
+
+// responseLine matches the status line starting the response half of a failure dump
+var responseLine = regexp.MustCompile(`\nHTTP/[0-9.]+ [0-9]{3}[^\n]*\r\n`)
+
+// parseDump reads the request of a failure dump, whose first comment holds the method and full URL
+func parseDump(data []byte) (*RecordedRequest, error) {
+	comment, rest, ok := strings.Cut(string(data), "\n")
+	fields := strings.Fields(strings.TrimPrefix(comment, "#"))
+	if !ok || !strings.HasPrefix(comment, "# ") || len(fields) < 2 {
+		return nil, errors.New("failed to parse failure dump: missing request comment")
+	}
+	req := &RecordedRequest{Method: fields[0], URL: fields[1], Header: http.Header{}}
+
+	// Skip the remaining comments and the request line, then read headers up to the blank line
+	r := bufio.NewReader(strings.NewReader(rest))
+	var line string
+	for {
+		var err error
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, errors.New("failed to parse failure dump: missing request line")
+		}
+		if !strings.HasPrefix(line, "#") && strings.TrimSpace(line) != "" {
+			break
+		}
+	}
+	for {
+		line, err := r.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("failed to parse failure dump: invalid header %q", line)
+		}
+		if !strings.EqualFold(name, "Host") {
+			req.Header.Add(name, strings.TrimSpace(value))
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	body, _ := io.ReadAll(r)
+	if loc := responseLine.FindIndex(body); loc != nil {
+		body = body[:loc[0]]
+	}
+	switch {
+	case bytes.HasPrefix(body, []byte("# streamed body not captured")):
+		req.Incomplete = "streamed body not captured"
+	case bytes.Contains(body, []byte("\n# body truncated to ")):
+		req.Incomplete = "body truncated"
+	default:
+		req.Body = string(body)
+	}
+	return req, nil
+}
+
+// Replayer re-sends loaded requests, e.g. a failing production request against staging:
+//
+//	requests, err := httpclienttest.LoadRequests("/var/tmp/failures/20261016T150405.123Z-0001-POST-api.example.com.http")
+//	replayer := &httpclienttest.Replayer{Target: "https://staging.example.com"}
+//	resp, err := replayer.Replay(ctx, requests[0], httpclient.WithHeader("Authorization", "Bearer "+token))
+//
+// Redacted headers are left out, options such as WithHeader supply fresh credentials.
+type Replayer struct {
+	// Client sends the requests, defaults to httpclient.Default()
+	Client *httpclient.Client
+	// Target replaces the scheme and host of request URLs when set, e.g. "https://staging.example.com"
+	Target string
+}
+
+// Replay sends req and returns its response whatever the status, failing with ErrIncompleteRequest when the
+// request body was not fully recorded
+func (r *Replayer) Replay(ctx context.Context, req RecordedRequest, opts ...httpclient.Option) (*RecordedResponse, error) {
+	if req.Incomplete != "" {
+		return nil, fmt.Errorf("%w: %s", ErrIncompleteRequest, req.Incomplete)
+	}
+	target, err := r.url(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	for name, values := range req.Header {
+		// The transport sets the length and negotiates compression itself
+		if strings.EqualFold(name, "Content-Length") || strings.EqualFold(name, "Accept-Encoding") || isRedacted(values) {
+			continue
+		}
+		header[name] = values
+	}
+	var body interface{}
+	if req.Body != "" {
+		body = []byte(req.Body)
+	}
+
+	client := r.Client
+	if client == nil {
+		client = httpclient.Default()
+	}
+	var status int
+	resp := &RecordedResponse{}
+	opts = append([]httpclient.Option{httpclient.WithHeaders(flatten(header)), httpclient.WithStatus(&status)}, opts...)
+	if err := client.Do(ctx, req.Method, target, body, (*replayedResponse)(resp), opts...); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// url rewrites the scheme and host of rawURL to the target
+func (r *Replayer) url(rawURL string) (string, error) {
+	if r.Target == "" {
+		return rawURL, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse request URL: %w", err)
+	}
+	target, err := url.Parse(r.Target)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse target: %w", err)
+	}
+	u.Scheme, u.Host, u.User = target.Scheme, target.Host, target.User
+	if target.Path != "" && target.Path != "/" {
+		u.Path = strings.TrimSuffix(target.Path, "/") + u.Path
+		u.RawPath = ""
+	}
+	return u.String(), nil
+}
+
+// replayedResponse decodes any response into a RecordedResponse
+type replayedResponse RecordedResponse
+
+// DecodeResponse implements httpclient.ResponseDecoder
+func (r *replayedResponse) DecodeResponse(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	r.StatusCode, r.Header, r.Body = resp.StatusCode, resp.Header, string(body)
+	return nil
+}
+
+// isRedacted reports whether header values were redacted by a cassette, a failure dump or a HAR exporter
+func isRedacted(values []string) bool {
+	for _, value := range values {
+		switch value {
+		case RedactedValue, "[REDACTED]", "[Filtered]":
+			return true
+		}
+	}
+	return false
+}
+
+// flatten keeps the first value of every header, as httpclient.WithHeaders takes
+func flatten(header http.Header) map[string]string {
+	flat := make(map[string]string, len(header))
+	for name := range header {
+		flat[name] = header.Get(name)
+	}
+	return flat
+}
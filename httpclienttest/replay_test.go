@@ -0,0 +1,123 @@
+package httpclienttest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+func TestReplay(t *testing.T) {
+	production := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer production.Close()
+
+	var received *http.Request
+	var receivedBody string
+	staging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received, receivedBody = r, string(body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":42}`))
+	}))
+	defer staging.Close()
+
+	t.Run("failure dump", func(t *testing.T) {
+		dir := t.TempDir()
+		client := &httpclient.Client{FailureDump: &httpclient.FailureDump{Dir: dir}}
+		err := client.Post(context.Background(), production.URL+"/pokemon?team=kanto", map[string]string{"name": "pikachu"}, nil,
+			httpclient.WithHeader("Authorization", "Bearer prod"), httpclient.WithHeader("X-Request-Id", "42"))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 dump, got %d", len(entries))
+		}
+
+		requests, err := LoadRequests(filepath.Join(dir, entries[0].Name()))
+		if err != nil {
+			t.Fatalf("loading requests failed: %v", err)
+		}
+		replayer := &Replayer{Target: staging.URL}
+		resp, err := replayer.Replay(context.Background(), requests[0], httpclient.WithHeader("Authorization", "Bearer staging"))
+		if err != nil {
+			t.Fatalf("replay failed: %v", err)
+		}
+		if resp.StatusCode != http.StatusCreated || resp.Body != `{"id":42}` {
+			t.Errorf("expected 201 with body, got %d %s", resp.StatusCode, resp.Body)
+		}
+		if received.Method != http.MethodPost || received.URL.String() != "/pokemon?team=kanto" {
+			t.Errorf("expected POST /pokemon?team=kanto, got %s %s", received.Method, received.URL)
+		}
+		if receivedBody != `{"name":"pikachu"}` {
+			t.Errorf("expected recorded body, got %s", receivedBody)
+		}
+		if received.Header.Get("Authorization") != "Bearer staging" || received.Header.Get("X-Request-Id") != "42" {
+			t.Errorf("expected fresh credentials and recorded headers, got %v", received.Header)
+		}
+	})
+
+	t.Run("har", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "session.har")
+		har := `{"log":{"entries":[{"request":{"method":"PUT","url":"https://api.example.com/pokemon/25",
+			"headers":[{"name":":authority","value":"api.example.com"},{"name":"Cookie","value":"[Filtered]"},{"name":"X-Team","value":"kanto"}],
+			"postData":{"mimeType":"application/json","text":"{\"name\":\"raichu\"}"}}}]}}`
+		if err := os.WriteFile(path, []byte(har), 0o600); err != nil {
+			t.Fatalf("writing HAR failed: %v", err)
+		}
+		requests, err := LoadRequests(path)
+		if err != nil {
+			t.Fatalf("loading requests failed: %v", err)
+		}
+		if _, err := (&Replayer{Target: staging.URL}).Replay(context.Background(), requests[0]); err != nil {
+			t.Fatalf("replay failed: %v", err)
+		}
+		if received.Method != http.MethodPut || received.URL.Path != "/pokemon/25" || receivedBody != `{"name":"raichu"}` {
+			t.Errorf("expected PUT /pokemon/25 with body, got %s %s %s", received.Method, received.URL, receivedBody)
+		}
+		if received.Header.Get("Cookie") != "" || received.Header.Get("X-Team") != "kanto" {
+			t.Errorf("expected redacted headers to be left out, got %v", received.Header)
+		}
+	})
+
+	t.Run("cassette", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cassette.json")
+		cassette := `{"interactions":[{"request":{"method":"GET","url":"https://api.example.com/pokemon"},"response":{"status_code":200}},
+			{"request":{"method":"DELETE","url":"https://api.example.com/pokemon/25"},"response":{"status_code":204}}]}`
+		if err := os.WriteFile(path, []byte(cassette), 0o600); err != nil {
+			t.Fatalf("writing cassette failed: %v", err)
+		}
+		requests, err := LoadRequests(path)
+		if err != nil {
+			t.Fatalf("loading requests failed: %v", err)
+		}
+		if len(requests) != 2 || requests[1].Method != http.MethodDelete {
+			t.Fatalf("expected 2 requests, got %+v", requests)
+		}
+	})
+
+	t.Run("truncated body", func(t *testing.T) {
+		dir := t.TempDir()
+		client := &httpclient.Client{FailureDump: &httpclient.FailureDump{Dir: dir, MaxBodyBytes: 4}}
+		_ = client.Post(context.Background(), production.URL+"/pokemon", map[string]string{"name": "pikachu"}, nil)
+		entries, _ := os.ReadDir(dir)
+		requests, err := LoadRequests(filepath.Join(dir, entries[0].Name()))
+		if err != nil {
+			t.Fatalf("loading requests failed: %v", err)
+		}
+		_, err = (&Replayer{Target: staging.URL}).Replay(context.Background(), requests[0])
+		if !errors.Is(err, ErrIncompleteRequest) || !strings.Contains(err.Error(), "truncated") {
+			t.Errorf("expected ErrIncompleteRequest, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package httpclienttest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+// NewTestClient starts an in-process server running handler and returns a client wired to it.
+// The server is closed when the test finishes.
+//
+//	client, server := httpclienttest.NewTestClient(t, handler)
+//	err := client.Get(ctx, server.URL+"/pokemon/25", &pokemon)
+func NewTestClient(t testing.TB, handler http.Handler) (*httpclient.Client, *httptest.Server) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return &httpclient.Client{Client: server.Client()}, server
+}
+
+// NewTLSTestClient is like NewTestClient but serves over TLS with a client trusting the test certificate
+func NewTLSTestClient(t testing.TB, handler http.Handler) (*httpclient.Client, *httptest.Server) {
+	t.Helper()
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+	return &httpclient.Client{Client: server.Client()}, server
+}
@@ -0,0 +1,37 @@
+package httpclienttest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestNewTestClient(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":25,"name":"pikachu"}`))
+	})
+
+	t.Run("plain server", func(t *testing.T) {
+		client, server := NewTestClient(t, handler)
+
+		var p pokemon
+		if err := client.Get(context.Background(), server.URL+"/pokemon/25", &p); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if p.Name != "pikachu" {
+			t.Errorf("expected name 'pikachu', got '%s'", p.Name)
+		}
+	})
+
+	t.Run("TLS server", func(t *testing.T) {
+		client, server := NewTLSTestClient(t, handler)
+
+		var p pokemon
+		if err := client.Get(context.Background(), server.URL+"/pokemon/25", &p); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if p.ID != 25 {
+			t.Errorf("expected ID 25, got %d", p.ID)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package httpclienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+// Stubs is an http.RoundTripper routing requests to canned responses by URL pattern. Unlike Mock it
+// has no expectations: any request may be sent any number of times and unmatched requests get a
+// 404, which suits fuzz and property tests and local demos.
+//
+//	stubs := httpclienttest.NewStubs()
+//	stubs.Stub("GET", "/pokemon/*", 200, pokemon)
+//	stubs.StubRegexp("GET", regexp.MustCompile(`/items/\d+$`), 200, item)
+//	client := stubs.Client()
+type Stubs struct {
+	mu     sync.RWMutex
+	routes []stubRoute
+}
+
+type stubRoute struct {
+	method string
+	re     *regexp.Regexp
+	path   bool
+	status int
+	body   []byte
+	json   bool
+}
+
+// NewStubs returns an empty stub registry
+func NewStubs() *Stubs {
+	return &Stubs{}
+}
+
+// Stub registers a canned response for requests matching method and the glob pattern.
+//
+// An empty method or "*" matches any method. In the pattern, "*" matches any characters except "/"
+// and "**" matches any characters. Patterns starting with "/" are matched against the request path,
+// others against the full URL. A []byte body is sent as is, any other body is marshalled to JSON.
+// The first matching stub answers a request.
+func (s *Stubs) Stub(method, pattern string, status int, body interface{}) *Stubs {
+	return s.add(method, globRegexp(pattern), strings.HasPrefix(pattern, "/"), status, body)
+}
+
+// StubRegexp is like Stub but matches the full request URL against re
+func (s *Stubs) StubRegexp(method string, re *regexp.Regexp, status int, body interface{}) *Stubs {
+	return s.add(method, re, false, status, body)
+}
+
+func (s *Stubs) add(method string, re *regexp.Regexp, path bool, status int, body interface{}) *Stubs {
+	route := stubRoute{method: strings.ToUpper(method), re: re, path: path, status: status}
+	if raw, ok := body.([]byte); ok {
+		route.body = raw
+	} else if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			panic(fmt.Sprintf("httpclienttest: failed to marshal stub: %v", err))
+		}
+		route.body = data
+		route.json = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes = append(s.routes, route)
+	return s
+}
+
+// globRegexp translates a glob pattern into an anchored regular expression
+func globRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+		case pattern[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func (r *stubRoute) matches(req *http.Request) bool {
+	if r.method != "" && r.method != "*" && r.method != req.Method {
+		return false
+	}
+	if r.path {
+		return r.re.MatchString(req.URL.Path)
+	}
+	return r.re.MatchString(req.URL.String())
+}
+
+// RoundTrip answers req with the first matching stub, or a 404 when none matches
+func (s *Stubs) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		_ = req.Body.Close()
+	}
+
+	s.mu.RLock()
+	var match *stubRoute
+	for i := range s.routes {
+		if s.routes[i].matches(req) {
+			match = &s.routes[i]
+			break
+		}
+	}
+	s.mu.RUnlock()
+
+	status, header, body := http.StatusNotFound, http.Header{"Content-Type": {"application/json"}}, []byte(`{"error":"no stub"}`)
+	if match != nil {
+		status, header, body = match.status, http.Header{}, match.body
+		if match.json {
+			header.Set("Content-Type", "application/json")
+		}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// HTTPClient returns an http.Client using the stubs as transport
+func (s *Stubs) HTTPClient() *http.Client {
+	return &http.Client{Transport: s}
+}
+
+// Client returns an httpclient.Client using the stubs as transport
+func (s *Stubs) Client() *httpclient.Client {
+	return &httpclient.Client{Client: s.HTTPClient()}
+}
@@ -0,0 +1,48 @@
+package httpclienttest
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+func TestStubs(t *testing.T) {
+	stubs := NewStubs().
+		Stub("GET", "/pokemon/*", http.StatusOK, pokemon{ID: 25, Name: "pikachu"}).
+		Stub("*", "https://*.example/**", http.StatusAccepted, []byte(`{"id":1,"name":"any"}`)).
+		StubRegexp("GET", regexp.MustCompile(`/items/\d+$`), http.StatusOK, pokemon{ID: 7, Name: "item"})
+	client := stubs.Client()
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		status int
+		want   string
+	}{
+		{"glob on path", "GET", "https://pokeapi.co/pokemon/25", http.StatusOK, "pikachu"},
+		{"single star stops at slash", "GET", "https://pokeapi.co/pokemon/25/moves", http.StatusNotFound, ""},
+		{"double star and any method", "DELETE", "https://shop.example/a/b/c", http.StatusAccepted, "any"},
+		{"regexp", "GET", "https://shop.test/items/42", http.StatusOK, "item"},
+		{"no match", "GET", "https://shop.test/items/abc", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var status int
+			var p pokemon
+			if err := client.Do(ctx, tt.method, tt.url, nil, &p, httpclient.WithStatus(&status)); err != nil {
+				t.Fatalf("%s request failed: %v", tt.method, err)
+			}
+			if status != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, status)
+			}
+			if p.Name != tt.want {
+				t.Errorf("expected name '%s', got '%s'", tt.want, p.Name)
+			}
+		})
+	}
+}
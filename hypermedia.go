@@ -0,0 +1,143 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrNoLink is returned when a document does not contain the requested link relation
+var ErrNoLink = errors.New("link not found")
+
+// Link is a hypermedia link as found in JSON:API "links" and HAL "_links" objects
+type Link struct {
+	Href  string                 `json:"href"`
+	Title string                 `json:"title,omitempty"`
+	Type  string                 `json:"type,omitempty"`
+	Meta  map[string]interface{} `json:"meta,omitempty"`
+}
+
+// UnmarshalJSON accepts a plain URL string (JSON:API), a link object, or an array of link
+// objects in which case the first link is used (HAL)
+func (l *Link) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case len(data) == 0 || bytes.Equal(data, []byte("null")):
+		*l = Link{}
+		return nil
+	case data[0] == '"':
+		*l = Link{}
+		return json.Unmarshal(data, &l.Href)
+	case data[0] == '[':
+		var links []Link
+		if err := json.Unmarshal(data, &links); err != nil {
+			return err
+		}
+		*l = Link{}
+		if len(links) > 0 {
+			*l = links[0]
+		}
+		return nil
+	}
+
+	type plain Link
+	return json.Unmarshal(data, (*plain)(l))
+}
+
+// Links maps link relation names such as "self" and "next" to links
+type Links map[string]Link
+
+// Resolve returns the absolute URL of the rel link, resolving relative links against base
+func (l Links) Resolve(base, rel string) (string, error) {
+	link, ok := l[rel]
+	if !ok || link.Href == "" {
+		return "", fmt.Errorf("%w: %s", ErrNoLink, rel)
+	}
+
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse base URL: %w", err)
+	}
+	ref, err := url.Parse(link.Href)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s link: %w", rel, err)
+	}
+	return baseURL.ResolveReference(ref).String(), nil
+}
+
+// JSONAPIDocument is a top-level JSON:API document whose sections are decoded on demand
+type JSONAPIDocument struct {
+	Data     json.RawMessage `json:"data"`
+	Included json.RawMessage `json:"included,omitempty"`
+	Links    Links           `json:"links,omitempty"`
+	Meta     json.RawMessage `json:"meta,omitempty"`
+}
+
+// DecodeData unmarshals the primary data into v, typically a JSONAPIResource or a slice of them
+func (d *JSONAPIDocument) DecodeData(v interface{}) error {
+	return decodeSection("data", d.Data, v)
+}
+
+// DecodeIncluded unmarshals the included resources into v
+func (d *JSONAPIDocument) DecodeIncluded(v interface{}) error {
+	return decodeSection("included", d.Included, v)
+}
+
+// JSONAPIResource is a JSON:API resource object
+type JSONAPIResource struct {
+	Type          string                     `json:"type"`
+	ID            string                     `json:"id"`
+	Attributes    json.RawMessage            `json:"attributes,omitempty"`
+	Relationships map[string]json.RawMessage `json:"relationships,omitempty"`
+	Links         Links                      `json:"links,omitempty"`
+}
+
+// DecodeAttributes unmarshals the resource attributes into v
+func (r *JSONAPIResource) DecodeAttributes(v interface{}) error {
+	return decodeSection("attributes", r.Attributes, v)
+}
+
+// HALDocument holds the hypermedia sections of a HAL resource, embed it in a struct to decode
+// both the resource fields and its links:
+//
+//	type PokemonResource struct {
+//	    httpclient.HALDocument
+//	    Name string `json:"name"`
+//	}
+type HALDocument struct {
+	Links    Links                      `json:"_links,omitempty"`
+	Embedded map[string]json.RawMessage `json:"_embedded,omitempty"`
+}
+
+// DecodeEmbedded unmarshals the embedded resource stored under name into v
+func (d *HALDocument) DecodeEmbedded(name string, v interface{}) error {
+	raw, ok := d.Embedded[name]
+	if !ok {
+		return fmt.Errorf("embedded resource %s not found", name)
+	}
+	return decodeSection(name, raw, v)
+}
+
+// FollowLink performs a GET request to the rel link, resolved against base, and unmarshals
+// the JSON response into result
+func (c *Client) FollowLink(ctx context.Context, base string, links Links, rel string, result interface{}, opts ...Option) error {
+	target, err := links.Resolve(base, rel)
+	if err != nil {
+		return err
+	}
+	// Hypermedia documents carry their own data and links sections
+	return c.Get(ctx, target, result, append(opts[:len(opts):len(opts)], WithoutEnvelope())...)
+}
+
+func decodeSection(name string, raw json.RawMessage, v interface{}) error {
+	if len(raw) == 0 {
+		return fmt.Errorf("document has no %s section", name)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHypermedia(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/jsonapi/pokemon":
+			_, _ = w.Write([]byte(`{
+				"data": [{"type": "pokemon", "id": "25", "attributes": {"name": "pikachu"}}],
+				"included": [{"type": "types", "id": "13", "attributes": {"name": "electric"}}],
+				"links": {"self": "/jsonapi/pokemon", "next": {"href": "/jsonapi/pokemon?page=2"}}
+			}`))
+		case "/hal/pokemon/25":
+			_, _ = w.Write([]byte(`{
+				"name": "pikachu",
+				"_links": {"self": {"href": "/hal/pokemon/25"}, "evolution": [{"href": "/hal/pokemon/26"}]},
+				"_embedded": {"types": [{"name": "electric"}]}
+			}`))
+		case "/hal/pokemon/26":
+			_, _ = w.Write([]byte(`{"name": "raichu", "_links": {"self": {"href": "/hal/pokemon/26"}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{}
+
+	t.Run("decode JSON:API document", func(t *testing.T) {
+		var doc JSONAPIDocument
+		if err := client.Get(context.Background(), server.URL+"/jsonapi/pokemon", &doc); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+
+		var resources []JSONAPIResource
+		if err := doc.DecodeData(&resources); err != nil {
+			t.Fatalf("decoding data failed: %v", err)
+		}
+		var pokemon Pokemon
+		if err := resources[0].DecodeAttributes(&pokemon); err != nil {
+			t.Fatalf("decoding attributes failed: %v", err)
+		}
+		if pokemon.Name != "pikachu" {
+			t.Errorf("expected name 'pikachu', got '%s'", pokemon.Name)
+		}
+
+		var included []JSONAPIResource
+		if err := doc.DecodeIncluded(&included); err != nil {
+			t.Fatalf("decoding included failed: %v", err)
+		}
+		if included[0].Type != "types" {
+			t.Errorf("expected included type 'types', got '%s'", included[0].Type)
+		}
+
+		next, err := doc.Links.Resolve(server.URL+"/jsonapi/pokemon", "next")
+		if err != nil {
+			t.Fatalf("resolving next link failed: %v", err)
+		}
+		if next != server.URL+"/jsonapi/pokemon?page=2" {
+			t.Errorf("unexpected next link: %s", next)
+		}
+	})
+
+	t.Run("follow HAL link", func(t *testing.T) {
+		type pokemonResource struct {
+			HALDocument
+			Name string `json:"name"`
+		}
+
+		base := server.URL + "/hal/pokemon/25"
+		var pikachu pokemonResource
+		if err := client.Get(context.Background(), base, &pikachu); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+
+		var types []map[string]string
+		if err := pikachu.DecodeEmbedded("types", &types); err != nil {
+			t.Fatalf("decoding embedded failed: %v", err)
+		}
+		if types[0]["name"] != "electric" {
+			t.Errorf("expected embedded type 'electric', got '%s'", types[0]["name"])
+		}
+
+		var raichu pokemonResource
+		if err := client.FollowLink(context.Background(), base, pikachu.Links, "evolution", &raichu); err != nil {
+			t.Fatalf("following link failed: %v", err)
+		}
+		if raichu.Name != "raichu" {
+			t.Errorf("expected name 'raichu', got '%s'", raichu.Name)
+		}
+	})
+
+	t.Run("missing link", func(t *testing.T) {
+		err := client.FollowLink(context.Background(), server.URL, Links{}, "next", nil)
+		if !errors.Is(err, ErrNoLink) {
+			t.Errorf("expected ErrNoLink, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+// Package jose encrypts and signs request bodies and decrypts and verifies response bodies using
+// JSON Web Encryption (JWE) and JSON Web Signature (JWS) compact serializations, as required by some
+// fintech and healthcare APIs (FAPI, eIDAS).
+//
+// Only the standard library is used. Supported algorithms are RS256, ES256 and HS256 for signatures,
+// RSA-OAEP-256 and dir for key management, and A128GCM and A256GCM for content encryption.
+//
+//	client := &httpclient.Client{Middleware: []httpclient.Middleware{jose.Middleware(jose.Config{
+//	    Signer:    &jose.Signer{Alg: jose.ES256, Key: clientKey, KeyID: "client-1"},
+//	    Encrypter: &jose.Encrypter{Alg: jose.RSAOAEP256, Key: serverPublicKey},
+//	    Decrypter: &jose.Decrypter{Key: clientDecryptionKey},
+//	    Verifier:  &jose.Verifier{Alg: jose.RS256, Key: serverSigningKey},
+//	})}}
+package jose
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+var (
+	// ErrMalformed is returned for tokens that are not valid compact serializations
+	ErrMalformed = errors.New("jose: malformed token")
+	// ErrInvalidSignature is returned when a JWS signature does not verify
+	ErrInvalidSignature = errors.New("jose: invalid signature")
+	// ErrDecryption is returned when a JWE cannot be decrypted with the configured key
+	ErrDecryption = errors.New("jose: decryption failed")
+	// ErrUnsupportedAlgorithm is returned for algorithms this package does not implement
+	ErrUnsupportedAlgorithm = errors.New("jose: unsupported algorithm")
+	// ErrUnsupportedKey is returned when a key does not fit the algorithm
+	ErrUnsupportedKey = errors.New("jose: unsupported key")
+)
+
+const (
+	// ContentType is the media type of JOSE compact serializations
+	ContentType = "application/jose"
+	// DefaultMaxBodyBytes is the size of the largest response body unwrapped when Config.MaxBodyBytes is not set
+	DefaultMaxBodyBytes = 10 << 20
+)
+
+type header struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Cty string `json:"cty,omitempty"`
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+func encodeHeader(h header) (string, error) {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal header: %w", err)
+	}
+	return encode(data), nil
+}
+
+func decodeHeader(s string) (header, error) {
+	var h header
+	data, err := decode(s)
+	if err != nil {
+		return h, ErrMalformed
+	}
+	if err := json.Unmarshal(data, &h); err != nil {
+		return h, ErrMalformed
+	}
+	return h, nil
+}
+
+// Config selects the protections applied by Middleware, nil fields are skipped
+type Config struct {
+	// Signer signs request bodies as JWS
+	Signer *Signer
+	// Encrypter encrypts request bodies, after signing when Signer is set
+	Encrypter *Encrypter
+	// Decrypter decrypts JWE response bodies
+	Decrypter *Decrypter
+	// Verifier verifies JWS response bodies, after decryption when Decrypter is set
+	Verifier *Verifier
+	// MaxBodyBytes fails responses with larger bodies with httpclient.ErrResponseTooLarge, since they are read
+	// in memory to be unwrapped, defaults to DefaultMaxBodyBytes
+	MaxBodyBytes int64
+}
+
+// Middleware protects request bodies and unwraps response bodies according to cfg.
+//
+// Protected requests are sent with Content-Type application/jose. Successful responses must be
+// protected as configured, while error responses (status 400 and above) that are not compact
+// serializations are passed through so API error bodies stay readable.
+func Middleware(cfg Config) httpclient.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if (cfg.Signer != nil || cfg.Encrypter != nil) && req.Body != nil {
+				var err error
+				if req, err = protectRequest(req, cfg); err != nil {
+					return nil, err
+				}
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil || (cfg.Decrypter == nil && cfg.Verifier == nil) {
+				return resp, err
+			}
+			if err := unwrapResponse(resp, cfg); err != nil {
+				_ = resp.Body.Close()
+				return nil, err
+			}
+			return resp, nil
+		})
+	}
+}
+
+func protectRequest(req *http.Request, cfg Config) (*http.Request, error) {
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	if len(body) == 0 {
+		req = req.Clone(req.Context())
+		req.Body = http.NoBody
+		return req, nil
+	}
+
+	payload := string(body)
+	if cfg.Signer != nil {
+		if payload, err = cfg.Signer.Sign(body); err != nil {
+			return nil, fmt.Errorf("failed to sign request body: %w", err)
+		}
+	}
+	if cfg.Encrypter != nil {
+		encrypter := *cfg.Encrypter
+		if cfg.Signer != nil && encrypter.ContentType == "" {
+			encrypter.ContentType = "JWT"
+		}
+		if payload, err = encrypter.Encrypt([]byte(payload)); err != nil {
+			return nil, fmt.Errorf("failed to encrypt request body: %w", err)
+		}
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader([]byte(payload)))
+	req.ContentLength = int64(len(payload))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader([]byte(payload))), nil
+	}
+	req.Header.Set("Content-Type", ContentType)
+	return req, nil
+}
+
+func unwrapResponse(resp *http.Response, cfg Config) error {
+	limit := cfg.MaxBodyBytes
+	if limit <= 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	_ = resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if int64(len(body)) > limit {
+		return fmt.Errorf("%w: limit is %d bytes", httpclient.ErrResponseTooLarge, limit)
+	}
+
+	token := string(bytes.TrimSpace(body))
+	dots := bytes.Count([]byte(token), []byte("."))
+	protected := (cfg.Decrypter != nil && dots == 4) || (cfg.Decrypter == nil && dots == 2)
+	if !protected && (resp.StatusCode >= 400 || len(token) == 0) {
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return nil
+	}
+
+	payload := []byte(token)
+	if cfg.Decrypter != nil {
+		if payload, err = cfg.Decrypter.Decrypt(token); err != nil {
+			return fmt.Errorf("failed to decrypt response body: %w", err)
+		}
+	}
+	if cfg.Verifier != nil {
+		if payload, err = cfg.Verifier.Verify(string(payload)); err != nil {
+			return fmt.Errorf("failed to verify response body: %w", err)
+		}
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(payload))
+	resp.ContentLength = int64(len(payload))
+	resp.Header.Del("Content-Length")
+	resp.Header.Set("Content-Type", "application/json")
+	return nil
+}
@@ -0,0 +1,174 @@
+package jose
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+func TestJWS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	tests := []struct {
+		alg     string
+		signKey interface{}
+		pubKey  interface{}
+	}{
+		{RS256, rsaKey, &rsaKey.PublicKey},
+		{ES256, ecKey, &ecKey.PublicKey},
+		{HS256, secret, secret},
+	}
+	for _, tt := range tests {
+		t.Run(strings.ToLower(tt.alg), func(t *testing.T) {
+			token, err := (&Signer{Alg: tt.alg, Key: tt.signKey, KeyID: "k1"}).Sign([]byte(`{"name":"pikachu"}`))
+			if err != nil {
+				t.Fatalf("signing failed: %v", err)
+			}
+
+			payload, err := (&Verifier{Alg: tt.alg, Key: tt.pubKey}).Verify(token)
+			if err != nil {
+				t.Fatalf("verification failed: %v", err)
+			}
+			if string(payload) != `{"name":"pikachu"}` {
+				t.Errorf("expected original payload, got %s", payload)
+			}
+
+			parts := strings.Split(token, ".")
+			tampered := parts[0] + "." + encode([]byte(`{"name":"mewtwo"}`)) + "." + parts[2]
+			if _, err := (&Verifier{Alg: tt.alg, Key: tt.pubKey}).Verify(tampered); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("expected ErrInvalidSignature, got %v", err)
+			}
+		})
+	}
+
+	t.Run("rejects other algorithms", func(t *testing.T) {
+		token, _ := (&Signer{Alg: HS256, Key: secret}).Sign([]byte(`{}`))
+		if _, err := (&Verifier{Alg: RS256, Key: &rsaKey.PublicKey}).Verify(token); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("expected ErrInvalidSignature, got %v", err)
+		}
+	})
+}
+
+func TestJWE(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shared := make([]byte, 32)
+	_, _ = rand.Read(shared)
+
+	t.Run("rsa-oaep-256", func(t *testing.T) {
+		token, err := (&Encrypter{Alg: RSAOAEP256, Key: &rsaKey.PublicKey}).Encrypt([]byte("secret"))
+		if err != nil {
+			t.Fatalf("encryption failed: %v", err)
+		}
+		plaintext, err := (&Decrypter{Key: rsaKey}).Decrypt(token)
+		if err != nil {
+			t.Fatalf("decryption failed: %v", err)
+		}
+		if string(plaintext) != "secret" {
+			t.Errorf("expected 'secret', got '%s'", plaintext)
+		}
+	})
+
+	t.Run("dir", func(t *testing.T) {
+		token, err := (&Encrypter{Alg: Dir, Key: shared}).Encrypt([]byte("secret"))
+		if err != nil {
+			t.Fatalf("encryption failed: %v", err)
+		}
+		plaintext, err := (&Decrypter{Key: shared}).Decrypt(token)
+		if err != nil {
+			t.Fatalf("decryption failed: %v", err)
+		}
+		if string(plaintext) != "secret" {
+			t.Errorf("expected 'secret', got '%s'", plaintext)
+		}
+
+		parts := strings.Split(token, ".")
+		parts[3] = encode([]byte("tampered"))
+		if _, err := (&Decrypter{Key: shared}).Decrypt(strings.Join(parts, ".")); !errors.Is(err, ErrDecryption) {
+			t.Errorf("expected ErrDecryption, got %v", err)
+		}
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	clientKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	serverKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shared := make([]byte, 32)
+	_, _ = rand.Read(shared)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"bad request"}`))
+			return
+		}
+		if r.Header.Get("Content-Type") != ContentType {
+			t.Errorf("expected Content-Type %s, got %s", ContentType, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		jws, err := (&Decrypter{Key: serverKey}).Decrypt(string(body))
+		if err != nil {
+			t.Errorf("server decryption failed: %v", err)
+		}
+		payload, err := (&Verifier{Alg: ES256, Key: &clientKey.PublicKey}).Verify(string(jws))
+		if err != nil {
+			t.Errorf("server verification failed: %v", err)
+		}
+
+		token, _ := (&Encrypter{Alg: Dir, Key: shared}).Encrypt(payload)
+		w.Header().Set("Content-Type", ContentType)
+		_, _ = w.Write([]byte(token))
+	}))
+	defer server.Close()
+
+	client := &httpclient.Client{Middleware: []httpclient.Middleware{Middleware(Config{
+		Signer:    &Signer{Alg: ES256, Key: clientKey},
+		Encrypter: &Encrypter{Alg: RSAOAEP256, Key: &serverKey.PublicKey},
+		Decrypter: &Decrypter{Key: shared},
+	})}}
+
+	t.Run("round trip", func(t *testing.T) {
+		var result map[string]string
+		if err := client.Post(context.Background(), server.URL, map[string]string{"name": "pikachu"}, &result); err != nil {
+			t.Fatalf("POST request failed: %v", err)
+		}
+		if result["name"] != "pikachu" {
+			t.Errorf("expected name 'pikachu', got '%s'", result["name"])
+		}
+	})
+
+	t.Run("response too large", func(t *testing.T) {
+		client := &httpclient.Client{Middleware: []httpclient.Middleware{Middleware(Config{
+			Signer:       &Signer{Alg: ES256, Key: clientKey},
+			Encrypter:    &Encrypter{Alg: RSAOAEP256, Key: &serverKey.PublicKey},
+			Decrypter:    &Decrypter{Key: shared},
+			MaxBodyBytes: 64,
+		})}}
+		err := client.Post(context.Background(), server.URL, map[string]string{"name": "pikachu"}, nil)
+		if !errors.Is(err, httpclient.ErrResponseTooLarge) {
+			t.Errorf("expected ErrResponseTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("plain error responses", func(t *testing.T) {
+		var status int
+		var result map[string]string
+		if err := client.Get(context.Background(), server.URL+"/error", &result, httpclient.WithStatus(&status)); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if status != http.StatusBadRequest || result["error"] != "bad request" {
+			t.Errorf("expected plain error body, got %d %v", status, result)
+		}
+	})
+}
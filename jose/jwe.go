@@ -0,0 +1,171 @@
+package jose
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// Key management and content encryption algorithms supported by Encrypter and Decrypter
+const (
+	RSAOAEP256 = "RSA-OAEP-256"
+	Dir        = "dir"
+	A128GCM    = "A128GCM"
+	A256GCM    = "A256GCM"
+)
+
+// Encrypter produces JWE compact serializations
+type Encrypter struct {
+	// Alg is the key management algorithm: RSA-OAEP-256 or dir
+	Alg string
+	// Enc is the content encryption algorithm: A128GCM or A256GCM, defaults to A256GCM
+	Enc string
+	// Key is the recipient *rsa.PublicKey for RSA-OAEP-256 or the shared []byte content key for dir
+	Key interface{}
+	// KeyID is sent as the "kid" header when not empty
+	KeyID string
+	// ContentType is sent as the "cty" header when not empty, e.g. "JWT" for nested signed payloads
+	ContentType string
+}
+
+// Encrypt returns plaintext encrypted as a JWE in compact serialization
+func (e *Encrypter) Encrypt(plaintext []byte) (string, error) {
+	enc := e.Enc
+	if enc == "" {
+		enc = A256GCM
+	}
+	size, err := keySize(enc)
+	if err != nil {
+		return "", err
+	}
+
+	var cek, encryptedKey []byte
+	switch e.Alg {
+	case RSAOAEP256:
+		pub, ok := e.Key.(*rsa.PublicKey)
+		if !ok {
+			return "", fmt.Errorf("%w: RSA-OAEP-256 requires an *rsa.PublicKey", ErrUnsupportedKey)
+		}
+		cek = make([]byte, size)
+		if _, err := rand.Read(cek); err != nil {
+			return "", fmt.Errorf("failed to generate content key: %w", err)
+		}
+		if encryptedKey, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, cek, nil); err != nil {
+			return "", fmt.Errorf("failed to encrypt content key: %w", err)
+		}
+	case Dir:
+		key, ok := e.Key.([]byte)
+		if !ok || len(key) != size {
+			return "", fmt.Errorf("%w: dir with %s requires a %d byte key", ErrUnsupportedKey, enc, size)
+		}
+		cek = key
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, e.Alg)
+	}
+
+	protected, err := encodeHeader(header{Alg: e.Alg, Enc: enc, Kid: e.KeyID, Cty: e.ContentType})
+	if err != nil {
+		return "", err
+	}
+	aead, err := newGCM(cek)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", fmt.Errorf("failed to generate IV: %w", err)
+	}
+	sealed := aead.Seal(nil, iv, plaintext, []byte(protected))
+	ciphertext, tag := sealed[:len(sealed)-aead.Overhead()], sealed[len(sealed)-aead.Overhead():]
+
+	return strings.Join([]string{protected, encode(encryptedKey), encode(iv), encode(ciphertext), encode(tag)}, "."), nil
+}
+
+// Decrypter opens JWE compact serializations
+type Decrypter struct {
+	// Key is the *rsa.PrivateKey for RSA-OAEP-256 or the shared []byte content key for dir,
+	// the key type decides which algorithm is accepted
+	Key interface{}
+}
+
+// Decrypt returns the plaintext of token
+func (d *Decrypter) Decrypt(token string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		return nil, ErrMalformed
+	}
+	h, err := decodeHeader(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	size, err := keySize(h.Enc)
+	if err != nil {
+		return nil, err
+	}
+
+	var cek []byte
+	switch key := d.Key.(type) {
+	case *rsa.PrivateKey:
+		if h.Alg != RSAOAEP256 {
+			return nil, fmt.Errorf("%w: unexpected algorithm %q", ErrDecryption, h.Alg)
+		}
+		encryptedKey, err := decode(parts[1])
+		if err != nil {
+			return nil, ErrMalformed
+		}
+		if cek, err = rsa.DecryptOAEP(sha256.New(), nil, key, encryptedKey, nil); err != nil {
+			return nil, ErrDecryption
+		}
+	case []byte:
+		if h.Alg != Dir || parts[1] != "" {
+			return nil, fmt.Errorf("%w: unexpected algorithm %q", ErrDecryption, h.Alg)
+		}
+		cek = key
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedKey, d.Key)
+	}
+	if len(cek) != size {
+		return nil, ErrDecryption
+	}
+
+	iv, err1 := decode(parts[2])
+	ciphertext, err2 := decode(parts[3])
+	tag, err3 := decode(parts[4])
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, ErrMalformed
+	}
+	aead, err := newGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	plaintext, err := aead.Open(nil, iv, append(ciphertext, tag...), []byte(parts[0]))
+	if err != nil {
+		return nil, ErrDecryption
+	}
+	return plaintext, nil
+}
+
+func keySize(enc string) (int, error) {
+	switch enc {
+	case A128GCM:
+		return 16, nil
+	case A256GCM:
+		return 32, nil
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, enc)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,156 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Signature algorithms supported by Signer and Verifier
+const (
+	RS256 = "RS256"
+	ES256 = "ES256"
+	HS256 = "HS256"
+)
+
+// Signer produces JWS compact serializations
+type Signer struct {
+	// Alg is the signature algorithm: RS256, ES256 or HS256
+	Alg string
+	// Key is an *rsa.PrivateKey for RS256, an *ecdsa.PrivateKey on P-256 for ES256 or a []byte secret for HS256
+	Key interface{}
+	// KeyID is sent as the "kid" header when not empty
+	KeyID string
+}
+
+// Sign returns payload signed as a JWS in compact serialization
+func (s *Signer) Sign(payload []byte) (string, error) {
+	protected, err := encodeHeader(header{Alg: s.Alg, Kid: s.KeyID})
+	if err != nil {
+		return "", err
+	}
+	signingInput := protected + "." + encode(payload)
+	signature, err := sign(s.Alg, s.Key, []byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + encode(signature), nil
+}
+
+// Verifier checks JWS compact serializations
+type Verifier struct {
+	// Alg is the only accepted signature algorithm, protecting against algorithm confusion
+	Alg string
+	// Key is an *rsa.PublicKey for RS256, an *ecdsa.PublicKey for ES256 or a []byte secret for HS256
+	Key interface{}
+}
+
+// Verify checks the signature of token and returns its payload
+func (v *Verifier) Verify(token string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	h, err := decodeHeader(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	if h.Alg != v.Alg {
+		return nil, fmt.Errorf("%w: unexpected algorithm %q", ErrInvalidSignature, h.Alg)
+	}
+	signature, err := decode(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if err := verify(v.Alg, v.Key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+	payload, err := decode(parts[1])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	return payload, nil
+}
+
+func sign(alg string, key interface{}, input []byte) ([]byte, error) {
+	digest := sha256.Sum256(input)
+	switch alg {
+	case RS256:
+		k, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: RS256 requires an *rsa.PrivateKey", ErrUnsupportedKey)
+		}
+		return rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case ES256:
+		k, ok := key.(*ecdsa.PrivateKey)
+		if !ok || k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("%w: ES256 requires a P-256 *ecdsa.PrivateKey", ErrUnsupportedKey)
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		// JWS uses the fixed size concatenation of r and s rather than ASN.1
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return signature, nil
+	case HS256:
+		k, ok := key.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("%w: HS256 requires a []byte secret", ErrUnsupportedKey)
+		}
+		mac := hmac.New(sha256.New, k)
+		mac.Write(input)
+		return mac.Sum(nil), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+}
+
+func verify(alg string, key interface{}, input, signature []byte) error {
+	digest := sha256.Sum256(input)
+	switch alg {
+	case RS256:
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: RS256 requires an *rsa.PublicKey", ErrUnsupportedKey)
+		}
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	case ES256:
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: ES256 requires an *ecdsa.PublicKey", ErrUnsupportedKey)
+		}
+		if len(signature) != 64 {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+	case HS256:
+		k, ok := key.([]byte)
+		if !ok {
+			return fmt.Errorf("%w: HS256 requires a []byte secret", ErrUnsupportedKey)
+		}
+		mac := hmac.New(sha256.New, k)
+		mac.Write(input)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+}
@@ -0,0 +1,607 @@
+// Package jsonschema validates JSON documents against JSON Schema (draft 7 and later keywords).
+//
+// The supported keywords are type, enum, const, properties, required, additionalProperties,
+// patternProperties, items (schema or tuple), prefixItems, minItems, maxItems, uniqueItems,
+// minProperties, maxProperties, minLength, maxLength, pattern, format (date-time, date, email,
+// uri, uuid, ipv4, ipv6), minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf,
+// allOf, anyOf, oneOf, not and local $ref pointers such as "#/$defs/Pokemon". Other keywords
+// are ignored.
+//
+//	schema, err := jsonschema.Compile([]byte(`{"type": "object", "required": ["name"]}`))
+//	err = client.Get(ctx, url, &pokemon, httpclient.WithValidator(schema))
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Schema is a compiled JSON Schema, safe for concurrent use
+type Schema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// FieldError describes one violation
+type FieldError struct {
+	// Path is the JSON pointer of the invalid value, empty for the document itself
+	Path string
+	// Message describes the violation
+	Message string
+}
+
+// ValidationError lists every violation found in a document
+type ValidationError struct {
+	Errors []FieldError
+}
+
+// Error implements error
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		path := fe.Path
+		if path == "" {
+			path = "/"
+		}
+		messages[i] = path + ": " + fe.Message
+	}
+	return "schema validation failed: " + strings.Join(messages, "; ")
+}
+
+// Compile parses a JSON Schema document
+func Compile(data []byte) (*Schema, error) {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	s := &Schema{root: root, patterns: map[string]*regexp.Regexp{}}
+	if err := s.compilePatterns(root); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// MustCompile is like Compile but panics on invalid schemas, for schemas defined in code
+func MustCompile(data []byte) *Schema {
+	s, err := Compile(data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// compilePatterns checks the schema shape and compiles every pattern and patternProperties key
+func (s *Schema) compilePatterns(root interface{}) error {
+	switch root.(type) {
+	case bool, map[string]interface{}:
+		return s.walkPatterns(root)
+	}
+	return fmt.Errorf("invalid schema: expected object or boolean, got %s", kind(root))
+}
+
+// walkPatterns compiles the patterns of the schema node and its subschemas
+func (s *Schema) walkPatterns(node interface{}) error {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		for key, child := range node {
+			switch key {
+			case "enum", "const", "default", "examples":
+				// Instance data, not schemas
+				continue
+			case "pattern":
+				if pattern, ok := child.(string); ok {
+					if err := s.compilePattern(pattern); err != nil {
+						return err
+					}
+					continue
+				}
+			case "patternProperties":
+				if props, ok := child.(map[string]interface{}); ok {
+					for pattern := range props {
+						if err := s.compilePattern(pattern); err != nil {
+							return err
+						}
+					}
+				}
+				fallthrough
+			case "properties", "$defs", "definitions", "dependentSchemas":
+				// Keys are names, only the values are schemas
+				if props, ok := child.(map[string]interface{}); ok {
+					for _, sub := range props {
+						if err := s.walkPatterns(sub); err != nil {
+							return err
+						}
+					}
+					continue
+				}
+			}
+			if err := s.walkPatterns(child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range node {
+			if err := s.walkPatterns(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) compilePattern(pattern string) error {
+	if _, ok := s.patterns[pattern]; ok {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid schema pattern %q: %w", pattern, err)
+	}
+	s.patterns[pattern] = re
+	return nil
+}
+
+// match reports whether v matches pattern, which is compiled when it was missed by compilePatterns
+func (s *Schema) match(pattern, v string) bool {
+	re, ok := s.patterns[pattern]
+	if !ok {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return false
+		}
+	}
+	return re.MatchString(v)
+}
+
+// ValidateJSON validates a JSON document
+func (s *Schema) ValidateJSON(data []byte) error {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return s.validateValue(v)
+}
+
+// Validate validates a Go value, such as a decoded response, through its JSON encoding
+func (s *Schema) Validate(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+	return s.ValidateJSON(data)
+}
+
+func (s *Schema) validateValue(v interface{}) error {
+	var errs []FieldError
+	s.validate(s.root, v, "", &errs, 0)
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: errs}
+}
+
+// maxDepth stops reference cycles
+const maxDepth = 64
+
+func (s *Schema) validate(node, v interface{}, path string, errs *[]FieldError, depth int) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if depth > maxDepth {
+		fail("schema nesting too deep")
+		return
+	}
+
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		if b, isBool := node.(bool); isBool && !b {
+			fail("value not allowed")
+		}
+		return
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := s.resolve(ref)
+		if err != nil {
+			fail("%v", err)
+			return
+		}
+		s.validate(target, v, path, errs, depth+1)
+	}
+
+	if t, ok := schema["type"]; ok && !matchesType(t, v) {
+		fail("expected %s, got %s", typeNames(t), kind(v))
+		return
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, candidate := range enum {
+			if equal(candidate, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value must be one of %s", compact(enum))
+		}
+	}
+	if c, ok := schema["const"]; ok && !equal(c, v) {
+		fail("value must be %s", compact(c))
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		s.validateObject(schema, v, path, errs, depth)
+	case []interface{}:
+		s.validateArray(schema, v, path, errs, depth)
+	case string:
+		s.validateString(schema, v, fail)
+	case json.Number:
+		validateNumber(schema, v, fail)
+	}
+
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			s.validate(sub, v, path, errs, depth+1)
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		if s.countValid(anyOf, v, path, depth) == 0 {
+			fail("value must match at least one schema of anyOf")
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		if n := s.countValid(oneOf, v, path, depth); n != 1 {
+			fail("value must match exactly one schema of oneOf, matched %d", n)
+		}
+	}
+	if not, ok := schema["not"]; ok {
+		var sub []FieldError
+		s.validate(not, v, path, &sub, depth+1)
+		if len(sub) == 0 {
+			fail("value must not match the schema of not")
+		}
+	}
+}
+
+func (s *Schema) countValid(schemas []interface{}, v interface{}, path string, depth int) int {
+	n := 0
+	for _, sub := range schemas {
+		var errs []FieldError
+		s.validate(sub, v, path, &errs, depth+1)
+		if len(errs) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Schema) validateObject(schema map[string]interface{}, v map[string]interface{}, path string, errs *[]FieldError, depth int) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, present := v[name]; !present {
+					*errs = append(*errs, FieldError{Path: path + "/" + escapePointer(name), Message: "required property missing"})
+				}
+			}
+		}
+	}
+	if n, ok := intKeyword(schema, "minProperties"); ok && len(v) < n {
+		fail("expected at least %d properties, got %d", n, len(v))
+	}
+	if n, ok := intKeyword(schema, "maxProperties"); ok && len(v) > n {
+		fail("expected at most %d properties, got %d", n, len(v))
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	patternProperties, _ := schema["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := schema["additionalProperties"]
+
+	// Sorted keys keep error order stable
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		childPath := path + "/" + escapePointer(key)
+		matched := false
+		if sub, ok := properties[key]; ok {
+			s.validate(sub, v[key], childPath, errs, depth+1)
+			matched = true
+		}
+		for pattern, sub := range patternProperties {
+			if s.match(pattern, key) {
+				s.validate(sub, v[key], childPath, errs, depth+1)
+				matched = true
+			}
+		}
+		if !matched && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				*errs = append(*errs, FieldError{Path: childPath, Message: "additional property not allowed"})
+				continue
+			}
+			s.validate(additional, v[key], childPath, errs, depth+1)
+		}
+	}
+}
+
+func (s *Schema) validateArray(schema map[string]interface{}, v []interface{}, path string, errs *[]FieldError, depth int) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if n, ok := intKeyword(schema, "minItems"); ok && len(v) < n {
+		fail("expected at least %d items, got %d", n, len(v))
+	}
+	if n, ok := intKeyword(schema, "maxItems"); ok && len(v) > n {
+		fail("expected at most %d items, got %d", n, len(v))
+	}
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+		for i := range v {
+			for j := i + 1; j < len(v); j++ {
+				if equal(v[i], v[j]) {
+					fail("items %d and %d are equal", i, j)
+				}
+			}
+		}
+	}
+
+	// A list of schemas in items (draft 7) or prefixItems (2020-12) validates positions
+	prefix, _ := schema["prefixItems"].([]interface{})
+	items := schema["items"]
+	if tuple, ok := items.([]interface{}); ok {
+		prefix, items = tuple, schema["additionalItems"]
+	}
+	for i, item := range v {
+		childPath := path + "/" + strconv.Itoa(i)
+		switch {
+		case i < len(prefix):
+			s.validate(prefix[i], item, childPath, errs, depth+1)
+		case items != nil:
+			s.validate(items, item, childPath, errs, depth+1)
+		}
+	}
+}
+
+func (s *Schema) validateString(schema map[string]interface{}, v string, fail func(string, ...interface{})) {
+	length := utf8.RuneCountInString(v)
+	if n, ok := intKeyword(schema, "minLength"); ok && length < n {
+		fail("expected at least %d characters, got %d", n, length)
+	}
+	if n, ok := intKeyword(schema, "maxLength"); ok && length > n {
+		fail("expected at most %d characters, got %d", n, length)
+	}
+	if pattern, ok := schema["pattern"].(string); ok && !s.match(pattern, v) {
+		fail("value does not match pattern %q", pattern)
+	}
+	if format, ok := schema["format"].(string); ok && !validFormat(format, v) {
+		fail("value is not a valid %s", format)
+	}
+}
+
+func validateNumber(schema map[string]interface{}, n json.Number, fail func(string, ...interface{})) {
+	v, err := n.Float64()
+	if err != nil {
+		fail("invalid number %s", n)
+		return
+	}
+	if min, ok := floatKeyword(schema, "minimum"); ok && v < min {
+		fail("value must be >= %v", min)
+	}
+	if max, ok := floatKeyword(schema, "maximum"); ok && v > max {
+		fail("value must be <= %v", max)
+	}
+	if min, ok := floatKeyword(schema, "exclusiveMinimum"); ok && v <= min {
+		fail("value must be > %v", min)
+	}
+	if max, ok := floatKeyword(schema, "exclusiveMaximum"); ok && v >= max {
+		fail("value must be < %v", max)
+	}
+	if m, ok := floatKeyword(schema, "multipleOf"); ok && m > 0 {
+		if q := v / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			fail("value must be a multiple of %v", m)
+		}
+	}
+}
+
+// resolve follows a local JSON pointer reference
+func (s *Schema) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported schema reference %q", ref)
+	}
+	node := s.root
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer == "" {
+		return node, nil
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch current := node.(type) {
+		case map[string]interface{}:
+			next, ok := current[token]
+			if !ok {
+				return nil, fmt.Errorf("unresolved schema reference %q", ref)
+			}
+			node = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(current) {
+				return nil, fmt.Errorf("unresolved schema reference %q", ref)
+			}
+			node = current[i]
+		default:
+			return nil, fmt.Errorf("unresolved schema reference %q", ref)
+		}
+	}
+	return node, nil
+}
+
+func matchesType(t, v interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		return matchesTypeName(t, v)
+	case []interface{}:
+		for _, name := range t {
+			if name, ok := name.(string); ok && matchesTypeName(name, v) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesTypeName(name string, v interface{}) bool {
+	switch name {
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	}
+	return kind(v) == name
+}
+
+func typeNames(t interface{}) string {
+	if list, ok := t.([]interface{}); ok {
+		names := make([]string, len(list))
+		for i, name := range list {
+			names[i] = fmt.Sprint(name)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+// kind returns the JSON type name of a decoded value
+func kind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// equal compares decoded JSON values, numbers by value
+func equal(a, b interface{}) bool {
+	if fa, ok := number(a); ok {
+		fb, ok := number(b)
+		return ok && fa == fb
+	}
+	switch a := a.(type) {
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := b[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func intKeyword(schema map[string]interface{}, key string) (int, bool) {
+	f, ok := floatKeyword(schema, key)
+	return int(f), ok
+}
+
+func floatKeyword(schema map[string]interface{}, key string) (float64, bool) {
+	return number(schema[key])
+}
+
+func compact(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// validFormat checks the common string formats, unknown formats are accepted
+func validFormat(format, v string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", v)
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(v)
+		return err == nil && addr.Address == v
+	case "uri":
+		u, err := url.Parse(v)
+		return err == nil && u.IsAbs()
+	case "uuid":
+		return uuidPattern.MatchString(v)
+	case "ipv4":
+		ip := net.ParseIP(v)
+		return ip != nil && ip.To4() != nil && !strings.Contains(v, ":")
+	case "ipv6":
+		ip := net.ParseIP(v)
+		return ip != nil && strings.Contains(v, ":")
+	}
+	return true
+}
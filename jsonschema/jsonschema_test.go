@@ -0,0 +1,136 @@
+package jsonschema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const pokemonSchema = `{
+	"$defs": {
+		"type": {"type": "string", "enum": ["electric", "fire", "water"]}
+	},
+	"type": "object",
+	"required": ["id", "name"],
+	"additionalProperties": false,
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"name": {"type": "string", "minLength": 2, "pattern": "^[a-z-]+$"},
+		"weight": {"type": "number", "exclusiveMinimum": 0, "multipleOf": 0.1},
+		"types": {"type": "array", "items": {"$ref": "#/$defs/type"}, "minItems": 1, "uniqueItems": true},
+		"sprite": {"type": ["string", "null"], "format": "uri"},
+		"caught": {"type": "string", "format": "date-time"},
+		"stats": {"type": "object", "patternProperties": {"^[a-z]+$": {"type": "integer"}}, "additionalProperties": false},
+		"evolution": {"oneOf": [{"type": "null"}, {"type": "object", "required": ["to"]}]},
+		"form": {"not": {"const": "glitch"}}
+	}
+}`
+
+func TestSchema(t *testing.T) {
+	schema, err := Compile([]byte(pokemonSchema))
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		document string
+		errors   []string
+	}{
+		{
+			name:     "valid",
+			document: `{"id": 25, "name": "pikachu", "weight": 6.0, "types": ["electric"], "sprite": null, "caught": "2024-05-01T10:00:00Z", "stats": {"speed": 90}, "evolution": {"to": "raichu"}, "form": "normal"}`,
+		},
+		{
+			name:     "missing and wrong types",
+			document: `{"id": 2.5, "extra": true}`,
+			errors:   []string{"/id: expected integer, got number", "/name: required property missing", "/extra: additional property not allowed"},
+		},
+		{
+			name:     "string keywords",
+			document: `{"id": 1, "name": "P", "sprite": "not a uri", "caught": "yesterday"}`,
+			errors:   []string{"/name: expected at least 2 characters, got 1", `/name: value does not match pattern "^[a-z-]+$"`, "/sprite: value is not a valid uri", "/caught: value is not a valid date-time"},
+		},
+		{
+			name:     "numbers",
+			document: `{"id": 0, "name": "mew", "weight": 0.25}`,
+			errors:   []string{"/id: value must be >= 1", "/weight: value must be a multiple of 0.1"},
+		},
+		{
+			name:     "arrays and references",
+			document: `{"id": 1, "name": "mew", "types": ["psychic", "psychic"]}`,
+			errors:   []string{"/types: items 0 and 1 are equal", `/types/0: value must be one of ["electric","fire","water"]`, `/types/1: value must be one of ["electric","fire","water"]`},
+		},
+		{
+			name:     "combinators and pattern properties",
+			document: `{"id": 1, "name": "mew", "stats": {"Speed": 1, "hp": "high"}, "evolution": {}, "form": "glitch"}`,
+			errors:   []string{"/stats/Speed: additional property not allowed", "/stats/hp: expected integer, got string", "/evolution: value must match exactly one schema of oneOf, matched 0", "/form: value must not match the schema of not"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.ValidateJSON([]byte(tt.document))
+			if len(tt.errors) == 0 {
+				if err != nil {
+					t.Fatalf("expected valid document, got %v", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected ValidationError, got %v", err)
+			}
+			for _, expected := range tt.errors {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected %q in %v", expected, err)
+				}
+			}
+			if len(validationErr.Errors) != len(tt.errors) {
+				t.Errorf("expected %d errors, got %d: %v", len(tt.errors), len(validationErr.Errors), err)
+			}
+		})
+	}
+
+	t.Run("go values", func(t *testing.T) {
+		type pokemon struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		}
+		if err := schema.Validate(pokemon{ID: 25, Name: "pikachu"}); err != nil {
+			t.Errorf("expected valid value, got %v", err)
+		}
+		if err := schema.Validate(pokemon{Name: "pikachu"}); err == nil {
+			t.Errorf("expected id 0 to be rejected")
+		}
+	})
+
+	t.Run("invalid schemas", func(t *testing.T) {
+		for _, s := range []string{`[]`, `{"pattern": "("}`, `{"patternProperties": {"(": {}}}`, `{`} {
+			if _, err := Compile([]byte(s)); err == nil {
+				t.Errorf("expected %s to be rejected", s)
+			}
+		}
+		if _, err := Compile([]byte(`{"properties": {"pattern": {"type": "string"}}, "enum": [{"pattern": "("}]}`)); err != nil {
+			t.Errorf("expected properties and enum values named pattern to be accepted, got %v", err)
+		}
+	})
+
+	t.Run("properties named like instance keywords", func(t *testing.T) {
+		schema, err := Compile([]byte(`{"type": "object", "properties": {
+			"default": {"type": "string", "pattern": "^a"},
+			"enum": {"type": "object", "patternProperties": {"^x": {"type": "integer"}}}
+		}, "$defs": {"examples": {"pattern": "^b"}}}`))
+		if err != nil {
+			t.Fatalf("Compile failed: %v", err)
+		}
+		if err := schema.ValidateJSON([]byte(`{"default": "abc", "enum": {"x1": 1}}`)); err != nil {
+			t.Errorf("expected valid document, got %v", err)
+		}
+		if err := schema.ValidateJSON([]byte(`{"default": "b", "enum": {"x1": "one"}}`)); err == nil {
+			t.Error("expected pattern and patternProperties violations")
+		}
+		if _, err := Compile([]byte(`{"properties": {"const": {"pattern": "("}}}`)); err == nil {
+			t.Error("expected invalid pattern under a property named const to be rejected")
+		}
+	})
+}
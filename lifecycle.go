@@ -0,0 +1,94 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// ErrClientClosed is returned for requests sent with a client that was closed
+var ErrClientClosed = errors.New("client closed")
+
+// lifecycle tracks the requests in flight of a client and whether it was closed
+type lifecycle struct {
+	mu       sync.Mutex
+	closed   bool
+	inflight int
+	drained  chan struct{}
+}
+
+// lifecycle returns the lifecycle of c, created on first use
+func (c *Client) lifecycle() *lifecycle {
+	if l, ok := c.life.Load().(*lifecycle); ok {
+		return l
+	}
+	c.life.CompareAndSwap(nil, &lifecycle{})
+	return c.life.Load().(*lifecycle)
+}
+
+// begin registers a request in flight, failing once the client is closed
+func (l *lifecycle) begin() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClientClosed
+	}
+	l.inflight++
+	return nil
+}
+
+// end unregisters a request begun with begin
+func (l *lifecycle) end() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inflight--
+	if l.inflight == 0 && l.drained != nil {
+		close(l.drained)
+		l.drained = nil
+	}
+}
+
+// Shutdown closes the client gracefully: new requests fail with ErrClientClosed, then it waits for the
+// requests in flight, including asynchronous and batched ones, and closes the idle connections of the
+// underlying HTTP client. It returns the context error when ctx is done first, leaving the remaining
+// requests running. Copies made with UpdateDefault have a lifecycle of their own.
+func (c *Client) Shutdown(ctx context.Context) error {
+	l := c.lifecycle()
+	l.mu.Lock()
+	l.closed = true
+	var drained chan struct{}
+	if l.inflight > 0 {
+		if l.drained == nil {
+			l.drained = make(chan struct{})
+		}
+		drained = l.drained
+	}
+	l.mu.Unlock()
+
+	if drained != nil {
+		select {
+		case <-drained:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	c.CloseIdleConnections()
+	return nil
+}
+
+// Close shuts the client down, waiting for the requests in flight, see Shutdown. Background helpers
+// holding the client, such as a queue.Queue, stop once their requests fail with ErrClientClosed.
+func (c *Client) Close() error {
+	return c.Shutdown(context.Background())
+}
+
+// CloseIdleConnections closes the idle keep-alive connections of the underlying HTTP client, without
+// interrupting requests in flight or preventing new ones
+func (c *Client) CloseIdleConnections() {
+	base := c.Client
+	if base == nil {
+		base = http.DefaultClient
+	}
+	base.CloseIdleConnections()
+}
@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_Shutdown(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			<-release
+		}
+		_, _ = w.Write([]byte(`{"name":"pikachu"}`))
+	}))
+	defer server.Close()
+
+	t.Run("drains requests in flight", func(t *testing.T) {
+		client := &Client{}
+		future := GetAsync[map[string]string](context.Background(), client, server.URL+"/slow")
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := client.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected shutdown to wait for the request in flight, got %v", err)
+		}
+		if err := client.Get(context.Background(), server.URL, nil); !errors.Is(err, ErrClientClosed) {
+			t.Errorf("expected ErrClientClosed, got %v", err)
+		}
+
+		close(release)
+		if err := client.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		select {
+		case <-future.Done():
+		default:
+			t.Error("expected Close to return after the request in flight")
+		}
+		if value, err := future.Await(context.Background()); err != nil || value["name"] != "pikachu" {
+			t.Errorf("expected the request in flight to complete, got %v (%v)", value, err)
+		}
+	})
+
+	t.Run("copies", func(t *testing.T) {
+		client := &Client{}
+		_ = client.Close()
+		if err := client.clone().Get(context.Background(), server.URL, nil); err != nil {
+			t.Errorf("expected copies to have a lifecycle of their own, got %v", err)
+		}
+	})
+
+	t.Run("close idle connections", func(t *testing.T) {
+		client := &Client{Client: &http.Client{Transport: &http.Transport{}}}
+		if err := client.Get(context.Background(), server.URL, nil); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		client.CloseIdleConnections()
+		if err := client.Get(context.Background(), server.URL, nil); err != nil {
+			t.Errorf("expected requests after CloseIdleConnections to succeed, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultLoadConcurrency is the number of requests a LoadTest keeps in flight when Concurrency is zero
+const DefaultLoadConcurrency = 10
+
+// LoadTest fires a request at a target rate for a duration through the whole stack of a client, middleware,
+// host profiles and scheduler included, with retries disabled so every failure counts. It is meant for
+// capacity checks of internal APIs:
+//
+//	report, err := (&httpclient.LoadTest{
+//	    Client:   client,
+//	    Method:   "GET",
+//	    URL:      "https://pokedex.staging.internal/pokemon/25",
+//	    Rate:     200,
+//	    Duration: time.Minute,
+//	}).Run(ctx)
+//	fmt.Println(report) // 12000 requests in 1m0s (200.0/s), 0.1% errors, p50 12ms, p90 31ms, p99 84ms, max 210ms
+type LoadTest struct {
+	// Client sends the requests, defaults to Default()
+	Client *Client
+	// Method is the request method, defaults to GET
+	Method string
+	// URL is the request URL, it is required
+	URL string
+	// Body is the request body, sent as Do sends it; io.Reader bodies cannot be sent twice
+	Body interface{}
+	// Options are applied to every request
+	Options []Option
+	// Rate is the number of requests started per second, zero starts them as fast as Concurrency allows
+	Rate float64
+	// Concurrency is the maximum number of requests in flight, defaults to DefaultLoadConcurrency; requests
+	// are started late when it is reached, which the report shows as a lower achieved rate
+	Concurrency int
+	// Duration is the time during which requests are started, it is required
+	Duration time.Duration
+}
+
+// LoadReport summarizes a LoadTest run
+type LoadReport struct {
+	// Requests is the number of requests sent
+	Requests int
+	// Errors is the number of failed requests
+	Errors int
+	// Failures counts failed requests by status code for HTTPErrors, e.g. "503", by category for network
+	// errors, e.g. "timeout", and by the message of their cause otherwise
+	Failures map[string]int
+	// Elapsed is the duration of the run, including the wait for the last responses
+	Elapsed time.Duration
+	// Rate is the achieved number of requests per second
+	Rate float64
+	// P50, P90 and P99 are latency percentiles, Max is the slowest request
+	P50, P90, P99, Max time.Duration
+}
+
+// ErrorRate returns the share in [0, 1] of failed requests
+func (r *LoadReport) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.Requests)
+}
+
+func (r *LoadReport) String() string {
+	return fmt.Sprintf("%d requests in %v (%.1f/s), %.1f%% errors, p50 %v, p90 %v, p99 %v, max %v",
+		r.Requests, r.Elapsed.Round(time.Millisecond), r.Rate, 100*r.ErrorRate(),
+		r.P50.Round(time.Millisecond), r.P90.Round(time.Millisecond), r.P99.Round(time.Millisecond), r.Max.Round(time.Millisecond))
+}
+
+// Run fires requests until Duration elapsed or ctx is done, then waits for the requests in flight
+func (l *LoadTest) Run(ctx context.Context) (*LoadReport, error) {
+	if l.URL == "" || l.Duration <= 0 {
+		return nil, errors.New("load test needs a URL and a duration")
+	}
+	client := l.Client
+	if client == nil {
+		client = Default()
+	}
+	method := l.Method
+	if method == "" {
+		method = "GET"
+	}
+	concurrency := l.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultLoadConcurrency
+	}
+
+	ctx = context.WithValue(ctx, noRetriesKey{}, true)
+	var interval time.Duration
+	if l.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / l.Rate)
+	}
+
+	stats := &loadStats{report: &LoadReport{Failures: map[string]int{}}}
+	slots := make(chan struct{}, concurrency)
+	start := time.Now()
+	deadline := time.NewTimer(l.Duration)
+	defer deadline.Stop()
+
+	for next := start; ; next = next.Add(interval) {
+		if wait := time.Until(next); interval > 0 && wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-deadline.C:
+				timer.Stop()
+				return stats.finish(start), nil
+			case <-ctx.Done():
+				timer.Stop()
+				return stats.finish(start), nil
+			}
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-deadline.C:
+			return stats.finish(start), nil
+		case <-ctx.Done():
+			return stats.finish(start), nil
+		}
+
+		stats.wg.Add(1)
+		go func() {
+			defer stats.wg.Done()
+			defer func() { <-slots }()
+			sent := time.Now()
+			err := client.Do(ctx, method, l.URL, l.Body, nil, l.Options...)
+			stats.add(time.Since(sent), err)
+		}()
+	}
+}
+
+// loadStats collects the outcome of LoadTest requests
+type loadStats struct {
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	latencies []time.Duration
+	report    *LoadReport
+}
+
+func (s *loadStats) add(latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.report.Requests++
+	s.latencies = append(s.latencies, latency)
+	if err != nil {
+		s.report.Errors++
+		s.report.Failures[failureKey(err)]++
+	}
+}
+
+// finish waits for the requests in flight and computes the report
+func (s *loadStats) finish(start time.Time) *LoadReport {
+	s.wg.Wait()
+	report := s.report
+	report.Elapsed = time.Since(start)
+	if report.Elapsed > 0 {
+		report.Rate = float64(report.Requests) / report.Elapsed.Seconds()
+	}
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	report.P50, report.P90, report.P99 = percentile(s.latencies, 50), percentile(s.latencies, 90), percentile(s.latencies, 99)
+	if len(s.latencies) > 0 {
+		report.Max = s.latencies[len(s.latencies)-1]
+	}
+	return report
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// failureKey groups errors by status code for HTTPErrors, by category for network errors and by the message
+// of their cause otherwise, leaving out the attempts and elapsed time of RequestErrors
+func failureKey(err error) string {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return strconv.Itoa(httpErr.StatusCode)
+	}
+	if category := errorCategory(err); category != nil {
+		return category.Error()
+	}
+	var requestErr *RequestError
+	if errors.As(err, &requestErr) && requestErr.Err != nil {
+		return requestErr.Err.Error()
+	}
+	return err.Error()
+}
@@ -0,0 +1,103 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadTest(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every fifth request fails
+		if atomic.AddInt32(&hits, 1)%5 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		time.Sleep(2 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"name":"pikachu"}`))
+	}))
+	defer server.Close()
+
+	t.Run("rate", func(t *testing.T) {
+		atomic.StoreInt32(&hits, 0)
+		// Retries are disabled so every failure counts
+		client := &Client{MaxRetries: 3}
+		report, err := (&LoadTest{Client: client, URL: server.URL, Rate: 100, Duration: 300 * time.Millisecond}).Run(context.Background())
+		if err != nil {
+			t.Fatalf("load test failed: %v", err)
+		}
+		if report.Requests < 20 || report.Requests > 40 {
+			t.Errorf("expected about 30 requests, got %d", report.Requests)
+		}
+		if int(atomic.LoadInt32(&hits)) != report.Requests {
+			t.Errorf("expected %d server hits without retries, got %d", report.Requests, hits)
+		}
+		if report.Errors != report.Requests/5 || report.Failures["503"] != report.Errors {
+			t.Errorf("expected %d 503 errors, got %d (%v)", report.Requests/5, report.Errors, report.Failures)
+		}
+		if report.P50 < 2*time.Millisecond || report.P50 > report.P99 || report.P99 > report.Max {
+			t.Errorf("expected ordered percentiles, got p50 %v, p99 %v, max %v", report.P50, report.P99, report.Max)
+		}
+	})
+
+	t.Run("concurrency", func(t *testing.T) {
+		report, err := (&LoadTest{URL: server.URL, Concurrency: 2, Duration: 100 * time.Millisecond}).Run(context.Background())
+		if err != nil {
+			t.Fatalf("load test failed: %v", err)
+		}
+		// Two requests in flight taking at least 2ms each cap the run at about 100 requests
+		if report.Requests == 0 || report.Requests > 110 {
+			t.Errorf("expected at most about 100 requests, got %d", report.Requests)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := (&LoadTest{URL: server.URL}).Run(context.Background()); err == nil {
+			t.Error("expected error without duration")
+		}
+	})
+}
+
+func TestFailureKey(t *testing.T) {
+	errDecode := errors.New("failed to decode response")
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"http error", &RequestError{Elapsed: time.Millisecond, Err: &HTTPError{StatusCode: http.StatusBadGateway}}, "502"},
+		{"timeout", &RequestError{Elapsed: time.Second, Err: fmt.Errorf("failed to send request: %w", context.DeadlineExceeded)}, "timeout"},
+		{"cause", &RequestError{Attempts: 2, Elapsed: 3 * time.Millisecond, Err: errDecode}, errDecode.Error()},
+		{"other cause", &RequestError{Attempts: 1, Elapsed: 5 * time.Millisecond, Err: errDecode}, errDecode.Error()},
+		{"plain error", errDecode, errDecode.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := failureKey(tt.err); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	if p := percentile(latencies, 50); p != 50*time.Millisecond {
+		t.Errorf("expected p50 50ms, got %v", p)
+	}
+	if p := percentile(latencies, 99); p != 99*time.Millisecond {
+		t.Errorf("expected p99 99ms, got %v", p)
+	}
+	if p := percentile(nil, 90); p != 0 {
+		t.Errorf("expected 0 without latencies, got %v", p)
+	}
+}
@@ -0,0 +1,89 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithFollowLocation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/orders":
+			w.Header().Set("Location", "/orders/42")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":42}`))
+		case r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(`{"id":7}`))
+		case r.URL.Path == "/orders/42":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":42,"status":"pending"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL}
+	ctx := context.Background()
+	type order struct {
+		ID     int
+		Status string
+	}
+
+	t.Run("created", func(t *testing.T) {
+		var created, followed order
+		if err := client.Post(ctx, "/orders", map[string]int{"item": 1}, &created, WithFollowLocation(&followed), WithHeader("Authorization", "Bearer token")); err != nil {
+			t.Fatalf("POST request failed: %v", err)
+		}
+		if created.ID != 42 || followed.Status != "pending" {
+			t.Errorf("unexpected created %+v and followed %+v", created, followed)
+		}
+	})
+
+	t.Run("ok is not followed", func(t *testing.T) {
+		followed := order{Status: "untouched"}
+		if err := client.Post(ctx, "/orders/7/cancel", nil, nil, WithFollowLocation(&followed)); err != nil {
+			t.Fatalf("POST request failed: %v", err)
+		}
+		if followed.Status != "untouched" {
+			t.Errorf("expected untouched result, got %+v", followed)
+		}
+	})
+
+	t.Run("other origin", func(t *testing.T) {
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "" || r.Header.Get("X-Trace") != "abc" {
+				t.Errorf("expected X-Trace without Authorization, got %v", r.Header)
+			}
+			_, _ = w.Write([]byte(`{"id":42,"status":"mirrored"}`))
+		}))
+		defer other.Close()
+		origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Location", other.URL+"/orders/42")
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer origin.Close()
+
+		var followed order
+		err := (&Client{}).Post(ctx, origin.URL+"/orders", nil, nil, WithFollowLocation(&followed),
+			WithHeader("authorization", "Bearer secret"), WithHeader("X-Trace", "abc"))
+		if err != nil {
+			t.Fatalf("POST request failed: %v", err)
+		}
+		if followed.Status != "mirrored" {
+			t.Errorf("expected followed order, got %+v", followed)
+		}
+	})
+
+	t.Run("follow failure", func(t *testing.T) {
+		var followed order
+		if err := client.Post(ctx, "/orders", nil, nil, WithFollowLocation(&followed)); err == nil {
+			t.Error("expected error for unauthorized follow request")
+		}
+	})
+}
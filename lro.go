@@ -0,0 +1,151 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrOperationPending is returned when an Operation is still running after MaxPolls polls
+var ErrOperationPending = errors.New("operation still pending")
+
+// Operation polls a long-running operation started by a request answered with 202 Accepted, decoding
+// every status document into a T until Done reports a terminal state:
+//
+//	op := &httpclient.Operation[Export]{Done: func(export *Export) (bool, error) {
+//	    switch export.State {
+//	    case "succeeded":
+//	        return true, nil
+//	    case "failed":
+//	        return true, errors.New(export.Error)
+//	    }
+//	    return false, nil
+//	}}
+//	export, err := op.Run(ctx, client, "POST", "/exports", request)
+//
+// The status URL is taken from the StatusHeader of the submit response, Location by default, or from the
+// submit response body with StatusURL. Polls are spaced by their Retry-After header, or by Backoff, until
+// the context is done.
+type Operation[T any] struct {
+	// Done reports whether the operation reached a terminal state, and its failure when it did not succeed;
+	// it is required
+	Done func(status *T) (bool, error)
+	// StatusHeader is the header of the submit response holding the status URL, defaults to Location;
+	// Azure APIs use Operation-Location
+	StatusHeader string
+	// StatusURL extracts the status URL from the submit response when it has no StatusHeader, optional
+	StatusURL func(status *T) string
+	// Backoff spaces polls without Retry-After, defaults to ExponentialBackoff{Base: time.Second, Max: 30 * time.Second}
+	Backoff Backoff
+	// MaxPolls is the maximum number of polls, zero polls until the context is done
+	MaxPolls int
+}
+
+// Run sends the submit request and polls the operation with GET requests sent with the same options,
+// returning the terminal status document
+func (o *Operation[T]) Run(ctx context.Context, c *Client, method, url string, body interface{}, opts ...Option) (*T, error) {
+	if c == nil {
+		c = Default()
+	}
+	submit := &operationStatus[T]{client: c, value: new(T)}
+	if err := c.Do(ctx, method, url, body, submit, opts...); err != nil {
+		return nil, err
+	}
+	if done, err := o.Done(submit.value); done || err != nil {
+		return submit.value, err
+	}
+
+	statusURL := submit.location(o.statusHeader())
+	if statusURL == "" && o.StatusURL != nil {
+		statusURL = o.StatusURL(submit.value)
+	}
+	if statusURL == "" {
+		return nil, fmt.Errorf("operation started with status %d has no status URL", submit.statusCode)
+	}
+
+	last := submit
+	for poll := 1; o.MaxPolls <= 0 || poll <= o.MaxPolls; poll++ {
+		delay := retryDelay(o.backoff(), poll, &http.Response{Header: last.header}, nil)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		last = &operationStatus[T]{client: c, value: new(T)}
+		if err := c.Get(ctx, statusURL, last, opts...); err != nil {
+			return nil, fmt.Errorf("failed to poll operation: %w", err)
+		}
+		if done, err := o.Done(last.value); done || err != nil {
+			return last.value, err
+		}
+	}
+	return last.value, fmt.Errorf("%w after %d polls", ErrOperationPending, o.MaxPolls)
+}
+
+func (o *Operation[T]) statusHeader() string {
+	if o.StatusHeader != "" {
+		return o.StatusHeader
+	}
+	return "Location"
+}
+
+func (o *Operation[T]) backoff() Backoff {
+	if o.Backoff != nil {
+		return o.Backoff
+	}
+	return ExponentialBackoff{Base: time.Second, Max: 30 * time.Second}
+}
+
+// operationStatus decodes a status document, keeping the response headers for the status URL and Retry-After
+type operationStatus[T any] struct {
+	client     *Client
+	value      *T
+	statusCode int
+	header     http.Header
+	request    *http.Request
+}
+
+// DecodeResponse implements ResponseDecoder
+func (s *operationStatus[T]) DecodeResponse(resp *http.Response) error {
+	s.statusCode, s.header, s.request = resp.StatusCode, resp.Header, resp.Request
+	data, err := io.ReadAll(resp.Body)
+	if err != nil || len(data) == 0 {
+		return err
+	}
+	return s.client.unmarshal(data, s.value)
+}
+
+// decodeEnvelope implements envelopeDecoder
+func (s *operationStatus[T]) decodeEnvelope(resp *http.Response, envelope string) error {
+	s.statusCode, s.header, s.request = resp.StatusCode, resp.Header, resp.Request
+	data, err := io.ReadAll(resp.Body)
+	if err != nil || len(data) == 0 {
+		return err
+	}
+	if data, err = unwrapEnvelope(data, envelope); err != nil {
+		return err
+	}
+	return s.client.unmarshal(data, s.value)
+}
+
+// location returns the status URL of header, resolved against the request URL
+func (s *operationStatus[T]) location(header string) string {
+	value := s.header.Get(header)
+	if value == "" {
+		return ""
+	}
+	if s.request == nil {
+		return value
+	}
+	u, err := s.request.URL.Parse(value)
+	if err != nil {
+		return value
+	}
+	return u.String()
+}
@@ -0,0 +1,116 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOperation_Run(t *testing.T) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "0")
+		switch r.URL.Path {
+		case "/exports":
+			w.Header().Set("Location", "/exports/1")
+			w.WriteHeader(http.StatusAccepted)
+		case "/jobs":
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"state":"queued","self":"/exports/1"}`))
+		case "/exports/1":
+			if r.Header.Get("X-Tenant") != "acme" {
+				t.Errorf("expected poll with the submit headers")
+			}
+			if atomic.AddInt32(&polls, 1) < 3 {
+				_, _ = w.Write([]byte(`{"state":"running"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"state":"succeeded","file":"export.csv"}`))
+		case "/failing":
+			_, _ = w.Write([]byte(`{"state":"failed","error":"disk full"}`))
+		}
+	}))
+	defer server.Close()
+
+	type export struct {
+		State string
+		Self  string
+		File  string
+		Error string
+	}
+	newOperation := func() *Operation[export] {
+		return &Operation[export]{
+			Done: func(e *export) (bool, error) {
+				switch e.State {
+				case "succeeded":
+					return true, nil
+				case "failed":
+					return true, errors.New(e.Error)
+				}
+				return false, nil
+			},
+			StatusURL: func(e *export) string { return e.Self },
+		}
+	}
+	client := &Client{BaseURL: server.URL}
+	ctx := context.Background()
+
+	t.Run("location", func(t *testing.T) {
+		atomic.StoreInt32(&polls, 0)
+		result, err := newOperation().Run(ctx, client, http.MethodPost, "/exports", map[string]string{"format": "csv"}, WithHeader("X-Tenant", "acme"))
+		if err != nil {
+			t.Fatalf("operation failed: %v", err)
+		}
+		if result.File != "export.csv" || atomic.LoadInt32(&polls) != 3 {
+			t.Errorf("unexpected result %+v after %d polls", result, polls)
+		}
+	})
+
+	t.Run("status url from body", func(t *testing.T) {
+		atomic.StoreInt32(&polls, 0)
+		result, err := newOperation().Run(ctx, client, http.MethodPost, "/jobs", nil, WithHeader("X-Tenant", "acme"))
+		if err != nil || result.State != "succeeded" {
+			t.Errorf("expected succeeded operation, got %+v, %v", result, err)
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		_, err := newOperation().Run(ctx, client, http.MethodGet, "/failing", nil)
+		if err == nil || err.Error() != "disk full" {
+			t.Errorf("expected disk full error, got %v", err)
+		}
+	})
+
+	t.Run("max polls", func(t *testing.T) {
+		atomic.StoreInt32(&polls, -10)
+		op := newOperation()
+		op.MaxPolls = 2
+		if _, err := op.Run(ctx, client, http.MethodPost, "/exports", nil, WithHeader("X-Tenant", "acme")); !errors.Is(err, ErrOperationPending) {
+			t.Errorf("expected ErrOperationPending, got %v", err)
+		}
+	})
+
+	t.Run("context deadline", func(t *testing.T) {
+		atomic.StoreInt32(&polls, -10)
+		op := newOperation()
+		op.Backoff = ConstantBackoff(time.Hour)
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		noRetryAfter := &Client{BaseURL: server.URL, Middleware: []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				resp, err := next.RoundTrip(req)
+				if err == nil {
+					resp.Header.Del("Retry-After")
+				}
+				return resp, err
+			})
+		}}}
+		if _, err := op.Run(ctx, noRetryAfter, http.MethodPost, "/exports", nil); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Middleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"order":"` + r.Header.Get("X-Order") + `"}`))
+	}))
+	defer server.Close()
+
+	appendOrder := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				req = req.Clone(req.Context())
+				req.Header.Set("X-Order", req.Header.Get("X-Order")+name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	client := &Client{Middleware: []Middleware{appendOrder("a"), appendOrder("b")}}
+	var result map[string]string
+	if err := client.Get(context.Background(), server.URL, &result); err != nil {
+		t.Fatalf("GET request failed: %v", err)
+	}
+
+	if result["order"] != "ab" {
+		t.Errorf("expected middleware order 'ab', got '%s'", result["order"])
+	}
+}
@@ -0,0 +1,184 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// ResponseDecoder is implemented by results decoding the raw response themselves instead of JSON,
+// e.g. multipart or XML bodies. DecodeResponse is called for successful responses only and must
+// consume the body before returning.
+type ResponseDecoder interface {
+	DecodeResponse(resp *http.Response) error
+}
+
+// contentTyper is implemented by request bodies choosing their own Content-Type
+type contentTyper interface {
+	ContentType() string
+}
+
+// RelatedPart is a part of a MultipartRelated body
+type RelatedPart struct {
+	// ContentType defaults to application/json for Value and application/octet-stream for Body
+	ContentType string
+	// Header holds extra part headers such as Content-ID
+	Header textproto.MIMEHeader
+	// Value is marshalled to JSON when Body is nil
+	Value interface{}
+	// Body is streamed as the part content
+	Body io.Reader
+}
+
+// MultipartRelated is a streamed multipart/related request body (RFC 2387), typically JSON metadata
+// followed by binary content as in Google Drive uploads or DICOMweb STOW-RS:
+//
+//	body := httpclient.NewMultipartRelated(
+//	    httpclient.RelatedPart{Value: metadata},
+//	    httpclient.RelatedPart{ContentType: "image/png", Body: file},
+//	)
+//	err := client.Post(ctx, "https://www.googleapis.com/upload/drive/v3/files?uploadType=multipart", body, &created)
+//
+// The Content-Type header, including the boundary and the type of the first part, is set automatically.
+// Like other io.Reader bodies it is sent once and cannot be retried.
+type MultipartRelated struct {
+	parts    []RelatedPart
+	boundary string
+
+	once sync.Once
+	pr   *io.PipeReader
+}
+
+// NewMultipartRelated returns a body made of parts, the first being the root part
+func NewMultipartRelated(parts ...RelatedPart) *MultipartRelated {
+	return &MultipartRelated{parts: parts, boundary: multipart.NewWriter(nil).Boundary()}
+}
+
+// ContentType returns the multipart/related media type with its boundary and root part type
+func (m *MultipartRelated) ContentType() string {
+	params := map[string]string{"boundary": m.boundary}
+	if len(m.parts) > 0 {
+		params["type"] = partContentType(m.parts[0])
+	}
+	return mime.FormatMediaType("multipart/related", params)
+}
+
+// Read streams the encoded parts
+func (m *MultipartRelated) Read(p []byte) (int, error) {
+	m.start()
+	return m.pr.Read(p)
+}
+
+// Close stops the encoding, e.g. when the request fails before the body is sent
+func (m *MultipartRelated) Close() error {
+	m.start()
+	return m.pr.Close()
+}
+
+func (m *MultipartRelated) start() {
+	m.once.Do(func() {
+		pr, pw := io.Pipe()
+		m.pr = pr
+		go func() {
+			pw.CloseWithError(m.write(pw))
+		}()
+	})
+}
+
+func (m *MultipartRelated) write(w io.Writer) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(m.boundary); err != nil {
+		return err
+	}
+	for i, part := range m.parts {
+		header := textproto.MIMEHeader{}
+		for key, values := range part.Header {
+			header[key] = values
+		}
+		header.Set("Content-Type", partContentType(part))
+		pw, err := mw.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if part.Body != nil {
+			_, err = io.Copy(pw, part.Body)
+		} else {
+			err = json.NewEncoder(pw).Encode(part.Value)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write part %d: %w", i, err)
+		}
+	}
+	return mw.Close()
+}
+
+func partContentType(part RelatedPart) string {
+	switch {
+	case part.ContentType != "":
+		return part.ContentType
+	case part.Body != nil:
+		return "application/octet-stream"
+	}
+	return "application/json"
+}
+
+// MultipartRelatedResponse decodes a multipart/related response when passed as result: the root part,
+// the first one unless the start parameter names another Content-ID, is decoded as JSON into Root and
+// every other part is streamed to OnPart in order.
+//
+//	result := &httpclient.MultipartRelatedResponse{Root: &metadata, OnPart: func(part *multipart.Part) error {
+//	    _, err := io.Copy(file, part)
+//	    return err
+//	}}
+//	err := client.Get(ctx, url, result)
+type MultipartRelatedResponse struct {
+	// Root receives the JSON root part, nil skips it
+	Root interface{}
+	// OnPart receives the other parts, nil discards them
+	OnPart func(part *multipart.Part) error
+}
+
+// DecodeResponse implements ResponseDecoder
+func (r *MultipartRelatedResponse) DecodeResponse(resp *http.Response) error {
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("failed to parse content type: %w", err)
+	}
+	if mediaType != "multipart/related" || params["boundary"] == "" {
+		return fmt.Errorf("expected multipart/related content, got %s", mediaType)
+	}
+
+	start := strings.Trim(params["start"], "<>")
+	reader := multipart.NewReader(resp.Body, params["boundary"])
+	for first := true; ; first = false {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read part: %w", err)
+		}
+
+		isRoot := first && start == ""
+		if start != "" {
+			isRoot = strings.Trim(part.Header.Get("Content-ID"), "<>") == start
+		}
+		switch {
+		case isRoot && r.Root != nil:
+			err = json.NewDecoder(part).Decode(r.Root)
+		case !isRoot && r.OnPart != nil:
+			err = r.OnPart(part)
+		}
+		if err != nil {
+			_ = part.Close()
+			return fmt.Errorf("failed to decode part: %w", err)
+		}
+		_ = part.Close()
+	}
+}
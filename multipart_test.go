@@ -0,0 +1,96 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func TestMultipartRelated(t *testing.T) {
+	type metadata struct {
+		Name string `json:"name"`
+	}
+	image := bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 1024)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "multipart/related" || params["type"] != "application/json" {
+			t.Errorf("unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		// Echo the parts back, the binary part first and the root part selected with start
+		mw := multipart.NewWriter(w)
+		w.Header().Set("Content-Type", mime.FormatMediaType("multipart/related", map[string]string{"boundary": mw.Boundary(), "start": "<meta>"}))
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		var parts [][2]string
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			data, _ := io.ReadAll(part)
+			parts = append(parts, [2]string{part.Header.Get("Content-Type"), string(data)})
+		}
+		if len(parts) != 2 {
+			t.Errorf("expected 2 parts, got %d", len(parts))
+			return
+		}
+		pw, _ := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {parts[1][0]}})
+		_, _ = pw.Write([]byte(parts[1][1]))
+		pw, _ = mw.CreatePart(textproto.MIMEHeader{"Content-Type": {parts[0][0]}, "Content-Id": {"<meta>"}})
+		_, _ = pw.Write([]byte(parts[0][1]))
+		_ = mw.Close()
+	}))
+	defer server.Close()
+
+	body := NewMultipartRelated(
+		RelatedPart{Value: metadata{Name: "pikachu.png"}},
+		RelatedPart{ContentType: "image/png", Body: bytes.NewReader(image)},
+	)
+	var root metadata
+	var received []byte
+	var contentType string
+	result := &MultipartRelatedResponse{Root: &root, OnPart: func(part *multipart.Part) error {
+		contentType = part.Header.Get("Content-Type")
+		var err error
+		received, err = io.ReadAll(part)
+		return err
+	}}
+	if err := (&Client{}).Post(context.Background(), server.URL, body, result); err != nil {
+		t.Fatalf("Post failed: %v", err)
+	}
+	if root.Name != "pikachu.png" {
+		t.Errorf("expected root part, got %+v", root)
+	}
+	if contentType != "image/png" || !bytes.Equal(received, image) {
+		t.Errorf("expected image part, got %s with %d bytes", contentType, len(received))
+	}
+
+	t.Run("not multipart", func(t *testing.T) {
+		plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer plain.Close()
+		err := (&Client{}).Get(context.Background(), plain.URL, &MultipartRelatedResponse{})
+		if err == nil || !strings.Contains(err.Error(), "expected multipart/related") {
+			t.Errorf("expected content type error, got %v", err)
+		}
+	})
+
+	t.Run("closed before sending", func(t *testing.T) {
+		body := NewMultipartRelated(RelatedPart{Body: strings.NewReader("data")})
+		if err := body.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		if _, err := body.Read(make([]byte, 1)); err == nil {
+			t.Errorf("expected reads to fail after Close")
+		}
+	})
+}
@@ -0,0 +1,157 @@
+// Package negotiate authenticates requests with SPNEGO (HTTP Negotiate, RFC 4559), as intranet APIs behind
+// Active Directory or Kerberos expect, without shelling out to curl --negotiate.
+//
+// The package handles the SPNEGO framing and the challenge exchange while a Provider supplies the tokens of
+// the underlying mechanism, usually Kerberos through a GSSAPI or SSPI binding or a pure Go Kerberos library:
+//
+//	auth := negotiate.New(negotiate.ProviderFunc(func(ctx context.Context, spn string) (negotiate.SecurityContext, error) {
+//	    return newKerberosContext(krbClient, spn) // e.g. wrapping gokrb5's spnego.KRB5Token
+//	}))
+//	client := &httpclient.Client{Middleware: []httpclient.Middleware{httpclient.ChallengeMiddleware(auth)}}
+package negotiate
+
+import (
+	"context"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+// ErrRejected is returned when the server rejects the security context
+var ErrRejected = errors.New("negotiate: rejected by server")
+
+// exchangeTTL bounds how long an unfinished exchange is kept when the server stops answering
+const exchangeTTL = time.Minute
+
+// SecurityContext is a security context being established with a service, as with gss_init_sec_context
+type SecurityContext interface {
+	// Mechanism returns the mechanism OID, e.g. OIDKerberos5
+	Mechanism() asn1.ObjectIdentifier
+	// Step returns the next token from the token of the server, nil on the first call, and whether the
+	// context is established on the client side, as GSS_S_COMPLETE would report
+	Step(input []byte) (token []byte, done bool, err error)
+}
+
+// Provider starts security contexts for service principal names such as "HTTP@intranet.example.com"
+type Provider interface {
+	NewContext(ctx context.Context, spn string) (SecurityContext, error)
+}
+
+// ProviderFunc adapts a function to the Provider interface
+type ProviderFunc func(ctx context.Context, spn string) (SecurityContext, error)
+
+// NewContext calls f(ctx, spn)
+func (f ProviderFunc) NewContext(ctx context.Context, spn string) (SecurityContext, error) {
+	return f(ctx, spn)
+}
+
+// Authenticator is an httpclient.Authenticator answering Negotiate challenges with SPNEGO tokens
+type Authenticator struct {
+	// Provider supplies the mechanism tokens
+	Provider Provider
+	// SPN returns the service principal name of a request, defaults to "HTTP@" followed by the host name
+	SPN func(req *http.Request) string
+
+	// exchanges holds the contexts of requests awaiting another challenge
+	mu        sync.Mutex
+	exchanges map[*http.Request]*exchange
+}
+
+type exchange struct {
+	sc      SecurityContext
+	started time.Time
+}
+
+// New returns an Authenticator using provider
+func New(provider Provider) *Authenticator {
+	return &Authenticator{Provider: provider}
+}
+
+// Scheme returns "Negotiate"
+func (a *Authenticator) Scheme() string {
+	return "Negotiate"
+}
+
+// Authenticate starts a security context on the first challenge and continues it with the token of later ones
+func (a *Authenticator) Authenticate(req *http.Request, challenge httpclient.Challenge) (string, error) {
+	var token []byte
+	var err error
+	if challenge.Token68 == "" {
+		token, err = a.start(req)
+	} else {
+		token, err = a.step(req, challenge.Token68)
+	}
+	if err != nil {
+		return "", err
+	}
+	return "Negotiate " + base64.StdEncoding.EncodeToString(token), nil
+}
+
+func (a *Authenticator) start(req *http.Request) ([]byte, error) {
+	spn := "HTTP@" + req.URL.Hostname()
+	if a.SPN != nil {
+		spn = a.SPN(req)
+	}
+	sc, err := a.Provider.NewContext(req.Context(), spn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start security context for %s: %w", spn, err)
+	}
+	token, done, err := sc.Step(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start security context for %s: %w", spn, err)
+	}
+	if !done {
+		a.save(req, &exchange{sc: sc, started: time.Now()})
+	}
+	return marshalInit(sc.Mechanism(), token)
+}
+
+func (a *Authenticator) save(req *http.Request, e *exchange) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.exchanges == nil {
+		a.exchanges = make(map[*http.Request]*exchange)
+	}
+	for r, other := range a.exchanges {
+		if time.Since(other.started) > exchangeTTL {
+			delete(a.exchanges, r)
+		}
+	}
+	a.exchanges[req] = e
+}
+
+func (a *Authenticator) step(req *http.Request, token68 string) ([]byte, error) {
+	a.mu.Lock()
+	e := a.exchanges[req]
+	delete(a.exchanges, req)
+	a.mu.Unlock()
+	if e == nil {
+		return nil, errors.New("negotiate: no security context in progress")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(token68)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode negotiate token: %w", err)
+	}
+	resp, err := unmarshalResp(data)
+	if err != nil {
+		return nil, err
+	}
+	if resp.NegState == stateReject {
+		return nil, ErrRejected
+	}
+	token, done, err := e.sc.Step(resp.ResponseToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to continue security context: %w", err)
+	}
+	if !done {
+		a.save(req, e)
+	}
+	return marshalResp(token)
+}
@@ -0,0 +1,145 @@
+package negotiate
+
+import (
+	"context"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+// fakeContext answers server tokens prefixed with its SPN, finishing after legs steps
+type fakeContext struct {
+	spn   string
+	steps int
+	legs  int
+}
+
+func (c *fakeContext) Mechanism() asn1.ObjectIdentifier {
+	return OIDKerberos5
+}
+
+func (c *fakeContext) Step(input []byte) ([]byte, bool, error) {
+	c.steps++
+	return []byte(c.spn + ":" + string(input)), c.steps >= c.legs, nil
+}
+
+// parseInit returns the mechanism and token of an InitialContextToken
+func parseInit(t *testing.T, header string) (asn1.ObjectIdentifier, []byte) {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(header, "Negotiate "))
+	if err != nil {
+		t.Fatalf("decoding token failed: %v", err)
+	}
+	var app asn1.RawValue
+	if _, err := asn1.Unmarshal(data, &app); err != nil || app.Class != asn1.ClassApplication {
+		t.Fatalf("expected InitialContextToken, got %v", err)
+	}
+	var oid asn1.ObjectIdentifier
+	rest, err := asn1.Unmarshal(app.Bytes, &oid)
+	if err != nil || !oid.Equal(OIDSPNEGO) {
+		t.Fatalf("expected SPNEGO OID, got %v (%v)", oid, err)
+	}
+	var choice asn1.RawValue
+	if _, err := asn1.Unmarshal(rest, &choice); err != nil || choice.Tag != 0 {
+		t.Fatalf("expected NegTokenInit, got %v", err)
+	}
+	var init negTokenInit
+	if _, err := asn1.Unmarshal(choice.Bytes, &init); err != nil {
+		t.Fatalf("parsing NegTokenInit failed: %v", err)
+	}
+	return init.MechTypes[0], init.MechToken
+}
+
+func TestAuthenticator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("single leg", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth := r.Header.Get("Authorization")
+			if auth == "" {
+				w.Header().Set("WWW-Authenticate", "Negotiate")
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			mech, token := parseInit(t, auth)
+			if !mech.Equal(OIDKerberos5) || string(token) != "HTTP@127.0.0.1:" {
+				t.Errorf("unexpected mechanism %v and token %q", mech, token)
+			}
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer server.Close()
+
+		auth := New(ProviderFunc(func(ctx context.Context, spn string) (SecurityContext, error) {
+			return &fakeContext{spn: spn, legs: 1}, nil
+		}))
+		client := &httpclient.Client{Middleware: []httpclient.Middleware{httpclient.ChallengeMiddleware(auth)}}
+		if err := client.Get(ctx, server.URL, nil); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if len(auth.exchanges) != 0 {
+			t.Errorf("expected finished exchanges to be dropped, got %d", len(auth.exchanges))
+		}
+	})
+
+	t.Run("continuation", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth := r.Header.Get("Authorization")
+			data, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(auth, "Negotiate "))
+			switch {
+			case auth == "":
+				w.Header().Set("WWW-Authenticate", "Negotiate")
+			case data[0] == 0x60: // InitialContextToken
+				resp, _ := marshalResp([]byte("challenge"))
+				w.Header().Set("WWW-Authenticate", "Negotiate "+base64.StdEncoding.EncodeToString(resp))
+			default:
+				resp, err := unmarshalResp(data)
+				if err != nil || string(resp.ResponseToken) != "svc:challenge" {
+					t.Errorf("unexpected response token %+v (%v)", resp, err)
+				}
+				_, _ = w.Write([]byte(`{}`))
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		auth := &Authenticator{
+			Provider: ProviderFunc(func(ctx context.Context, spn string) (SecurityContext, error) {
+				return &fakeContext{spn: spn, legs: 2}, nil
+			}),
+			SPN: func(*http.Request) string { return "svc" },
+		}
+		client := &httpclient.Client{Middleware: []httpclient.Middleware{httpclient.ChallengeMiddleware(auth)}}
+		if err := client.Get(ctx, server.URL, nil); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				w.Header().Set("WWW-Authenticate", "Negotiate")
+			} else {
+				resp, _ := asn1.Marshal(negTokenResp{NegState: stateReject})
+				token, _ := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: resp})
+				w.Header().Set("WWW-Authenticate", "Negotiate "+base64.StdEncoding.EncodeToString(token))
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		auth := New(ProviderFunc(func(ctx context.Context, spn string) (SecurityContext, error) {
+			return &fakeContext{spn: spn, legs: 2}, nil
+		}))
+		client := &httpclient.Client{Middleware: []httpclient.Middleware{httpclient.ChallengeMiddleware(auth)}}
+		if err := client.Get(ctx, server.URL, nil); !errors.Is(err, ErrRejected) {
+			t.Errorf("expected ErrRejected, got %v", err)
+		}
+	})
+}
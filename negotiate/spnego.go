@@ -0,0 +1,77 @@
+package negotiate
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+var (
+	// OIDSPNEGO identifies the SPNEGO pseudo-mechanism
+	OIDSPNEGO = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 2}
+	// OIDKerberos5 identifies the Kerberos 5 mechanism
+	OIDKerberos5 = asn1.ObjectIdentifier{1, 2, 840, 113554, 1, 2, 2}
+	// OIDMSKerberos5 identifies the Kerberos 5 mechanism as advertised by older Windows servers
+	OIDMSKerberos5 = asn1.ObjectIdentifier{1, 2, 840, 48018, 1, 2, 2}
+	// OIDNTLMSSP identifies the NTLM mechanism
+	OIDNTLMSSP = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 2, 2, 10}
+)
+
+// stateReject is the negotiation state of a NegTokenResp rejecting the context, see RFC 4178
+const stateReject = 2
+
+// negTokenInit is the first SPNEGO token, offering mechanisms with an optimistic token of the first one
+type negTokenInit struct {
+	MechTypes []asn1.ObjectIdentifier `asn1:"explicit,tag:0"`
+	MechToken []byte                  `asn1:"explicit,optional,tag:2"`
+}
+
+// negTokenResp carries the following tokens of the exchange
+type negTokenResp struct {
+	NegState      asn1.Enumerated       `asn1:"explicit,optional,default:-1,tag:0"`
+	SupportedMech asn1.ObjectIdentifier `asn1:"explicit,optional,tag:1"`
+	ResponseToken []byte                `asn1:"explicit,optional,tag:2"`
+	MechListMIC   []byte                `asn1:"explicit,optional,tag:3"`
+}
+
+// marshalInit wraps the first mechanism token in a GSS-API InitialContextToken holding a NegTokenInit
+func marshalInit(mech asn1.ObjectIdentifier, token []byte) ([]byte, error) {
+	init, err := asn1.Marshal(negTokenInit{MechTypes: []asn1.ObjectIdentifier{mech}, MechToken: token})
+	if err != nil {
+		return nil, err
+	}
+	choice, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: init})
+	if err != nil {
+		return nil, err
+	}
+	oid, err := asn1.Marshal(OIDSPNEGO)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(asn1.RawValue{Class: asn1.ClassApplication, Tag: 0, IsCompound: true, Bytes: append(oid, choice...)})
+}
+
+// marshalResp wraps a following mechanism token in a NegTokenResp
+func marshalResp(token []byte) ([]byte, error) {
+	resp, err := asn1.Marshal(negTokenResp{NegState: -1, ResponseToken: token})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: resp})
+}
+
+// unmarshalResp parses the NegTokenResp sent by the server
+func unmarshalResp(data []byte) (*negTokenResp, error) {
+	var choice asn1.RawValue
+	if _, err := asn1.Unmarshal(data, &choice); err != nil {
+		return nil, fmt.Errorf("failed to parse SPNEGO token: %w", err)
+	}
+	if choice.Class != asn1.ClassContextSpecific || choice.Tag != 1 {
+		return nil, errors.New("failed to parse SPNEGO token: expected NegTokenResp")
+	}
+	var resp negTokenResp
+	if _, err := asn1.Unmarshal(choice.Bytes, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse SPNEGO token: %w", err)
+	}
+	return &resp, nil
+}
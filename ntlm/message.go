@@ -0,0 +1,160 @@
+package ntlm
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"unicode/utf16"
+)
+
+// Negotiate flags from MS-NLMP section 2.2.2.5
+const (
+	flagUnicode                 = 0x00000001
+	flagOEM                     = 0x00000002
+	flagRequestTarget           = 0x00000004
+	flagNTLM                    = 0x00000200
+	flagAlwaysSign              = 0x00008000
+	flagExtendedSessionSecurity = 0x00080000
+	flagTargetInfo              = 0x00800000
+	flagVersion                 = 0x02000000
+	flag128                     = 0x20000000
+	flagKeyExchange             = 0x40000000
+	flag56                      = 0x80000000
+
+	negotiateFlags uint32 = flagUnicode | flagOEM | flagRequestTarget | flagNTLM | flagAlwaysSign |
+		flagExtendedSessionSecurity | flag128 | flag56
+)
+
+// avTimestamp is the AV_PAIR id of the server time in the target info
+const avTimestamp = 7
+
+var signature = []byte("NTLMSSP\x00")
+
+// challengeMessage holds the fields of a CHALLENGE_MESSAGE used to answer it
+type challengeMessage struct {
+	flags      uint32
+	challenge  [8]byte
+	targetInfo []byte
+}
+
+// negotiateMessage returns the NEGOTIATE_MESSAGE opening the handshake
+func negotiateMessage() []byte {
+	msg := make([]byte, 32)
+	copy(msg, signature)
+	binary.LittleEndian.PutUint32(msg[8:], 1)
+	binary.LittleEndian.PutUint32(msg[12:], negotiateFlags)
+	// Empty domain and workstation fields pointing at the end of the message
+	binary.LittleEndian.PutUint32(msg[20:], 32)
+	binary.LittleEndian.PutUint32(msg[28:], 32)
+	return msg
+}
+
+// parseChallenge parses the CHALLENGE_MESSAGE sent by the server
+func parseChallenge(msg []byte) (*challengeMessage, error) {
+	if len(msg) < 32 || !bytes.Equal(msg[:8], signature) || binary.LittleEndian.Uint32(msg[8:]) != 2 {
+		return nil, errors.New("ntlm: invalid challenge message")
+	}
+	c := &challengeMessage{flags: binary.LittleEndian.Uint32(msg[20:])}
+	copy(c.challenge[:], msg[24:32])
+	if c.flags&flagTargetInfo != 0 && len(msg) >= 48 {
+		length := int(binary.LittleEndian.Uint16(msg[40:]))
+		offset := int(binary.LittleEndian.Uint32(msg[44:]))
+		if offset+length > len(msg) {
+			return nil, errors.New("ntlm: invalid target info")
+		}
+		c.targetInfo = msg[offset : offset+length]
+	}
+	return c, nil
+}
+
+// timestamp returns the server time from the target info, if any
+func (c *challengeMessage) timestamp() ([]byte, bool) {
+	info := c.targetInfo
+	for len(info) >= 4 {
+		id := binary.LittleEndian.Uint16(info)
+		length := int(binary.LittleEndian.Uint16(info[2:]))
+		if len(info) < 4+length {
+			break
+		}
+		if id == avTimestamp && length == 8 {
+			return info[4:12], true
+		}
+		info = info[4+length:]
+	}
+	return nil, false
+}
+
+// ntowfv2 derives the NTLMv2 response key from the credentials
+func ntowfv2(user, password, domain string) []byte {
+	mac := hmac.New(md5.New, md4(encodeUTF16(password)))
+	mac.Write(encodeUTF16(strings.ToUpper(user) + domain))
+	return mac.Sum(nil)
+}
+
+func hmacMD5(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(md5.New, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+	return mac.Sum(nil)
+}
+
+// responses computes the NTLMv2 and LMv2 responses to the server challenge, time being a FILETIME
+func responses(key []byte, c *challengeMessage, clientChallenge, time []byte) (nt, lm []byte) {
+	var temp bytes.Buffer
+	temp.Write([]byte{1, 1, 0, 0, 0, 0, 0, 0})
+	temp.Write(time)
+	temp.Write(clientChallenge)
+	temp.Write([]byte{0, 0, 0, 0})
+	temp.Write(c.targetInfo)
+	temp.Write([]byte{0, 0, 0, 0})
+
+	proof := hmacMD5(key, c.challenge[:], temp.Bytes())
+	nt = append(proof, temp.Bytes()...)
+	lm = append(hmacMD5(key, c.challenge[:], clientChallenge), clientChallenge...)
+	return nt, lm
+}
+
+// authenticateMessage returns the AUTHENTICATE_MESSAGE answering the challenge
+func authenticateMessage(c *challengeMessage, nt, lm []byte, user, domain, workstation string) []byte {
+	flags := c.flags &^ (flagKeyExchange | flagVersion)
+	encode := func(s string) []byte {
+		if flags&flagUnicode != 0 {
+			return encodeUTF16(s)
+		}
+		return []byte(s)
+	}
+
+	const headerLen = 64
+	header := make([]byte, headerLen)
+	copy(header, signature)
+	binary.LittleEndian.PutUint32(header[8:], 3)
+	binary.LittleEndian.PutUint32(header[60:], flags)
+
+	var payload []byte
+	field := func(at int, value []byte) {
+		binary.LittleEndian.PutUint16(header[at:], uint16(len(value)))
+		binary.LittleEndian.PutUint16(header[at+2:], uint16(len(value)))
+		binary.LittleEndian.PutUint32(header[at+4:], uint32(headerLen+len(payload)))
+		payload = append(payload, value...)
+	}
+	field(12, lm)
+	field(20, nt)
+	field(28, encode(domain))
+	field(36, encode(user))
+	field(44, encode(workstation))
+	field(52, nil)
+	return append(header, payload...)
+}
+
+func encodeUTF16(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(b[2*i:], u)
+	}
+	return b
+}
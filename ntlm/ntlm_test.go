@@ -0,0 +1,128 @@
+package ntlm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+func TestMD4(t *testing.T) {
+	// Test suite from RFC 1320
+	tests := map[string]string{
+		"":               "31d6cfe0d16ae931b73c59d7e0c089c0",
+		"abc":            "a448017aaf21d8525fc10ae87aa6729d",
+		"message digest": "d9130a8164549fe818874806e1c7014b",
+		"12345678901234567890123456789012345678901234567890123456789012345678901234567890": "e33b4ddc9c38f2199c3e7b164fcc0536",
+	}
+	for input, want := range tests {
+		if got := hex.EncodeToString(md4([]byte(input))); got != want {
+			t.Errorf("md4(%q): expected %s, got %s", input, want, got)
+		}
+	}
+}
+
+// targetInfo holds the AV pairs of the MS-NLMP examples, NetBIOS domain "Domain" and computer "Server"
+func targetInfo() []byte {
+	var b bytes.Buffer
+	for _, pair := range []struct {
+		id    uint16
+		value string
+	}{{2, "Domain"}, {1, "Server"}} {
+		value := encodeUTF16(pair.value)
+		_ = binary.Write(&b, binary.LittleEndian, [2]uint16{pair.id, uint16(len(value))})
+		b.Write(value)
+	}
+	b.Write([]byte{0, 0, 0, 0})
+	return b.Bytes()
+}
+
+func TestResponses(t *testing.T) {
+	// NTLMv2 example from MS-NLMP section 4.2.4
+	key := ntowfv2("User", "Password", "Domain")
+	if got := hex.EncodeToString(key); got != "0c868a403bfd7a93a3001ef22ef02e3f" {
+		t.Errorf("expected NTOWFv2 0c868a403bfd7a93a3001ef22ef02e3f, got %s", got)
+	}
+
+	c := &challengeMessage{targetInfo: targetInfo()}
+	copy(c.challenge[:], []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef})
+	nt, lm := responses(key, c, bytes.Repeat([]byte{0xaa}, 8), make([]byte, 8))
+	if got := hex.EncodeToString(nt[:16]); got != "68cd0ab851e51c96aabc927bebef6a1c" {
+		t.Errorf("expected NTProofStr 68cd0ab851e51c96aabc927bebef6a1c, got %s", got)
+	}
+	if got := hex.EncodeToString(lm); got != "86c35097ac9cec102554764a57cccc19aaaaaaaaaaaaaaaa" {
+		t.Errorf("expected LMv2 response 86c35097ac9cec102554764a57cccc19aaaaaaaaaaaaaaaa, got %s", got)
+	}
+}
+
+// challengeFor returns a CHALLENGE_MESSAGE with the given server challenge and the example target info
+func challengeFor(serverChallenge []byte) []byte {
+	info := targetInfo()
+	msg := make([]byte, 48)
+	copy(msg, signature)
+	binary.LittleEndian.PutUint32(msg[8:], 2)
+	binary.LittleEndian.PutUint32(msg[20:], negotiateFlags|flagTargetInfo)
+	copy(msg[24:], serverChallenge)
+	binary.LittleEndian.PutUint16(msg[40:], uint16(len(info)))
+	binary.LittleEndian.PutUint16(msg[42:], uint16(len(info)))
+	binary.LittleEndian.PutUint32(msg[44:], 48)
+	return append(msg, info...)
+}
+
+// field returns a payload field of an AUTHENTICATE_MESSAGE
+func field(msg []byte, at int) []byte {
+	length := int(binary.LittleEndian.Uint16(msg[at:]))
+	offset := int(binary.LittleEndian.Uint32(msg[at+4:]))
+	return msg[offset : offset+length]
+}
+
+func TestAuthenticator(t *testing.T) {
+	serverChallenge := []byte("pikachu!")
+	key := ntowfv2("Ash", "Pikachu", "KANTO")
+
+	var attempts, connections int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		data, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(r.Header.Get("Authorization"), "NTLM "))
+		switch {
+		case len(data) < 12:
+			w.Header().Set("WWW-Authenticate", "NTLM")
+		case binary.LittleEndian.Uint32(data[8:]) == 1:
+			w.Header().Set("WWW-Authenticate", "NTLM "+base64.StdEncoding.EncodeToString(challengeFor(serverChallenge)))
+		default:
+			nt := field(data, 20)
+			proof := hmacMD5(key, serverChallenge, nt[16:])
+			if !bytes.Equal(proof, nt[:16]) || !bytes.Equal(field(data, 36), encodeUTF16("Ash")) {
+				t.Error("expected a valid NTLMv2 response for Ash")
+			}
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	auth := &Authenticator{Username: `KANTO\Ash`, Password: "Pikachu"}
+	client := &httpclient.Client{Middleware: []httpclient.Middleware{httpclient.ChallengeMiddleware(auth)}}
+	if err := client.Get(context.Background(), server.URL, nil); err != nil {
+		t.Fatalf("GET request failed: %v", err)
+	}
+	if attempts != 3 || connections != 1 {
+		t.Errorf("expected 3 attempts on 1 connection, got %d on %d", attempts, connections)
+	}
+}
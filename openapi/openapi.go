@@ -0,0 +1,216 @@
+// Package openapi loads OpenAPI 3 documents in JSON form.
+//
+// Only the subset of the specification needed to generate and validate clients is modelled:
+// paths, operations, parameters, JSON request and response bodies and component schemas.
+// YAML documents must be converted to JSON first.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Document is the root of an OpenAPI 3 document
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components,omitempty"`
+}
+
+// Info holds the API metadata
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL the API is served from
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Components holds reusable definitions referenced with $ref
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// PathItem holds the operations available on a path
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	Get        *Operation   `json:"get,omitempty"`
+	Put        *Operation   `json:"put,omitempty"`
+	Post       *Operation   `json:"post,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty"`
+	Head       *Operation   `json:"head,omitempty"`
+	Options    *Operation   `json:"options,omitempty"`
+}
+
+// Operation describes a single API call
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses,omitempty"`
+}
+
+// Parameter is a path, query, header or cookie parameter
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema,omitempty"`
+}
+
+// RequestBody describes the payload of an operation
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Response describes a response of an operation
+type Response struct {
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body in a given content type
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Schema is a JSON Schema as used by OpenAPI 3.0
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+// OperationRef is an operation together with its method and path
+type OperationRef struct {
+	Method    string
+	Path      string
+	Operation *Operation
+	// Parameters are the path level parameters merged with the operation parameters
+	Parameters []*Parameter
+}
+
+// Load reads and parses the JSON document at path
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI document: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses a JSON OpenAPI 3 document
+func Parse(data []byte) (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q", doc.OpenAPI)
+	}
+	return &doc, nil
+}
+
+// Operations returns every operation of the document sorted by path and method
+func (d *Document) Operations() []OperationRef {
+	paths := make([]string, 0, len(d.Paths))
+	for path := range d.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var ops []OperationRef
+	for _, path := range paths {
+		item := d.Paths[path]
+		for _, m := range []struct {
+			method string
+			op     *Operation
+		}{
+			{"GET", item.Get}, {"PUT", item.Put}, {"POST", item.Post}, {"DELETE", item.Delete},
+			{"PATCH", item.Patch}, {"HEAD", item.Head}, {"OPTIONS", item.Options},
+		} {
+			if m.op == nil {
+				continue
+			}
+			ops = append(ops, OperationRef{
+				Method:     m.method,
+				Path:       path,
+				Operation:  m.op,
+				Parameters: mergeParameters(item.Parameters, m.op.Parameters),
+			})
+		}
+	}
+	return ops
+}
+
+// Resolve follows a local "#/components/schemas/Name" reference, returning s unchanged when it has no $ref
+func (d *Document) Resolve(s *Schema) (*Schema, error) {
+	for s != nil && s.Ref != "" {
+		name, err := RefName(s.Ref)
+		if err != nil {
+			return nil, err
+		}
+		target, ok := d.Components.Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("unresolved schema reference %q", s.Ref)
+		}
+		s = target
+	}
+	return s, nil
+}
+
+// RefName returns the schema name of a local component reference
+func RefName(ref string) (string, error) {
+	const prefix = "#/components/schemas/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("unsupported schema reference %q", ref)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+// JSONSchema returns the schema of the application/json content, or nil
+func JSONSchema(content map[string]*MediaType) *Schema {
+	for contentType, media := range content {
+		if strings.HasPrefix(contentType, "application/json") && media != nil {
+			return media.Schema
+		}
+	}
+	return nil
+}
+
+// mergeParameters returns operation parameters, inheriting path level parameters not overridden by name and location
+func mergeParameters(pathParams, opParams []*Parameter) []*Parameter {
+	merged := append([]*Parameter{}, opParams...)
+	for _, p := range pathParams {
+		overridden := false
+		for _, o := range opParams {
+			if o.Name == p.Name && o.In == p.In {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
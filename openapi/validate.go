@@ -0,0 +1,385 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/llkhacquan/httpclient"
+	"github.com/llkhacquan/httpclient/jsonschema"
+)
+
+// ViolationError reports a request or response that does not match the document
+type ViolationError struct {
+	// Method and Path identify the operation, Path is the request path when no operation matched
+	Method string
+	Path   string
+	// Part is "request" or "response"
+	Part string
+	Err  error
+}
+
+// Error implements error
+func (e *ViolationError) Error() string {
+	return fmt.Sprintf("OpenAPI violation in %s %s %s: %v", e.Method, e.Path, e.Part, e.Err)
+}
+
+// Unwrap returns the underlying error, e.g. a *jsonschema.ValidationError
+func (e *ViolationError) Unwrap() error {
+	return e.Err
+}
+
+// Validator checks requests and responses against a document at runtime, catching drift between a
+// client and the API specification in development and test builds:
+//
+//	doc, err := openapi.Load("petstore.json")
+//	validator, err := openapi.NewValidator(doc)
+//	client := &httpclient.Client{Middleware: []httpclient.Middleware{validator.Middleware()}}
+//
+// Requests must match a documented operation, with required parameters present, parameter values
+// of the documented type and JSON bodies matching their schemas. Responses must have a documented
+// status code and a JSON body matching its schema.
+type Validator struct {
+	// OnViolation receives violations instead of failing the request, e.g. to log them in production
+	OnViolation func(err *ViolationError)
+
+	basePath   string
+	operations []*validatedOperation
+}
+
+type validatedOperation struct {
+	OperationRef
+	pattern   *regexp.Regexp
+	names     []string
+	params    map[*Parameter]*jsonschema.Schema
+	body      *jsonschema.Schema
+	responses map[string]*jsonschema.Schema
+}
+
+// NewValidator compiles the schemas of every operation of doc
+func NewValidator(doc *Document) (*Validator, error) {
+	components, err := componentsJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+	v := &Validator{}
+	if len(doc.Servers) > 0 {
+		if u, err := url.Parse(doc.Servers[0].URL); err == nil {
+			v.basePath = strings.TrimSuffix(u.Path, "/")
+		}
+	}
+
+	for _, ref := range doc.Operations() {
+		op := &validatedOperation{OperationRef: ref, params: map[*Parameter]*jsonschema.Schema{}, responses: map[string]*jsonschema.Schema{}}
+		op.pattern, op.names = pathPattern(ref.Path)
+		for _, p := range ref.Parameters {
+			if p.Schema != nil {
+				if op.params[p], err = compileSchema(p.Schema, components); err != nil {
+					return nil, fmt.Errorf("%s %s parameter %s: %w", ref.Method, ref.Path, p.Name, err)
+				}
+			}
+		}
+		if ref.Operation.RequestBody != nil {
+			if schema := JSONSchema(ref.Operation.RequestBody.Content); schema != nil {
+				if op.body, err = compileSchema(schema, components); err != nil {
+					return nil, fmt.Errorf("%s %s request body: %w", ref.Method, ref.Path, err)
+				}
+			}
+		}
+		for code, resp := range ref.Operation.Responses {
+			op.responses[code] = nil
+			if resp == nil {
+				continue
+			}
+			if schema := JSONSchema(resp.Content); schema != nil {
+				if op.responses[code], err = compileSchema(schema, components); err != nil {
+					return nil, fmt.Errorf("%s %s response %s: %w", ref.Method, ref.Path, code, err)
+				}
+			}
+		}
+		v.operations = append(v.operations, op)
+	}
+	return v, nil
+}
+
+// componentsJSON returns the component schemas as generic JSON with OpenAPI 3.0 nullable turned into a null type
+func componentsJSON(doc *Document) (interface{}, error) {
+	schemas, err := schemaJSON(doc.Components.Schemas)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"schemas": schemas}, nil
+}
+
+func compileSchema(schema *Schema, components interface{}) (*jsonschema.Schema, error) {
+	s, err := schemaJSON(schema)
+	if err != nil {
+		return nil, err
+	}
+	// Local references point into the components of the document
+	data, err := json.Marshal(map[string]interface{}{"components": components, "allOf": []interface{}{s}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	return jsonschema.Compile(data)
+}
+
+func schemaJSON(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	convertNullable(generic)
+	return generic, nil
+}
+
+func convertNullable(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if nullable, _ := v["nullable"].(bool); nullable {
+			if t, ok := v["type"].(string); ok {
+				v["type"] = []interface{}{t, "null"}
+			}
+			if enum, ok := v["enum"].([]interface{}); ok {
+				v["enum"] = append(enum, nil)
+			}
+		}
+		for _, child := range v {
+			convertNullable(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			convertNullable(child)
+		}
+	}
+}
+
+// pathPattern turns "/pets/{id}" into a regexp capturing the parameters
+func pathPattern(path string) (*regexp.Regexp, []string) {
+	var names []string
+	var b strings.Builder
+	b.WriteString("^")
+	for {
+		start := strings.Index(path, "{")
+		end := strings.Index(path, "}")
+		if start < 0 || end < start {
+			break
+		}
+		b.WriteString(regexp.QuoteMeta(path[:start]))
+		b.WriteString("([^/]+)")
+		names = append(names, path[start+1:end])
+		path = path[end+1:]
+	}
+	b.WriteString(regexp.QuoteMeta(path))
+	b.WriteString("$")
+	return regexp.MustCompile(b.String()), names
+}
+
+// Middleware returns a Middleware validating requests before sending them and responses before returning them
+func (v *Validator) Middleware() httpclient.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			op, err := v.validateRequest(req)
+			if err != nil && v.report(err) {
+				if req.Body != nil {
+					_ = req.Body.Close()
+				}
+				return nil, err
+			}
+
+			resp, rtErr := next.RoundTrip(req)
+			if rtErr != nil || op == nil {
+				return resp, rtErr
+			}
+			if err := v.validateResponse(op, resp); err != nil && v.report(err) {
+				_ = resp.Body.Close()
+				return nil, err
+			}
+			return resp, nil
+		})
+	}
+}
+
+// report hands err to OnViolation and reports whether the request must fail
+func (v *Validator) report(err error) bool {
+	violation, ok := err.(*ViolationError)
+	if !ok || v.OnViolation == nil {
+		return true
+	}
+	v.OnViolation(violation)
+	return false
+}
+
+// ValidateRequest checks req against the document and returns the operation it matched.
+// A request body is read and replaced so it can still be sent.
+func (v *Validator) ValidateRequest(req *http.Request) (*OperationRef, error) {
+	op, err := v.validateRequest(req)
+	if op == nil {
+		return nil, err
+	}
+	return &op.OperationRef, err
+}
+
+func (v *Validator) validateRequest(req *http.Request) (*validatedOperation, error) {
+	path := strings.TrimPrefix(req.URL.Path, v.basePath)
+	op, values := v.match(req.Method, path)
+	if op == nil {
+		return nil, &ViolationError{Method: req.Method, Path: req.URL.Path, Part: "request", Err: fmt.Errorf("no documented operation")}
+	}
+	violation := func(err error) error {
+		return &ViolationError{Method: op.Method, Path: op.Path, Part: "request", Err: err}
+	}
+
+	for _, p := range op.Parameters {
+		var raw string
+		var present bool
+		switch p.In {
+		case "path":
+			raw, present = values[p.Name]
+		case "query":
+			raw, present = req.URL.Query().Get(p.Name), req.URL.Query().Has(p.Name)
+		case "header":
+			raw, present = req.Header.Get(p.Name), req.Header.Get(p.Name) != ""
+		default:
+			continue
+		}
+		if !present {
+			if p.Required {
+				return op, violation(fmt.Errorf("missing required %s parameter %q", p.In, p.Name))
+			}
+			continue
+		}
+		if err := validateParameter(p, raw, op.params[p]); err != nil {
+			return op, violation(err)
+		}
+	}
+
+	rb := op.Operation.RequestBody
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return op, err
+	}
+	if len(body) == 0 {
+		if rb != nil && rb.Required {
+			return op, violation(fmt.Errorf("missing required body"))
+		}
+		return op, nil
+	}
+	if op.body != nil && isJSON(req.Header.Get("Content-Type")) {
+		if err := op.body.ValidateJSON(body); err != nil {
+			return op, violation(fmt.Errorf("body: %w", err))
+		}
+	}
+	return op, nil
+}
+
+func (v *Validator) validateResponse(op *validatedOperation, resp *http.Response) error {
+	violation := func(err error) error {
+		return &ViolationError{Method: op.Method, Path: op.Path, Part: "response", Err: err}
+	}
+
+	code := strconv.Itoa(resp.StatusCode)
+	schema, ok := op.responses[code]
+	if !ok {
+		schema, ok = op.responses[code[:1]+"XX"]
+	}
+	if !ok {
+		schema, ok = op.responses["default"]
+	}
+	if !ok {
+		return violation(fmt.Errorf("undocumented status %d", resp.StatusCode))
+	}
+	if schema == nil || !isJSON(resp.Header.Get("Content-Type")) {
+		return nil
+	}
+
+	body, err := readBody(&resp.Body)
+	if err != nil {
+		return err
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	if err := schema.ValidateJSON(body); err != nil {
+		return violation(fmt.Errorf("status %d body: %w", resp.StatusCode, err))
+	}
+	return nil
+}
+
+func (v *Validator) match(method, path string) (*validatedOperation, map[string]string) {
+	for _, op := range v.operations {
+		if op.Method != method {
+			continue
+		}
+		m := op.pattern.FindStringSubmatch(path)
+		if m == nil {
+			continue
+		}
+		values := make(map[string]string, len(op.names))
+		for i, name := range op.names {
+			values[name], _ = url.PathUnescape(m[i+1])
+		}
+		return op, values
+	}
+	return nil, nil
+}
+
+// validateParameter converts a raw parameter to the documented type and validates it
+func validateParameter(p *Parameter, raw string, schema *jsonschema.Schema) error {
+	if schema == nil {
+		return nil
+	}
+	var value interface{} = raw
+	switch p.Schema.Type {
+	case "integer", "number":
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%s parameter %q: expected %s, got %q", p.In, p.Name, p.Schema.Type, raw)
+		}
+		value = n
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s parameter %q: expected boolean, got %q", p.In, p.Name, raw)
+		}
+		value = b
+	case "array", "object":
+		// Serialization styles are not modelled
+		return nil
+	}
+	if err := schema.Validate(value); err != nil {
+		return fmt.Errorf("%s parameter %q: %w", p.In, p.Name, err)
+	}
+	return nil
+}
+
+// readBody reads *body and replaces it with a reader over the same bytes
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	_ = (*body).Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+func isJSON(contentType string) bool {
+	return contentType == "" || strings.Contains(contentType, "json")
+}
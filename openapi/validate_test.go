@@ -0,0 +1,160 @@
+package openapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+	"github.com/llkhacquan/httpclient/jsonschema"
+)
+
+const petstore = `{
+	"openapi": "3.0.3",
+	"info": {"title": "Petstore", "version": "1.0.0"},
+	"servers": [{"url": "https://petstore.example.com/v1"}],
+	"paths": {
+		"/pets/{petId}": {
+			"parameters": [{"name": "petId", "in": "path", "required": true, "schema": {"type": "integer"}}],
+			"get": {
+				"parameters": [{"name": "fields", "in": "query", "schema": {"type": "string", "enum": ["all", "basic"]}}],
+				"responses": {
+					"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}},
+					"404": {"description": "not found"}
+				}
+			}
+		},
+		"/pets": {
+			"post": {
+				"parameters": [{"name": "X-Request-Id", "in": "header", "required": true, "schema": {"type": "string"}}],
+				"requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}},
+				"responses": {"2XX": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}
+			}
+		}
+	},
+	"components": {
+		"schemas": {
+			"Pet": {
+				"type": "object",
+				"required": ["id", "name"],
+				"properties": {
+					"id": {"type": "integer"},
+					"name": {"type": "string"},
+					"tag": {"type": "string", "nullable": true}
+				}
+			}
+		}
+	}
+}`
+
+func TestValidator(t *testing.T) {
+	doc, err := Parse([]byte(petstore))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	validator, err := NewValidator(doc)
+	if err != nil {
+		t.Fatalf("NewValidator failed: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/pets/1":
+			_, _ = w.Write([]byte(`{"id": 1, "name": "rex", "tag": null}`))
+		case "/v1/pets/2":
+			_, _ = w.Write([]byte(`{"id": "2"}`))
+		case "/v1/pets/3":
+			w.WriteHeader(http.StatusTeapot)
+		case "/v1/pets":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id": 4, "name": "fido"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := &httpclient.Client{BaseURL: server.URL + "/v1", Middleware: []httpclient.Middleware{validator.Middleware()}}
+	ctx := context.Background()
+	type pet struct {
+		ID   int     `json:"id"`
+		Name string  `json:"name"`
+		Tag  *string `json:"tag,omitempty"`
+	}
+
+	valid := []struct {
+		name string
+		call func() error
+	}{
+		{"get", func() error { return c.Get(ctx, "/pets/1?fields=all", &pet{}) }},
+		{"documented error status", func() error {
+			var status int
+			return c.Get(ctx, "/pets/9", nil, httpclient.WithStatus(&status))
+		}},
+		{"post", func() error {
+			return c.Post(ctx, "/pets", pet{ID: 4, Name: "fido"}, &pet{}, httpclient.WithHeader("X-Request-Id", "abc"))
+		}},
+	}
+	for _, tt := range valid {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); err != nil {
+				t.Errorf("expected no violation, got %v", err)
+			}
+		})
+	}
+
+	violations := []struct {
+		name     string
+		call     func() error
+		expected string
+	}{
+		{"unknown operation", func() error { return c.Delete(ctx, "/pets/1", nil) }, "DELETE /v1/pets/1 request: no documented operation"},
+		{"path parameter type", func() error { return c.Get(ctx, "/pets/rex", nil) }, `path parameter "petId": expected integer`},
+		{"query enum", func() error { return c.Get(ctx, "/pets/1?fields=some", nil) }, `query parameter "fields"`},
+		{"missing header", func() error { return c.Post(ctx, "/pets", pet{ID: 4, Name: "fido"}, nil) }, `missing required header parameter "X-Request-Id"`},
+		{"missing body", func() error {
+			return c.Post(ctx, "/pets", nil, nil, httpclient.WithHeader("X-Request-Id", "abc"))
+		}, "missing required body"},
+		{"request body", func() error {
+			return c.Post(ctx, "/pets", map[string]int{"id": 4}, nil, httpclient.WithHeader("X-Request-Id", "abc"))
+		}, "/name: required property missing"},
+		{"response body", func() error { return c.Get(ctx, "/pets/2", nil) }, "GET /pets/{petId} response: status 200 body"},
+		{"undocumented status", func() error { return c.Get(ctx, "/pets/3", nil) }, "undocumented status 418"},
+	}
+	for _, tt := range violations {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			var violation *ViolationError
+			if !errors.As(err, &violation) || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected violation containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	t.Run("schema errors are unwrapped", func(t *testing.T) {
+		err := c.Get(ctx, "/pets/2", nil)
+		var validationErr *jsonschema.ValidationError
+		if !errors.As(err, &validationErr) || len(validationErr.Errors) != 2 {
+			t.Errorf("expected 2 schema errors, got %v", err)
+		}
+	})
+
+	t.Run("report only", func(t *testing.T) {
+		var reported []string
+		reporting := *validator
+		reporting.OnViolation = func(err *ViolationError) { reported = append(reported, err.Part) }
+		c := &httpclient.Client{BaseURL: server.URL + "/v1", Middleware: []httpclient.Middleware{reporting.Middleware()}}
+
+		var status int
+		if err := c.Get(ctx, "/pets/3?fields=some", nil, httpclient.WithStatus(&status)); err != nil {
+			t.Fatalf("expected violations not to fail the request, got %v", err)
+		}
+		if status != http.StatusTeapot || len(reported) != 2 || reported[0] != "request" || reported[1] != "response" {
+			t.Errorf("expected request and response violations, got %v with status %d", reported, status)
+		}
+	})
+}
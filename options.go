@@ -1,6 +1,14 @@
 package httpclient
 
-import "net/http"
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
 
 // Options contains configuration for HTTP requests
 type Options struct {
@@ -10,15 +18,98 @@ type Options struct {
 	Status *int
 	// Custom HTTP client for this request only
 	Client *http.Client
+	// Query parameters added to the request URL
+	Query url.Values
+	// Locale overrides Client.Locale for the request
+	Locale string
+	// NotModified reports whether the server answered 304 Not Modified
+	NotModified *bool
+	// ETag receives the ETag header of the response
+	ETag *string
+	// Trailer receives the response trailers
+	Trailer *http.Header
+	// RequestTrailer declares trailers sent after the request body
+	RequestTrailer http.Header
+	// Fallback handles the error of a failed request
+	Fallback func(ctx context.Context, err error) error
+	// Cookies sent with the request
+	Cookies []*http.Cookie
+	// ResponseCookies receives the cookies set by the response
+	ResponseCookies *[]*http.Cookie
+	// Priority orders the request in Client.Scheduler
+	Priority Priority
+	// PathParams replace {name} placeholders in the request URL
+	PathParams map[string]string
+	// ExpectedStatus lists the accepted status codes, others fail with an HTTPError
+	ExpectedStatus []int
+	// Validator checks successful response bodies before they are decoded
+	Validator Validator
+	// RetryNonIdempotent allows retrying methods such as POST and PATCH
+	RetryNonIdempotent bool
+	// ServerTiming receives the metrics of the Server-Timing response header
+	ServerTiming *[]ServerTiming
+	// Route is the route template reported to AccountingMiddleware
+	Route string
+	// UploadProgress is called as UploadFile sends the body
+	UploadProgress func(sent, total int64)
+	// UploadChecksum receives the hex SHA-256 of the file sent by UploadFile
+	UploadChecksum *string
+	// ContentDigest sends the SHA-256 Content-Digest of the request body
+	ContentDigest bool
+	// ContentMD5 sends the Content-MD5 of the request body
+	ContentMD5 bool
+	// VerifyDigest checks the response body against its Content-Digest and Content-MD5 headers
+	VerifyDigest bool
+	// FollowLocation receives the resource at the Location of a 201 or 202 response
+	FollowLocation interface{}
+	// BeforeRetry prepares the body value before every retry attempt, which is then marshalled again
+	BeforeRetry func(attempt int, body interface{}) error
+	// ResponseTransformers rewrite the raw body of successful responses before they are decoded
+	ResponseTransformers []ResponseTransformer
+	// RequestTransformers rewrite marshalled request bodies before they are sent
+	RequestTransformers []RequestTransformer
+	// Envelope overrides Client.Envelope for the request
+	Envelope string
+	// NoEnvelope decodes the whole response even when Client.Envelope is set
+	NoEnvelope bool
+	// RawFields receive top-level fields of successful responses as raw JSON
+	RawFields map[string]*json.RawMessage
+	// Fields receive the values at JSON paths of successful responses
+	Fields map[string]interface{}
+	// NoBodyBuffering decodes the response while it is read, even when the status is captured
+	NoBodyBuffering bool
+	// DeadlineHeader overrides Client.DeadlineHeader for the request
+	DeadlineHeader string
+	// Deprecation receives the Deprecation and Sunset headers of the response
+	Deprecation *Deprecation
+	// Warnings receives the Warning headers of the response
+	Warnings *[]Warning
+	// IdentityEncoding requests an identity encoded response, like Client.DisableCompression
+	IdentityEncoding bool
 }
 
-// Option is a function that modifies Options
+// Validator checks a raw JSON document, e.g. a *jsonschema.Schema
+type Validator interface {
+	ValidateJSON(data []byte) error
+}
+
+// Option is a function that modifies Options. Options are pooled and reused across requests,
+// so an Option must not retain the *Options it receives.
 type Option func(*Options)
 
+// optionMapSize pre-sizes option maps for the usual handful of headers or query parameters
+const optionMapSize = 8
+
 // WithHeaders sets custom headers for the request
 func WithHeaders(headers map[string]string) Option {
 	return func(o *Options) {
-		o.Headers = headers
+		if o.Headers == nil {
+			o.Headers = make(map[string]string, len(headers)+optionMapSize)
+		}
+		// Copy rather than keep the caller's map, which later options would otherwise modify
+		for key, value := range headers {
+			o.Headers[key] = value
+		}
 	}
 }
 
@@ -26,12 +117,317 @@ func WithHeaders(headers map[string]string) Option {
 func WithHeader(key, value string) Option {
 	return func(o *Options) {
 		if o.Headers == nil {
-			o.Headers = make(map[string]string)
+			o.Headers = make(map[string]string, optionMapSize)
 		}
 		o.Headers[key] = value
 	}
 }
 
+// WithUserAgent overrides the User-Agent header of the client for the request
+func WithUserAgent(userAgent string) Option {
+	return WithHeader("User-Agent", userAgent)
+}
+
+// WithLocale sets the language tag sent in Accept-Language, and Client.LocaleHeader when configured
+func WithLocale(tag string) Option {
+	return func(o *Options) {
+		o.Locale = tag
+	}
+}
+
+// WithIfModifiedSince makes the request conditional on the resource having changed after t
+func WithIfModifiedSince(t time.Time) Option {
+	return WithHeader("If-Modified-Since", t.UTC().Format(http.TimeFormat))
+}
+
+// WithNotModified reports through the provided pointer whether the server answered 304 Not Modified,
+// in which case the result is left untouched
+func WithNotModified(notModified *bool) Option {
+	return func(o *Options) {
+		o.NotModified = notModified
+	}
+}
+
+// WithIfMatch makes the request conditional on the resource still having the given ETag, so a concurrent
+// modification fails with an error matching ErrPreconditionFailed instead of being overwritten
+func WithIfMatch(etag string) Option {
+	return WithHeader("If-Match", etag)
+}
+
+// WithETag stores the ETag header of the response in the provided pointer, empty when absent
+func WithETag(etag *string) Option {
+	return func(o *Options) {
+		o.ETag = etag
+	}
+}
+
+// WithServerTiming stores the metrics of the Server-Timing response header in the provided pointer,
+// e.g. to tell time spent upstream from network latency in telemetry
+func WithServerTiming(timings *[]ServerTiming) Option {
+	return func(o *Options) {
+		o.ServerTiming = timings
+	}
+}
+
+// WithRanges requests the given byte ranges of the resource in the Range header, decode the response with
+// a *ByteRangesResponse result
+func WithRanges(ranges ...ByteRange) Option {
+	specs := make([]string, len(ranges))
+	for i, r := range ranges {
+		specs[i] = r.String()
+	}
+	return WithHeader("Range", "bytes="+strings.Join(specs, ","))
+}
+
+// WithTrailers stores the trailers of the response in the provided pointer once the body has been read
+func WithTrailers(trailer *http.Header) Option {
+	return func(o *Options) {
+		o.Trailer = trailer
+	}
+}
+
+// WithRequestTrailers sends trailer after the request body, which is then sent with chunked encoding.
+// As with http.Request.Trailer, the keys must be present up front while values may be filled in as the
+// body is read, e.g. a checksum computed by an io.Reader body.
+func WithRequestTrailers(trailer http.Header) Option {
+	return func(o *Options) {
+		o.RequestTrailer = trailer
+	}
+}
+
+// WithFallback calls fallback with the error of a failed request, after retries are exhausted, and returns
+// its error instead. Returning nil after filling the result with a default or cached value degrades gracefully.
+func WithFallback(fallback func(ctx context.Context, err error) error) Option {
+	return func(o *Options) {
+		o.Fallback = fallback
+	}
+}
+
+// WithPriority sets the priority of the request when Client.Scheduler is saturated
+func WithPriority(priority Priority) Option {
+	return func(o *Options) {
+		o.Priority = priority
+	}
+}
+
+// WithPathParam replaces the {name} placeholder in the request URL with the escaped value
+func WithPathParam(name, value string) Option {
+	return func(o *Options) {
+		if o.PathParams == nil {
+			o.PathParams = make(map[string]string, optionMapSize)
+		}
+		o.PathParams[name] = value
+	}
+}
+
+// WithPathParams replaces several placeholders in the request URL, see WithPathParam
+func WithPathParams(params map[string]string) Option {
+	return func(o *Options) {
+		for name, value := range params {
+			WithPathParam(name, value)(o)
+		}
+	}
+}
+
+// WithExpectedStatus accepts only the given status codes, any other response fails with an HTTPError
+// even below 400, e.g. a 200 from an endpoint that must answer 201 Created
+func WithExpectedStatus(codes ...int) Option {
+	return func(o *Options) {
+		o.ExpectedStatus = append(o.ExpectedStatus, codes...)
+	}
+}
+
+// WithValidator validates successful non-empty response bodies with v before decoding them, so contract
+// violations of upstream APIs fail at the boundary; the error wraps the one returned by v
+func WithValidator(v Validator) Option {
+	return func(o *Options) {
+		o.Validator = v
+	}
+}
+
+// WithRetryNonIdempotent allows retrying the request when its method is not idempotent, e.g. POST or PATCH.
+// Only GET, HEAD, OPTIONS, TRACE, PUT, DELETE and requests with an Idempotency-Key header are retried
+// otherwise, since a retry may repeat side effects the server already applied.
+func WithRetryNonIdempotent() Option {
+	return func(o *Options) {
+		o.RetryNonIdempotent = true
+	}
+}
+
+// WithBeforeRetry calls hook with the retry number, starting at 1, and the body value before every retry
+// attempt, then marshals the body again; with a pointer body, the hook can refresh timestamps or nonces
+// that the server rejects when replayed. Middleware runs again for every attempt, so signatures computed
+// there cover the new body. Hook errors abort the request, and []byte and io.Reader bodies are resent as is.
+//
+//	err := client.Put(ctx, url, &payment, &receipt, httpclient.WithBeforeRetry(func(attempt int, body interface{}) error {
+//	    body.(*Payment).Timestamp = time.Now().Unix()
+//	    return nil
+//	}))
+func WithBeforeRetry(hook func(attempt int, body interface{}) error) Option {
+	return func(o *Options) {
+		o.BeforeRetry = hook
+	}
+}
+
+// WithResponseTransformer rewrites the raw body of a successful response before it is validated and decoded,
+// after the transformers of the client; several transformers run in order
+func WithResponseTransformer(t ResponseTransformer) Option {
+	return func(o *Options) {
+		o.ResponseTransformers = append(o.ResponseTransformers, t)
+	}
+}
+
+// WithRequestTransformer rewrites the marshalled request body before it is sent, after the transformers of
+// the client; several transformers run in order
+func WithRequestTransformer(t RequestTransformer) Option {
+	return func(o *Options) {
+		o.RequestTransformers = append(o.RequestTransformers, t)
+	}
+}
+
+// WithEnvelope decodes successful responses from their top-level field, e.g. WithEnvelope("data") for
+// {"data": {...}, "meta": {...}}, instead of the whole document
+func WithEnvelope(field string) Option {
+	return func(o *Options) {
+		o.Envelope = field
+	}
+}
+
+// WithoutEnvelope decodes the whole response of the request, ignoring Client.Envelope
+func WithoutEnvelope() Option {
+	return func(o *Options) {
+		o.NoEnvelope = true
+	}
+}
+
+// WithRawField stores the top-level field name of a successful response in raw, nil when the field is
+// missing, e.g. to keep "meta" for later while the result is decoded from WithEnvelope("data") in the same pass
+func WithRawField(name string, raw *json.RawMessage) Option {
+	return func(o *Options) {
+		if o.RawFields == nil {
+			o.RawFields = make(map[string]*json.RawMessage)
+		}
+		o.RawFields[name] = raw
+	}
+}
+
+// WithField decodes the values at path in a successful response into target. Path segments are separated by
+// dots and match object keys or array indexes, "#" matches every element of an array and makes target receive
+// the array of all the matches, e.g. WithField("items.#.id", &ids). Unmatched parts of the document are skipped
+// without being decoded; without a result, the response is projected while it is read so only the selected
+// values are held in memory. Paths apply to the whole document, regardless of WithEnvelope, and targets of
+// paths matching nothing are left as they were.
+func WithField(path string, target interface{}) Option {
+	return func(o *Options) {
+		if o.Fields == nil {
+			o.Fields = make(map[string]interface{})
+		}
+		o.Fields[path] = target
+	}
+}
+
+// WithoutBodyBuffering decodes the result of the request while the response is read, so huge responses are
+// handled in constant memory. Unlike buffered requests, the responses of statuses captured with WithStatus or
+// WithExpectedStatus are decoded as they arrive too, and their decoding errors are returned instead of being
+// ignored for statuses of 400 and above since the body cannot be read again. Options needing the whole body,
+// such as WithValidator, WithVerifyDigest, WithRawField, envelopes and response transformers, fail with
+// ErrBodyBufferingRequired. Failed requests still buffer their body into the HTTPError.
+func WithoutBodyBuffering() Option {
+	return func(o *Options) {
+		o.NoBodyBuffering = true
+	}
+}
+
+// WithDeadlinePropagationHeader sends the time left before the deadline of the request in header, e.g.
+// DeadlineHeaderMillis or DeadlineHeaderGRPC, overriding Client.DeadlineHeader
+func WithDeadlinePropagationHeader(header string) Option {
+	return func(o *Options) {
+		o.DeadlineHeader = header
+	}
+}
+
+// WithDeprecation stores the Deprecation and Sunset headers of the response in d, left zero when the
+// response has neither
+func WithDeprecation(d *Deprecation) Option {
+	return func(o *Options) {
+		o.Deprecation = d
+	}
+}
+
+// WithWarnings stores the Warning headers of the response in warnings, e.g. 110 for stale responses
+func WithWarnings(warnings *[]Warning) Option {
+	return func(o *Options) {
+		o.Warnings = warnings
+	}
+}
+
+// WithIdentityEncoding requests an uncompressed response with Accept-Encoding: identity instead of the gzip
+// negotiated by net/http, so the response keeps its exact Content-Length
+func WithIdentityEncoding() Option {
+	return func(o *Options) {
+		o.IdentityEncoding = true
+	}
+}
+
+// WithRoute sets the route template reported to AccountingMiddleware, e.g. "/v1/users/{id}" for a URL built
+// without path parameters
+func WithRoute(route string) Option {
+	return func(o *Options) {
+		o.Route = route
+	}
+}
+
+// WithUploadProgress calls progress with the bytes sent so far and the body length as UploadFile streams the file
+func WithUploadProgress(progress func(sent, total int64)) Option {
+	return func(o *Options) {
+		o.UploadProgress = progress
+	}
+}
+
+// WithUploadChecksum stores the hex SHA-256 of the file content sent by UploadFile in the provided pointer,
+// e.g. to compare with the checksum reported by the server
+func WithUploadChecksum(sum *string) Option {
+	return func(o *Options) {
+		o.UploadChecksum = sum
+	}
+}
+
+// WithContentDigest sends the RFC 9530 Content-Digest header with the SHA-256 of the request body. Streamed
+// bodies are hashed as they are sent, so their digest is sent as a trailer with chunked encoding.
+func WithContentDigest() Option {
+	return func(o *Options) {
+		o.ContentDigest = true
+	}
+}
+
+// WithContentMD5 sends the Content-MD5 header required by some storage APIs, as a trailer for streamed bodies
+func WithContentMD5() Option {
+	return func(o *Options) {
+		o.ContentMD5 = true
+	}
+}
+
+// WithVerifyDigest checks the response body against the SHA-256 or SHA-512 Content-Digest and the Content-MD5
+// it advertises, failing the read of a corrupted body with an error matching ErrDigestMismatch
+func WithVerifyDigest() Option {
+	return func(o *Options) {
+		o.VerifyDigest = true
+	}
+}
+
+// WithFollowLocation fetches the resource at the Location header of a 201 Created or 202 Accepted response
+// with a GET request, sent with the same headers, and decodes it into created. Other responses leave created
+// untouched, as do responses without a Location. Like redirects, a Location on another origin is not sent the
+// sensitive headers of the client.
+//
+//	var order Order
+//	err := client.Post(ctx, "/orders", newOrder, nil, httpclient.WithFollowLocation(&order))
+func WithFollowLocation(created interface{}) Option {
+	return func(o *Options) {
+		o.FollowLocation = created
+	}
+}
+
 // WithStatus allows non-200 status codes without returning an error, the response status code will be stored in the provided pointer
 func WithStatus(status *int) Option {
 	return func(o *Options) {
@@ -39,11 +435,77 @@ func WithStatus(status *int) Option {
 	}
 }
 
-// buildOptions creates Options from Option functions
+// WithQuery adds query parameters to the request URL, keeping parameters already present in the URL
+func WithQuery(values url.Values) Option {
+	return func(o *Options) {
+		if o.Query == nil {
+			o.Query = make(url.Values, optionMapSize)
+		}
+		for key, vs := range values {
+			o.Query[key] = append(o.Query[key], vs...)
+		}
+	}
+}
+
+// WithQueryParam adds a single query parameter to the request URL
+func WithQueryParam(key, value string) Option {
+	return func(o *Options) {
+		if o.Query == nil {
+			o.Query = make(url.Values, optionMapSize)
+		}
+		o.Query.Add(key, value)
+	}
+}
+
+// WithCookie sends a cookie with the request, without needing a cookie jar
+func WithCookie(name, value string) Option {
+	return func(o *Options) {
+		o.Cookies = append(o.Cookies, &http.Cookie{Name: name, Value: value})
+	}
+}
+
+// WithCookies sends cookies with the request
+func WithCookies(cookies ...*http.Cookie) Option {
+	return func(o *Options) {
+		o.Cookies = append(o.Cookies, cookies...)
+	}
+}
+
+// WithResponseCookies stores the cookies set by the response through Set-Cookie headers in the provided pointer
+func WithResponseCookies(cookies *[]*http.Cookie) Option {
+	return func(o *Options) {
+		o.ResponseCookies = cookies
+	}
+}
+
+var optionsPool = sync.Pool{
+	New: func() interface{} { return &Options{} },
+}
+
+// buildOptions creates Options from Option functions, the result should be returned with releaseOptions
 func buildOptions(opts ...Option) *Options {
-	options := &Options{}
+	options := optionsPool.Get().(*Options)
 	for _, opt := range opts {
 		opt(options)
 	}
 	return options
 }
+
+// releaseOptions resets options and returns them to the pool, keeping small maps for reuse
+func releaseOptions(o *Options) {
+	headers, query := o.Headers, o.Query
+	if len(headers) > 4*optionMapSize {
+		headers = nil
+	}
+	for key := range headers {
+		delete(headers, key)
+	}
+	if len(query) > 4*optionMapSize {
+		query = nil
+	}
+	for key := range query {
+		delete(query, key)
+	}
+	*o = Options{Headers: headers, Query: query}
+	optionsPool.Put(o)
+}
@@ -0,0 +1,258 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/llkhacquan/httpclient/jsonschema"
+)
+
+func TestBuildOptions(t *testing.T) {
+	t.Run("does not modify caller headers", func(t *testing.T) {
+		headers := map[string]string{"X-Trace": "abc"}
+		options := buildOptions(WithHeaders(headers), WithHeader("X-Extra", "1"))
+		releaseOptions(options)
+
+		if len(headers) != 1 || headers["X-Trace"] != "abc" {
+			t.Errorf("expected caller headers unchanged, got %v", headers)
+		}
+	})
+
+	t.Run("released options are reset", func(t *testing.T) {
+		var status int
+		releaseOptions(buildOptions(WithHeader("X-Trace", "abc"), WithQueryParam("page", "2"), WithStatus(&status)))
+
+		options := buildOptions()
+		defer releaseOptions(options)
+		if len(options.Headers) != 0 || len(options.Query) != 0 || options.Status != nil {
+			t.Errorf("expected empty options, got %+v", options)
+		}
+	})
+}
+
+func BenchmarkBuildOptions(b *testing.B) {
+	var status int
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		options := buildOptions(WithHeader("Authorization", "Bearer token"), WithHeader("X-Trace", "abc"), WithStatus(&status))
+		releaseOptions(options)
+	}
+}
+
+func BenchmarkClient_Get(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":25,"name":"pikachu"}`))
+	}))
+	defer server.Close()
+
+	client := &Client{}
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var result struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		}
+		if err := client.Get(ctx, server.URL, &result, WithHeader("X-Trace", "abc")); err != nil {
+			b.Fatalf("GET request failed: %v", err)
+		}
+	}
+}
+
+func TestWithCookies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "new-session", Path: "/"})
+		_, _ = w.Write([]byte(`{"cookie":"` + r.Header.Get("Cookie") + `"}`))
+	}))
+	defer server.Close()
+
+	var result map[string]string
+	var cookies []*http.Cookie
+	err := (&Client{}).Get(context.Background(), server.URL, &result,
+		WithCookie("session", "abc"),
+		WithCookies(&http.Cookie{Name: "theme", Value: "dark"}),
+		WithResponseCookies(&cookies))
+	if err != nil {
+		t.Fatalf("GET request failed: %v", err)
+	}
+
+	if result["cookie"] != "session=abc; theme=dark" {
+		t.Errorf("expected cookies 'session=abc; theme=dark', got '%s'", result["cookie"])
+	}
+	if len(cookies) != 1 || cookies[0].Name != "session" || cookies[0].Value != "new-session" {
+		t.Errorf("expected response cookie session=new-session, got %v", cookies)
+	}
+}
+
+func TestClient_UserAgent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"agent":"` + r.UserAgent() + `"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		client *Client
+		opts   []Option
+		want   string
+	}{
+		{"default", &Client{}, nil, DefaultUserAgent},
+		{"client", &Client{UserAgent: "pokedex/2.0"}, nil, "pokedex/2.0"},
+		{"per request", &Client{UserAgent: "pokedex/2.0"}, []Option{WithUserAgent("pokedex-cli/1.0")}, "pokedex-cli/1.0"},
+		{"header", &Client{}, []Option{WithHeader("User-Agent", "custom")}, "custom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result map[string]string
+			if err := tt.client.Get(context.Background(), server.URL, &result, tt.opts...); err != nil {
+				t.Fatalf("GET request failed: %v", err)
+			}
+			if result["agent"] != tt.want {
+				t.Errorf("expected User-Agent '%s', got '%s'", tt.want, result["agent"])
+			}
+		})
+	}
+}
+
+func TestWithLocale(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"accept":"` + r.Header.Get("Accept-Language") + `","locale":"` + r.Header.Get("X-Locale") + `"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		client     *Client
+		opts       []Option
+		wantAccept string
+		wantLocale string
+	}{
+		{"none", &Client{}, nil, "", ""},
+		{"client default", &Client{Locale: "fr-CA", LocaleHeader: "X-Locale"}, nil, "fr-CA", "fr-CA"},
+		{"per request", &Client{Locale: "fr-CA"}, []Option{WithLocale("ja-JP")}, "ja-JP", ""},
+		{"explicit header wins", &Client{Locale: "fr-CA"}, []Option{WithHeader("Accept-Language", "de, en;q=0.5")}, "de, en;q=0.5", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result map[string]string
+			if err := tt.client.Get(context.Background(), server.URL, &result, tt.opts...); err != nil {
+				t.Fatalf("GET request failed: %v", err)
+			}
+			if result["accept"] != tt.wantAccept || result["locale"] != tt.wantLocale {
+				t.Errorf("expected Accept-Language '%s' and X-Locale '%s', got %v", tt.wantAccept, tt.wantLocale, result)
+			}
+		})
+	}
+}
+
+func TestWithIdentityEncoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"encoding":"` + r.Header.Get("Accept-Encoding") + `"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		client   *Client
+		opts     []Option
+		expected string
+	}{
+		{"negotiated by net/http", &Client{}, nil, "gzip"},
+		{"client default", &Client{DisableCompression: true}, nil, "identity"},
+		{"per request", &Client{}, []Option{WithIdentityEncoding()}, "identity"},
+		{"explicit header wins", &Client{DisableCompression: true}, []Option{WithHeader("Accept-Encoding", "br")}, "br"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result map[string]string
+			if err := tt.client.Get(context.Background(), server.URL, &result, tt.opts...); err != nil {
+				t.Fatalf("GET request failed: %v", err)
+			}
+			if result["encoding"] != tt.expected {
+				t.Errorf("expected Accept-Encoding '%s', got '%s'", tt.expected, result["encoding"])
+			}
+		})
+	}
+}
+
+func TestWithIfModifiedSince(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !modified.After(since) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		_, _ = w.Write([]byte(`{"name":"pikachu"}`))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	client := &Client{}
+
+	t.Run("modified", func(t *testing.T) {
+		var result map[string]string
+		notModified := true
+		err := client.Get(ctx, server.URL, &result, WithIfModifiedSince(modified.Add(-time.Hour)), WithNotModified(&notModified))
+		if err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if notModified || result["name"] != "pikachu" {
+			t.Errorf("expected fresh result, got notModified=%v result=%v", notModified, result)
+		}
+	})
+
+	t.Run("not modified", func(t *testing.T) {
+		result := map[string]string{"name": "cached"}
+		var notModified bool
+		err := client.Get(ctx, server.URL, &result, WithIfModifiedSince(modified.In(time.FixedZone("CEST", 2*3600))), WithNotModified(&notModified))
+		if err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if !notModified || result["name"] != "cached" {
+			t.Errorf("expected untouched result, got notModified=%v result=%v", notModified, result)
+		}
+	})
+}
+
+func TestWithValidator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/valid":
+			_, _ = w.Write([]byte(`{"id": 25, "name": "pikachu"}`))
+		case "/invalid":
+			_, _ = w.Write([]byte(`{"id": "25"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`not json`))
+		}
+	}))
+	defer server.Close()
+
+	schema := jsonschema.MustCompile([]byte(`{"type": "object", "required": ["id", "name"], "properties": {"id": {"type": "integer"}}}`))
+	c := &Client{}
+	ctx := context.Background()
+
+	var pokemon struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := c.Get(ctx, server.URL+"/valid", &pokemon, WithValidator(schema)); err != nil || pokemon.Name != "pikachu" {
+		t.Errorf("expected valid response to be decoded, got %+v and %v", pokemon, err)
+	}
+
+	var validationErr *jsonschema.ValidationError
+	err := c.Get(ctx, server.URL+"/invalid", &pokemon, WithValidator(schema))
+	if !errors.As(err, &validationErr) || len(validationErr.Errors) != 2 {
+		t.Errorf("expected 2 validation errors, got %v", err)
+	}
+
+	var httpErr *HTTPError
+	if err := c.Get(ctx, server.URL+"/missing", &pokemon, WithValidator(schema)); !errors.As(err, &httpErr) {
+		t.Errorf("expected error responses to skip validation, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// DefaultMaxPages is the page limit used by a Paginator when MaxPages is not set
+const DefaultMaxPages = 1000
+
+// ErrPageLimit is returned when a Paginator would fetch more pages than allowed by MaxPages
+var ErrPageLimit = errors.New("page limit reached")
+
+// ErrItemLimit is returned by GetAll when more items are available than allowed by maxItems
+var ErrItemLimit = errors.New("item limit reached")
+
+// Paginator walks a paginated API, decoding every page into a T.
+//
+// The next function extracts the URL of the following page from a decoded page and returns ""
+// on the last page; relative URLs are resolved against the URL of the current page.
+// Cursor based APIs can build the next URL from the cursor inside the function:
+//
+//	p := httpclient.NewPaginator(client, "https://pokeapi.co/api/v2/pokemon?limit=100",
+//	    func(page *PokemonList) string { return page.Next })
+//	for p.HasNext() {
+//	    page, err := p.Next(ctx)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    // use page.Results
+//	}
+type Paginator[T any] struct {
+	// MaxPages is the maximum number of pages fetched, defaults to DefaultMaxPages
+	MaxPages int
+	// Interval is the minimum delay between two page requests, used to stay under rate limits
+	Interval time.Duration
+
+	client  *Client
+	url     string
+	next    func(page *T) string
+	opts    []Option
+	pages   int
+	fetched time.Time
+}
+
+// NewPaginator creates a Paginator starting at url, a nil client uses the default client
+func NewPaginator[T any](c *Client, url string, next func(page *T) string, opts ...Option) *Paginator[T] {
+	if c == nil {
+		c = Default()
+	}
+	return &Paginator[T]{client: c, url: url, next: next, opts: opts}
+}
+
+// HasNext reports whether there is another page to fetch
+func (p *Paginator[T]) HasNext() bool {
+	return p.url != ""
+}
+
+// Next fetches and decodes the next page
+func (p *Paginator[T]) Next(ctx context.Context) (*T, error) {
+	if p.url == "" {
+		return nil, errors.New("no more pages")
+	}
+
+	maxPages := p.MaxPages
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+	if p.pages >= maxPages {
+		return nil, fmt.Errorf("%w: fetched %d pages", ErrPageLimit, p.pages)
+	}
+
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	page := new(T)
+	err := p.client.Get(ctx, p.url, page, p.opts...)
+	p.fetched = time.Now()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch page %d: %w", p.pages+1, err)
+	}
+	p.pages++
+
+	next := p.next(page)
+	if next == "" {
+		p.url = ""
+		return page, nil
+	}
+
+	base, err := url.Parse(p.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse page URL: %w", err)
+	}
+	ref, err := url.Parse(next)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse next page URL: %w", err)
+	}
+	p.url = base.ResolveReference(ref).String()
+
+	return page, nil
+}
+
+// wait blocks until Interval has elapsed since the previous page was fetched
+func (p *Paginator[T]) wait(ctx context.Context) error {
+	if p.Interval <= 0 || p.fetched.IsZero() {
+		return nil
+	}
+	delay := p.Interval - time.Since(p.fetched)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// GetAll drains p and appends the items extracted from every page to dst.
+//
+// Page count is capped by the paginator's MaxPages and, when maxItems is positive, at most
+// maxItems items are appended. Hitting either cap while more data is available returns
+// ErrPageLimit or ErrItemLimit, with dst holding the items collected so far.
+//
+//	var pokemon []PokemonRef
+//	err := httpclient.GetAll(ctx, p, &pokemon,
+//	    func(page *PokemonList) []PokemonRef { return page.Results }, 10000)
+func GetAll[P any, E any](ctx context.Context, p *Paginator[P], dst *[]E, items func(page *P) []E, maxItems int) error {
+	collected := 0
+	for p.HasNext() {
+		if maxItems > 0 && collected >= maxItems {
+			return fmt.Errorf("%w: collected %d items", ErrItemLimit, collected)
+		}
+
+		page, err := p.Next(ctx)
+		if err != nil {
+			return err
+		}
+
+		pageItems := items(page)
+		if maxItems > 0 && collected+len(pageItems) > maxItems {
+			*dst = append(*dst, pageItems[:maxItems-collected]...)
+			return fmt.Errorf("%w: collected %d items", ErrItemLimit, maxItems)
+		}
+		*dst = append(*dst, pageItems...)
+		collected += len(pageItems)
+	}
+	return nil
+}
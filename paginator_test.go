@@ -0,0 +1,116 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// newPokemonPagesServer serves total pokemon in pages of two, linking pages with relative next URLs
+func newPokemonPagesServer(total int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		next := ""
+		if offset+2 < total {
+			next = fmt.Sprintf("/pokemon?offset=%d", offset+2)
+		}
+
+		results := ""
+		for i := offset; i < offset+2 && i < total; i++ {
+			if results != "" {
+				results += ","
+			}
+			results += fmt.Sprintf(`{"name":"pokemon-%d"}`, i)
+		}
+		_, _ = fmt.Fprintf(w, `{"count":%d,"next":%q,"results":[%s]}`, total, next, results)
+	}))
+}
+
+func TestPaginator(t *testing.T) {
+	server := newPokemonPagesServer(5)
+	defer server.Close()
+
+	next := func(page *PokemonList) string { return page.Next }
+
+	t.Run("iterates all pages", func(t *testing.T) {
+		p := NewPaginator(&Client{}, server.URL+"/pokemon", next)
+
+		var names []string
+		for p.HasNext() {
+			page, err := p.Next(context.Background())
+			if err != nil {
+				t.Fatalf("fetching page failed: %v", err)
+			}
+			for _, result := range page.Results {
+				names = append(names, result.Name)
+			}
+		}
+
+		if len(names) != 5 {
+			t.Errorf("expected 5 pokemon, got %d", len(names))
+		}
+		if names[4] != "pokemon-4" {
+			t.Errorf("expected last pokemon 'pokemon-4', got '%s'", names[4])
+		}
+	})
+
+	t.Run("stops at max pages", func(t *testing.T) {
+		p := NewPaginator(&Client{}, server.URL+"/pokemon", next)
+		p.MaxPages = 2
+
+		var err error
+		for p.HasNext() && err == nil {
+			_, err = p.Next(context.Background())
+		}
+
+		if !errors.Is(err, ErrPageLimit) {
+			t.Errorf("expected ErrPageLimit, got %v", err)
+		}
+	})
+}
+
+func TestGetAll(t *testing.T) {
+	server := newPokemonPagesServer(5)
+	defer server.Close()
+
+	type ref struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+	next := func(page *PokemonList) string { return page.Next }
+	items := func(page *PokemonList) []ref {
+		refs := make([]ref, len(page.Results))
+		for i, r := range page.Results {
+			refs[i] = ref(r)
+		}
+		return refs
+	}
+
+	t.Run("collects every item", func(t *testing.T) {
+		var all []ref
+		err := GetAll(context.Background(), NewPaginator(&Client{}, server.URL, next), &all, items, 0)
+		if err != nil {
+			t.Fatalf("GetAll failed: %v", err)
+		}
+
+		if len(all) != 5 {
+			t.Errorf("expected 5 items, got %d", len(all))
+		}
+	})
+
+	t.Run("stops at item cap", func(t *testing.T) {
+		var all []ref
+		err := GetAll(context.Background(), NewPaginator(&Client{}, server.URL, next), &all, items, 3)
+		if !errors.Is(err, ErrItemLimit) {
+			t.Errorf("expected ErrItemLimit, got %v", err)
+		}
+
+		if len(all) != 3 {
+			t.Errorf("expected 3 items, got %d", len(all))
+		}
+	})
+}
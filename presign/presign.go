@@ -0,0 +1,97 @@
+// Package presign generates pre-signed URLs for S3-compatible object stores, such as Amazon S3, Google Cloud
+// Storage with HMAC keys, Cloudflare R2 or MinIO, so that download and upload links can be handed out
+// without the cloud SDKs.
+//
+//	signer := &presign.Signer{Endpoint: "https://s3.eu-west-1.amazonaws.com", Region: "eu-west-1",
+//	    AccessKeyID: key, SecretAccessKey: secret}
+//	link, err := signer.GetURL("reports", "2024/q1.pdf", 15*time.Minute)
+package presign
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/llkhacquan/httpclient/internal/sigv4"
+)
+
+// MaxExpiry is the longest validity of a pre-signed URL accepted by S3
+const MaxExpiry = 7 * 24 * time.Hour
+
+// ErrInvalidExpiry is returned for expiries that are not positive or exceed MaxExpiry
+var ErrInvalidExpiry = errors.New("invalid pre-signed URL expiry")
+
+// Signer signs object URLs with AWS Signature Version 4 query parameters
+type Signer struct {
+	// Endpoint is the base URL of the object store, e.g. "https://s3.eu-west-1.amazonaws.com",
+	// "https://storage.googleapis.com" or "https://<account>.r2.cloudflarestorage.com"
+	Endpoint string
+	// Region is the signing region, defaults to "us-east-1"; use "auto" for GCS and R2
+	Region string
+	// VirtualHosted puts the bucket in the host name, e.g. "https://reports.s3.eu-west-1.amazonaws.com/key",
+	// instead of the path
+	VirtualHosted bool
+	// AccessKeyID, SecretAccessKey and SessionToken are the credentials, HMAC keys for GCS
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// GetURL returns a URL downloading the object key of bucket until expires elapses
+func (s *Signer) GetURL(bucket, key string, expires time.Duration) (string, error) {
+	return s.URL(http.MethodGet, bucket, key, expires, nil)
+}
+
+// PutURL returns a URL uploading the object key of bucket with a PUT request until expires elapses
+func (s *Signer) PutURL(bucket, key string, expires time.Duration) (string, error) {
+	return s.URL(http.MethodPut, bucket, key, expires, nil)
+}
+
+// URL returns a URL authenticating a method request on the object key of bucket until expires elapses.
+// Query parameters such as response-content-disposition are signed along with the URL.
+func (s *Signer) URL(method, bucket, key string, expires time.Duration, query url.Values) (string, error) {
+	if expires <= 0 || expires > MaxExpiry {
+		return "", fmt.Errorf("%w: %s", ErrInvalidExpiry, expires)
+	}
+	u, err := url.Parse(s.Endpoint)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid endpoint %q", s.Endpoint)
+	}
+
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+	if s.VirtualHosted {
+		u.Host = bucket + "." + u.Host
+	} else {
+		path += "/" + escapePath(bucket)
+	}
+	u.RawPath = path + "/" + escapePath(key)
+	if u.Path, err = url.PathUnescape(u.RawPath); err != nil {
+		return "", fmt.Errorf("invalid endpoint path %q: %w", s.Endpoint, err)
+	}
+	u.RawQuery = query.Encode()
+
+	region := s.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	creds := sigv4.Credentials{AccessKeyID: s.AccessKeyID, SecretAccessKey: s.SecretAccessKey, SessionToken: s.SessionToken}
+	return sigv4.Presign(method, u, creds, region, "s3", expires, time.Now()), nil
+}
+
+// escapePath percent-encodes every byte of an object key but unreserved characters and slashes, as the
+// canonical URI of Signature Version 4 requires
+func escapePath(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,55 @@
+package presign
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSigner_URL(t *testing.T) {
+	signer := &Signer{Endpoint: "https://storage.example.com/", AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"}
+
+	t.Run("path style", func(t *testing.T) {
+		link, err := signer.GetURL("reports", "2024/q1 summary+final.pdf", time.Hour)
+		if err != nil {
+			t.Fatalf("presign failed: %v", err)
+		}
+		u, err := url.Parse(link)
+		if err != nil {
+			t.Fatalf("invalid URL %s: %v", link, err)
+		}
+		if u.Host != "storage.example.com" || u.EscapedPath() != "/reports/2024/q1%20summary%2Bfinal.pdf" {
+			t.Errorf("unexpected URL %s", link)
+		}
+		query := u.Query()
+		if query.Get("X-Amz-Expires") != "3600" || query.Get("X-Amz-Security-Token") != "token" || len(query.Get("X-Amz-Signature")) != 64 {
+			t.Errorf("unexpected query %v", query)
+		}
+		credential := query.Get("X-Amz-Credential")
+		if want := "AKID/" + time.Now().UTC().Format("20060102") + "/us-east-1/s3/aws4_request"; credential != want {
+			t.Errorf("expected credential %s, got %s", want, credential)
+		}
+	})
+
+	t.Run("virtual hosted", func(t *testing.T) {
+		hosted := *signer
+		hosted.VirtualHosted, hosted.Region = true, "auto"
+		link, err := hosted.URL("PUT", "uploads", "avatar.png", time.Minute, url.Values{"x-id": {"PutObject"}})
+		if err != nil {
+			t.Fatalf("presign failed: %v", err)
+		}
+		u, _ := url.Parse(link)
+		if u.Host != "uploads.storage.example.com" || u.Path != "/avatar.png" || u.Query().Get("x-id") != "PutObject" {
+			t.Errorf("unexpected URL %s", link)
+		}
+	})
+
+	t.Run("invalid expiry", func(t *testing.T) {
+		for _, expires := range []time.Duration{0, MaxExpiry + time.Second} {
+			if _, err := signer.PutURL("uploads", "avatar.png", expires); !errors.Is(err, ErrInvalidExpiry) {
+				t.Errorf("expected ErrInvalidExpiry for %s, got %v", expires, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostProfile overrides client settings for requests to matching hosts, so one client can talk to
+// several third parties with different requirements:
+//
+//	client := &httpclient.Client{HostProfiles: map[string]*httpclient.HostProfile{
+//	    "api.stripe.com": {Timeout: 10 * time.Second, MaxRetries: 3},
+//	    "*.pokeapi.co":   {RateLimit: 5, Headers: map[string]string{"X-Team": "pokedex"}},
+//	}}
+type HostProfile struct {
+	// Timeout bounds each request including retries, zero means no extra timeout
+	Timeout time.Duration
+	// Headers are sent unless the request sets them
+	Headers map[string]string
+	// RateLimit is the maximum number of attempts per second, zero means unlimited
+	RateLimit float64
+	// Burst is the number of attempts allowed at once above RateLimit, defaults to 1
+	Burst int
+	// MaxRetries overrides Client.MaxRetries when not zero, a negative value disables retries
+	MaxRetries int
+	// RetryPolicy overrides Client.RetryPolicy when set
+	RetryPolicy RetryPolicy
+	// Backoff overrides Client.Backoff when set
+	Backoff Backoff
+
+	limiterOnce sync.Once
+	limiter     *rateLimiter
+}
+
+// hostProfile returns the profile for host, preferring an exact match over the longest wildcard
+func (c *Client) hostProfile(host string) *HostProfile {
+	if len(c.HostProfiles) == 0 {
+		return nil
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if profile, ok := c.HostProfiles[host]; ok {
+		return profile
+	}
+
+	var best *HostProfile
+	bestLen := -1
+	for pattern, profile := range c.HostProfiles {
+		if strings.Contains(pattern, "*") && matchHost(pattern, host) && len(pattern) > bestLen {
+			best, bestLen = profile, len(pattern)
+		}
+	}
+	return best
+}
+
+func (p *HostProfile) rateLimiter() *rateLimiter {
+	p.limiterOnce.Do(func() {
+		if p.RateLimit > 0 {
+			p.limiter = newRateLimiter(p.RateLimit, p.Burst)
+		}
+	})
+	return p.limiter
+}
+
+// needsProfiles reports whether requests must go through profileTransport
+func (c *Client) needsProfiles() bool {
+	for _, profile := range c.HostProfiles {
+		if profile.RateLimit > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// profileTransport applies the rate limits of host profiles to every attempt
+func (c *Client) profileTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if profile := c.hostProfile(req.URL.Hostname()); profile != nil {
+			if limiter := profile.rateLimiter(); limiter != nil {
+				if err := limiter.wait(req.Context()); err != nil {
+					closeBody(req)
+					return nil, err
+				}
+			}
+		}
+		return next.RoundTrip(req)
+	})
+}
+
+// rateLimiter is a token bucket refilled at rate tokens per second up to burst tokens
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait takes a token, sleeping until one is available or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		// Give the reserved token back
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
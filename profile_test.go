@@ -0,0 +1,131 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_HostProfiles(t *testing.T) {
+	t.Run("exact host wins over wildcard", func(t *testing.T) {
+		exact, wildcard, broad := &HostProfile{}, &HostProfile{}, &HostProfile{}
+		c := &Client{HostProfiles: map[string]*HostProfile{
+			"api.example.com": exact,
+			"*.example.com":   wildcard,
+			"*":               broad,
+		}}
+		if got := c.hostProfile("api.example.com"); got != exact {
+			t.Errorf("expected exact profile, got %p", got)
+		}
+		if got := c.hostProfile("www.example.com"); got != wildcard {
+			t.Errorf("expected wildcard profile, got %p", got)
+		}
+		if got := c.hostProfile("other.org"); got != broad {
+			t.Errorf("expected catch-all profile, got %p", got)
+		}
+		if got := (&Client{}).hostProfile("api.example.com"); got != nil {
+			t.Errorf("expected no profile, got %p", got)
+		}
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		var team, trace string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			team, trace = r.Header.Get("X-Team"), r.Header.Get("X-Trace")
+		}))
+		defer server.Close()
+
+		c := &Client{HostProfiles: map[string]*HostProfile{
+			"127.0.0.1": {Headers: map[string]string{"X-Team": "pokedex", "X-Trace": "profile"}},
+		}}
+		if err := c.Get(context.Background(), server.URL, nil, WithHeader("X-Trace", "request")); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if team != "pokedex" {
+			t.Errorf("expected X-Team pokedex, got %q", team)
+		}
+		if trace != "request" {
+			t.Errorf("expected request header to win, got %q", trace)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		}))
+		defer server.Close()
+
+		c := &Client{HostProfiles: map[string]*HostProfile{"127.0.0.1": {Timeout: 20 * time.Millisecond}}}
+		err := c.Get(context.Background(), server.URL, nil)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		server, calls := flakyServer(t, 2, http.StatusServiceUnavailable)
+
+		c := &Client{HostProfiles: map[string]*HostProfile{
+			"127.0.0.1": {MaxRetries: 2, Backoff: ConstantBackoff(time.Millisecond)},
+		}}
+		if err := c.Get(context.Background(), server.URL, nil); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if got := atomic.LoadInt32(calls); got != 3 {
+			t.Errorf("expected 3 calls, got %d", got)
+		}
+	})
+
+	t.Run("negative retries disable client retries", func(t *testing.T) {
+		server, calls := flakyServer(t, 1, http.StatusServiceUnavailable)
+
+		c := &Client{MaxRetries: 3, Backoff: ConstantBackoff(time.Millisecond), HostProfiles: map[string]*HostProfile{
+			"127.0.0.1": {MaxRetries: -1},
+		}}
+		var status int
+		if err := c.Get(context.Background(), server.URL, nil, WithStatus(&status)); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if status != http.StatusServiceUnavailable || atomic.LoadInt32(calls) != 1 {
+			t.Errorf("expected a single 503, got %d after %d calls", status, atomic.LoadInt32(calls))
+		}
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		c := &Client{HostProfiles: map[string]*HostProfile{"127.0.0.1": {RateLimit: 20, Burst: 2}}}
+		start := time.Now()
+		for i := 0; i < 4; i++ {
+			if err := c.Get(context.Background(), server.URL, nil); err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+		}
+		// Two requests use the burst, the other two wait 50ms each
+		if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+			t.Errorf("expected requests to be rate limited, took %v", elapsed)
+		}
+	})
+}
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("context canceled", func(t *testing.T) {
+		l := newRateLimiter(1, 1)
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatalf("wait failed: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := l.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+	})
+}
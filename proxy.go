@@ -0,0 +1,155 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProxyAuth authenticates to proxies requiring credentials, e.g. corporate proxies. The transport only
+// talks to the proxy itself for plain HTTP requests, so HTTPS requests are authenticated when opening
+// their CONNECT tunnel through GetProxyConnectHeader, which Transport sets up:
+//
+//	auth := &httpclient.ProxyAuth{ProxyURL: proxyURL, Username: "svc-reports", Password: os.Getenv("PROXY_PASSWORD")}
+//	client := &httpclient.Client{
+//	    Client:     &http.Client{Transport: auth.Transport()},
+//	    Middleware: []httpclient.Middleware{auth.Middleware()},
+//	}
+type ProxyAuth struct {
+	// ProxyURL is the proxy, defaults to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+	ProxyURL *url.URL
+	// Username and Password are sent with the Basic scheme
+	Username string
+	Password string
+	// Source supplies a token sent with Scheme instead of Username and Password. A 407 response invalidates
+	// it when Source has an Invalidate method, as CachedCredentials does, and the request is sent once more
+	Source CredentialSource
+	// Scheme is the authentication scheme of Source tokens, defaults to "Bearer"
+	Scheme string
+	// Authenticators answer Proxy-Authenticate challenges of plain HTTP requests, e.g. a DigestAuthenticator
+	Authenticators []Authenticator
+}
+
+// credentials returns the Proxy-Authorization value, empty without credentials
+func (p *ProxyAuth) credentials(ctx context.Context) (string, error) {
+	if p.Source != nil {
+		cred, err := p.Source.Credential(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get proxy credential: %w", err)
+		}
+		scheme := p.Scheme
+		if scheme == "" {
+			scheme = "Bearer"
+		}
+		return scheme + " " + cred.Token, nil
+	}
+	if p.Username != "" {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(p.Username+":"+p.Password)), nil
+	}
+	return "", nil
+}
+
+// GetProxyConnectHeader returns the Proxy-Authorization header of CONNECT requests,
+// it fits http.Transport.GetProxyConnectHeader
+func (p *ProxyAuth) GetProxyConnectHeader(ctx context.Context, _ *url.URL, _ string) (http.Header, error) {
+	value, err := p.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	if value != "" {
+		header.Set("Proxy-Authorization", value)
+	}
+	return header, nil
+}
+
+// Transport returns a clone of http.DefaultTransport sending requests through the proxy and authenticating
+// its CONNECT tunnels
+func (p *ProxyAuth) Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = p.proxy
+	transport.GetProxyConnectHeader = p.GetProxyConnectHeader
+	return transport
+}
+
+// proxy returns the proxy of req, nil when it is sent directly
+func (p *ProxyAuth) proxy(req *http.Request) (*url.URL, error) {
+	if p.ProxyURL != nil {
+		return p.ProxyURL, nil
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// Middleware returns a Middleware sending credentials with plain HTTP requests, answering 407 challenges
+// with Authenticators and re-authenticating once after a 407
+func (p *ProxyAuth) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		challenged := challengeTransport(next, http.StatusProxyAuthRequired, "Proxy-Authenticate", "Proxy-Authorization", p.Authenticators, false)
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			invalidator, canRefresh := p.Source.(interface{ Invalidate() })
+			replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+			for attempt := 0; ; attempt++ {
+				attemptReq, err := p.authorize(req, attempt)
+				if err != nil {
+					closeBody(req)
+					return nil, err
+				}
+				resp, err := challenged.RoundTrip(attemptReq)
+				if attempt > 0 || !canRefresh || !replayable || !proxyAuthRequired(resp, err) {
+					return resp, err
+				}
+
+				invalidator.Invalidate()
+				if resp != nil {
+					_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+					_ = resp.Body.Close()
+				}
+			}
+		})
+	}
+}
+
+// authorize returns a copy of req carrying the proxy credentials when it is sent to the proxy in plain text,
+// other requests would leak them to the origin server
+func (p *ProxyAuth) authorize(req *http.Request, attempt int) (*http.Request, error) {
+	proxied := false
+	if req.URL.Scheme == "http" {
+		proxyURL, err := p.proxy(req)
+		proxied = err == nil && proxyURL != nil
+	}
+	if attempt == 0 && !proxied {
+		return req, nil
+	}
+	authReq := req.Clone(req.Context())
+	if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		authReq.Body = body
+	}
+	if proxied {
+		value, err := p.credentials(req.Context())
+		if err != nil {
+			return nil, err
+		}
+		if value != "" {
+			authReq.Header.Set("Proxy-Authorization", value)
+		}
+	}
+	return authReq, nil
+}
+
+// proxyAuthRequired reports whether the proxy refused the credentials, either with a 407 response to a plain
+// HTTP request or with the error the transport returns when a CONNECT is answered with 407
+func proxyAuthRequired(resp *http.Response, err error) bool {
+	if err != nil {
+		return strings.Contains(err.Error(), http.StatusText(http.StatusProxyAuthRequired))
+	}
+	return resp.StatusCode == http.StatusProxyAuthRequired
+}
@@ -0,0 +1,109 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+// authenticatingProxy serves plain HTTP requests itself and tunnels CONNECT requests, requiring
+// the Proxy-Authorization value returned by want
+func authenticatingProxy(t *testing.T, want func() string) (*httptest.Server, *int32) {
+	var refused int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != want() {
+			atomic.AddInt32(&refused, 1)
+			w.Header().Set("Proxy-Authenticate", `Basic realm="corp"`)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		if r.Method != http.MethodConnect {
+			_, _ = w.Write([]byte(`{"host":"` + r.URL.Host + `"}`))
+			return
+		}
+
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("hijacking failed: %v", err)
+			return
+		}
+		go func() {
+			_, _ = io.Copy(target, conn)
+			_ = target.Close()
+		}()
+		_, _ = io.Copy(conn, target)
+		_ = conn.Close()
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy, &refused
+}
+
+func TestProxyAuth(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("basic over plain http", func(t *testing.T) {
+		proxy, _ := authenticatingProxy(t, func() string { return "Basic YXNoOnBpa2FjaHU=" })
+		proxyURL, _ := url.Parse(proxy.URL)
+		auth := &ProxyAuth{ProxyURL: proxyURL, Username: "ash", Password: "pikachu"}
+		client := &Client{Client: &http.Client{Transport: auth.Transport()}, Middleware: []Middleware{auth.Middleware()}}
+
+		var result map[string]string
+		if err := client.Get(ctx, "http://pokeapi.test/pokemon/1", &result); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if result["host"] != "pokeapi.test" {
+			t.Errorf("expected request for pokeapi.test, got %v", result)
+		}
+	})
+
+	t.Run("re-authenticates CONNECT after 407", func(t *testing.T) {
+		target := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Proxy-Authorization") != "" {
+				t.Error("expected proxy credentials not to reach the origin")
+			}
+			_, _ = w.Write([]byte(`{"name":"pikachu"}`))
+		}))
+		defer target.Close()
+
+		var current atomic.Value
+		current.Store("token-1")
+		proxy, refused := authenticatingProxy(t, func() string { return "Bearer " + current.Load().(string) })
+		proxyURL, _ := url.Parse(proxy.URL)
+
+		var fetches int32
+		source := NewCachedCredentials(CredentialSourceFunc(func(ctx context.Context) (*Credential, error) {
+			atomic.AddInt32(&fetches, 1)
+			return &Credential{Token: current.Load().(string)}, nil
+		}), 0)
+		auth := &ProxyAuth{ProxyURL: proxyURL, Source: source}
+		transport := auth.Transport()
+		transport.TLSClientConfig = target.Client().Transport.(*http.Transport).TLSClientConfig
+		client := &Client{Client: &http.Client{Transport: transport}, Middleware: []Middleware{auth.Middleware()}}
+
+		if err := client.Get(ctx, target.URL, nil); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+
+		// Rotate the proxy token: the tunnel is refused once, then opened with the new token
+		current.Store("token-2")
+		transport.CloseIdleConnections()
+		var result map[string]string
+		if err := client.Get(ctx, target.URL, &result); err != nil {
+			t.Fatalf("GET request after rotation failed: %v", err)
+		}
+		if result["name"] != "pikachu" || *refused != 1 || fetches != 2 {
+			t.Errorf("expected pikachu after 1 refusal and 2 fetches, got %v after %d and %d", result, *refused, fetches)
+		}
+	})
+}
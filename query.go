@@ -0,0 +1,150 @@
+package httpclient
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// QueryStyle selects the REST convention used to serialize a Query
+type QueryStyle int
+
+const (
+	// PlainQuery serializes to status=active&sort=-created&page=2&per_page=50
+	PlainQuery QueryStyle = iota
+	// JSONAPIQuery serializes to filter[status]=active&sort=-created&page[number]=2&page[size]=50
+	JSONAPIQuery
+	// ODataQuery serializes to $filter=status eq 'active'&$orderby=created desc&$skip=50&$top=50
+	ODataQuery
+)
+
+// Query builds filter, sort and pagination parameters for list endpoints:
+//
+//	q := httpclient.Filter("status", "active").Sort("-created").Page(2, 50)
+//	err := client.Get(ctx, url, &result, httpclient.WithQuery(q.Values(httpclient.JSONAPIQuery)))
+//
+// Sort fields prefixed with "-" are sorted in descending order, pages are numbered from 1.
+type Query struct {
+	filters [][2]string
+	sorts   []string
+	page    int
+	size    int
+	params  url.Values
+}
+
+// NewQuery returns an empty Query
+func NewQuery() *Query {
+	return &Query{}
+}
+
+// Filter returns a new Query filtering field by value
+func Filter(field, value string) *Query {
+	return NewQuery().Filter(field, value)
+}
+
+// Filter adds an equality filter on field
+func (q *Query) Filter(field, value string) *Query {
+	q.filters = append(q.filters, [2]string{field, value})
+	return q
+}
+
+// Sort adds sort fields, a "-" prefix sorts in descending order
+func (q *Query) Sort(fields ...string) *Query {
+	q.sorts = append(q.sorts, fields...)
+	return q
+}
+
+// Page selects the 1-based page number and the page size
+func (q *Query) Page(number, size int) *Query {
+	q.page = number
+	q.size = size
+	return q
+}
+
+// Set adds a raw parameter that is passed through unchanged in every style
+func (q *Query) Set(key, value string) *Query {
+	if q.params == nil {
+		q.params = url.Values{}
+	}
+	q.params.Set(key, value)
+	return q
+}
+
+// Values serializes the query using style
+func (q *Query) Values(style QueryStyle) url.Values {
+	values := url.Values{}
+	switch style {
+	case JSONAPIQuery:
+		q.jsonAPIValues(values)
+	case ODataQuery:
+		q.odataValues(values)
+	default:
+		q.plainValues(values)
+	}
+	for key, vs := range q.params {
+		values[key] = append(values[key], vs...)
+	}
+	return values
+}
+
+// Encode serializes the query using style into URL-encoded form
+func (q *Query) Encode(style QueryStyle) string {
+	return q.Values(style).Encode()
+}
+
+func (q *Query) plainValues(values url.Values) {
+	for _, f := range q.filters {
+		values.Add(f[0], f[1])
+	}
+	if len(q.sorts) > 0 {
+		values.Set("sort", strings.Join(q.sorts, ","))
+	}
+	if q.page > 0 {
+		values.Set("page", strconv.Itoa(q.page))
+	}
+	if q.size > 0 {
+		values.Set("per_page", strconv.Itoa(q.size))
+	}
+}
+
+func (q *Query) jsonAPIValues(values url.Values) {
+	for _, f := range q.filters {
+		values.Add("filter["+f[0]+"]", f[1])
+	}
+	if len(q.sorts) > 0 {
+		values.Set("sort", strings.Join(q.sorts, ","))
+	}
+	if q.page > 0 {
+		values.Set("page[number]", strconv.Itoa(q.page))
+	}
+	if q.size > 0 {
+		values.Set("page[size]", strconv.Itoa(q.size))
+	}
+}
+
+func (q *Query) odataValues(values url.Values) {
+	if len(q.filters) > 0 {
+		clauses := make([]string, len(q.filters))
+		for i, f := range q.filters {
+			clauses[i] = f[0] + " eq '" + strings.ReplaceAll(f[1], "'", "''") + "'"
+		}
+		values.Set("$filter", strings.Join(clauses, " and "))
+	}
+	if len(q.sorts) > 0 {
+		orders := make([]string, len(q.sorts))
+		for i, s := range q.sorts {
+			if strings.HasPrefix(s, "-") {
+				orders[i] = s[1:] + " desc"
+			} else {
+				orders[i] = s
+			}
+		}
+		values.Set("$orderby", strings.Join(orders, ","))
+	}
+	if q.size > 0 {
+		values.Set("$top", strconv.Itoa(q.size))
+		if q.page > 1 {
+			values.Set("$skip", strconv.Itoa((q.page-1)*q.size))
+		}
+	}
+}
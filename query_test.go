@@ -0,0 +1,45 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	q := Filter("status", "active").Sort("-created", "name").Page(3, 50)
+
+	tests := []struct {
+		style    QueryStyle
+		expected string
+	}{
+		{PlainQuery, "page=3&per_page=50&sort=-created%2Cname&status=active"},
+		{JSONAPIQuery, "filter%5Bstatus%5D=active&page%5Bnumber%5D=3&page%5Bsize%5D=50&sort=-created%2Cname"},
+		{ODataQuery, "%24filter=status+eq+%27active%27&%24orderby=created+desc%2Cname&%24skip=100&%24top=50"},
+	}
+	for _, tt := range tests {
+		if got := q.Encode(tt.style); got != tt.expected {
+			t.Errorf("style %d: expected '%s', got '%s'", tt.style, tt.expected, got)
+		}
+	}
+
+	t.Run("query option merges with URL query", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"query":"` + r.URL.RawQuery + `"}`))
+		}))
+		defer server.Close()
+
+		var result map[string]string
+		err := (&Client{}).Get(context.Background(), server.URL+"/pokemon?limit=5", &result,
+			WithQuery(Filter("type", "electric").Values(PlainQuery)),
+			WithQueryParam("offset", "10"))
+		if err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+
+		if result["query"] != "limit=5&offset=10&type=electric" {
+			t.Errorf("unexpected query: %s", result["query"])
+		}
+	})
+}
@@ -0,0 +1,337 @@
+// Package queue delivers requests in the background with retries, persisting them in a Store so
+// webhooks and telemetry survive process restarts:
+//
+//	q := queue.New(client, &queue.FileStore{Dir: "/var/lib/app/outbox"})
+//	go q.Run(ctx)
+//	job, err := queue.NewJob("POST", "https://hooks.example.com/orders", order)
+//	// ...
+//	id, err := q.Enqueue(job)
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+// Defaults used by a Queue
+const (
+	DefaultMaxAttempts  = 10
+	DefaultPollInterval = time.Second
+)
+
+// DefaultBackoff spaces delivery attempts when Queue.Backoff is not set
+var DefaultBackoff httpclient.Backoff = httpclient.ExponentialBackoff{Base: time.Second, Max: 10 * time.Minute, Jitter: true}
+
+// Job is a persisted request
+type Job struct {
+	// ID is assigned by Enqueue when empty, and may only hold ASCII letters, digits, '-' and '_'
+	ID     string            `json:"id"`
+	Method string            `json:"method"`
+	URL    string            `json:"url"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   []byte            `json:"body,omitempty"`
+	// CreatedAt is set by Enqueue
+	CreatedAt time.Time `json:"created_at"`
+	// RunAt is the time of the next attempt
+	RunAt time.Time `json:"run_at"`
+	// Attempts is the number of failed attempts
+	Attempts int `json:"attempts"`
+	// LastError describes the last failed attempt
+	LastError string `json:"last_error,omitempty"`
+}
+
+// NewJob returns a job sending body, marshalled to JSON unless it is a []byte
+func NewJob(method, url string, body interface{}) (*Job, error) {
+	job := &Job{Method: method, URL: url}
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		job.Body = b
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal body: %w", err)
+		}
+		job.Body = data
+	}
+	return job, nil
+}
+
+func (j *Job) clone() *Job {
+	clone := *j
+	if j.Header != nil {
+		clone.Header = make(map[string]string, len(j.Header))
+		for key, value := range j.Header {
+			clone.Header[key] = value
+		}
+	}
+	return &clone
+}
+
+// Queue sends persisted jobs in the background until they succeed, fail permanently or run out of attempts
+type Queue struct {
+	// Client sends the jobs, defaults to httpclient.Default()
+	Client *httpclient.Client
+	// Store persists the jobs
+	Store Store
+	// MaxAttempts is the number of attempts before a job is dropped, defaults to DefaultMaxAttempts
+	MaxAttempts int
+	// Backoff spaces the attempts of a job, defaults to DefaultBackoff
+	Backoff httpclient.Backoff
+	// PollInterval is how often due jobs are looked for, defaults to DefaultPollInterval
+	PollInterval time.Duration
+	// OnDrop is called when a job is given up on, with the error of its last attempt
+	OnDrop func(job *Job, err error)
+
+	wake chan struct{}
+
+	mu               sync.Mutex // guards the in-flight job against Cancel
+	inflight         string
+	inflightCanceled bool
+}
+
+// New returns a Queue sending jobs of store with client
+func New(client *httpclient.Client, store Store) *Queue {
+	return &Queue{Client: client, Store: store, wake: make(chan struct{}, 1)}
+}
+
+// Enqueue persists job, assigning its ID and creation time, and returns its ID, or ErrInvalidID when the ID
+// of job is not valid. The job is sent as soon as possible unless its RunAt is set.
+func (q *Queue) Enqueue(job *Job) (string, error) {
+	return q.enqueue(job, job.RunAt)
+}
+
+// EnqueueAt persists job to be sent at t, e.g. to revoke a token when it expires
+func (q *Queue) EnqueueAt(job *Job, t time.Time) (string, error) {
+	return q.enqueue(job, t)
+}
+
+// EnqueueAfter persists job to be sent once delay has elapsed
+func (q *Queue) EnqueueAfter(job *Job, delay time.Duration) (string, error) {
+	return q.enqueue(job, time.Now().Add(delay))
+}
+
+// Cancel removes a job that has not been delivered yet, returning ErrNotFound when it was already sent,
+// dropped or never enqueued. An attempt in flight is not interrupted but the job is not retried.
+func (q *Queue) Cancel(id string) error {
+	if err := checkID(id); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.Store.Delete(id); err != nil {
+		return err
+	}
+	if id == q.inflight {
+		q.inflightCanceled = true
+	}
+	return nil
+}
+
+func (q *Queue) enqueue(job *Job, runAt time.Time) (string, error) {
+	job = job.clone()
+	if job.ID == "" {
+		id, err := newID()
+		if err != nil {
+			return "", err
+		}
+		job.ID = id
+	} else if err := checkID(job.ID); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	job.CreatedAt = now
+	job.RunAt = runAt
+	if runAt.IsZero() {
+		job.RunAt = now
+	}
+	if err := q.Store.Put(job); err != nil {
+		return "", fmt.Errorf("failed to store job: %w", err)
+	}
+	q.notify()
+	return job.ID, nil
+}
+
+// Run sends due jobs until ctx is done or the client is closed, including jobs left over by a previous process.
+// A queue must be run by a single goroutine.
+func (q *Queue) Run(ctx context.Context) error {
+	interval := q.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		next, err := q.process(ctx)
+		if err != nil {
+			return err
+		}
+		// Wake up for the next scheduled job, polling for jobs added by other processes
+		wait := interval
+		if !next.IsZero() && time.Until(next) < wait {
+			wait = time.Until(next)
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		case <-q.wakeup():
+		}
+	}
+}
+
+// process sends every due job once and returns when the earliest remaining job is due,
+// zero when none remains
+func (q *Queue) process(ctx context.Context) (time.Time, error) {
+	jobs, err := q.Store.List()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	now := time.Now()
+	var next time.Time
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return time.Time{}, nil
+		}
+		if job.RunAt.After(now) {
+			if next.IsZero() || job.RunAt.Before(next) {
+				next = job.RunAt
+			}
+			continue
+		}
+		if err := q.send(ctx, job); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return next, nil
+}
+
+// send makes one attempt and records its outcome in the store
+func (q *Queue) send(ctx context.Context, job *Job) error {
+	client := q.Client
+	if client == nil {
+		client = httpclient.Default()
+	}
+
+	q.mu.Lock()
+	q.inflight, q.inflightCanceled = job.ID, false
+	q.mu.Unlock()
+	defer func() {
+		q.mu.Lock()
+		q.inflight = ""
+		q.mu.Unlock()
+	}()
+
+	var status int
+	var body interface{}
+	if job.Body != nil {
+		body = job.Body
+	}
+	err := client.Do(ctx, job.Method, job.URL, body, nil, httpclient.WithHeaders(job.Header), httpclient.WithStatus(&status))
+	if err == nil && status >= 400 {
+		err = fmt.Errorf("unexpected status %d", status)
+	}
+	if err == nil {
+		return q.delete(job.ID)
+	}
+	if ctx.Err() != nil {
+		// Shutting down, the job is retried by the next run
+		return nil
+	}
+	if errors.Is(err, httpclient.ErrClientClosed) {
+		// The job is kept for the next run, which stops with the error
+		return err
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+	maxAttempts := q.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	if job.Attempts >= maxAttempts || !retryable(status, err) {
+		if err := q.delete(job.ID); err != nil {
+			return err
+		}
+		if q.OnDrop != nil {
+			q.OnDrop(job, err)
+		}
+		return nil
+	}
+
+	backoff := q.Backoff
+	if backoff == nil {
+		backoff = DefaultBackoff
+	}
+	job.RunAt = time.Now().Add(backoff.NextDelay(job.Attempts, nil, err))
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.inflightCanceled {
+		return nil
+	}
+	if err := q.Store.Put(job); err != nil {
+		return fmt.Errorf("failed to store job: %w", err)
+	}
+	return nil
+}
+
+func (q *Queue) delete(id string) error {
+	if err := q.Store.Delete(id); err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to delete job: %w", err)
+	}
+	return nil
+}
+
+// retryable reports whether a failed attempt may succeed later
+func retryable(status int, err error) bool {
+	if status == 0 {
+		return httpclient.IsRetryableError(err)
+	}
+	for _, code := range httpclient.DefaultRetryStatusCodes {
+		if status == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *Queue) notify() {
+	select {
+	case q.wakeup() <- struct{}{}:
+	default:
+	}
+}
+
+func (q *Queue) wakeup() chan struct{} {
+	if q.wake == nil {
+		// Zero value queues are polled only
+		return nil
+	}
+	return q.wake
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
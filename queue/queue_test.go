@@ -0,0 +1,277 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+// runQueue runs q until the test ends
+func runQueue(t *testing.T, q *Queue) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = q.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// waitEmpty blocks until store holds no job
+func waitEmpty(t *testing.T, store Store) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		jobs, err := store.List()
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(jobs) == 0 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("expected the queue to be drained")
+}
+
+func TestQueue(t *testing.T) {
+	t.Run("delivers with retries", func(t *testing.T) {
+		var attempts int32
+		var body, header string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&attempts, 1) <= 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			data, _ := io.ReadAll(r.Body)
+			body, header = string(data), r.Header.Get("X-Signature")
+		}))
+		defer server.Close()
+
+		store := NewMemoryStore()
+		q := New(&httpclient.Client{}, store)
+		q.Backoff = httpclient.ConstantBackoff(time.Millisecond)
+		q.PollInterval = 5 * time.Millisecond
+
+		job, err := NewJob(http.MethodPost, server.URL, map[string]string{"event": "order.created"})
+		if err != nil {
+			t.Fatalf("NewJob failed: %v", err)
+		}
+		job.Header = map[string]string{"X-Signature": "abc"}
+		if _, err := q.Enqueue(job); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+		runQueue(t, q)
+		waitEmpty(t, store)
+
+		if atomic.LoadInt32(&attempts) != 3 {
+			t.Errorf("expected 3 attempts, got %d", attempts)
+		}
+		if body != `{"event":"order.created"}` || header != "abc" {
+			t.Errorf("expected body and header to be sent, got %q and %q", body, header)
+		}
+	})
+
+	t.Run("drops permanent failures", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		var mu sync.Mutex
+		var dropped *Job
+		store := NewMemoryStore()
+		q := New(&httpclient.Client{}, store)
+		q.PollInterval = 5 * time.Millisecond
+		q.OnDrop = func(job *Job, err error) {
+			mu.Lock()
+			dropped = job
+			mu.Unlock()
+		}
+
+		job, _ := NewJob(http.MethodPost, server.URL, []byte("ping"))
+		id, _ := q.Enqueue(job)
+		runQueue(t, q)
+		waitEmpty(t, store)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if dropped == nil || dropped.ID != id || dropped.Attempts != 1 {
+			t.Fatalf("expected job %s dropped after 1 attempt, got %+v", id, dropped)
+		}
+		if dropped.LastError != "unexpected status 400" {
+			t.Errorf("expected last error, got %q", dropped.LastError)
+		}
+	})
+
+	t.Run("max attempts", func(t *testing.T) {
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		store := NewMemoryStore()
+		q := New(&httpclient.Client{}, store)
+		q.MaxAttempts = 3
+		q.Backoff = httpclient.ConstantBackoff(time.Millisecond)
+		q.PollInterval = 5 * time.Millisecond
+
+		job, _ := NewJob(http.MethodGet, server.URL, nil)
+		_, _ = q.Enqueue(job)
+		runQueue(t, q)
+		waitEmpty(t, store)
+
+		if atomic.LoadInt32(&attempts) != 3 {
+			t.Errorf("expected 3 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("survives restarts", func(t *testing.T) {
+		var delivered int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&delivered, 1)
+		}))
+		defer server.Close()
+
+		dir := t.TempDir()
+		job, _ := NewJob(http.MethodPost, server.URL, []byte("telemetry"))
+		if _, err := New(nil, &FileStore{Dir: dir}).Enqueue(job); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+
+		// A new process picks the job up from disk
+		store := &FileStore{Dir: dir}
+		q := New(&httpclient.Client{}, store)
+		runQueue(t, q)
+		waitEmpty(t, store)
+
+		if atomic.LoadInt32(&delivered) != 1 {
+			t.Errorf("expected 1 delivery, got %d", delivered)
+		}
+	})
+
+	t.Run("scheduled", func(t *testing.T) {
+		var mu sync.Mutex
+		delivered := map[string]time.Time{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			delivered[r.URL.Path] = time.Now()
+			mu.Unlock()
+		}))
+		defer server.Close()
+
+		store := NewMemoryStore()
+		q := New(&httpclient.Client{}, store)
+		// A long poll interval makes sure scheduled jobs wake the queue up themselves
+		q.PollInterval = time.Hour
+		runQueue(t, q)
+
+		start := time.Now()
+		later, _ := NewJob(http.MethodPost, server.URL+"/later", nil)
+		if _, err := q.EnqueueAfter(later, 50*time.Millisecond); err != nil {
+			t.Fatalf("EnqueueAfter failed: %v", err)
+		}
+		at, _ := NewJob(http.MethodPost, server.URL+"/at", nil)
+		if _, err := q.EnqueueAt(at, start.Add(20*time.Millisecond)); err != nil {
+			t.Fatalf("EnqueueAt failed: %v", err)
+		}
+		canceled, _ := NewJob(http.MethodPost, server.URL+"/canceled", nil)
+		id, _ := q.EnqueueAfter(canceled, 30*time.Millisecond)
+		if err := q.Cancel(id); err != nil {
+			t.Fatalf("Cancel failed: %v", err)
+		}
+		waitEmpty(t, store)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if got := delivered["/at"].Sub(start); got < 20*time.Millisecond {
+			t.Errorf("expected /at after 20ms, got %v", got)
+		}
+		if got := delivered["/later"].Sub(start); got < 50*time.Millisecond {
+			t.Errorf("expected /later after 50ms, got %v", got)
+		}
+		if _, ok := delivered["/canceled"]; ok {
+			t.Errorf("expected canceled job not to be sent")
+		}
+		if err := q.Cancel(id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("cancel in flight", func(t *testing.T) {
+		var attempts int32
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			<-release
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		store := NewMemoryStore()
+		q := New(&httpclient.Client{}, store)
+		q.Backoff = httpclient.ConstantBackoff(time.Millisecond)
+		q.PollInterval = 5 * time.Millisecond
+		job, _ := NewJob(http.MethodGet, server.URL, nil)
+		id, _ := q.Enqueue(job)
+		runQueue(t, q)
+
+		for atomic.LoadInt32(&attempts) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		if err := q.Cancel(id); err != nil {
+			t.Fatalf("Cancel failed: %v", err)
+		}
+		close(release)
+		time.Sleep(50 * time.Millisecond)
+
+		if got := atomic.LoadInt32(&attempts); got != 1 {
+			t.Errorf("expected the canceled job not to be retried, got %d attempts", got)
+		}
+		if jobs, _ := store.List(); len(jobs) != 0 {
+			t.Errorf("expected no job left, got %d", len(jobs))
+		}
+	})
+	t.Run("invalid IDs", func(t *testing.T) {
+		q := New(&httpclient.Client{}, NewMemoryStore())
+		if _, err := q.Enqueue(&Job{ID: "../../x", Method: http.MethodGet, URL: "https://example.com"}); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("expected ErrInvalidID from Enqueue, got %v", err)
+		}
+		if err := q.Cancel("../../x"); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("expected ErrInvalidID from Cancel, got %v", err)
+		}
+	})
+
+	t.Run("closed client", func(t *testing.T) {
+		client := &httpclient.Client{}
+		if err := client.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		store := NewMemoryStore()
+		q := New(client, store)
+		job, _ := NewJob(http.MethodGet, "http://127.0.0.1:1", nil)
+		_, _ = q.Enqueue(job)
+
+		err := q.Run(context.Background())
+		if !errors.Is(err, httpclient.ErrClientClosed) {
+			t.Errorf("expected ErrClientClosed, got %v", err)
+		}
+		if jobs, _ := store.List(); len(jobs) != 1 {
+			t.Errorf("expected the job to be kept, got %d jobs", len(jobs))
+		}
+	})
+}
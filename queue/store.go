@@ -0,0 +1,182 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrNotFound is returned by stores for unknown job IDs
+	ErrNotFound = errors.New("job not found")
+	// ErrInvalidID is returned for job IDs with characters other than ASCII letters, digits, '-' and '_'
+	ErrInvalidID = errors.New("invalid job ID")
+)
+
+// checkID returns ErrInvalidID unless id is a valid job ID, which FileStore can use as a file name
+func checkID(id string) error {
+	if id == "" {
+		return fmt.Errorf("%w: empty", ErrInvalidID)
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return fmt.Errorf("%w: %q", ErrInvalidID, id)
+		}
+	}
+	return nil
+}
+
+// Store persists queued jobs. Implementations must be safe for concurrent use.
+type Store interface {
+	// Put inserts or replaces the job with the same ID
+	Put(job *Job) error
+	// Delete removes a job, returning ErrNotFound when it does not exist
+	Delete(id string) error
+	// List returns every job ordered by creation time
+	List() ([]*Job, error)
+}
+
+// MemoryStore keeps jobs in memory, they are lost when the process exits
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]*Job)}
+}
+
+// Put implements Store
+func (s *MemoryStore) Put(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job.clone()
+	return nil
+}
+
+// Delete implements Store
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.jobs, id)
+	return nil
+}
+
+// List implements Store
+func (s *MemoryStore) List() ([]*Job, error) {
+	s.mu.Lock()
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job.clone())
+	}
+	s.mu.Unlock()
+	sortJobs(jobs)
+	return jobs, nil
+}
+
+// FileStore keeps every job in a JSON file of its own inside Dir, surviving process restarts
+type FileStore struct {
+	// Dir is created on the first Put when missing
+	Dir string
+}
+
+// Put implements Store, writing to a temporary file renamed into place so a crash never leaves a partial job
+func (s *FileStore) Put(job *Job) error {
+	if err := checkID(job.ID); err != nil {
+		return err
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create queue directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(s.Dir, job.ID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create job file: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path(job.ID))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write job file: %w", err)
+	}
+	return nil
+}
+
+// Delete implements Store
+func (s *FileStore) Delete(id string) error {
+	if err := checkID(id); err != nil {
+		return err
+	}
+	err := os.Remove(s.path(id))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete job file: %w", err)
+	}
+	return nil
+}
+
+// List implements Store
+func (s *FileStore) List() ([]*Job, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue directory: %w", err)
+	}
+
+	var jobs []*Job
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.Dir, entry.Name()))
+		if os.IsNotExist(err) {
+			// Deleted while listing
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read job file: %w", err)
+		}
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil {
+			return nil, fmt.Errorf("failed to parse job file %s: %w", entry.Name(), err)
+		}
+		jobs = append(jobs, &job)
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
+
+// path returns the file of the job id, which must have been checked by checkID
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.Dir, id+".json")
+}
+
+func sortJobs(jobs []*Job) {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+}
@@ -0,0 +1,77 @@
+package queue
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStores(t *testing.T) {
+	stores := map[string]Store{
+		"memory": NewMemoryStore(),
+		"file":   &FileStore{Dir: t.TempDir() + "/outbox"},
+	}
+	for name, store := range stores {
+		store := store
+		t.Run(name, func(t *testing.T) {
+			jobs, err := store.List()
+			if err != nil || len(jobs) != 0 {
+				t.Fatalf("expected an empty store, got %v and %v", jobs, err)
+			}
+
+			now := time.Now()
+			second := &Job{ID: "b", Method: "POST", URL: "https://example.com/b", CreatedAt: now.Add(time.Second)}
+			first := &Job{ID: "a", Method: "POST", URL: "https://example.com/a", Header: map[string]string{"X-Id": "1"}, Body: []byte(`{}`), CreatedAt: now}
+			for _, job := range []*Job{second, first} {
+				if err := store.Put(job); err != nil {
+					t.Fatalf("Put failed: %v", err)
+				}
+			}
+			first.Header["X-Id"] = "changed"
+
+			jobs, err = store.List()
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			if len(jobs) != 2 || jobs[0].ID != "a" || jobs[1].ID != "b" {
+				t.Fatalf("expected jobs a and b in creation order, got %+v", jobs)
+			}
+			if jobs[0].Header["X-Id"] != "1" || string(jobs[0].Body) != `{}` {
+				t.Errorf("expected stored copy of job a, got %+v", jobs[0])
+			}
+
+			if err := store.Delete("a"); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if err := store.Delete("a"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected ErrNotFound, got %v", err)
+			}
+			if jobs, _ := store.List(); len(jobs) != 1 {
+				t.Errorf("expected 1 job left, got %d", len(jobs))
+			}
+		})
+	}
+}
+
+func TestFileStore_InvalidID(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(dir, "keep.json")
+	if err := os.WriteFile(outside, []byte(`{}`), 0o600); err != nil {
+		t.Fatalf("writing file failed: %v", err)
+	}
+	store := &FileStore{Dir: filepath.Join(dir, "outbox")}
+
+	for _, id := range []string{"", "../keep", "a/b", `a\b`, "a.b"} {
+		if err := store.Put(&Job{ID: id}); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("Put(%q): expected ErrInvalidID, got %v", id, err)
+		}
+		if err := store.Delete(id); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("Delete(%q): expected ErrInvalidID, got %v", id, err)
+		}
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("expected the file outside the store to be kept, got %v", err)
+	}
+}
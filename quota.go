@@ -0,0 +1,230 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned for requests rejected by a hard QuotaLimit
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaLimit bounds the usage of a quota key over a sliding window
+type QuotaLimit struct {
+	// Window is the length of the sliding window, e.g. time.Minute
+	Window time.Duration
+	// MaxRequests is the number of requests allowed in the window, zero for no limit
+	MaxRequests int64
+	// MaxBytes is the number of request and response body bytes allowed in the window, zero for no limit
+	MaxBytes int64
+	// Soft only reports requests over the limit to QuotaTracker.OnSoftLimit instead of rejecting them
+	Soft bool
+}
+
+// exceeded reports whether usage reached the limit
+func (l QuotaLimit) exceeded(usage QuotaUsage) bool {
+	return (l.MaxRequests > 0 && usage.Requests >= l.MaxRequests) || (l.MaxBytes > 0 && usage.Bytes >= l.MaxBytes)
+}
+
+// QuotaUsage is the usage of a quota key over a window
+type QuotaUsage struct {
+	Window   time.Duration
+	Requests int64
+	Bytes    int64
+}
+
+// QuotaTracker counts requests and body bytes per credential or host over sliding windows, so that features
+// sharing a vendor quota in one process can watch and cap their combined usage. Use it through its Middleware;
+// with retries every attempt is counted.
+//
+//	quota := &httpclient.QuotaTracker{
+//	    Key:    func(req *http.Request) string { return req.Header.Get("X-Api-Key") },
+//	    Limits: []httpclient.QuotaLimit{{Window: time.Minute, MaxRequests: 600}, {Window: 24 * time.Hour, MaxBytes: 10 << 30, Soft: true}},
+//	}
+//	client := &httpclient.Client{Middleware: []httpclient.Middleware{quota.Middleware()}}
+type QuotaTracker struct {
+	// Key returns the quota key of a request, defaults to the request host
+	Key func(req *http.Request) string
+	// Limits are enforced on every key separately
+	Limits []QuotaLimit
+	// Resolution is the granularity of the sliding windows, defaults to 1s
+	Resolution time.Duration
+	// OnSoftLimit is called for every request sent while a soft limit is reached, outside the tracker lock
+	OnSoftLimit func(key string, limit QuotaLimit, usage QuotaUsage)
+
+	mu   sync.Mutex
+	keys map[string][]quotaBucket
+}
+
+// quotaBucket holds the usage of one Resolution interval
+type quotaBucket struct {
+	start    time.Time
+	requests int64
+	bytes    int64
+}
+
+// Usage returns the usage of key over the last window, which is capped by the longest configured limit window
+func (q *QuotaTracker) Usage(key string, window time.Duration) QuotaUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.usage(key, window, time.Now())
+}
+
+// Keys returns the keys with usage in the longest configured window
+func (q *QuotaTracker) Keys() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	keys := make([]string, 0, len(q.keys))
+	for key := range q.keys {
+		if q.prune(key, now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Reset clears the usage of all keys
+func (q *QuotaTracker) Reset() {
+	q.mu.Lock()
+	q.keys = nil
+	q.mu.Unlock()
+}
+
+// Middleware returns a Middleware counting requests, rejecting them with ErrQuotaExceeded when a hard limit is reached
+func (q *QuotaTracker) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			key := q.key(req)
+			var body int64
+			if req.ContentLength > 0 {
+				body = req.ContentLength
+			}
+			if err := q.allow(key, body); err != nil {
+				closeBody(req)
+				return nil, err
+			}
+			resp, err := next.RoundTrip(req)
+			if err == nil && resp.Body != nil && resp.Body != http.NoBody {
+				resp.Body = &quotaReader{ReadCloser: resp.Body, tracker: q, key: key}
+			}
+			return resp, err
+		})
+	}
+}
+
+// allow counts a request of key with its body, failing when a hard limit is reached
+func (q *QuotaTracker) allow(key string, body int64) error {
+	type softLimit struct {
+		limit QuotaLimit
+		usage QuotaUsage
+	}
+	var soft []softLimit
+
+	q.mu.Lock()
+	now := time.Now()
+	q.prune(key, now)
+	for _, limit := range q.Limits {
+		usage := q.usage(key, limit.Window, now)
+		if !limit.exceeded(usage) {
+			continue
+		}
+		if !limit.Soft {
+			q.mu.Unlock()
+			return fmt.Errorf("%w: %s used %d requests and %d bytes in %s", ErrQuotaExceeded, key, usage.Requests, usage.Bytes, limit.Window)
+		}
+		soft = append(soft, softLimit{limit, usage})
+	}
+	q.add(key, now, 1, body)
+	q.mu.Unlock()
+
+	if q.OnSoftLimit != nil {
+		for _, s := range soft {
+			q.OnSoftLimit(key, s.limit, s.usage)
+		}
+	}
+	return nil
+}
+
+// add records usage of key in the bucket of now
+func (q *QuotaTracker) add(key string, now time.Time, requests, bytes int64) {
+	if q.keys == nil {
+		q.keys = make(map[string][]quotaBucket)
+	}
+	start := now.Truncate(q.resolution())
+	buckets := q.keys[key]
+	if n := len(buckets); n == 0 || !buckets[n-1].start.Equal(start) {
+		buckets = append(buckets, quotaBucket{start: start})
+	}
+	buckets[len(buckets)-1].requests += requests
+	buckets[len(buckets)-1].bytes += bytes
+	q.keys[key] = buckets
+}
+
+// usage sums the buckets of key overlapping the window ending at now
+func (q *QuotaTracker) usage(key string, window time.Duration, now time.Time) QuotaUsage {
+	usage := QuotaUsage{Window: window}
+	since := now.Add(-window)
+	buckets := q.keys[key]
+	for i := len(buckets) - 1; i >= 0 && buckets[i].start.Add(q.resolution()).After(since); i-- {
+		usage.Requests += buckets[i].requests
+		usage.Bytes += buckets[i].bytes
+	}
+	return usage
+}
+
+// prune drops the buckets of key older than the longest window, reporting whether any remain
+func (q *QuotaTracker) prune(key string, now time.Time) bool {
+	var longest time.Duration
+	for _, limit := range q.Limits {
+		if limit.Window > longest {
+			longest = limit.Window
+		}
+	}
+	since := now.Add(-longest)
+	buckets := q.keys[key]
+	i := 0
+	for i < len(buckets) && !buckets[i].start.Add(q.resolution()).After(since) {
+		i++
+	}
+	if i == len(buckets) {
+		delete(q.keys, key)
+		return false
+	}
+	q.keys[key] = buckets[i:]
+	return true
+}
+
+func (q *QuotaTracker) key(req *http.Request) string {
+	if q.Key != nil {
+		return q.Key(req)
+	}
+	return req.URL.Host
+}
+
+func (q *QuotaTracker) resolution() time.Duration {
+	if q.Resolution > 0 {
+		return q.Resolution
+	}
+	return time.Second
+}
+
+// quotaReader counts response body bytes as they are read
+type quotaReader struct {
+	io.ReadCloser
+	tracker *QuotaTracker
+	key     string
+}
+
+func (r *quotaReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.tracker.mu.Lock()
+		r.tracker.add(r.key, time.Now(), 0, int64(n))
+		r.tracker.mu.Unlock()
+	}
+	return n, err
+}
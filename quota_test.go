@@ -0,0 +1,81 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQuotaTracker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	t.Run("hard limit", func(t *testing.T) {
+		quota := &QuotaTracker{
+			Key:    func(req *http.Request) string { return req.Header.Get("X-Api-Key") },
+			Limits: []QuotaLimit{{Window: time.Minute, MaxRequests: 2}},
+		}
+		client := &Client{Middleware: []Middleware{quota.Middleware()}}
+		for i := 0; i < 2; i++ {
+			if err := client.Post(ctx, server.URL, map[string]int{"n": i}, nil, WithHeader("X-Api-Key", "a")); err != nil {
+				t.Fatalf("POST request failed: %v", err)
+			}
+		}
+		if err := client.Get(ctx, server.URL, nil, WithHeader("X-Api-Key", "a")); !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("expected ErrQuotaExceeded, got %v", err)
+		}
+		if err := client.Get(ctx, server.URL, nil, WithHeader("X-Api-Key", "b")); err != nil {
+			t.Errorf("expected other key to be allowed, got %v", err)
+		}
+
+		usage := quota.Usage("a", time.Minute)
+		if usage.Requests != 2 || usage.Bytes != int64(2*len(`{"n":0}`)+2*len(`{"ok":true}`)) {
+			t.Errorf("unexpected usage %+v", usage)
+		}
+		if keys := quota.Keys(); len(keys) != 2 {
+			t.Errorf("expected 2 keys, got %v", keys)
+		}
+		quota.Reset()
+		if usage := quota.Usage("a", time.Minute); usage.Requests != 0 {
+			t.Errorf("expected usage to be reset, got %+v", usage)
+		}
+	})
+
+	t.Run("soft limit", func(t *testing.T) {
+		var reports []QuotaUsage
+		quota := &QuotaTracker{
+			Limits:      []QuotaLimit{{Window: time.Hour, MaxBytes: 1, Soft: true}},
+			OnSoftLimit: func(key string, limit QuotaLimit, usage QuotaUsage) { reports = append(reports, usage) },
+		}
+		client := &Client{Middleware: []Middleware{quota.Middleware()}}
+		for i := 0; i < 3; i++ {
+			if err := client.Get(ctx, server.URL, nil); err != nil {
+				t.Fatalf("GET request failed: %v", err)
+			}
+		}
+		if len(reports) != 2 || reports[1].Requests != 2 {
+			t.Errorf("expected 2 soft limit reports, got %+v", reports)
+		}
+	})
+
+	t.Run("sliding window", func(t *testing.T) {
+		quota := &QuotaTracker{Limits: []QuotaLimit{{Window: 50 * time.Millisecond, MaxRequests: 1}}, Resolution: 10 * time.Millisecond}
+		client := &Client{Middleware: []Middleware{quota.Middleware()}}
+		if err := client.Get(ctx, server.URL, nil); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if err := client.Get(ctx, server.URL, nil); !errors.Is(err, ErrQuotaExceeded) {
+			t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+		}
+		time.Sleep(70 * time.Millisecond)
+		if err := client.Get(ctx, server.URL, nil); err != nil {
+			t.Errorf("expected request after the window to be allowed, got %v", err)
+		}
+	})
+}
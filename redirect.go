@@ -0,0 +1,69 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultSensitiveHeaders are removed from redirected requests that leave the original origin
+var DefaultSensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+}
+
+// maxDefaultRedirects mirrors the redirect limit of net/http when no CheckRedirect is configured
+const maxDefaultRedirects = 10
+
+// checkRedirect wraps next so that sensitive headers are stripped when a redirect changes origin
+func (c *Client) checkRedirect(next func(req *http.Request, via []*http.Request) error) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > 0 && !sameOrigin(via[0].URL, req.URL) {
+			for _, key := range c.sensitiveHeaders() {
+				req.Header.Del(key)
+			}
+		}
+
+		if c.MaxRedirects > 0 && len(via) > c.MaxRedirects {
+			return fmt.Errorf("stopped after %d redirects", c.MaxRedirects)
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if c.MaxRedirects <= 0 && len(via) >= maxDefaultRedirects {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+}
+
+func (c *Client) sensitiveHeaders() []string {
+	if c.SensitiveHeaders != nil {
+		return c.SensitiveHeaders
+	}
+	return DefaultSensitiveHeaders
+}
+
+// sameOrigin reports whether a and b share scheme, host and port
+func sameOrigin(a, b *url.URL) bool {
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(originHost(a), originHost(b))
+}
+
+// originHost returns the host of u including the default port for its scheme
+func originHost(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		return u.Host + ":443"
+	case "http":
+		return u.Host + ":80"
+	}
+	return u.Host
+}
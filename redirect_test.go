@@ -0,0 +1,104 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestClient_RedirectHeaders(t *testing.T) {
+	echo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"authorization":"` + r.Header.Get("Authorization") +
+			`","api_key":"` + r.Header.Get("X-Api-Key") +
+			`","trace":"` + r.Header.Get("X-Trace") + `"}`))
+	}))
+	defer echo.Close()
+
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cross":
+			http.Redirect(w, r, echo.URL, http.StatusFound)
+		case "/same":
+			http.Redirect(w, r, "/echo", http.StatusFound)
+		default:
+			_, _ = w.Write([]byte(`{"authorization":"` + r.Header.Get("Authorization") + `"}`))
+		}
+	}))
+	defer redirector.Close()
+
+	headers := WithHeaders(map[string]string{
+		"Authorization": "Bearer secret",
+		"X-Api-Key":     "key",
+		"X-Trace":       "trace-id",
+	})
+
+	t.Run("strips sensitive headers on cross-origin redirect", func(t *testing.T) {
+		client := &Client{}
+		var result map[string]string
+		err := client.Get(context.Background(), redirector.URL+"/cross", &result, headers)
+		if err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+
+		if result["authorization"] != "" {
+			t.Errorf("expected Authorization to be stripped, got '%s'", result["authorization"])
+		}
+		if result["api_key"] != "" {
+			t.Errorf("expected X-Api-Key to be stripped, got '%s'", result["api_key"])
+		}
+		if result["trace"] != "trace-id" {
+			t.Errorf("expected X-Trace 'trace-id', got '%s'", result["trace"])
+		}
+	})
+
+	t.Run("keeps headers on same-origin redirect", func(t *testing.T) {
+		client := &Client{}
+		var result map[string]string
+		err := client.Get(context.Background(), redirector.URL+"/same", &result, headers)
+		if err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+
+		if result["authorization"] != "Bearer secret" {
+			t.Errorf("expected Authorization 'Bearer secret', got '%s'", result["authorization"])
+		}
+	})
+
+	t.Run("empty sensitive header list keeps custom headers", func(t *testing.T) {
+		client := &Client{SensitiveHeaders: []string{}}
+		var result map[string]string
+		err := client.Get(context.Background(), redirector.URL+"/cross", &result, headers)
+		if err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+
+		if result["api_key"] != "key" {
+			t.Errorf("expected X-Api-Key 'key', got '%s'", result["api_key"])
+		}
+	})
+}
+
+func TestClient_MaxRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hop, _ := strconv.Atoi(r.URL.Query().Get("hop"))
+		if hop < 15 {
+			http.Redirect(w, r, "/?hop="+strconv.Itoa(hop+1), http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	if err := (&Client{MaxRedirects: 20}).Get(context.Background(), server.URL, nil); err != nil {
+		t.Errorf("expected 15 redirects to be followed, got %v", err)
+	}
+	if err := (&Client{MaxRedirects: 12}).Get(context.Background(), server.URL, nil); err == nil || !strings.Contains(err.Error(), "stopped after 12 redirects") {
+		t.Errorf("expected error after 12 redirects, got %v", err)
+	}
+	if err := (&Client{}).Get(context.Background(), server.URL, nil); err == nil || !strings.Contains(err.Error(), "stopped after 10 redirects") {
+		t.Errorf("expected error after 10 redirects, got %v", err)
+	}
+}
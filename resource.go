@@ -0,0 +1,161 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Resource binds the CRUD operations of a REST collection to a type, so API bindings reduce to a
+// declaration:
+//
+//	pokemon := &httpclient.Resource[Pokemon]{Client: client, URL: "/pokemon"}
+//	created, err := pokemon.Create(ctx, Pokemon{Name: "pikachu"})
+//	pikachu, err := pokemon.Get(ctx, "25")
+//	all, err := pokemon.List(ctx, httpclient.WithQueryParam("type", "electric"))
+//
+// Items are addressed at URL/{id}. List follows the pages of paginated collections with Page.
+type Resource[T any] struct {
+	// Client sends the requests, defaults to Default()
+	Client *Client
+	// URL is the collection URL, appended to Client.BaseURL unless it has a scheme
+	URL string
+	// Options are applied to every request before the options of the call
+	Options []Option
+	// Page extracts the items and the next page URL of a list response, "" on the last page; defaults to
+	// decoding a JSON array without further pages. Relative next URLs are resolved against the page URL.
+	Page func(page json.RawMessage) (items []T, next string, err error)
+	// MaxPages is the maximum number of pages List fetches, defaults to DefaultMaxPages
+	MaxPages int
+}
+
+// List fetches every item of the collection, following pages
+func (r *Resource[T]) List(ctx context.Context, opts ...Option) ([]T, error) {
+	var items []T
+	var pageErr error
+	c := r.client()
+	// Next page URLs are resolved against the full URL of the collection
+	p := NewPaginator[json.RawMessage](c, c.requestURL(r.URL, nil), func(page *json.RawMessage) string {
+		pageItems, next, err := r.page(*page)
+		if err != nil {
+			pageErr = err
+			return ""
+		}
+		items = append(items, pageItems...)
+		return next
+	}, r.options(opts)...)
+	p.MaxPages = r.MaxPages
+
+	for p.HasNext() {
+		if _, err := p.Next(ctx); err != nil {
+			return items, err
+		}
+		if pageErr != nil {
+			return items, fmt.Errorf("failed to decode page: %w", pageErr)
+		}
+	}
+	return items, nil
+}
+
+// Get fetches the item with the given ID
+func (r *Resource[T]) Get(ctx context.Context, id string, opts ...Option) (*T, error) {
+	return r.do(ctx, http.MethodGet, r.itemURL(id), nil, opts)
+}
+
+// Create posts item to the collection and returns the created item, nil when the response has no body
+func (r *Resource[T]) Create(ctx context.Context, item T, opts ...Option) (*T, error) {
+	return r.do(ctx, http.MethodPost, r.URL, item, opts)
+}
+
+// Update replaces the item with the given ID and returns the updated item, nil when the response has no body
+func (r *Resource[T]) Update(ctx context.Context, id string, item T, opts ...Option) (*T, error) {
+	return r.do(ctx, http.MethodPut, r.itemURL(id), item, opts)
+}
+
+// Patch sends a partial update of the item with the given ID, such as a map or a JSONPatch, and returns the
+// updated item, nil when the response has no body
+func (r *Resource[T]) Patch(ctx context.Context, id string, patch interface{}, opts ...Option) (*T, error) {
+	return r.do(ctx, http.MethodPatch, r.itemURL(id), patch, opts)
+}
+
+// Delete deletes the item with the given ID
+func (r *Resource[T]) Delete(ctx context.Context, id string, opts ...Option) error {
+	return r.client().Do(ctx, http.MethodDelete, r.itemURL(id), nil, nil, r.options(opts)...)
+}
+
+func (r *Resource[T]) do(ctx context.Context, method, url string, body interface{}, opts []Option) (*T, error) {
+	c := r.client()
+	item := &resourceItem[T]{client: c}
+	if err := c.Do(ctx, method, url, body, item, r.options(opts)...); err != nil {
+		return nil, err
+	}
+	return item.value, nil
+}
+
+func (r *Resource[T]) client() *Client {
+	if r.Client != nil {
+		return r.Client
+	}
+	return Default()
+}
+
+func (r *Resource[T]) options(opts []Option) []Option {
+	if len(r.Options) == 0 {
+		return opts
+	}
+	return append(append(make([]Option, 0, len(r.Options)+len(opts)), r.Options...), opts...)
+}
+
+// itemURL returns the URL of the item with the given ID, keeping the query of the collection URL
+func (r *Resource[T]) itemURL(id string) string {
+	base, query, _ := strings.Cut(r.URL, "?")
+	itemURL := strings.TrimSuffix(base, "/") + "/" + url.PathEscape(id)
+	if query != "" {
+		itemURL += "?" + query
+	}
+	return itemURL
+}
+
+func (r *Resource[T]) page(page json.RawMessage) ([]T, string, error) {
+	if r.Page != nil {
+		return r.Page(page)
+	}
+	var items []T
+	if err := r.client().unmarshal(page, &items); err != nil {
+		return nil, "", err
+	}
+	return items, "", nil
+}
+
+// resourceItem decodes an item, leaving it nil for responses without body
+type resourceItem[T any] struct {
+	client *Client
+	value  *T
+}
+
+// DecodeResponse implements ResponseDecoder
+func (i *resourceItem[T]) DecodeResponse(resp *http.Response) error {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil || len(strings.TrimSpace(string(data))) == 0 {
+		return err
+	}
+	i.value = new(T)
+	return i.client.unmarshal(data, i.value)
+}
+
+// decodeEnvelope implements envelopeDecoder
+func (i *resourceItem[T]) decodeEnvelope(resp *http.Response, envelope string) error {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil || len(strings.TrimSpace(string(data))) == 0 {
+		return err
+	}
+	if data, err = unwrapEnvelope(data, envelope); err != nil {
+		return err
+	}
+	i.value = new(T)
+	return i.client.unmarshal(data, i.value)
+}
@@ -0,0 +1,141 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResource(t *testing.T) {
+	type pokemon struct {
+		ID   int    `json:"id,omitempty"`
+		Name string `json:"name"`
+	}
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/pokemon" && r.URL.Query().Get("page") == "":
+			_, _ = w.Write([]byte(`{"results":[{"id":1,"name":"bulbasaur"}],"next":"/v1/pokemon?page=2"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/pokemon":
+			_, _ = w.Write([]byte(`{"results":[{"id":25,"name":"pikachu"}],"next":""}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/pokemon/25":
+			_, _ = w.Write([]byte(`{"id":25,"name":"pikachu"}`))
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":133,"name":"eevee"}`))
+		case r.Method == http.MethodPut, r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPatch:
+			_, _ = w.Write([]byte(`{"id":25,"name":"raichu"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	resource := &Resource[pokemon]{
+		Client:  &Client{BaseURL: server.URL + "/v1"},
+		URL:     "/pokemon",
+		Options: []Option{WithHeader("X-Trainer", "ash")},
+		Page: func(page json.RawMessage) ([]pokemon, string, error) {
+			var list struct {
+				Results []pokemon `json:"results"`
+				Next    string    `json:"next"`
+			}
+			err := json.Unmarshal(page, &list)
+			return list.Results, list.Next, err
+		},
+	}
+	ctx := context.Background()
+
+	t.Run("list", func(t *testing.T) {
+		requests = nil
+		all, err := resource.List(ctx)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(all) != 2 || all[0].Name != "bulbasaur" || all[1].Name != "pikachu" {
+			t.Errorf("expected bulbasaur and pikachu, got %+v", all)
+		}
+		if len(requests) != 2 {
+			t.Errorf("expected 2 page requests, got %v", requests)
+		}
+	})
+
+	t.Run("get", func(t *testing.T) {
+		p, err := resource.Get(ctx, "25")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if p.Name != "pikachu" {
+			t.Errorf("expected pikachu, got %+v", p)
+		}
+	})
+
+	t.Run("create", func(t *testing.T) {
+		requests = nil
+		created, err := resource.Create(ctx, pokemon{Name: "eevee"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if created.ID != 133 || requests[0] != `POST /v1/pokemon {"name":"eevee"}` {
+			t.Errorf("expected created eevee, got %+v from %v", created, requests)
+		}
+	})
+
+	t.Run("update without body", func(t *testing.T) {
+		updated, err := resource.Update(ctx, "133", pokemon{ID: 133, Name: "vaporeon"})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		if updated != nil {
+			t.Errorf("expected nil item for 204, got %+v", updated)
+		}
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		patched, err := resource.Patch(ctx, "25", map[string]string{"name": "raichu"})
+		if err != nil {
+			t.Fatalf("Patch failed: %v", err)
+		}
+		if patched.Name != "raichu" {
+			t.Errorf("expected raichu, got %+v", patched)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		requests = nil
+		if err := resource.Delete(ctx, "a/b"); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if !strings.HasPrefix(requests[0], "DELETE /v1/pokemon/a%2Fb") {
+			t.Errorf("expected escaped ID, got %v", requests)
+		}
+	})
+
+	t.Run("plain array", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`[{"name":"mew"},{"name":"mewtwo"}]`))
+		}))
+		defer server.Close()
+		all, err := (&Resource[pokemon]{URL: server.URL + "/legendaries"}).List(ctx)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(all) != 2 {
+			t.Errorf("expected 2 items, got %+v", all)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if _, err := resource.Get(ctx, "0"); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -0,0 +1,272 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultMaxRetryAfter is the longest Retry-After delay waited for when Client.MaxRetryAfter is not set
+const DefaultMaxRetryAfter = time.Minute
+
+// DefaultRetryStatusCodes are the status codes retried by StatusRetryPolicy when none are configured
+var DefaultRetryStatusCodes = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooEarly,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy decides whether an attempt is retried, given either its response or its transport error
+type RetryPolicy interface {
+	ShouldRetry(req *http.Request, resp *http.Response, err error) bool
+}
+
+// RetryPolicyFunc adapts a function to the RetryPolicy interface
+type RetryPolicyFunc func(req *http.Request, resp *http.Response, err error) bool
+
+// ShouldRetry calls f(req, resp, err)
+func (f RetryPolicyFunc) ShouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	return f(req, resp, err)
+}
+
+// StatusRetryPolicy retries responses with selected status codes and transport errors accepted by a classifier
+type StatusRetryPolicy struct {
+	// StatusCodes are the retried status codes, defaults to DefaultRetryStatusCodes
+	StatusCodes []int
+	// RetryError classifies transport errors, defaults to IsRetryableError
+	RetryError func(err error) bool
+}
+
+// ShouldRetry implements RetryPolicy
+func (p StatusRetryPolicy) ShouldRetry(_ *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		if p.RetryError != nil {
+			return p.RetryError(err)
+		}
+		return IsRetryableError(err)
+	}
+
+	codes := p.StatusCodes
+	if codes == nil {
+		codes = DefaultRetryStatusCodes
+	}
+	for _, code := range codes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRetryableError reports whether a transport error is worth retrying. Cancellations, deadlines,
+// certificate errors, open circuits and requests refused by the client's own policies are not.
+func IsRetryableError(err error) bool {
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalidCertificate x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, ErrHostNotAllowed), errors.Is(err, ErrInsecureScheme), errors.Is(err, ErrBlockedAddress),
+		errors.Is(err, ErrCircuitOpen), errors.Is(err, ErrQuotaExceeded),
+		errors.As(err, &unknownAuthority), errors.As(err, &invalidCertificate), errors.As(err, &hostname):
+		return false
+	}
+	return true
+}
+
+// retryNonIdempotentKey marks contexts of requests sent with WithRetryNonIdempotent
+type retryNonIdempotentKey struct{}
+
+func retryNonIdempotent(ctx context.Context) bool {
+	allowed, _ := ctx.Value(retryNonIdempotentKey{}).(bool)
+	return allowed
+}
+
+// noRetriesKey marks contexts of requests that are sent once whatever the retry settings, such as LoadTest requests
+type noRetriesKey struct{}
+
+// beforeRetryKey carries the hook and body value of requests sent with WithBeforeRetry
+type beforeRetryKey struct{}
+
+type beforeRetry struct {
+	hook          func(attempt int, body interface{}) error
+	body          interface{}
+	transformers  []RequestTransformer
+	contentDigest bool
+	contentMD5    bool
+}
+
+// prepareRetry calls the WithBeforeRetry hook of req, if any, and sets the body of the next attempt,
+// marshalled again unless it is sent as is, with digest headers matching the new body
+func (c *Client) prepareRetry(req *http.Request, attempt int) error {
+	if before, ok := req.Context().Value(beforeRetryKey{}).(*beforeRetry); ok {
+		if err := before.hook(attempt, before.body); err != nil {
+			return fmt.Errorf("failed to prepare retry: %w", err)
+		}
+		switch before.body.(type) {
+		case nil, []byte, io.Reader:
+		default:
+			data, err := c.marshal(before.body)
+			if err != nil {
+				return fmt.Errorf("failed to marshal request body: %w", err)
+			}
+			if data, err = transformRequest(req, data, before.transformers); err != nil {
+				return fmt.Errorf("failed to transform request body: %w", err)
+			}
+			setBytesBody(req, data)
+			setBodyDigests(req.Header, data, before.contentDigest, before.contentMD5)
+			return nil
+		}
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	body, err := req.GetBody()
+	req.Body = body
+	return err
+}
+
+// isIdempotent reports whether req can be sent twice without extra side effects, which like net/http
+// includes requests carrying an Idempotency-Key header
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	_, hasKey := req.Header["Idempotency-Key"]
+	_, hasXKey := req.Header["X-Idempotency-Key"]
+	return hasKey || hasXKey
+}
+
+// needsRetries reports whether requests must go through retryTransport
+func (c *Client) needsRetries() bool {
+	if c.MaxRetries > 0 {
+		return true
+	}
+	for _, profile := range c.HostProfiles {
+		if profile.MaxRetries > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// retrySettings returns the maximum retries, policy and backoff for req, applying its host profile
+func (c *Client) retrySettings(req *http.Request) (int, RetryPolicy, Backoff) {
+	maxRetries, policy, backoff := c.MaxRetries, c.RetryPolicy, c.Backoff
+	if profile := c.hostProfile(req.URL.Hostname()); profile != nil {
+		if profile.MaxRetries != 0 {
+			maxRetries = profile.MaxRetries
+		}
+		if profile.RetryPolicy != nil {
+			policy = profile.RetryPolicy
+		}
+		if profile.Backoff != nil {
+			backoff = profile.Backoff
+		}
+	}
+	if policy == nil {
+		policy = StatusRetryPolicy{}
+	}
+	if backoff == nil {
+		backoff = ExponentialBackoff{}
+	}
+	return maxRetries, policy, backoff
+}
+
+// retryTransport retries attempts rejected by the retry policy up to MaxRetries times, replaying the
+// request body through GetBody; requests whose body cannot be replayed are sent once
+func (c *Client) retryTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		maxRetries, policy, backoff := c.retrySettings(req)
+		if req.Context().Value(noRetriesKey{}) != nil {
+			maxRetries = 0
+		}
+		replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+		// Retrying POST or PATCH could duplicate side effects
+		replayable = replayable && (isIdempotent(req) || retryNonIdempotent(req.Context()))
+
+		for attempt := 0; ; attempt++ {
+			countAttempt(req.Context())
+			attemptReq := req
+			if attempt > 0 {
+				attemptReq = req.Clone(req.Context())
+				if err := c.prepareRetry(attemptReq, attempt); err != nil {
+					return nil, err
+				}
+			}
+
+			resp, err := next.RoundTrip(attemptReq)
+			if attempt >= maxRetries || !replayable || !policy.ShouldRetry(attemptReq, resp, err) {
+				return resp, err
+			}
+
+			// Rather than sleeping for hours, give the caller the response asking for it
+			if wait, ok := retryAfter(resp); ok && wait > c.maxRetryAfter() {
+				return resp, err
+			}
+			delay := retryDelay(backoff, attempt+1, resp, err)
+			if resp != nil {
+				// Drain the body so the connection can be reused
+				_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+				_ = resp.Body.Close()
+			}
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-req.Context().Done():
+				timer.Stop()
+				return nil, req.Context().Err()
+			case <-timer.C:
+			}
+		}
+	})
+}
+
+// retryDelay honors a Retry-After header and otherwise asks backoff
+func retryDelay(backoff Backoff, attempt int, resp *http.Response, err error) time.Duration {
+	if delay, ok := retryAfter(resp); ok {
+		return delay
+	}
+	return backoff.NextDelay(attempt, resp, err)
+}
+
+// retryAfter returns the delay of the Retry-After header of resp, in seconds or as a date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(at); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func (c *Client) maxRetryAfter() time.Duration {
+	if c.MaxRetryAfter > 0 {
+		return c.MaxRetryAfter
+	}
+	return DefaultMaxRetryAfter
+}
@@ -0,0 +1,219 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first failures requests with status, echoing the request body afterwards
+func flakyServer(t *testing.T, failures int32, status int) (*httptest.Server, *int32) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if atomic.AddInt32(&attempts, 1) <= failures {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &attempts
+}
+
+func TestClient_Retry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("retries retryable statuses and replays bodies", func(t *testing.T) {
+		server, attempts := flakyServer(t, 2, http.StatusServiceUnavailable)
+		client := &Client{MaxRetries: 2}
+
+		var result map[string]string
+		if err := client.Post(ctx, server.URL, map[string]string{"name": "pikachu"}, &result, WithRetryNonIdempotent()); err != nil {
+			t.Fatalf("POST request failed: %v", err)
+		}
+		if result["name"] != "pikachu" || *attempts != 3 {
+			t.Errorf("expected echoed body after 3 attempts, got %v after %d", result, *attempts)
+		}
+	})
+
+	t.Run("does not retry non-idempotent methods by default", func(t *testing.T) {
+		server, attempts := flakyServer(t, 1, http.StatusServiceUnavailable)
+		client := &Client{MaxRetries: 2}
+
+		var httpErr *HTTPError
+		if err := client.Patch(ctx, server.URL, map[string]string{"name": "raichu"}, nil); !errors.As(err, &httpErr) {
+			t.Errorf("expected 503 HTTPError, got %v", err)
+		}
+		if *attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", *attempts)
+		}
+	})
+
+	t.Run("retries requests with idempotency keys", func(t *testing.T) {
+		server, attempts := flakyServer(t, 1, http.StatusServiceUnavailable)
+		client := &Client{MaxRetries: 2}
+
+		if err := client.Post(ctx, server.URL, map[string]string{"name": "pikachu"}, nil, WithHeader("Idempotency-Key", "order-1")); err != nil {
+			t.Fatalf("POST request failed: %v", err)
+		}
+		if *attempts != 2 {
+			t.Errorf("expected 2 attempts, got %d", *attempts)
+		}
+	})
+
+	t.Run("before retry hook marshals the body again", func(t *testing.T) {
+		server, attempts := flakyServer(t, 2, http.StatusServiceUnavailable)
+		client := &Client{MaxRetries: 2}
+
+		type payment struct{ Nonce int }
+		var retries []int
+		var result payment
+		err := client.Put(ctx, server.URL, &payment{Nonce: 1}, &result, WithBeforeRetry(func(attempt int, body interface{}) error {
+			retries = append(retries, attempt)
+			body.(*payment).Nonce++
+			return nil
+		}))
+		if err != nil {
+			t.Fatalf("PUT request failed: %v", err)
+		}
+		if result.Nonce != 3 || *attempts != 3 || len(retries) != 2 || retries[1] != 2 {
+			t.Errorf("expected nonce 3 after 2 retries, got %+v after %v", result, retries)
+		}
+	})
+
+	t.Run("before retry hook errors abort", func(t *testing.T) {
+		server, attempts := flakyServer(t, 1, http.StatusServiceUnavailable)
+		client := &Client{MaxRetries: 2}
+
+		errSigner := errors.New("signer unavailable")
+		err := client.Put(ctx, server.URL, []byte(`{}`), nil, WithBeforeRetry(func(int, interface{}) error { return errSigner }))
+		if !errors.Is(err, errSigner) || *attempts != 1 {
+			t.Errorf("expected signer error after 1 attempt, got %v after %d", err, *attempts)
+		}
+	})
+
+	t.Run("does not wait for long retry after", func(t *testing.T) {
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		client := &Client{MaxRetries: 2, MaxRetryAfter: time.Hour}
+
+		start := time.Now()
+		var httpErr *HTTPError
+		if err := client.Get(ctx, server.URL, nil); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("expected 503 HTTPError, got %v", err)
+		}
+		if attempts != 1 || time.Since(start) > time.Second {
+			t.Errorf("expected a single attempt without waiting, got %d after %v", attempts, time.Since(start))
+		}
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		server, attempts := flakyServer(t, 5, http.StatusServiceUnavailable)
+		client := &Client{MaxRetries: 1}
+
+		var httpErr *HTTPError
+		if err := client.Get(ctx, server.URL, nil); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("expected 503 HTTPError, got %v", err)
+		}
+		if *attempts != 2 {
+			t.Errorf("expected 2 attempts, got %d", *attempts)
+		}
+	})
+
+	t.Run("custom status codes", func(t *testing.T) {
+		server, attempts := flakyServer(t, 1, http.StatusNotImplemented)
+		client := &Client{MaxRetries: 3, RetryPolicy: StatusRetryPolicy{StatusCodes: []int{http.StatusServiceUnavailable}}}
+
+		if err := client.Get(ctx, server.URL, nil); err == nil {
+			t.Error("expected 501 not to be retried")
+		}
+		if *attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", *attempts)
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		server, attempts := flakyServer(t, 1, http.StatusServiceUnavailable)
+		if err := (&Client{}).Get(ctx, server.URL, nil); err == nil {
+			t.Error("expected error without retries")
+		}
+		if *attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", *attempts)
+		}
+	})
+
+	t.Run("error classifier", func(t *testing.T) {
+		var calls int32
+		client := &Client{
+			MaxRetries: 2,
+			Middleware: []Middleware{(&ChaosTransport{FailureRate: 1}).Middleware()},
+			RetryPolicy: StatusRetryPolicy{RetryError: func(err error) bool {
+				atomic.AddInt32(&calls, 1)
+				return !errors.Is(err, ErrInjectedFault)
+			}},
+		}
+		if err := client.Get(ctx, "http://pokeapi.test/", nil); !errors.Is(err, ErrInjectedFault) {
+			t.Errorf("expected ErrInjectedFault, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected classifier to be called once, got %d", calls)
+		}
+	})
+
+	t.Run("default classifier", func(t *testing.T) {
+		if IsRetryableError(context.Canceled) || IsRetryableError(ErrHostNotAllowed) {
+			t.Error("expected cancellations and policy errors not to be retryable")
+		}
+		if !IsRetryableError(io.ErrUnexpectedEOF) {
+			t.Error("expected unexpected EOF to be retryable")
+		}
+	})
+}
+
+func TestWithFallback(t *testing.T) {
+	server, attempts := flakyServer(t, 10, http.StatusServiceUnavailable)
+	client := &Client{MaxRetries: 1}
+	ctx := context.Background()
+
+	t.Run("serves default", func(t *testing.T) {
+		var result map[string]string
+		var fallbackErr error
+		err := client.Get(ctx, server.URL, &result, WithFallback(func(ctx context.Context, err error) error {
+			fallbackErr = err
+			result = map[string]string{"name": "cached"}
+			return nil
+		}))
+		if err != nil {
+			t.Fatalf("expected fallback to recover, got %v", err)
+		}
+		if result["name"] != "cached" {
+			t.Errorf("expected cached result, got %v", result)
+		}
+		var httpErr *HTTPError
+		if !errors.As(fallbackErr, &httpErr) || *attempts != 2 {
+			t.Errorf("expected fallback after 2 attempts with HTTPError, got %v after %d", fallbackErr, *attempts)
+		}
+	})
+
+	t.Run("replaces error", func(t *testing.T) {
+		errUnavailable := errors.New("pokedex unavailable")
+		err := client.Get(ctx, server.URL, nil, WithFallback(func(ctx context.Context, err error) error {
+			return errUnavailable
+		}))
+		if !errors.Is(err, errUnavailable) {
+			t.Errorf("expected fallback error, got %v", err)
+		}
+	})
+}
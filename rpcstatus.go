@@ -0,0 +1,154 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// RPCCode is a canonical gRPC status code
+type RPCCode int
+
+// Canonical gRPC status codes
+const (
+	RPCCodeOK RPCCode = iota
+	RPCCodeCanceled
+	RPCCodeUnknown
+	RPCCodeInvalidArgument
+	RPCCodeDeadlineExceeded
+	RPCCodeNotFound
+	RPCCodeAlreadyExists
+	RPCCodePermissionDenied
+	RPCCodeResourceExhausted
+	RPCCodeFailedPrecondition
+	RPCCodeAborted
+	RPCCodeOutOfRange
+	RPCCodeUnimplemented
+	RPCCodeInternal
+	RPCCodeUnavailable
+	RPCCodeDataLoss
+	RPCCodeUnauthenticated
+)
+
+var rpcCodeNames = []string{
+	"OK", "CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED", "NOT_FOUND", "ALREADY_EXISTS",
+	"PERMISSION_DENIED", "RESOURCE_EXHAUSTED", "FAILED_PRECONDITION", "ABORTED", "OUT_OF_RANGE",
+	"UNIMPLEMENTED", "INTERNAL", "UNAVAILABLE", "DATA_LOSS", "UNAUTHENTICATED",
+}
+
+// String returns the name of the code as used in google.rpc.Code, e.g. "NOT_FOUND"
+func (c RPCCode) String() string {
+	if c >= 0 && int(c) < len(rpcCodeNames) {
+		return rpcCodeNames[c]
+	}
+	return "CODE(" + strconv.Itoa(int(c)) + ")"
+}
+
+// rpcCodeFromHTTPStatus maps an HTTP status to the gRPC code gRPC-gateway would have mapped to it
+func rpcCodeFromHTTPStatus(status int) RPCCode {
+	switch status {
+	case http.StatusOK:
+		return RPCCodeOK
+	case http.StatusBadRequest:
+		return RPCCodeInvalidArgument
+	case http.StatusUnauthorized:
+		return RPCCodeUnauthenticated
+	case http.StatusForbidden:
+		return RPCCodePermissionDenied
+	case http.StatusNotFound:
+		return RPCCodeNotFound
+	case http.StatusConflict:
+		return RPCCodeAborted
+	case http.StatusPreconditionFailed:
+		return RPCCodeFailedPrecondition
+	case http.StatusRequestedRangeNotSatisfiable:
+		return RPCCodeOutOfRange
+	case http.StatusTooManyRequests:
+		return RPCCodeResourceExhausted
+	case 499:
+		return RPCCodeCanceled
+	case http.StatusNotImplemented:
+		return RPCCodeUnimplemented
+	case http.StatusServiceUnavailable:
+		return RPCCodeUnavailable
+	case http.StatusGatewayTimeout:
+		return RPCCodeDeadlineExceeded
+	}
+	if status >= 500 {
+		return RPCCodeInternal
+	}
+	return RPCCodeUnknown
+}
+
+// RPCError is a google.rpc.Status error envelope, as returned by gRPC-gateway and Google Cloud APIs
+type RPCError struct {
+	// Code is the canonical gRPC status code
+	Code RPCCode
+	// Message is the developer-facing error message
+	Message string
+	// Details holds the error details, e.g. google.rpc.BadRequest, with their "@type" field
+	Details []json.RawMessage
+	// HTTPError is the response the status was decoded from
+	HTTPError *HTTPError
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error: code = %s desc = %s", e.Code, e.Message)
+}
+
+// Unwrap returns the HTTPError so errors.As keeps matching it
+func (e *RPCError) Unwrap() error {
+	return e.HTTPError
+}
+
+// Detail returns the first detail whose "@type" is typeURL, e.g. "type.googleapis.com/google.rpc.ErrorInfo"
+func (e *RPCError) Detail(typeURL string) (json.RawMessage, bool) {
+	for _, detail := range e.Details {
+		var typed struct {
+			Type string `json:"@type"`
+		}
+		if json.Unmarshal(detail, &typed) == nil && typed.Type == typeURL {
+			return detail, true
+		}
+	}
+	return nil, false
+}
+
+// rpcStatus holds both the gRPC-gateway form, {code, message, details} with a gRPC code, and the
+// Google Cloud form nested under "error" with the HTTP status as code and the gRPC code name as status
+type rpcStatus struct {
+	Code    *int              `json:"code"`
+	Message string            `json:"message"`
+	Status  string            `json:"status"`
+	Details []json.RawMessage `json:"details"`
+	Error   *rpcStatus        `json:"error"`
+}
+
+// DecodeRPCStatus decodes a google.rpc.Status body into an *RPCError, returning nil for other bodies.
+// Use it as Client.ErrorDecoder for gRPC-gateway and Google Cloud APIs.
+func DecodeRPCStatus(httpErr *HTTPError) error {
+	var status rpcStatus
+	if err := json.Unmarshal(httpErr.Body, &status); err != nil {
+		return nil
+	}
+
+	if status.Error != nil {
+		cloud := status.Error
+		if cloud.Code == nil && cloud.Status == "" {
+			return nil
+		}
+		code := rpcCodeFromHTTPStatus(httpErr.StatusCode)
+		for i, name := range rpcCodeNames {
+			if name == cloud.Status {
+				code = RPCCode(i)
+			}
+		}
+		return &RPCError{Code: code, Message: cloud.Message, Details: cloud.Details, HTTPError: httpErr}
+	}
+
+	if status.Code == nil || status.Message == "" && status.Details == nil {
+		return nil
+	}
+	return &RPCError{Code: RPCCode(*status.Code), Message: status.Message, Details: status.Details, HTTPError: httpErr}
+}
@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDecodeRPCStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		code    RPCCode
+		message string
+	}{
+		{"grpc-gateway", http.StatusNotFound, `{"code":5,"message":"pokemon not found","details":[]}`, RPCCodeNotFound, "pokemon not found"},
+		{"cloud status name", http.StatusBadRequest, `{"error":{"code":400,"message":"bad name","status":"FAILED_PRECONDITION"}}`, RPCCodeFailedPrecondition, "bad name"},
+		{"cloud http code", http.StatusTooManyRequests, `{"error":{"code":429,"message":"quota exceeded"}}`, RPCCodeResourceExhausted, "quota exceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DecodeRPCStatus(&HTTPError{StatusCode: tt.status, Body: []byte(tt.body)})
+			var rpcErr *RPCError
+			if !errors.As(err, &rpcErr) {
+				t.Fatalf("expected RPCError, got %v", err)
+			}
+			if rpcErr.Code != tt.code || rpcErr.Message != tt.message {
+				t.Errorf("expected %s %q, got %s %q", tt.code, tt.message, rpcErr.Code, rpcErr.Message)
+			}
+		})
+	}
+
+	t.Run("other bodies", func(t *testing.T) {
+		for _, body := range []string{`not json`, `{"message":"no code"}`, `{"error":"invalid_grant"}`, `[]`} {
+			if err := DecodeRPCStatus(&HTTPError{StatusCode: http.StatusBadRequest, Body: []byte(body)}); err != nil {
+				t.Errorf("expected nil for %s, got %v", body, err)
+			}
+		}
+	})
+}
+
+func TestClient_ErrorDecoder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":3,"message":"invalid name","details":[{"@type":"type.googleapis.com/google.rpc.BadRequest","fieldViolations":[{"field":"name"}]}]}`))
+	}))
+	defer server.Close()
+
+	client := &Client{ErrorDecoder: DecodeRPCStatus}
+	err := client.Get(context.Background(), server.URL, nil)
+
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != RPCCodeInvalidArgument {
+		t.Fatalf("expected INVALID_ARGUMENT RPCError, got %v", err)
+	}
+	if rpcErr.Error() != "rpc error: code = INVALID_ARGUMENT desc = invalid name" {
+		t.Errorf("unexpected message %q", rpcErr.Error())
+	}
+	if _, ok := rpcErr.Detail("type.googleapis.com/google.rpc.BadRequest"); !ok {
+		t.Error("expected BadRequest detail")
+	}
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected wrapped 400 HTTPError, got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrLoadShed is returned for requests dropped by a Scheduler whose queue is full
+var ErrLoadShed = errors.New("request shed under load")
+
+// DefaultMaxConcurrent is the number of requests a Scheduler runs at once when MaxConcurrent is not set
+const DefaultMaxConcurrent = 10
+
+// Priority orders requests waiting for a Scheduler, set with WithPriority
+type Priority int
+
+// Request priorities, PriorityNormal is the default
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+// String returns the name of the priority
+func (p Priority) String() string {
+	switch {
+	case p < PriorityNormal:
+		return "low"
+	case p > PriorityNormal:
+		return "high"
+	}
+	return "normal"
+}
+
+// Scheduler limits the number of requests in flight. Once saturated, waiting requests are dispatched
+// highest priority first and in arrival order within a priority. Set it on Client.Scheduler and share
+// it between clients to share the limit.
+type Scheduler struct {
+	// MaxConcurrent is the number of requests in flight, defaults to DefaultMaxConcurrent
+	MaxConcurrent int
+	// MaxQueue is the number of waiting requests, zero means unlimited. When the queue is full the lowest
+	// priority request, the newest among equals, is shed with ErrLoadShed.
+	MaxQueue int
+
+	mu      sync.Mutex
+	active  int
+	waiting []*schedulerWaiter
+}
+
+type schedulerWaiter struct {
+	priority Priority
+	ready    chan error
+}
+
+func (s *Scheduler) maxConcurrent() int {
+	if s.MaxConcurrent > 0 {
+		return s.MaxConcurrent
+	}
+	return DefaultMaxConcurrent
+}
+
+// acquire waits for a slot and returns the function releasing it
+func (s *Scheduler) acquire(ctx context.Context, priority Priority) (func(), error) {
+	s.mu.Lock()
+	if s.active < s.maxConcurrent() && len(s.waiting) == 0 {
+		s.active++
+		s.mu.Unlock()
+		return s.release, nil
+	}
+
+	if s.MaxQueue > 0 && len(s.waiting) >= s.MaxQueue {
+		lowest := 0
+		for i, w := range s.waiting {
+			if w.priority <= s.waiting[lowest].priority {
+				lowest = i
+			}
+		}
+		shed := s.waiting[lowest]
+		if priority <= shed.priority {
+			s.mu.Unlock()
+			return nil, ErrLoadShed
+		}
+		s.waiting = append(s.waiting[:lowest], s.waiting[lowest+1:]...)
+		shed.ready <- ErrLoadShed
+	}
+
+	w := &schedulerWaiter{priority: priority, ready: make(chan error, 1)}
+	s.waiting = append(s.waiting, w)
+	s.mu.Unlock()
+
+	select {
+	case err := <-w.ready:
+		if err != nil {
+			return nil, err
+		}
+		return s.release, nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	for i, other := range s.waiting {
+		if other == w {
+			s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+			s.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+	s.mu.Unlock()
+	// The waiter was dispatched or shed concurrently, hand a granted slot over
+	if err := <-w.ready; err == nil {
+		s.release()
+	}
+	return nil, ctx.Err()
+}
+
+// release hands the slot to the highest priority waiter or frees it
+func (s *Scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.waiting) == 0 {
+		s.active--
+		return
+	}
+	next := 0
+	for i, w := range s.waiting {
+		if w.priority > s.waiting[next].priority {
+			next = i
+		}
+	}
+	w := s.waiting[next]
+	s.waiting = append(s.waiting[:next], s.waiting[next+1:]...)
+	w.ready <- nil
+}
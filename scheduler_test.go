@@ -0,0 +1,158 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitQueued blocks until n requests wait in s
+func waitQueued(t *testing.T, s *Scheduler, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		queued := len(s.waiting)
+		s.mu.Unlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d queued requests", n)
+}
+
+func TestScheduler(t *testing.T) {
+	t.Run("priority order", func(t *testing.T) {
+		s := &Scheduler{MaxConcurrent: 1}
+		release, err := s.acquire(context.Background(), PriorityNormal)
+		if err != nil {
+			t.Fatalf("acquire failed: %v", err)
+		}
+
+		var mu sync.Mutex
+		var order []string
+		var wg sync.WaitGroup
+		enqueue := func(name string, priority Priority, queued int) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				release, err := s.acquire(context.Background(), priority)
+				if err != nil {
+					t.Errorf("acquire failed: %v", err)
+					return
+				}
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+				release()
+			}()
+			waitQueued(t, s, queued)
+		}
+		enqueue("low", PriorityLow, 1)
+		enqueue("normal-1", PriorityNormal, 2)
+		enqueue("high", PriorityHigh, 3)
+		enqueue("normal-2", PriorityNormal, 4)
+
+		release()
+		wg.Wait()
+		expected := []string{"high", "normal-1", "normal-2", "low"}
+		for i := range expected {
+			if i >= len(order) || order[i] != expected[i] {
+				t.Fatalf("expected order %v, got %v", expected, order)
+			}
+		}
+		if s.active != 0 {
+			t.Errorf("expected no active requests, got %d", s.active)
+		}
+	})
+
+	t.Run("load shedding", func(t *testing.T) {
+		s := &Scheduler{MaxConcurrent: 1, MaxQueue: 1}
+		release, _ := s.acquire(context.Background(), PriorityNormal)
+
+		lowErr := make(chan error, 1)
+		go func() {
+			_, err := s.acquire(context.Background(), PriorityLow)
+			lowErr <- err
+		}()
+		waitQueued(t, s, 1)
+
+		if _, err := s.acquire(context.Background(), PriorityLow); !errors.Is(err, ErrLoadShed) {
+			t.Errorf("expected ErrLoadShed for an equal priority, got %v", err)
+		}
+
+		highErr := make(chan error, 1)
+		go func() {
+			release, err := s.acquire(context.Background(), PriorityHigh)
+			if err == nil {
+				release()
+			}
+			highErr <- err
+		}()
+		if err := <-lowErr; !errors.Is(err, ErrLoadShed) {
+			t.Errorf("expected low priority request to be shed, got %v", err)
+		}
+		release()
+		if err := <-highErr; err != nil {
+			t.Errorf("expected high priority request to run, got %v", err)
+		}
+	})
+
+	t.Run("context canceled while waiting", func(t *testing.T) {
+		s := &Scheduler{MaxConcurrent: 1}
+		release, _ := s.acquire(context.Background(), PriorityNormal)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := s.acquire(ctx, PriorityHigh); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+		release()
+		if s.active != 0 || len(s.waiting) != 0 {
+			t.Errorf("expected an idle scheduler, got %d active and %d waiting", s.active, len(s.waiting))
+		}
+	})
+}
+
+func TestClient_Scheduler(t *testing.T) {
+	var inFlight, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+	}))
+	defer server.Close()
+
+	c := &Client{Scheduler: &Scheduler{MaxConcurrent: 2}}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			priority := PriorityNormal
+			if i%2 == 0 {
+				priority = PriorityHigh
+			}
+			if err := c.Get(context.Background(), server.URL, nil, WithPriority(priority)); err != nil {
+				t.Errorf("Get failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Errorf("expected at most 2 requests in flight, got %d", peak)
+	}
+}
@@ -0,0 +1,42 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrResponseTooLarge is returned when a response body exceeds Client.MaxResponseBytes
+var ErrResponseTooLarge = errors.New("response too large")
+
+// Defaults applied by NewSecureClient
+const (
+	SecureTimeout          = 30 * time.Second
+	SecureMaxRedirects     = 5
+	SecureMaxResponseBytes = 10 << 20
+)
+
+// NewSecureClient returns a Client with hardened defaults, a safe starting point for services handling
+// untrusted input, unlike the permissive zero value Client:
+//   - only HTTPS requests and redirects, with TLS 1.2 or later
+//   - at most SecureMaxRedirects redirects, stripping sensitive headers across origins
+//   - response bodies limited to SecureMaxResponseBytes
+//   - a SecureTimeout overall timeout
+//   - SSRFGuard refusing connections to loopback, private and link-local addresses
+//
+// Fields may be adjusted afterwards, e.g. to allow-list internal networks in the guard.
+func NewSecureClient() *Client {
+	guard := &SSRFGuard{}
+	transport := guard.Transport()
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	transport.TLSHandshakeTimeout = 10 * time.Second
+	transport.ResponseHeaderTimeout = SecureTimeout
+
+	return &Client{
+		Client:           &http.Client{Transport: transport, Timeout: SecureTimeout},
+		RequireHTTPS:     true,
+		MaxRedirects:     SecureMaxRedirects,
+		MaxResponseBytes: SecureMaxResponseBytes,
+	}
+}
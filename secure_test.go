@@ -0,0 +1,79 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewSecureClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/downgrade":
+			http.Redirect(w, r, "http://"+r.Host+"/", http.StatusFound)
+		case "/large":
+			_, _ = w.Write([]byte(`"` + strings.Repeat("a", 100) + `"`))
+		default:
+			_, _ = w.Write([]byte(`{"name":"pikachu"}`))
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	t.Run("rejects plain http", func(t *testing.T) {
+		err := NewSecureClient().Get(ctx, "http://example.com/", nil)
+		if !errors.Is(err, ErrInsecureScheme) {
+			t.Errorf("expected ErrInsecureScheme, got %v", err)
+		}
+	})
+
+	t.Run("blocks loopback", func(t *testing.T) {
+		err := NewSecureClient().Get(ctx, server.URL, nil)
+		if !errors.Is(err, ErrBlockedAddress) {
+			t.Errorf("expected ErrBlockedAddress, got %v", err)
+		}
+	})
+
+	// The remaining checks use the test server transport, which trusts its certificate
+	client := NewSecureClient()
+	client.Client = server.Client()
+
+	t.Run("allows https", func(t *testing.T) {
+		var result map[string]string
+		if err := client.Get(ctx, server.URL, &result); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if result["name"] != "pikachu" {
+			t.Errorf("expected name 'pikachu', got '%s'", result["name"])
+		}
+	})
+
+	t.Run("rejects downgrade redirects", func(t *testing.T) {
+		err := client.Get(ctx, server.URL+"/downgrade", nil)
+		if !errors.Is(err, ErrInsecureScheme) {
+			t.Errorf("expected ErrInsecureScheme, got %v", err)
+		}
+	})
+
+	t.Run("caps redirects", func(t *testing.T) {
+		err := client.Get(ctx, server.URL+"/loop", nil)
+		if err == nil || !strings.Contains(err.Error(), "stopped after 5 redirects") {
+			t.Errorf("expected redirect cap error, got %v", err)
+		}
+	})
+
+	t.Run("limits response size", func(t *testing.T) {
+		limited := *client
+		limited.MaxResponseBytes = 50
+		var result string
+		err := limited.Get(ctx, server.URL+"/large", &result)
+		if !errors.Is(err, ErrResponseTooLarge) {
+			t.Errorf("expected ErrResponseTooLarge, got %v", err)
+		}
+	})
+}
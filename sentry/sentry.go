@@ -0,0 +1,178 @@
+// Package sentry records requests as Sentry breadcrumbs and captures failed requests with redacted metadata,
+// without depending on sentry-go. A few lines adapt a sentry-go hub to the Reporter interface:
+//
+//	type hubReporter struct{}
+//
+//	func (hubReporter) AddBreadcrumb(ctx context.Context, b sentryhook.Breadcrumb) {
+//	    sentry.GetHubFromContext(ctx).AddBreadcrumb(&sentry.Breadcrumb{Type: b.Type, Category: b.Category,
+//	        Message: b.Message, Level: sentry.Level(b.Level), Data: b.Data, Timestamp: b.Timestamp}, nil)
+//	}
+//	func (hubReporter) CaptureError(ctx context.Context, err error, request map[string]interface{}) {
+//	    hub := sentry.GetHubFromContext(ctx)
+//	    hub.WithScope(func(scope *sentry.Scope) {
+//	        scope.SetContext("http request", request)
+//	        hub.CaptureException(err)
+//	    })
+//	}
+//
+//	hook := &sentryhook.Hook{Reporter: hubReporter{}}
+//	client := &httpclient.Client{Middleware: []httpclient.Middleware{hook.Middleware()}, OnError: hook.OnError}
+//
+// Every attempt becomes a breadcrumb, while only requests still failing once their retries are exhausted are
+// captured. Credentials never leave the process: sensitive headers, URL user info and query values are redacted.
+package sentry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+// RedactedValue replaces the values of redacted headers and query parameters, as Sentry filters data
+const RedactedValue = "[Filtered]"
+
+// Breadcrumb levels, matching the sentry-go levels
+const (
+	LevelInfo    = "info"
+	LevelWarning = "warning"
+	LevelError   = "error"
+)
+
+// Breadcrumb is a Sentry breadcrumb recording one attempt of a request
+type Breadcrumb struct {
+	// Type is the breadcrumb type, "http"
+	Type string
+	// Category is the breadcrumb category, "http"
+	Category string
+	// Message summarizes the attempt, e.g. "GET https://api.example.com/pokemon [404]"
+	Message string
+	// Level is LevelError for status 500 and above or transport errors, LevelWarning for status 400 and above,
+	// LevelInfo otherwise
+	Level string
+	// Data holds the redacted url, the method, the status_code and the reason of failed attempts
+	Data map[string]interface{}
+	// Timestamp is the time the attempt started
+	Timestamp time.Time
+}
+
+// Reporter sends breadcrumbs and errors to Sentry, as implemented by sentry-go hubs with the adapter shown in
+// the package documentation
+type Reporter interface {
+	// AddBreadcrumb records a breadcrumb on the hub of ctx
+	AddBreadcrumb(ctx context.Context, breadcrumb Breadcrumb)
+	// CaptureError reports err with the redacted method, url, headers and status_code of its request
+	CaptureError(ctx context.Context, err error, request map[string]interface{})
+}
+
+// Hook records breadcrumbs with its Middleware and captures failed requests with OnError
+type Hook struct {
+	// Reporter receives breadcrumbs and errors, it is required
+	Reporter Reporter
+	// RedactHeaders are the request headers whose values are redacted, defaults to
+	// httpclient.DefaultSensitiveHeaders
+	RedactHeaders []string
+	// Capture selects the errors that are captured, defaults to all of them but context cancellations
+	Capture func(err error) bool
+}
+
+// Middleware records a breadcrumb for every attempt
+func (h *Hook) Middleware() httpclient.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+
+			breadcrumb := Breadcrumb{
+				Type:      "http",
+				Category:  "http",
+				Level:     LevelInfo,
+				Message:   req.Method + " " + redactURL(req.URL),
+				Data:      map[string]interface{}{"url": redactURL(req.URL), "method": req.Method},
+				Timestamp: start,
+			}
+			switch {
+			case err != nil:
+				breadcrumb.Level = LevelError
+				breadcrumb.Data["reason"] = err.Error()
+			case resp.StatusCode >= 500:
+				breadcrumb.Level = LevelError
+			case resp.StatusCode >= 400:
+				breadcrumb.Level = LevelWarning
+			}
+			if resp != nil {
+				breadcrumb.Message += " [" + strconv.Itoa(resp.StatusCode) + "]"
+				breadcrumb.Data["status_code"] = resp.StatusCode
+				if resp.StatusCode >= 400 {
+					breadcrumb.Data["reason"] = resp.Status
+				}
+			}
+			h.Reporter.AddBreadcrumb(req.Context(), breadcrumb)
+			return resp, err
+		})
+	}
+}
+
+// OnError captures a request failing once its retries are exhausted, it is meant for Client.OnError
+func (h *Hook) OnError(req *http.Request, err error) {
+	if !h.capture(err) {
+		return
+	}
+	request := map[string]interface{}{
+		"method":  req.Method,
+		"url":     redactURL(req.URL),
+		"headers": h.redactHeaders(req.Header),
+	}
+	var httpErr *httpclient.HTTPError
+	if errors.As(err, &httpErr) {
+		request["status_code"] = httpErr.StatusCode
+	}
+	h.Reporter.CaptureError(req.Context(), err, request)
+}
+
+func (h *Hook) capture(err error) bool {
+	if h.Capture != nil {
+		return h.Capture(err)
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// redactHeaders flattens header, redacting the values of sensitive headers
+func (h *Hook) redactHeaders(header http.Header) map[string]string {
+	names := h.RedactHeaders
+	if names == nil {
+		names = httpclient.DefaultSensitiveHeaders
+	}
+	redacted := make(map[string]string, len(header))
+	for name := range header {
+		redacted[name] = header.Get(name)
+	}
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		if _, ok := redacted[name]; ok {
+			redacted[name] = RedactedValue
+		}
+	}
+	return redacted
+}
+
+// redactURL returns u without user info and fragment, with the values of its query parameters redacted
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User, redacted.Fragment = nil, ""
+	if query := u.Query(); len(query) > 0 {
+		keys := make([]string, 0, len(query))
+		for key := range query {
+			keys = append(keys, url.QueryEscape(key)+"="+RedactedValue)
+		}
+		sort.Strings(keys)
+		redacted.RawQuery = strings.Join(keys, "&")
+	}
+	return redacted.String()
+}
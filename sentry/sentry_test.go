@@ -0,0 +1,113 @@
+package sentry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/llkhacquan/httpclient"
+)
+
+type fakeReporter struct {
+	mu          sync.Mutex
+	breadcrumbs []Breadcrumb
+	errors      []error
+	requests    []map[string]interface{}
+}
+
+func (r *fakeReporter) AddBreadcrumb(ctx context.Context, breadcrumb Breadcrumb) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.breadcrumbs = append(r.breadcrumbs, breadcrumb)
+}
+
+func (r *fakeReporter) CaptureError(ctx context.Context, err error, request map[string]interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, err)
+	r.requests = append(r.requests, request)
+}
+
+func TestHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	reporter := &fakeReporter{}
+	hook := &Hook{Reporter: reporter}
+	client := &httpclient.Client{
+		MaxRetries: 2,
+		Backoff:    httpclient.ConstantBackoff(0),
+		Middleware: []httpclient.Middleware{hook.Middleware()},
+		OnError:    hook.OnError,
+	}
+
+	t.Run("success", func(t *testing.T) {
+		err := client.Get(context.Background(), server.URL+"/pokemon?token=secret", nil,
+			httpclient.WithHeader("Authorization", "Bearer secret"))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if len(reporter.breadcrumbs) != 1 || len(reporter.errors) != 0 {
+			t.Fatalf("expected one breadcrumb and no error, got %d and %d", len(reporter.breadcrumbs), len(reporter.errors))
+		}
+		breadcrumb := reporter.breadcrumbs[0]
+		if breadcrumb.Level != LevelInfo || breadcrumb.Data["status_code"] != http.StatusNoContent {
+			t.Errorf("expected info breadcrumb with status 204, got %+v", breadcrumb)
+		}
+		if url := breadcrumb.Data["url"]; url != server.URL+"/pokemon?token=[Filtered]" {
+			t.Errorf("expected redacted url, got %v", url)
+		}
+	})
+
+	t.Run("exhausted retries", func(t *testing.T) {
+		reporter.breadcrumbs = nil
+		err := client.Get(context.Background(), server.URL+"/broken?token=secret", nil,
+			httpclient.WithHeader("Authorization", "Bearer secret"), httpclient.WithHeader("X-Request-Id", "42"))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if len(reporter.breadcrumbs) != 3 {
+			t.Errorf("expected a breadcrumb per attempt, got %d", len(reporter.breadcrumbs))
+		}
+		for _, breadcrumb := range reporter.breadcrumbs {
+			if breadcrumb.Level != LevelError || !strings.HasSuffix(breadcrumb.Message, "[503]") {
+				t.Errorf("expected error breadcrumb for 503, got %+v", breadcrumb)
+			}
+		}
+		if len(reporter.errors) != 1 {
+			t.Fatalf("expected one captured error, got %d", len(reporter.errors))
+		}
+		request := reporter.requests[0]
+		headers := request["headers"].(map[string]string)
+		if headers["Authorization"] != RedactedValue || headers["X-Request-Id"] != "42" {
+			t.Errorf("expected redacted Authorization only, got %v", headers)
+		}
+		if request["status_code"] != http.StatusServiceUnavailable || request["method"] != http.MethodGet {
+			t.Errorf("expected GET with status 503, got %v", request)
+		}
+		if url := request["url"].(string); strings.Contains(url, "secret") {
+			t.Errorf("expected redacted url, got %s", url)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		reporter.errors = nil
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := client.Get(ctx, server.URL+"/pokemon", nil); err == nil {
+			t.Fatal("expected error")
+		}
+		if len(reporter.errors) != 0 {
+			t.Errorf("expected canceled request not to be captured, got %v", reporter.errors)
+		}
+	})
+}
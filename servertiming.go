@@ -0,0 +1,78 @@
+package httpclient
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerTiming is a metric of a Server-Timing response header, e.g. `db;dur=53;desc="Query"`
+type ServerTiming struct {
+	// Name is the metric name, e.g. "db"
+	Name string
+	// Duration is the time spent, zero when the metric has no dur parameter
+	Duration time.Duration
+	// Description is the human readable description, optional
+	Description string
+}
+
+// ParseServerTiming parses the metrics of Server-Timing header values, skipping malformed ones
+func ParseServerTiming(values ...string) []ServerTiming {
+	var timings []ServerTiming
+	for _, value := range values {
+		for _, metric := range splitQuoted(value, ',') {
+			params := splitQuoted(metric, ';')
+			timing := ServerTiming{Name: strings.TrimSpace(params[0])}
+			if timing.Name == "" {
+				continue
+			}
+			for _, param := range params[1:] {
+				name, value, _ := strings.Cut(param, "=")
+				value = unquote(strings.TrimSpace(value))
+				switch strings.ToLower(strings.TrimSpace(name)) {
+				case "dur":
+					if ms, err := strconv.ParseFloat(value, 64); err == nil {
+						timing.Duration = time.Duration(ms * float64(time.Millisecond))
+					}
+				case "desc":
+					timing.Description = value
+				}
+			}
+			timings = append(timings, timing)
+		}
+	}
+	return timings
+}
+
+// splitQuoted splits s around sep outside of quoted strings
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquote returns the content of an HTTP quoted string, s itself when it is a token
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	var b strings.Builder
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] == '\\' && i+1 < len(s)-1 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
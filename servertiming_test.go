@@ -0,0 +1,40 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseServerTiming(t *testing.T) {
+	got := ParseServerTiming(`cache;desc="Cache Read";dur=23.2, db;dur=53`, `miss, app;DUR=47.5;desc="a, \"b\""`, ` ;dur=1`)
+	want := []ServerTiming{
+		{Name: "cache", Duration: 23200 * time.Microsecond, Description: "Cache Read"},
+		{Name: "db", Duration: 53 * time.Millisecond},
+		{Name: "miss"},
+		{Name: "app", Duration: 47500 * time.Microsecond, Description: `a, "b"`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestWithServerTiming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server-Timing", "db;dur=12.5")
+		w.Header().Add("Server-Timing", `render;dur=3;desc="Template"`)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var timings []ServerTiming
+	if err := (&Client{}).Get(context.Background(), server.URL, nil, WithServerTiming(&timings)); err != nil {
+		t.Fatalf("GET request failed: %v", err)
+	}
+	if len(timings) != 2 || timings[0].Duration != 12500*time.Microsecond || timings[1].Description != "Template" {
+		t.Errorf("unexpected timings %+v", timings)
+	}
+}
@@ -0,0 +1,144 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultShadowTimeout bounds mirrored requests when Shadow.Timeout is not set
+const DefaultShadowTimeout = 10 * time.Second
+
+// Shadow mirrors a share of the requests to a secondary base URL in the background, discarding the
+// responses, so a new API version or vendor can be validated against production traffic safely.
+// The primary request is never delayed or affected by the mirror.
+//
+// Mirrored requests carry the original headers, including credentials; use a Middleware on the
+// Transport to change them for a different vendor. Requests with bodies that cannot be replayed,
+// such as streamed io.Reader bodies, are not mirrored.
+type Shadow struct {
+	// BaseURL receives the mirrored requests, its scheme and host replace the original ones and its
+	// path is prepended to the original path
+	BaseURL string
+	// Rate is the share in [0, 1] of requests mirrored
+	Rate float64
+	// Transport sends mirrored requests, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+	// Timeout bounds every mirrored request, defaults to DefaultShadowTimeout
+	Timeout time.Duration
+	// OnError is called for mirrored requests failing or answering 500 and above, defaults to logging them
+	OnError func(req *http.Request, err error)
+	// Rand returns random numbers in [0, 1), defaults to math/rand.Float64
+	Rand func() float64
+
+	wg sync.WaitGroup
+}
+
+// Middleware returns a Middleware mirroring requests passed to next
+func (s *Shadow) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if s.sample() {
+				if shadow, cancel, err := s.request(req); err != nil {
+					s.report(req, err)
+				} else if shadow != nil {
+					s.wg.Add(1)
+					go s.send(shadow, cancel)
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// Wait blocks until mirrored requests in flight complete, e.g. before the process exits
+func (s *Shadow) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Shadow) sample() bool {
+	if s.Rate <= 0 {
+		return false
+	}
+	random := rand.Float64
+	if s.Rand != nil {
+		random = s.Rand
+	}
+	return random() < s.Rate
+}
+
+// request builds the mirrored request and the function canceling its context,
+// a nil request when the body cannot be replayed
+func (s *Shadow) request(req *http.Request) (*http.Request, context.CancelFunc, error) {
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	if hasBody && req.GetBody == nil {
+		return nil, nil, nil
+	}
+	base, err := url.Parse(s.BaseURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse shadow base URL: %w", err)
+	}
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultShadowTimeout
+	}
+	// The mirror outlives the primary request, so it must not share its context
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	shadow := req.Clone(ctx)
+	rebase(shadow, base)
+	if hasBody {
+		if shadow.Body, err = req.GetBody(); err != nil {
+			cancel()
+			return nil, nil, fmt.Errorf("failed to copy request body: %w", err)
+		}
+	}
+	return shadow, cancel, nil
+}
+
+// rebase points req at base, replacing scheme and host and prefixing the path
+func rebase(req *http.Request, base *url.URL) {
+	req.URL.Scheme = base.Scheme
+	req.URL.Host = base.Host
+	req.URL.Path = strings.TrimSuffix(base.Path, "/") + req.URL.Path
+	req.URL.RawPath = ""
+	req.Host = ""
+}
+
+func (s *Shadow) send(req *http.Request, cancel context.CancelFunc) {
+	defer s.wg.Done()
+	defer cancel()
+
+	transport := s.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		s.report(req, err)
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		s.report(req, fmt.Errorf("shadow response status %s", resp.Status))
+	}
+}
+
+func (s *Shadow) report(req *http.Request, err error) {
+	if s.OnError != nil {
+		s.OnError(req, err)
+		return
+	}
+	log.Printf("httpclient: shadow %s %s failed: %v", req.Method, req.URL.Redacted(), err)
+}
@@ -0,0 +1,88 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestShadow(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"version":"v1"}`))
+	}))
+	defer primary.Close()
+
+	var mu sync.Mutex
+	var mirrored []string
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		mirrored = append(mirrored, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+		mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/broken") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"version":"v2"}`))
+	}))
+	defer secondary.Close()
+
+	var errs []string
+	shadow := &Shadow{
+		BaseURL: secondary.URL + "/v2",
+		Rate:    1,
+		OnError: func(req *http.Request, err error) {
+			mu.Lock()
+			errs = append(errs, req.URL.Path+": "+err.Error())
+			mu.Unlock()
+		},
+	}
+	c := &Client{Middleware: []Middleware{shadow.Middleware()}}
+	ctx := context.Background()
+
+	var result map[string]string
+	if err := c.Post(ctx, primary.URL+"/orders?id=1", map[string]int{"qty": 2}, &result); err != nil {
+		t.Fatalf("Post failed: %v", err)
+	}
+	if result["version"] != "v1" {
+		t.Errorf("expected the primary response, got %v", result)
+	}
+	if err := c.Get(ctx, primary.URL+"/broken", nil); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if err := c.Post(ctx, primary.URL+"/stream", io.MultiReader(strings.NewReader("data")), nil); err != nil {
+		t.Fatalf("Post failed: %v", err)
+	}
+	shadow.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(mirrored) != 2 {
+		t.Fatalf("expected 2 mirrored requests, got %v", mirrored)
+	}
+	if mirrored[0] != `POST /v2/orders?id=1 {"qty":2}` && mirrored[1] != `POST /v2/orders?id=1 {"qty":2}` {
+		t.Errorf("expected the mirrored POST, got %v", mirrored)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0], "/v2/broken: shadow response status 500") {
+		t.Errorf("expected the 500 to be reported, got %v", errs)
+	}
+
+	t.Run("rate", func(t *testing.T) {
+		rolls := []float64{0.05, 0.5}
+		s := &Shadow{Rate: 0.1, Rand: func() float64 {
+			r := rolls[0]
+			rolls = rolls[1:]
+			return r
+		}}
+		if !s.sample() || s.sample() {
+			t.Errorf("expected only rolls below the rate to be mirrored")
+		}
+		if (&Shadow{}).sample() {
+			t.Errorf("expected a zero rate to mirror nothing")
+		}
+	})
+}
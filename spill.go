@@ -0,0 +1,105 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// spillWriter buffers writes in memory until they exceed threshold, then moves them to a temporary file
+type spillWriter struct {
+	threshold int64
+	dir       string
+	buf       bytes.Buffer
+	file      *os.File
+	size      int64
+}
+
+func (w *spillWriter) Write(p []byte) (int, error) {
+	if w.file == nil && int64(w.buf.Len()+len(p)) > w.threshold {
+		file, err := os.CreateTemp(w.dir, "httpclient-body-*")
+		if err != nil {
+			return 0, err
+		}
+		w.file = file
+		if _, err := w.buf.WriteTo(file); err != nil {
+			return 0, err
+		}
+	}
+	var n int
+	var err error
+	if w.file != nil {
+		n, err = w.file.Write(p)
+	} else {
+		n, err = w.buf.Write(p)
+	}
+	w.size += int64(n)
+	return n, err
+}
+
+// discard closes and removes the temporary file, if any
+func (w *spillWriter) discard() {
+	if w.file != nil {
+		_ = w.file.Close()
+		_ = os.Remove(w.file.Name())
+	}
+}
+
+// spillJSON marshals v like json.Marshal, into a spilledBody when it is larger than the client's
+// SpillThreshold and into memory otherwise, in which case the returned body is nil
+func (c *Client) spillJSON(v interface{}) (*spilledBody, []byte, error) {
+	w := &spillWriter{threshold: c.SpillThreshold, dir: c.SpillDir}
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		w.discard()
+		return nil, nil, err
+	}
+	if w.file == nil {
+		data := w.buf.Bytes()
+		return nil, data[:len(data)-1], nil
+	}
+	// Leave out the trailing newline of json.Encoder
+	return &spilledBody{file: w.file, size: w.size - 1, refs: 1}, nil, nil
+}
+
+// spilledBody shares a temporary file between the readers of a request body like pooledBody,
+// removing the file once the owner and every reader released it
+type spilledBody struct {
+	file *os.File
+	size int64
+	refs int32
+}
+
+// reader returns a new reader over the body, failing when the file was already removed
+func (s *spilledBody) reader() (io.ReadCloser, error) {
+	for {
+		refs := atomic.LoadInt32(&s.refs)
+		if refs <= 0 {
+			return nil, errors.New("request body already released")
+		}
+		if atomic.CompareAndSwapInt32(&s.refs, refs, refs+1) {
+			return &spilledReader{SectionReader: io.NewSectionReader(s.file, 0, s.size), body: s}, nil
+		}
+	}
+}
+
+func (s *spilledBody) release() {
+	if atomic.AddInt32(&s.refs, -1) == 0 {
+		_ = s.file.Close()
+		_ = os.Remove(s.file.Name())
+	}
+}
+
+type spilledReader struct {
+	*io.SectionReader
+	body *spilledBody
+	once sync.Once
+}
+
+func (r *spilledReader) Close() error {
+	r.once.Do(r.body.release)
+	return nil
+}
@@ -0,0 +1,52 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_SpillThreshold(t *testing.T) {
+	var attempts int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if r.ContentLength != int64(len(body)) {
+			t.Errorf("expected Content-Length %d, got %d", len(body), r.ContentLength)
+		}
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	client := &Client{SpillThreshold: 64, SpillDir: dir, MaxRetries: 1, Backoff: ConstantBackoff(time.Millisecond)}
+	items := map[string]string{"payload": strings.Repeat("x", 100)}
+	if err := client.Put(context.Background(), server.URL, items, nil); err != nil {
+		t.Fatalf("PUT request failed: %v", err)
+	}
+	want := `{"payload":"` + strings.Repeat("x", 100) + `"}`
+	if len(bodies) != 2 || bodies[0] != want || bodies[1] != want {
+		t.Errorf("expected body %s twice, got %v", want, bodies)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected temporary files to be removed, got %d", len(entries))
+	}
+
+	t.Run("small bodies stay in memory", func(t *testing.T) {
+		body, data, err := (&Client{SpillThreshold: 64, SpillDir: dir}).spillJSON(map[string]int{"id": 1})
+		if err != nil || body != nil || string(data) != `{"id":1}` {
+			t.Errorf("expected in-memory body, got %v, %s, %v", body, data, err)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestSSRFGuard(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"pikachu"}`))
+	}))
+	defer server.Close()
+
+	t.Run("check", func(t *testing.T) {
+		guard := &SSRFGuard{Allow: []netip.Prefix{netip.MustParsePrefix("10.1.0.0/16")}}
+		tests := map[string]bool{
+			"127.0.0.1":        true,
+			"10.0.0.5":         true,
+			"10.1.2.3":         false,
+			"172.16.0.1":       true,
+			"192.168.1.1":      true,
+			"169.254.169.254":  true,
+			"0.0.0.0":          true,
+			"::1":              true,
+			"fd00::1":          true,
+			"fe80::1":          true,
+			"::ffff:127.0.0.1": true,
+			"8.8.8.8":          false,
+			"2606:4700::1111":  false,
+		}
+		for addr, blocked := range tests {
+			err := guard.Check(netip.MustParseAddr(addr))
+			if blocked != errors.Is(err, ErrBlockedAddress) {
+				t.Errorf("expected %s blocked=%v, got %v", addr, blocked, err)
+			}
+		}
+	})
+
+	t.Run("blocks loopback", func(t *testing.T) {
+		guard := &SSRFGuard{}
+		client := &Client{Client: &http.Client{Transport: guard.Transport()}}
+		err := client.Get(context.Background(), server.URL, nil)
+		if !errors.Is(err, ErrBlockedAddress) {
+			t.Errorf("expected ErrBlockedAddress, got %v", err)
+		}
+	})
+
+	t.Run("allow list", func(t *testing.T) {
+		guard := &SSRFGuard{Allow: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}}
+		client := &Client{Client: &http.Client{Transport: guard.Transport()}}
+		var result map[string]string
+		if err := client.Get(context.Background(), server.URL, &result); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if result["name"] != "pikachu" {
+			t.Errorf("expected name 'pikachu', got '%s'", result["name"])
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownTemplate is returned when executing a template that was not registered
+var ErrUnknownTemplate = errors.New("unknown request template")
+
+// templatePlaceholder matches {{name}} placeholders
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// RequestTemplate is a reusable request whose URL, headers and body contain {{name}} placeholders
+// filled from a parameter map when executed. In the body, a string made of a single placeholder is
+// replaced by the parameter value itself, keeping numbers, booleans and objects as they are.
+type RequestTemplate struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body is the JSON body skeleton, nil sends no body
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// Templates is a registry of named request templates executed with a client, e.g. loaded from
+// configuration for integrations defined without code:
+//
+//	templates := httpclient.NewTemplates(client)
+//	templates.Register("notify", &httpclient.RequestTemplate{
+//	    Method: "POST",
+//	    URL:    "https://hooks.example.com/{{channel}}",
+//	    Body:   json.RawMessage(`{"text": "{{message}}", "priority": "{{priority}}"}`),
+//	})
+//	err := templates.Execute(ctx, "notify", map[string]interface{}{"channel": "ops", "message": "deployed", "priority": 1}, nil)
+type Templates struct {
+	client *Client
+
+	mu        sync.RWMutex
+	templates map[string]*RequestTemplate
+}
+
+// NewTemplates returns an empty registry sending requests with c, a nil client uses the default client
+func NewTemplates(c *Client) *Templates {
+	return &Templates{client: c, templates: make(map[string]*RequestTemplate)}
+}
+
+// Register adds or replaces the template called name
+func (t *Templates) Register(name string, tmpl *RequestTemplate) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.templates[name] = tmpl
+}
+
+// Load registers the templates of a JSON object mapping names to templates
+func (t *Templates) Load(r io.Reader) error {
+	var templates map[string]*RequestTemplate
+	if err := json.NewDecoder(r).Decode(&templates); err != nil {
+		return fmt.Errorf("failed to parse request templates: %w", err)
+	}
+	for name, tmpl := range templates {
+		t.Register(name, tmpl)
+	}
+	return nil
+}
+
+// Execute fills the template called name with params and sends it, decoding the response into result
+func (t *Templates) Execute(ctx context.Context, name string, params map[string]interface{}, result interface{}, opts ...Option) error {
+	t.mu.RLock()
+	tmpl, ok := t.templates[name]
+	t.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+
+	rawURL, err := expandURL(tmpl.URL, params)
+	if err != nil {
+		return err
+	}
+	headers := make(map[string]string, len(tmpl.Headers))
+	for key, value := range tmpl.Headers {
+		if headers[key], err = expandString(value, params, nil); err != nil {
+			return err
+		}
+	}
+
+	var body interface{}
+	if len(tmpl.Body) > 0 {
+		var skeleton interface{}
+		if err := json.Unmarshal(tmpl.Body, &skeleton); err != nil {
+			return fmt.Errorf("failed to parse body of template %s: %w", name, err)
+		}
+		if body, err = expandValue(skeleton, params); err != nil {
+			return err
+		}
+	}
+
+	c := t.client
+	if c == nil {
+		c = Default()
+	}
+	return c.Do(ctx, tmpl.Method, rawURL, body, result, append([]Option{WithHeaders(headers)}, opts...)...)
+}
+
+// expandURL fills placeholders, escaping values as path segments before the query and as query values after it
+func expandURL(rawURL string, params map[string]interface{}) (string, error) {
+	path, query, hasQuery := strings.Cut(rawURL, "?")
+	path, err := expandString(path, params, url.PathEscape)
+	if err != nil || !hasQuery {
+		return path, err
+	}
+	query, err = expandString(query, params, url.QueryEscape)
+	return path + "?" + query, err
+}
+
+// expandString replaces every placeholder of s with its parameter, escaped when escape is set
+func expandString(s string, params map[string]interface{}, escape func(string) string) (string, error) {
+	var missing string
+	expanded := templatePlaceholder.ReplaceAllStringFunc(s, func(match string) string {
+		name := templatePlaceholder.FindStringSubmatch(match)[1]
+		value, ok := params[name]
+		if !ok {
+			missing = name
+			return match
+		}
+		str := fmt.Sprint(value)
+		if escape != nil {
+			str = escape(str)
+		}
+		return str
+	})
+	if missing != "" {
+		return "", fmt.Errorf("missing template parameter %q", missing)
+	}
+	return expanded, nil
+}
+
+// expandValue fills placeholders in a decoded JSON value
+func expandValue(v interface{}, params map[string]interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		if m := templatePlaceholder.FindStringSubmatch(v); m != nil && m[0] == v {
+			value, ok := params[m[1]]
+			if !ok {
+				return nil, fmt.Errorf("missing template parameter %q", m[1])
+			}
+			return value, nil
+		}
+		return expandString(v, params, nil)
+	case map[string]interface{}:
+		for key, value := range v {
+			expanded, err := expandValue(value, params)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = expanded
+		}
+	case []interface{}:
+		for i, value := range v {
+			expanded, err := expandValue(value, params)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,87 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTemplates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"path":  r.URL.EscapedPath(),
+			"query": r.URL.RawQuery,
+			"token": r.Header.Get("Authorization"),
+			"body":  string(body),
+		})
+	}))
+	defer server.Close()
+
+	templates := NewTemplates(&Client{})
+	err := templates.Load(strings.NewReader(`{
+		"notify": {
+			"method": "POST",
+			"url": "` + server.URL + `/hooks/{{channel}}?source={{source}}",
+			"headers": {"Authorization": "Bearer {{token}}"},
+			"body": {"text": "Deployed {{version}}", "priority": "{{priority}}", "tags": ["{{tag}}"]}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	ctx := context.Background()
+
+	t.Run("execute", func(t *testing.T) {
+		var result map[string]string
+		err := templates.Execute(ctx, "notify", map[string]interface{}{
+			"channel":  "ops team",
+			"source":   "ci&cd",
+			"token":    "secret",
+			"version":  "v1.2",
+			"priority": 1,
+			"tag":      map[string]bool{"urgent": true},
+		}, &result)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result["path"] != "/hooks/ops%20team" || result["query"] != "source=ci%26cd" {
+			t.Errorf("expected escaped URL, got %s?%s", result["path"], result["query"])
+		}
+		if result["token"] != "Bearer secret" {
+			t.Errorf("expected Authorization header, got %q", result["token"])
+		}
+		if result["body"] != `{"priority":1,"tags":[{"urgent":true}],"text":"Deployed v1.2"}` {
+			t.Errorf("expected typed body values, got %s", result["body"])
+		}
+	})
+
+	t.Run("missing parameter", func(t *testing.T) {
+		err := templates.Execute(ctx, "notify", map[string]interface{}{"channel": "ops"}, nil)
+		if err == nil || !strings.Contains(err.Error(), "missing template parameter") {
+			t.Errorf("expected missing parameter error, got %v", err)
+		}
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		if err := templates.Execute(ctx, "page", nil, nil); !errors.Is(err, ErrUnknownTemplate) {
+			t.Errorf("expected ErrUnknownTemplate, got %v", err)
+		}
+	})
+
+	t.Run("register", func(t *testing.T) {
+		templates.Register("ping", &RequestTemplate{Method: http.MethodGet, URL: server.URL + "/ping/{{id}}"})
+		var result map[string]string
+		if err := templates.Execute(ctx, "ping", map[string]interface{}{"id": 25}, &result); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result["path"] != "/ping/25" || result["body"] != "" {
+			t.Errorf("expected GET /ping/25 without body, got %+v", result)
+		}
+	})
+}
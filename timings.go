@@ -0,0 +1,126 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestTimings tells where the time of a timed out request went, phase by phase for its last attempt
+type RequestTimings struct {
+	// Queued is the time before the last attempt, waiting for the scheduler, earlier attempts and backoff
+	Queued time.Duration
+	// DNS, Connect and TLS are the host lookup, connection and handshake times, zero on reused connections
+	DNS, Connect, TLS time.Duration
+	// Write is the time taken to send the request
+	Write time.Duration
+	// Wait is the time to first response byte once the request was sent
+	Wait time.Duration
+	// Body is the time spent reading the response body
+	Body time.Duration
+	// InProgress is the phase that ran out of time: "queued", "dns", "connect", "tls", "write", "wait" or "body"
+	InProgress string
+}
+
+// String lists the phases of the request, e.g. "dns 2ms, connect 1ms, wait 4.997s in progress"
+func (t *RequestTimings) String() string {
+	phases := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"queued", t.Queued}, {"dns", t.DNS}, {"connect", t.Connect}, {"tls", t.TLS},
+		{"write", t.Write}, {"wait", t.Wait}, {"body", t.Body},
+	}
+	parts := make([]string, 0, len(phases))
+	for _, phase := range phases {
+		if phase.duration == 0 && phase.name != t.InProgress {
+			continue
+		}
+		part := phase.name + " " + phase.duration.Round(time.Millisecond).String()
+		if phase.name == t.InProgress {
+			part += " in progress"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// requestTracer records the phases of a request with httptrace; hooks can run on other goroutines
+type requestTracer struct {
+	mu                                         sync.Mutex
+	start, attempt                             time.Time
+	dnsStart, dnsDone, connectStart, connected time.Time
+	tlsStart, tlsDone, gotConn, wrote, first   time.Time
+}
+
+// withTracer returns ctx tracing the phases of its request into a new requestTracer
+func withTracer(ctx context.Context, start time.Time) (context.Context, *requestTracer) {
+	t := &requestTracer{start: start}
+	now := func(field *time.Time) {
+		t.mu.Lock()
+		*field = time.Now()
+		t.mu.Unlock()
+	}
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) {
+			// Every attempt starts over
+			t.mu.Lock()
+			t.attempt = time.Now()
+			t.dnsStart, t.dnsDone, t.connectStart, t.connected = time.Time{}, time.Time{}, time.Time{}, time.Time{}
+			t.tlsStart, t.tlsDone, t.gotConn, t.wrote, t.first = time.Time{}, time.Time{}, time.Time{}, time.Time{}, time.Time{}
+			t.mu.Unlock()
+		},
+		DNSStart:             func(httptrace.DNSStartInfo) { now(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { now(&t.dnsDone) },
+		ConnectStart:         func(string, string) { now(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { now(&t.connected) },
+		TLSHandshakeStart:    func() { now(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { now(&t.tlsDone) },
+		GotConn:              func(httptrace.GotConnInfo) { now(&t.gotConn) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { now(&t.wrote) },
+		GotFirstResponseByte: func() { now(&t.first) },
+	}
+	return httptrace.WithClientTrace(ctx, trace), t
+}
+
+// timings returns the phases of the last attempt up to end
+func (t *requestTracer) timings(end time.Time) *RequestTimings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timings := &RequestTimings{}
+	phase := func(start, done time.Time, name string) time.Duration {
+		switch {
+		case start.IsZero():
+			return 0
+		case done.IsZero():
+			timings.InProgress = name
+			return end.Sub(start)
+		}
+		return done.Sub(start)
+	}
+	if t.attempt.IsZero() {
+		timings.Queued = phase(t.start, time.Time{}, "queued")
+		return timings
+	}
+	timings.Queued = t.attempt.Sub(t.start)
+	timings.DNS = phase(t.dnsStart, t.dnsDone, "dns")
+	timings.Connect = phase(t.connectStart, t.connected, "connect")
+	timings.TLS = phase(t.tlsStart, t.tlsDone, "tls")
+	if t.gotConn.IsZero() {
+		if timings.InProgress == "" {
+			// Waiting for an idle connection or a dial finished by another request
+			timings.Connect, timings.InProgress = end.Sub(t.attempt), "connect"
+		}
+		return timings
+	}
+	timings.Write = phase(t.gotConn, t.wrote, "write")
+	timings.Wait = phase(t.wrote, t.first, "wait")
+	if !t.first.IsZero() {
+		timings.Body, timings.InProgress = end.Sub(t.first), "body"
+	}
+	return timings
+}
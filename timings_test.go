@@ -0,0 +1,80 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestTimings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/body" {
+			_, _ = w.Write([]byte(`{"items":[`))
+			w.(http.Flusher).Flush()
+		}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name, path, phase string
+	}{
+		{"waiting for response", "/", "wait"},
+		{"reading body", "/body", "body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			var result interface{}
+			err := (&Client{}).Get(ctx, server.URL+tt.path, &result)
+			var requestErr *RequestError
+			if !errors.As(err, &requestErr) || requestErr.Timings == nil {
+				t.Fatalf("expected RequestError with timings, got %v", err)
+			}
+			if requestErr.Timings.InProgress != tt.phase {
+				t.Errorf("expected %s in progress, got %+v", tt.phase, requestErr.Timings)
+			}
+			if !strings.Contains(err.Error(), tt.phase+" ") || !strings.Contains(err.Error(), "in progress") {
+				t.Errorf("expected timings in message, got %s", err)
+			}
+		})
+	}
+
+	t.Run("host profile timeout with a custom client", func(t *testing.T) {
+		client := &Client{
+			Client:       &http.Client{},
+			HostProfiles: map[string]*HostProfile{"127.0.0.1": {Timeout: 50 * time.Millisecond}},
+		}
+		var result interface{}
+		err := client.Get(context.Background(), server.URL, &result)
+		var requestErr *RequestError
+		if !errors.As(err, &requestErr) || requestErr.Timings == nil {
+			t.Errorf("expected RequestError with timings, got %v", err)
+		}
+	})
+
+	t.Run("queued", func(t *testing.T) {
+		timings := (&requestTracer{start: time.Now().Add(-time.Second)}).timings(time.Now())
+		if timings.InProgress != "queued" || timings.Queued < time.Second {
+			t.Errorf("expected queued for a second, got %+v", timings)
+		}
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		err := (&Client{}).Get(ctx, "http://127.0.0.1:1", nil)
+		var requestErr *RequestError
+		if !errors.As(err, &requestErr) || requestErr.Timings != nil {
+			t.Errorf("expected RequestError without timings, got %v", err)
+		}
+	})
+}
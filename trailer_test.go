@@ -0,0 +1,64 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// checksumReader fills the Checksum trailer once the wrapped body is exhausted
+type checksumReader struct {
+	r       io.Reader
+	h       hash.Hash
+	trailer http.Header
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.h.Write(p[:n])
+	if err == io.EOF {
+		c.trailer.Set("Checksum", hex.EncodeToString(c.h.Sum(nil)))
+	}
+	return n, err
+}
+
+func TestTrailers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+
+		w.Header().Set("Trailer", "Grpc-Status")
+		_, _ = w.Write([]byte(`{"received":"` + string(body) + `","valid":` +
+			strconv.FormatBool(r.Trailer.Get("Checksum") == hex.EncodeToString(sum[:])) + `}`))
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	defer server.Close()
+
+	trailer := http.Header{"Checksum": nil}
+	body := &checksumReader{r: strings.NewReader("pikachu"), h: sha256.New(), trailer: trailer}
+
+	var result struct {
+		Received string `json:"received"`
+		Valid    bool   `json:"valid"`
+	}
+	var responseTrailer http.Header
+	err := (&Client{}).Post(context.Background(), server.URL, body, &result,
+		WithRequestTrailers(trailer), WithTrailers(&responseTrailer))
+	if err != nil {
+		t.Fatalf("POST request failed: %v", err)
+	}
+
+	if result.Received != "pikachu" || !result.Valid {
+		t.Errorf("expected streamed body with valid checksum trailer, got %+v", result)
+	}
+	if responseTrailer.Get("Grpc-Status") != "0" {
+		t.Errorf("expected Grpc-Status trailer '0', got %v", responseTrailer)
+	}
+}
@@ -0,0 +1,185 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ResponseTransformer rewrites the raw body of a successful response before it is validated and decoded,
+// e.g. to work around known server quirks. It may return body modified in place or a new slice. Results
+// implementing ResponseDecoder read the body themselves and are not transformed.
+//
+//	client := &httpclient.Client{ResponseTransformers: []httpclient.ResponseTransformer{httpclient.NonFiniteToNull}}
+type ResponseTransformer func(resp *http.Response, body []byte) ([]byte, error)
+
+// transformResponse applies the transformers of the client, then those of the request
+func (c *Client) transformResponse(resp *http.Response, body []byte, options *Options) ([]byte, error) {
+	for _, transformers := range [][]ResponseTransformer{c.ResponseTransformers, options.ResponseTransformers} {
+		for _, transform := range transformers {
+			var err error
+			if body, err = transform(resp, body); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return body, nil
+}
+
+// nonFiniteTokens are the number literals JSON does not allow, written by Python's json module and others
+var nonFiniteTokens = [][]byte{[]byte("-Infinity"), []byte("Infinity"), []byte("NaN")}
+
+// NonFiniteToNull is a ResponseTransformer replacing the NaN, Infinity and -Infinity tokens that some
+// servers emit, which are not valid JSON, with null outside of strings
+func NonFiniteToNull(_ *http.Response, body []byte) ([]byte, error) {
+	if !bytes.Contains(body, []byte("NaN")) && !bytes.Contains(body, []byte("Infinity")) {
+		return body, nil
+	}
+	out := make([]byte, 0, len(body))
+	inString := false
+	for i := 0; i < len(body); i++ {
+		b := body[i]
+		switch {
+		case inString:
+			if b == '\\' && i+1 < len(body) {
+				out = append(out, b, body[i+1])
+				i++
+				continue
+			}
+			inString = b != '"'
+		case b == '"':
+			inString = true
+		default:
+			if token := nonFiniteToken(body[i:]); token > 0 {
+				out = append(out, "null"...)
+				i += token - 1
+				continue
+			}
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+// nonFiniteToken returns the length of the non-finite token starting data, zero when there is none
+func nonFiniteToken(data []byte) int {
+	for _, token := range nonFiniteTokens {
+		if bytes.HasPrefix(data, token) {
+			return len(token)
+		}
+	}
+	return 0
+}
+
+// RequestTransformer rewrites the marshalled body of a request before it is sent, e.g. to wrap it in an
+// envelope or canonicalize it. req carries the URL and option headers of the request and its body is replaced
+// by the result. Transformers run before middleware, so signatures computed there cover the rewritten body,
+// and again on retries with WithBeforeRetry. Streamed io.Reader bodies are not transformed.
+//
+//	client := &httpclient.Client{RequestTransformers: []httpclient.RequestTransformer{httpclient.CompactJSON}}
+type RequestTransformer func(req *http.Request, body []byte) ([]byte, error)
+
+// requestTransformers returns the transformers of the client followed by those of the request, nil if none
+func (c *Client) requestTransformers(options *Options) []RequestTransformer {
+	if len(c.RequestTransformers) == 0 && len(options.RequestTransformers) == 0 {
+		return nil
+	}
+	transformers := make([]RequestTransformer, 0, len(c.RequestTransformers)+len(options.RequestTransformers))
+	transformers = append(transformers, c.RequestTransformers...)
+	return append(transformers, options.RequestTransformers...)
+}
+
+func transformRequest(req *http.Request, body []byte, transformers []RequestTransformer) ([]byte, error) {
+	for _, transform := range transformers {
+		var err error
+		if body, err = transform(req, body); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// setBytesBody replaces the body of req with body, keeping the unknown length of requests with trailers
+func setBytesBody(req *http.Request, body []byte) {
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	if req.Trailer == nil {
+		req.ContentLength = int64(len(body))
+	}
+	if len(body) == 0 {
+		req.Body = http.NoBody
+	}
+}
+
+// CompactJSON is a RequestTransformer removing insignificant whitespace from JSON bodies, so signatures
+// computed over the body do not depend on the formatting of MarshalFunc
+func CompactJSON(_ *http.Request, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// envelope returns the field unwrapped from the responses of the request, empty for none
+func (c *Client) envelope(options *Options) string {
+	switch {
+	case options.NoEnvelope:
+		return ""
+	case options.Envelope != "":
+		return options.Envelope
+	}
+	return c.Envelope
+}
+
+// splitFields fills the RawFields of the request and returns the part of body decoded into result, its
+// envelope field if any; the body is only split into top-level fields once for both. A missing envelope field
+// is an error so that responses not matching the expected envelope are not silently decoded as empty values
+func (c *Client) splitFields(body []byte, result interface{}, options *Options) ([]byte, error) {
+	envelope := c.envelope(options)
+	if result == nil {
+		envelope = ""
+	}
+	if envelope == "" && len(options.RawFields) == 0 {
+		return body, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	for name, raw := range options.RawFields {
+		*raw = fields[name]
+	}
+	if envelope == "" {
+		return body, nil
+	}
+	return envelopeValue(fields, envelope)
+}
+
+// envelopeDecoder is implemented by the ResponseDecoders of the package decoding JSON, which decode the
+// envelope field of the response like plain results; other ResponseDecoders get the whole response
+type envelopeDecoder interface {
+	decodeEnvelope(resp *http.Response, envelope string) error
+}
+
+// unwrapEnvelope returns the envelope field of the JSON object body
+func unwrapEnvelope(body []byte, envelope string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	return envelopeValue(fields, envelope)
+}
+
+func envelopeValue(fields map[string]json.RawMessage, envelope string) ([]byte, error) {
+	value, ok := fields[envelope]
+	if !ok {
+		return nil, fmt.Errorf("no %q field in response", envelope)
+	}
+	return value, nil
+}
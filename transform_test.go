@@ -0,0 +1,304 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseTransformer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.Error(w, `{"error":"NaN"}`, http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"name":"pikachu","weight":NaN,"note":"NaN stays"}}`))
+	}))
+	defer server.Close()
+
+	unwrap := func(_ *http.Response, body []byte) ([]byte, error) {
+		return bytes.TrimSuffix(bytes.TrimPrefix(body, []byte(`{"data":`)), []byte("}")), nil
+	}
+	type pokemon struct {
+		Name   string   `json:"name"`
+		Weight *float64 `json:"weight"`
+		Note   string   `json:"note"`
+	}
+
+	t.Run("client and request", func(t *testing.T) {
+		client := &Client{ResponseTransformers: []ResponseTransformer{NonFiniteToNull}}
+		var p pokemon
+		if err := client.Get(context.Background(), server.URL, &p, WithResponseTransformer(unwrap)); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if p.Name != "pikachu" || p.Weight != nil || p.Note != "NaN stays" {
+			t.Errorf("expected transformed pikachu, got %+v", p)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		failure := errors.New("quirk")
+		err := (&Client{}).Get(context.Background(), server.URL, nil, WithResponseTransformer(func(*http.Response, []byte) ([]byte, error) {
+			return nil, failure
+		}))
+		if !errors.Is(err, failure) {
+			t.Errorf("expected transformer error, got %v", err)
+		}
+	})
+
+	t.Run("error responses", func(t *testing.T) {
+		called := false
+		err := (&Client{}).Get(context.Background(), server.URL+"/missing", nil, WithResponseTransformer(func(_ *http.Response, body []byte) ([]byte, error) {
+			called = true
+			return body, nil
+		}))
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || called {
+			t.Errorf("expected untransformed HTTPError, got %v (called %v)", err, called)
+		}
+	})
+}
+
+func TestNonFiniteToNull(t *testing.T) {
+	tests := []struct {
+		in, expected string
+	}{
+		{`{"a":NaN,"b":-Infinity,"c":[Infinity]}`, `{"a":null,"b":null,"c":[null]}`},
+		{`{"a":"NaN \"Infinity\"","b":1}`, `{"a":"NaN \"Infinity\"","b":1}`},
+		{`[1,2]`, `[1,2]`},
+	}
+	for _, tt := range tests {
+		got, err := NonFiniteToNull(nil, []byte(tt.in))
+		if err != nil {
+			t.Fatalf("NonFiniteToNull failed: %v", err)
+		}
+		if string(got) != tt.expected {
+			t.Errorf("expected %s, got %s", tt.expected, got)
+		}
+	}
+}
+
+func TestRequestTransformer(t *testing.T) {
+	var bodies []string
+	var failures int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if r.URL.Path == "/flaky" && failures < 1 {
+			failures++
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	envelope := func(_ *http.Request, body []byte) ([]byte, error) {
+		return append(append([]byte(`{"data":`), body...), '}'), nil
+	}
+
+	t.Run("client and request", func(t *testing.T) {
+		bodies = nil
+		var signed string
+		sign := func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				body, _ := req.GetBody()
+				data, _ := io.ReadAll(body)
+				signed = string(data)
+				return next.RoundTrip(req)
+			})
+		}
+		client := &Client{
+			MarshalFunc:         func(v interface{}) ([]byte, error) { return json.MarshalIndent(v, "", "  ") },
+			RequestTransformers: []RequestTransformer{CompactJSON},
+			Middleware:          []Middleware{sign},
+		}
+		err := client.Post(context.Background(), server.URL, map[string]int{"level": 5}, nil, WithRequestTransformer(envelope))
+		if err != nil {
+			t.Fatalf("POST request failed: %v", err)
+		}
+		expected := `{"data":{"level":5}}`
+		if len(bodies) != 1 || bodies[0] != expected || signed != expected {
+			t.Errorf("expected %s sent and signed, got %v and %s", expected, bodies, signed)
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		bodies = nil
+		client := &Client{MaxRetries: 1, Backoff: ConstantBackoff(0)}
+		err := client.Put(context.Background(), server.URL+"/flaky", map[string]int{"level": 5}, nil,
+			WithRequestTransformer(envelope), WithBeforeRetry(func(int, interface{}) error { return nil }))
+		if err != nil {
+			t.Fatalf("PUT request failed: %v", err)
+		}
+		if len(bodies) != 2 || bodies[0] != bodies[1] || bodies[1] != `{"data":{"level":5}}` {
+			t.Errorf("expected enveloped body on each attempt, got %v", bodies)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		failure := errors.New("unsigned")
+		err := (&Client{}).Post(context.Background(), server.URL, []byte(`{}`), nil, WithRequestTransformer(func(*http.Request, []byte) ([]byte, error) {
+			return nil, failure
+		}))
+		if !errors.Is(err, failure) {
+			t.Errorf("expected transformer error, got %v", err)
+		}
+	})
+}
+
+func TestEnvelope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bare" {
+			_, _ = w.Write([]byte(`{"name":"eevee"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"name":"pikachu"},"meta":{"page":1}}`))
+	}))
+	defer server.Close()
+
+	type pokemon struct {
+		Name string `json:"name"`
+	}
+
+	t.Run("request", func(t *testing.T) {
+		var p pokemon
+		if err := (&Client{}).Get(context.Background(), server.URL, &p, WithEnvelope("data")); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if p.Name != "pikachu" {
+			t.Errorf("expected 'pikachu', got '%s'", p.Name)
+		}
+	})
+
+	t.Run("client default", func(t *testing.T) {
+		client := &Client{Envelope: "data"}
+		var p pokemon
+		if err := client.Get(context.Background(), server.URL, &p); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if p.Name != "pikachu" {
+			t.Errorf("expected 'pikachu', got '%s'", p.Name)
+		}
+		var meta struct {
+			Page int `json:"page"`
+		}
+		if err := client.Get(context.Background(), server.URL, &meta, WithEnvelope("meta")); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if meta.Page != 1 {
+			t.Errorf("expected page 1, got %d", meta.Page)
+		}
+		p = pokemon{}
+		if err := client.Get(context.Background(), server.URL+"/bare", &p, WithoutEnvelope()); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if p.Name != "eevee" {
+			t.Errorf("expected 'eevee', got '%s'", p.Name)
+		}
+	})
+
+	t.Run("missing field", func(t *testing.T) {
+		var p pokemon
+		err := (&Client{Envelope: "data"}).Get(context.Background(), server.URL+"/bare", &p)
+		if err == nil || !strings.Contains(err.Error(), `no "data" field`) {
+			t.Errorf("expected missing envelope error, got %v", err)
+		}
+	})
+}
+
+func TestRawFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"name":"pikachu"},"meta":{"page":1,"next":"abc"}}`))
+	}))
+	defer server.Close()
+
+	var p struct {
+		Name string `json:"name"`
+	}
+	var meta, links json.RawMessage
+	err := (&Client{}).Get(context.Background(), server.URL, &p,
+		WithEnvelope("data"), WithRawField("meta", &meta), WithRawField("links", &links))
+	if err != nil {
+		t.Fatalf("GET request failed: %v", err)
+	}
+	if p.Name != "pikachu" {
+		t.Errorf("expected 'pikachu', got '%s'", p.Name)
+	}
+	if string(meta) != `{"page":1,"next":"abc"}` {
+		t.Errorf("expected raw meta, got %s", meta)
+	}
+	if links != nil {
+		t.Errorf("expected nil links, got %s", links)
+	}
+}
+
+func TestEnvelope_Helpers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/graphql":
+			_, _ = w.Write([]byte(`{"data":{"name":"pikachu"},"errors":[{"message":"partial"}]}`))
+		case "/pokemon":
+			_, _ = w.Write([]byte(`{"meta":{"count":2},"data":[{"name":"bulbasaur"},{"name":"ivysaur"}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"data":{"name":"pikachu"}}`))
+		}
+	}))
+	defer server.Close()
+
+	type pokemon struct {
+		Name string `json:"name"`
+	}
+	client := &Client{BaseURL: server.URL, Envelope: "data"}
+	ctx := context.Background()
+
+	t.Run("graphql", func(t *testing.T) {
+		var p pokemon
+		err := client.GraphQL(ctx, "/graphql", "{ pokemon { name } }", nil, &p)
+		var gqlErrs GraphQLErrors
+		if !errors.As(err, &gqlErrs) || gqlErrs[0].Message != "partial" {
+			t.Errorf("expected GraphQL errors, got %v", err)
+		}
+		if p.Name != "pikachu" {
+			t.Errorf("expected 'pikachu', got '%s'", p.Name)
+		}
+	})
+
+	t.Run("resource", func(t *testing.T) {
+		r := &Resource[pokemon]{Client: client, URL: "/pokemon"}
+		p, err := r.Get(ctx, "25")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if p == nil || p.Name != "pikachu" {
+			t.Errorf("expected 'pikachu', got %+v", p)
+		}
+		items, err := r.List(ctx)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(items) != 2 || items[1].Name != "ivysaur" {
+			t.Errorf("expected 2 items, got %+v", items)
+		}
+	})
+
+	t.Run("for each", func(t *testing.T) {
+		var names []string
+		err := client.Get(ctx, "/pokemon", ForEachElement(func(p pokemon) error {
+			names = append(names, p.Name)
+			return nil
+		}))
+		if err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+		if len(names) != 2 || names[0] != "bulbasaur" {
+			t.Errorf("expected 2 names, got %v", names)
+		}
+	})
+}
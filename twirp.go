@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// TwirpPrefix is the path prefix of Twirp routes
+const TwirpPrefix = "/twirp"
+
+// TwirpError is the error envelope of a Twirp server, e.g. {"code": "not_found", "msg": "..."}
+type TwirpError struct {
+	// Code is the Twirp error code, e.g. "not_found" or "invalid_argument"
+	Code string `json:"code"`
+	// Msg is the error message
+	Msg string `json:"msg"`
+	// Meta holds additional string values attached by the server
+	Meta map[string]string `json:"meta,omitempty"`
+	// HTTPError is the response the error was decoded from
+	HTTPError *HTTPError `json:"-"`
+}
+
+func (e *TwirpError) Error() string {
+	return fmt.Sprintf("twirp error %s: %s", e.Code, e.Msg)
+}
+
+// Unwrap returns the HTTPError so errors.As keeps matching it
+func (e *TwirpError) Unwrap() error {
+	return e.HTTPError
+}
+
+// DecodeTwirpError decodes a Twirp error envelope into a *TwirpError, returning nil for other bodies.
+// Twirp calls decode it themselves, it can also be used as Client.ErrorDecoder.
+func DecodeTwirpError(httpErr *HTTPError) error {
+	var twirpErr TwirpError
+	if err := json.Unmarshal(httpErr.Body, &twirpErr); err != nil || twirpErr.Code == "" {
+		return nil
+	}
+	twirpErr.HTTPError = httpErr
+	return &twirpErr
+}
+
+// ProtoMessage is a protobuf message marshalling itself, as generated by gogo/protobuf and vtprotobuf;
+// messages of google.golang.org/protobuf can be wrapped in a type calling proto.Marshal and proto.Unmarshal
+type ProtoMessage interface {
+	Marshal() ([]byte, error)
+	Unmarshal(data []byte) error
+}
+
+// protoResult decodes a protobuf response body into a message
+type protoResult struct {
+	msg ProtoMessage
+}
+
+func (r protoResult) DecodeResponse(resp *http.Response) error {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return r.msg.Unmarshal(data)
+}
+
+// Twirp calls method of the Twirp service, e.g. "example.haberdasher.Haberdasher", hosted at baseURL, which
+// may be empty to use Client.BaseURL. Messages implementing ProtoMessage are sent and decoded as protobuf,
+// others as JSON. Errors returned by the server are *TwirpError.
+//
+// Twirp calls are POST requests, so they are only retried with WithRetryNonIdempotent.
+func (c *Client) Twirp(ctx context.Context, baseURL, service, method string, in, out interface{}, opts ...Option) error {
+	url := strings.TrimSuffix(baseURL, "/") + TwirpPrefix + "/" + service + "/" + method
+
+	var body, result interface{} = in, out
+	if msg, ok := in.(ProtoMessage); ok {
+		data, err := msg.Marshal()
+		if err != nil {
+			return fmt.Errorf("failed to marshal protobuf request: %w", err)
+		}
+		body = data
+		opts = append(opts, WithHeader("Content-Type", "application/protobuf"))
+	}
+	if msg, ok := out.(ProtoMessage); ok {
+		result = protoResult{msg: msg}
+		opts = append(opts, WithHeader("Accept", "application/protobuf"))
+	}
+
+	// Twirp messages are never wrapped
+	err := c.Post(ctx, url, body, result, append(opts[:len(opts):len(opts)], WithoutEnvelope())...)
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		if twirpErr := DecodeTwirpError(httpErr); twirpErr != nil {
+			return twirpErr
+		}
+	}
+	return err
+}
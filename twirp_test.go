@@ -0,0 +1,81 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// rawMessage is a ProtoMessage holding its wire bytes
+type rawMessage struct {
+	data []byte
+}
+
+func (m *rawMessage) Marshal() ([]byte, error) { return m.data, nil }
+
+func (m *rawMessage) Unmarshal(data []byte) error {
+	m.data = append([]byte(nil), data...)
+	return nil
+}
+
+func TestClient_Twirp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/twirp/pokedex.Pokedex/GetPokemon":
+			if r.Header.Get("Content-Type") == "application/protobuf" {
+				w.Header().Set("Content-Type", "application/protobuf")
+				_, _ = w.Write(append([]byte("echo:"), body...))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"name":"pikachu"}`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":"bad_route","msg":"no handler for path","meta":{"twirp_invalid_route":"POST ` + r.URL.Path + `"}}`))
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{}
+	ctx := context.Background()
+
+	t.Run("json", func(t *testing.T) {
+		var result map[string]string
+		if err := client.Twirp(ctx, server.URL, "pokedex.Pokedex", "GetPokemon", map[string]int{"id": 25}, &result); err != nil {
+			t.Fatalf("Twirp call failed: %v", err)
+		}
+		if result["name"] != "pikachu" {
+			t.Errorf("expected pikachu, got %v", result)
+		}
+	})
+
+	t.Run("protobuf", func(t *testing.T) {
+		var result rawMessage
+		if err := client.Twirp(ctx, server.URL, "pokedex.Pokedex", "GetPokemon", &rawMessage{data: []byte{0x08, 0x19}}, &result); err != nil {
+			t.Fatalf("Twirp call failed: %v", err)
+		}
+		if string(result.data) != "echo:\x08\x19" {
+			t.Errorf("expected echoed protobuf, got %q", result.data)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		err := (&Client{BaseURL: server.URL}).Twirp(ctx, "", "pokedex.Pokedex", "Evolve", map[string]int{}, nil)
+		var twirpErr *TwirpError
+		if !errors.As(err, &twirpErr) || twirpErr.Code != "bad_route" || twirpErr.Meta["twirp_invalid_route"] != "POST /twirp/pokedex.Pokedex/Evolve" {
+			t.Fatalf("expected bad_route TwirpError, got %v", err)
+		}
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+			t.Errorf("expected wrapped 404 HTTPError, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// contentLengther is implemented by streamed request bodies knowing their length up front
+type contentLengther interface {
+	ContentLength() int64
+}
+
+// UploadFile streams the file at path to url in a POST request and decodes the response into result.
+// With a field name, the file is sent as that field of a multipart/form-data body along with its file name;
+// with an empty field, it is sent as the raw body. Its Content-Type is detected from the extension, or the
+// content, and the Content-Length is set. Report progress with WithUploadProgress and get the checksum of
+// the sent content with WithUploadChecksum:
+//
+//	var sum string
+//	err := client.UploadFile(ctx, "/v1/imports", "export.csv", "file", &job,
+//	    httpclient.WithUploadProgress(func(sent, total int64) { log.Printf("%d/%d bytes", sent, total) }),
+//	    httpclient.WithUploadChecksum(&sum))
+//
+// Like other io.Reader bodies, the file is sent once and not retried.
+func (c *Client) UploadFile(ctx context.Context, url, path, field string, result interface{}, opts ...Option) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open upload file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	body, err := newUploadBody(file, field)
+	if err != nil {
+		return err
+	}
+	options := buildOptions(opts...)
+	body.progress = options.UploadProgress
+	if options.UploadChecksum != nil {
+		body.hash = sha256.New()
+	}
+	checksum := options.UploadChecksum
+	releaseOptions(options)
+
+	if err := c.Do(ctx, http.MethodPost, url, body, result, opts...); err != nil {
+		return err
+	}
+	if checksum != nil {
+		*checksum = hex.EncodeToString(body.hash.Sum(nil))
+	}
+	return nil
+}
+
+// uploadBody streams a file, raw or wrapped in a multipart/form-data envelope of known length
+type uploadBody struct {
+	io.Reader
+	contentType string
+	length      int64
+	sent        int64
+	progress    func(sent, total int64)
+	// hash receives the file content only, not the multipart envelope
+	hash hash.Hash
+}
+
+func newUploadBody(file *os.File, field string) (*uploadBody, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat upload file: %w", err)
+	}
+	contentType, err := detectContentType(file)
+	if err != nil {
+		return nil, err
+	}
+	body := &uploadBody{contentType: contentType, length: info.Size()}
+	content := &hashingReader{r: file, body: body}
+
+	if field == "" {
+		body.Reader = content
+		return body, nil
+	}
+	var envelope bytes.Buffer
+	mw := multipart.NewWriter(&envelope)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		escapeQuotes(field), escapeQuotes(filepath.Base(file.Name()))))
+	header.Set("Content-Type", contentType)
+	if _, err := mw.CreatePart(header); err != nil {
+		return nil, fmt.Errorf("failed to create multipart body: %w", err)
+	}
+	preamble := append([]byte(nil), envelope.Bytes()...)
+	envelope.Reset()
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to create multipart body: %w", err)
+	}
+	body.Reader = io.MultiReader(bytes.NewReader(preamble), content, bytes.NewReader(envelope.Bytes()))
+	body.contentType = mw.FormDataContentType()
+	body.length += int64(len(preamble) + envelope.Len())
+	return body, nil
+}
+
+// Read streams the body, reporting progress
+func (b *uploadBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if n > 0 {
+		b.sent += int64(n)
+		if b.progress != nil {
+			b.progress(b.sent, b.length)
+		}
+	}
+	return n, err
+}
+
+// ContentType returns the detected type of the file, or the multipart/form-data type with its boundary
+func (b *uploadBody) ContentType() string {
+	return b.contentType
+}
+
+// ContentLength returns the length of the body
+func (b *uploadBody) ContentLength() int64 {
+	return b.length
+}
+
+// hashingReader feeds the file content to the hash of the upload, when one is set
+type hashingReader struct {
+	r    io.Reader
+	body *uploadBody
+}
+
+func (h *hashingReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	if n > 0 && h.body.hash != nil {
+		h.body.hash.Write(p[:n])
+	}
+	return n, err
+}
+
+// detectContentType returns the media type of file from its extension, or from its first bytes
+func detectContentType(file *os.File) (string, error) {
+	if contentType := mime.TypeByExtension(filepath.Ext(file.Name())); contentType != "" {
+		return contentType, nil
+	}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("failed to read upload file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to rewind upload file: %w", err)
+	}
+	return http.DetectContentType(head[:n]), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes escapes a multipart parameter value like mime/multipart does for form files
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,88 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClient_UploadFile(t *testing.T) {
+	content := strings.Repeat(`{"name":"pikachu"}`+"\n", 100)
+	path := filepath.Join(t.TempDir(), "pokemon.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	sum := sha256.Sum256([]byte(content))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength <= 0 {
+			t.Errorf("expected Content-Length, got %d", r.ContentLength)
+		}
+		var received string
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			file, header, err := r.FormFile("file")
+			if err != nil {
+				t.Fatalf("failed to read form file: %v", err)
+			}
+			data, _ := io.ReadAll(file)
+			received = header.Filename + ":" + header.Header.Get("Content-Type") + ":" + string(data)
+		} else {
+			data, _ := io.ReadAll(r.Body)
+			received = r.Header.Get("Content-Type") + ":" + string(data)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"received": received})
+	}))
+	defer server.Close()
+
+	client := &Client{}
+	ctx := context.Background()
+
+	t.Run("multipart", func(t *testing.T) {
+		var result struct{ Received string }
+		var checksum string
+		var sent, total int64
+		err := client.UploadFile(ctx, server.URL, path, "file", &result,
+			WithUploadProgress(func(s, t int64) { sent, total = s, t }), WithUploadChecksum(&checksum))
+		if err != nil {
+			t.Fatalf("upload failed: %v", err)
+		}
+		if result.Received != "pokemon.json:application/json:"+content {
+			t.Errorf("unexpected upload %.60q", result.Received)
+		}
+		if sent != total || total <= int64(len(content)) {
+			t.Errorf("expected progress to reach the body length, got %d/%d", sent, total)
+		}
+		if checksum != hex.EncodeToString(sum[:]) {
+			t.Errorf("expected checksum %x, got %s", sum, checksum)
+		}
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		gif := filepath.Join(t.TempDir(), "sprite")
+		if err := os.WriteFile(gif, []byte("GIF89a rest"), 0o600); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		var result struct{ Received string }
+		if err := client.UploadFile(ctx, server.URL, gif, "", &result); err != nil {
+			t.Fatalf("upload failed: %v", err)
+		}
+		if result.Received != "image/gif:GIF89a rest" {
+			t.Errorf("unexpected upload %q", result.Received)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if err := client.UploadFile(ctx, server.URL, filepath.Join(t.TempDir(), "missing"), "", nil); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected not exist error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,7 @@
+package httpclient
+
+// Version is the version of this package, sent in DefaultUserAgent
+const Version = "0.1.0"
+
+// DefaultUserAgent identifies requests made by this package when Client.UserAgent is not set
+const DefaultUserAgent = "llkhacquan-httpclient/" + Version + " (+go)"
@@ -0,0 +1,58 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Warmup pre-establishes connections to the given hosts so that the first real requests
+// can reuse pooled connections instead of paying for DNS, TCP and TLS setup.
+//
+// Hosts may be bare host names ("pokeapi.co"), host:port pairs or base URLs
+// ("http://localhost:8080"); bare hosts default to https. A HEAD request is sent
+// to the root of each host and its response status is ignored.
+func (c *Client) Warmup(ctx context.Context, hosts ...string) error {
+	client := c.getClient(&Options{})
+
+	errs := make([]error, len(hosts))
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			errs[i] = warmupHost(ctx, client, host)
+		}(i, host)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("failed to warm up %s: %w", hosts[i], err)
+		}
+	}
+	return nil
+}
+
+func warmupHost(ctx context.Context, client *http.Client, host string) error {
+	target := host
+	if !strings.Contains(target, "://") {
+		target = "https://" + target
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, strings.TrimSuffix(target, "/")+"/", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HEAD request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make HEAD request: %w", err)
+	}
+	// Drain the body so the connection is returned to the pool
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
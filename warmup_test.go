@@ -0,0 +1,48 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_Warmup(t *testing.T) {
+	var conns int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	t.Run("warmed connection is reused", func(t *testing.T) {
+		client := &Client{Client: &http.Client{Transport: &http.Transport{}}}
+		if err := client.Warmup(context.Background(), server.URL); err != nil {
+			t.Fatalf("warmup failed: %v", err)
+		}
+
+		var result map[string]interface{}
+		if err := client.Get(context.Background(), server.URL+"/pokemon", &result); err != nil {
+			t.Fatalf("GET request failed: %v", err)
+		}
+
+		if n := atomic.LoadInt32(&conns); n != 1 {
+			t.Errorf("expected 1 connection, got %d", n)
+		}
+	})
+
+	t.Run("unreachable host returns error", func(t *testing.T) {
+		client := &Client{}
+		err := client.Warmup(context.Background(), server.URL, "http://127.0.0.1:1")
+		if err == nil {
+			t.Error("expected error for unreachable host")
+		}
+	})
+}
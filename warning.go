@@ -0,0 +1,66 @@
+package httpclient
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Warning is a value of a Warning response header (RFC 7234), e.g. `110 - "Response is Stale"`, telling that
+// a cache or an upstream served a degraded response
+type Warning struct {
+	// Code is the warning code, e.g. 110 for stale responses or 299 for miscellaneous persistent warnings
+	Code int
+	// Agent is the host or pseudonym of the server adding the warning, "-" when unknown
+	Agent string
+	// Text is the human readable warning
+	Text string
+	// Date is the optional date of the warning, zero when absent
+	Date time.Time
+}
+
+// String formats the warning like the header, e.g. `110 - "Response is Stale"`
+func (w Warning) String() string {
+	s := strconv.Itoa(w.Code) + " " + w.Agent + " " + strconv.Quote(w.Text)
+	if !w.Date.IsZero() {
+		s += ` "` + w.Date.UTC().Format(http.TimeFormat) + `"`
+	}
+	return s
+}
+
+// ParseWarnings parses the warnings of Warning header values, skipping malformed ones
+func ParseWarnings(values ...string) []Warning {
+	var warnings []Warning
+	for _, value := range values {
+		for _, entry := range splitQuoted(value, ',') {
+			var fields []string
+			for _, field := range splitQuoted(strings.TrimSpace(entry), ' ') {
+				if field != "" {
+					fields = append(fields, field)
+				}
+			}
+			if len(fields) < 3 {
+				continue
+			}
+			code, err := strconv.Atoi(fields[0])
+			if err != nil || len(fields[0]) != 3 {
+				continue
+			}
+			warning := Warning{Code: code, Agent: fields[1], Text: unquote(fields[2])}
+			if len(fields) > 3 {
+				if date, err := http.ParseTime(unquote(fields[3])); err == nil {
+					warning.Date = date
+				}
+			}
+			warnings = append(warnings, warning)
+		}
+	}
+	return warnings
+}
+
+// LogWarning is a Client.OnWarning callback logging the warning with the standard logger
+func LogWarning(req *http.Request, w Warning) {
+	log.Printf("httpclient: %s %s returned warning %s", req.Method, redactedURL(req.URL), w)
+}
@@ -0,0 +1,48 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseWarnings(t *testing.T) {
+	warnings := ParseWarnings(`110 cache.example.com "Response is Stale", 299 - "Deprecated, use v2" "Wed, 21 Oct 2015 07:28:00 GMT"`, "bogus", `11 - "short code"`)
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %+v", warnings)
+	}
+	if w := warnings[0]; w.Code != 110 || w.Agent != "cache.example.com" || w.Text != "Response is Stale" || !w.Date.IsZero() {
+		t.Errorf("unexpected first warning %+v", w)
+	}
+	if w := warnings[1]; w.Code != 299 || w.Agent != "-" || w.Text != "Deprecated, use v2" || !w.Date.Equal(time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)) {
+		t.Errorf("unexpected second warning %+v", w)
+	}
+	if expected := `299 - "Deprecated, use v2" "Wed, 21 Oct 2015 07:28:00 GMT"`; warnings[1].String() != expected {
+		t.Errorf("expected %s, got %s", expected, warnings[1])
+	}
+}
+
+func TestClient_OnWarning(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Warning", `110 - "Response is Stale"`)
+		w.Header().Add("Warning", `299 - "Degraded"`)
+	}))
+	defer server.Close()
+
+	var reported []int
+	client := &Client{OnWarning: func(req *http.Request, w Warning) {
+		reported = append(reported, w.Code)
+	}}
+	var warnings []Warning
+	if err := client.Get(context.Background(), server.URL, nil, WithWarnings(&warnings)); err != nil {
+		t.Fatalf("GET request failed: %v", err)
+	}
+	if len(warnings) != 2 || warnings[1].Text != "Degraded" {
+		t.Errorf("expected 2 captured warnings, got %+v", warnings)
+	}
+	if len(reported) != 2 || reported[0] != 110 || reported[1] != 299 {
+		t.Errorf("expected warnings 110 and 299 reported, got %v", reported)
+	}
+}
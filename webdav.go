@@ -0,0 +1,124 @@
+package httpclient
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// MultiStatus decodes a WebDAV 207 Multi-Status response (RFC 4918) into per-resource results when passed
+// as result, for WebDAV and CalDAV integrations:
+//
+//	var ms httpclient.MultiStatus
+//	err := client.Do(ctx, "PROPFIND", url, []byte(propfind), &ms,
+//	    httpclient.WithHeader("Depth", "1"), httpclient.WithHeader("Content-Type", "application/xml"))
+//	for _, r := range ms.Responses {
+//	    etag, _ := r.Prop("DAV:", "getetag")
+//	}
+type MultiStatus struct {
+	XMLName             xml.Name              `xml:"DAV: multistatus"`
+	Responses           []MultiStatusResponse `xml:"DAV: response"`
+	ResponseDescription string                `xml:"DAV: responsedescription,omitempty"`
+}
+
+// MultiStatusResponse is the result for one resource, either a status of its own or property results
+type MultiStatusResponse struct {
+	Hrefs []string `xml:"DAV: href"`
+	// RawStatus is the status line of the resource, e.g. "HTTP/1.1 404 Not Found", empty with PropStats
+	RawStatus           string     `xml:"DAV: status,omitempty"`
+	PropStats           []PropStat `xml:"DAV: propstat"`
+	ResponseDescription string     `xml:"DAV: responsedescription,omitempty"`
+}
+
+// PropStat groups properties sharing a status
+type PropStat struct {
+	Props struct {
+		Values []Property `xml:",any"`
+	} `xml:"DAV: prop"`
+	// RawStatus is the status line of the properties
+	RawStatus           string `xml:"DAV: status"`
+	ResponseDescription string `xml:"DAV: responsedescription,omitempty"`
+}
+
+// Property is a WebDAV property, its value being the raw inner XML
+type Property struct {
+	XMLName xml.Name
+	Value   string `xml:",innerxml"`
+}
+
+// DecodeResponse implements ResponseDecoder
+func (m *MultiStatus) DecodeResponse(resp *http.Response) error {
+	if resp.StatusCode != http.StatusMultiStatus {
+		return fmt.Errorf("expected status 207 Multi-Status, got %s", resp.Status)
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(m); err != nil {
+		return fmt.Errorf("failed to decode multistatus: %w", err)
+	}
+	return nil
+}
+
+// Failed returns the responses whose status or any property status is 400 or above
+func (m *MultiStatus) Failed() []MultiStatusResponse {
+	var failed []MultiStatusResponse
+	for _, r := range m.Responses {
+		if r.StatusCode() >= 400 {
+			failed = append(failed, r)
+			continue
+		}
+		for _, ps := range r.PropStats {
+			if ps.StatusCode() >= 400 {
+				failed = append(failed, r)
+				break
+			}
+		}
+	}
+	return failed
+}
+
+// Href returns the first href of the response
+func (r *MultiStatusResponse) Href() string {
+	if len(r.Hrefs) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(r.Hrefs[0])
+}
+
+// StatusCode returns the status code of the resource, 200 when only properties carry a status
+func (r *MultiStatusResponse) StatusCode() int {
+	if r.RawStatus == "" {
+		return http.StatusOK
+	}
+	return parseStatusLine(r.RawStatus)
+}
+
+// Prop returns the inner XML of a property found with a 2xx status
+func (r *MultiStatusResponse) Prop(space, local string) (string, bool) {
+	for _, ps := range r.PropStats {
+		if code := ps.StatusCode(); code < 200 || code >= 300 {
+			continue
+		}
+		for _, p := range ps.Props.Values {
+			if p.XMLName.Space == space && p.XMLName.Local == local {
+				return p.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// StatusCode returns the status code of the properties
+func (ps *PropStat) StatusCode() int {
+	return parseStatusLine(ps.RawStatus)
+}
+
+// parseStatusLine extracts the code of "HTTP/1.1 404 Not Found", zero when malformed
+func parseStatusLine(line string) int {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return 0
+	}
+	code, _ := strconv.Atoi(fields[1])
+	return code
+}
@@ -0,0 +1,92 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const propfindResponse = `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cs="http://calendarserver.org/ns/">
+  <d:response>
+    <d:href>/calendars/ash/</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:displayname>Ash</d:displayname>
+        <d:resourcetype><d:collection/></d:resourcetype>
+        <cs:getctag>"42"</cs:getctag>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+    <d:propstat>
+      <d:prop><d:getetag/></d:prop>
+      <d:status>HTTP/1.1 404 Not Found</d:status>
+    </d:propstat>
+  </d:response>
+  <d:response>
+    <d:href>/calendars/ash/gym.ics</d:href>
+    <d:status>HTTP/1.1 403 Forbidden</d:status>
+  </d:response>
+</d:multistatus>`
+
+func TestMultiStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PROPFIND" || r.Header.Get("Depth") != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.URL.Path == "/plain" {
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		_, _ = w.Write([]byte(propfindResponse))
+	}))
+	defer server.Close()
+
+	c := &Client{}
+	ctx := context.Background()
+	propfind := []byte(`<d:propfind xmlns:d="DAV:"><d:allprop/></d:propfind>`)
+
+	var ms MultiStatus
+	err := c.Do(ctx, "PROPFIND", server.URL+"/calendars/ash/", propfind, &ms,
+		WithHeader("Depth", "1"), WithHeader("Content-Type", "application/xml"))
+	if err != nil {
+		t.Fatalf("PROPFIND failed: %v", err)
+	}
+	if len(ms.Responses) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(ms.Responses))
+	}
+
+	collection := ms.Responses[0]
+	if collection.Href() != "/calendars/ash/" || collection.StatusCode() != http.StatusOK {
+		t.Errorf("expected the collection with status 200, got %s and %d", collection.Href(), collection.StatusCode())
+	}
+	if name, ok := collection.Prop("DAV:", "displayname"); !ok || name != "Ash" {
+		t.Errorf("expected displayname Ash, got %q", name)
+	}
+	if ctag, ok := collection.Prop("http://calendarserver.org/ns/", "getctag"); !ok || ctag != `"42"` {
+		t.Errorf("expected ctag, got %q", ctag)
+	}
+	if resourceType, _ := collection.Prop("DAV:", "resourcetype"); !strings.Contains(resourceType, "collection") {
+		t.Errorf("expected raw resourcetype XML, got %q", resourceType)
+	}
+	if _, ok := collection.Prop("DAV:", "getetag"); ok {
+		t.Errorf("expected properties with a 404 status to be missing")
+	}
+
+	failed := ms.Failed()
+	if len(failed) != 2 || failed[1].StatusCode() != http.StatusForbidden {
+		t.Errorf("expected both responses to report failures, got %+v", failed)
+	}
+
+	t.Run("not multistatus", func(t *testing.T) {
+		var ms MultiStatus
+		err := c.Do(ctx, "PROPFIND", server.URL+"/plain", nil, &ms, WithHeader("Depth", "1"))
+		if err == nil || !strings.Contains(err.Error(), "expected status 207") {
+			t.Errorf("expected status error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,468 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xmlrpcTimeFormat is the dateTime.iso8601 layout of the XML-RPC specification
+const xmlrpcTimeFormat = "20060102T15:04:05"
+
+// XMLRPCFault is the fault returned by an XML-RPC server instead of a result
+type XMLRPCFault struct {
+	Code   int
+	String string
+}
+
+func (f *XMLRPCFault) Error() string {
+	return fmt.Sprintf("XML-RPC fault %d: %s", f.Code, f.String)
+}
+
+// XMLRPC calls method with params on the XML-RPC server at url, e.g. WordPress or OpenSubtitles, and
+// decodes the result into result. Faults are returned as *XMLRPCFault.
+//
+// Go values map to XML-RPC types: integers to int, bools to boolean, strings, floats to double,
+// time.Time to dateTime.iso8601, []byte to base64, slices to array, and maps with string keys and
+// structs to struct. Struct members are named by an `xmlrpc:"name"` tag or the field name.
+func (c *Client) XMLRPC(ctx context.Context, url, method string, params []interface{}, result interface{}, opts ...Option) error {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0"?><methodCall><methodName>`)
+	_ = xml.EscapeText(&b, []byte(method))
+	b.WriteString(`</methodName><params>`)
+	for i, param := range params {
+		b.WriteString(`<param>`)
+		if err := encodeXMLRPC(&b, reflect.ValueOf(param)); err != nil {
+			return fmt.Errorf("failed to encode XML-RPC param %d: %w", i, err)
+		}
+		b.WriteString(`</param>`)
+	}
+	b.WriteString(`</params></methodCall>`)
+
+	opts = append(opts, WithHeader("Content-Type", "text/xml"))
+	return c.Post(ctx, url, b.Bytes(), &xmlrpcResult{result: result}, opts...)
+}
+
+func encodeXMLRPC(b *bytes.Buffer, v reflect.Value) error {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			b.WriteString(`<value><nil/></value>`)
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		b.WriteString(`<value><nil/></value>`)
+		return nil
+	}
+
+	b.WriteString(`<value>`)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fmt.Fprintf(b, `<int>%d</int>`, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fmt.Fprintf(b, `<int>%d</int>`, v.Uint())
+	case reflect.Bool:
+		if v.Bool() {
+			b.WriteString(`<boolean>1</boolean>`)
+		} else {
+			b.WriteString(`<boolean>0</boolean>`)
+		}
+	case reflect.Float32, reflect.Float64:
+		fmt.Fprintf(b, `<double>%s</double>`, strconv.FormatFloat(v.Float(), 'f', -1, 64))
+	case reflect.String:
+		b.WriteString(`<string>`)
+		_ = xml.EscapeText(b, []byte(v.String()))
+		b.WriteString(`</string>`)
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(data), v)
+			fmt.Fprintf(b, `<base64>%s</base64>`, base64.StdEncoding.EncodeToString(data))
+			break
+		}
+		b.WriteString(`<array><data>`)
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeXMLRPC(b, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		b.WriteString(`</data></array>`)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type %s", v.Type().Key())
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		b.WriteString(`<struct>`)
+		for _, key := range keys {
+			if err := encodeXMLRPCMember(b, key.String(), v.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+		b.WriteString(`</struct>`)
+	case reflect.Struct:
+		if t, ok := v.Interface().(time.Time); ok {
+			fmt.Fprintf(b, `<dateTime.iso8601>%s</dateTime.iso8601>`, t.Format(xmlrpcTimeFormat))
+			break
+		}
+		b.WriteString(`<struct>`)
+		for i := 0; i < v.NumField(); i++ {
+			name, ok := xmlrpcFieldName(v.Type().Field(i))
+			if !ok {
+				continue
+			}
+			if err := encodeXMLRPCMember(b, name, v.Field(i)); err != nil {
+				return err
+			}
+		}
+		b.WriteString(`</struct>`)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	b.WriteString(`</value>`)
+	return nil
+}
+
+func encodeXMLRPCMember(b *bytes.Buffer, name string, v reflect.Value) error {
+	b.WriteString(`<member><name>`)
+	_ = xml.EscapeText(b, []byte(name))
+	b.WriteString(`</name>`)
+	if err := encodeXMLRPC(b, v); err != nil {
+		return err
+	}
+	b.WriteString(`</member>`)
+	return nil
+}
+
+// xmlrpcFieldName returns the member name of an exported field, from its xmlrpc tag or its name
+func xmlrpcFieldName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" {
+		return "", false
+	}
+	tag := field.Tag.Get("xmlrpc")
+	if tag == "-" {
+		return "", false
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, true
+	}
+	return field.Name, true
+}
+
+// xmlrpcResult decodes a methodResponse into result
+type xmlrpcResult struct {
+	result interface{}
+}
+
+func (r *xmlrpcResult) DecodeResponse(resp *http.Response) error {
+	dec := xml.NewDecoder(resp.Body)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to parse XML-RPC response: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "fault":
+			value, err := nextXMLRPCValue(dec)
+			if err != nil {
+				return err
+			}
+			fault := &XMLRPCFault{}
+			if members, ok := value.(map[string]interface{}); ok {
+				code, _ := members["faultCode"].(int64)
+				fault.Code = int(code)
+				fault.String, _ = members["faultString"].(string)
+			}
+			return fault
+		case "param":
+			value, err := nextXMLRPCValue(dec)
+			if err != nil {
+				return err
+			}
+			if r.result == nil {
+				return nil
+			}
+			target := reflect.ValueOf(r.result)
+			if target.Kind() != reflect.Pointer || target.IsNil() {
+				return errors.New("XML-RPC result must be a non-nil pointer")
+			}
+			return assignXMLRPC(target.Elem(), value)
+		}
+	}
+}
+
+// nextXMLRPCValue decodes the next value element into nil, int64, bool, string, float64, time.Time,
+// []byte, []interface{} or map[string]interface{}
+func nextXMLRPCValue(dec *xml.Decoder) (interface{}, error) {
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML-RPC value: %w", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == "value" {
+			return decodeXMLRPCValue(dec)
+		}
+	}
+}
+
+// decodeXMLRPCValue decodes the content of a value element whose start tag was consumed
+func decodeXMLRPCValue(dec *xml.Decoder) (interface{}, error) {
+	var text strings.Builder
+	var value interface{}
+	typed := false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML-RPC value: %w", err)
+		}
+		switch tok := tok.(type) {
+		case xml.CharData:
+			text.Write(tok)
+		case xml.EndElement:
+			// A value without a type element is a string
+			if !typed {
+				return text.String(), nil
+			}
+			return value, nil
+		case xml.StartElement:
+			typed = true
+			if value, err = decodeXMLRPCType(dec, tok); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func decodeXMLRPCType(dec *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "struct":
+		members := map[string]interface{}{}
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse XML-RPC struct: %w", err)
+			}
+			if _, ok := tok.(xml.EndElement); ok {
+				return members, nil
+			}
+			if member, ok := tok.(xml.StartElement); ok && member.Name.Local == "member" {
+				name, value, err := decodeXMLRPCMember(dec)
+				if err != nil {
+					return nil, err
+				}
+				members[name] = value
+			}
+		}
+	case "array":
+		values := []interface{}{}
+		depth := 1
+		for depth > 0 {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse XML-RPC array: %w", err)
+			}
+			switch tok := tok.(type) {
+			case xml.StartElement:
+				if tok.Name.Local == "value" {
+					value, err := decodeXMLRPCValue(dec)
+					if err != nil {
+						return nil, err
+					}
+					values = append(values, value)
+				} else {
+					depth++
+				}
+			case xml.EndElement:
+				depth--
+			}
+		}
+		return values, nil
+	case "nil":
+		return nil, dec.Skip()
+	}
+
+	var text string
+	if err := dec.DecodeElement(&text, &start); err != nil {
+		return nil, fmt.Errorf("failed to parse XML-RPC %s: %w", start.Name.Local, err)
+	}
+	text = strings.TrimSpace(text)
+	switch start.Name.Local {
+	case "int", "i4", "i8":
+		return strconv.ParseInt(text, 10, 64)
+	case "boolean":
+		return text == "1", nil
+	case "double":
+		return strconv.ParseFloat(text, 64)
+	case "dateTime.iso8601":
+		for _, layout := range []string{xmlrpcTimeFormat, "2006-01-02T15:04:05Z07:00", "20060102T15:04:05Z07:00", "2006-01-02T15:04:05"} {
+			if t, err := time.Parse(layout, text); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("failed to parse XML-RPC dateTime %q", text)
+	case "base64":
+		return base64.StdEncoding.DecodeString(text)
+	default:
+		return text, nil
+	}
+}
+
+// decodeXMLRPCMember decodes the name and value of a member element whose start tag was consumed
+func decodeXMLRPCMember(dec *xml.Decoder) (string, interface{}, error) {
+	var name string
+	var value interface{}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to parse XML-RPC member: %w", err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			switch tok.Name.Local {
+			case "name":
+				err = dec.DecodeElement(&name, &tok)
+			case "value":
+				value, err = decodeXMLRPCValue(dec)
+			default:
+				err = dec.Skip()
+			}
+			if err != nil {
+				return "", nil, err
+			}
+		case xml.EndElement:
+			return name, value, nil
+		}
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// assignXMLRPC stores a decoded value into target
+func assignXMLRPC(target reflect.Value, value interface{}) error {
+	if value == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+	if target.Kind() == reflect.Pointer {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		return assignXMLRPC(target.Elem(), value)
+	}
+	if target.Kind() == reflect.Interface && target.NumMethod() == 0 {
+		target.Set(reflect.ValueOf(value))
+		return nil
+	}
+
+	mismatch := fmt.Errorf("cannot decode XML-RPC %T into %s", value, target.Type())
+	switch v := value.(type) {
+	case int64:
+		switch target.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			target.SetInt(v)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			target.SetUint(uint64(v))
+		case reflect.Float32, reflect.Float64:
+			target.SetFloat(float64(v))
+		default:
+			return mismatch
+		}
+	case float64:
+		if target.Kind() != reflect.Float32 && target.Kind() != reflect.Float64 {
+			return mismatch
+		}
+		target.SetFloat(v)
+	case bool:
+		if target.Kind() != reflect.Bool {
+			return mismatch
+		}
+		target.SetBool(v)
+	case string:
+		if target.Kind() != reflect.String {
+			return mismatch
+		}
+		target.SetString(v)
+	case time.Time:
+		if target.Type() != timeType {
+			return mismatch
+		}
+		target.Set(reflect.ValueOf(v))
+	case []byte:
+		if target.Kind() != reflect.Slice || target.Type().Elem().Kind() != reflect.Uint8 {
+			return mismatch
+		}
+		target.SetBytes(v)
+	case []interface{}:
+		if target.Kind() != reflect.Slice {
+			return mismatch
+		}
+		slice := reflect.MakeSlice(target.Type(), len(v), len(v))
+		for i, item := range v {
+			if err := assignXMLRPC(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		target.Set(slice)
+	case map[string]interface{}:
+		return assignXMLRPCStruct(target, v, mismatch)
+	}
+	return nil
+}
+
+func assignXMLRPCStruct(target reflect.Value, members map[string]interface{}, mismatch error) error {
+	switch target.Kind() {
+	case reflect.Map:
+		if target.Type().Key().Kind() != reflect.String {
+			return mismatch
+		}
+		if target.IsNil() {
+			target.Set(reflect.MakeMap(target.Type()))
+		}
+		for name, member := range members {
+			elem := reflect.New(target.Type().Elem()).Elem()
+			if err := assignXMLRPC(elem, member); err != nil {
+				return err
+			}
+			target.SetMapIndex(reflect.ValueOf(name).Convert(target.Type().Key()), elem)
+		}
+		return nil
+	case reflect.Struct:
+		for i := 0; i < target.NumField(); i++ {
+			name, ok := xmlrpcFieldName(target.Type().Field(i))
+			if !ok {
+				continue
+			}
+			member, found := members[name]
+			if !found {
+				for key, value := range members {
+					if strings.EqualFold(key, name) {
+						member, found = value, true
+						break
+					}
+				}
+			}
+			if !found {
+				continue
+			}
+			if err := assignXMLRPC(target.Field(i), member); err != nil {
+				return fmt.Errorf("failed to decode member %s: %w", name, err)
+			}
+		}
+		return nil
+	}
+	return mismatch
+}
@@ -0,0 +1,114 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_XMLRPC(t *testing.T) {
+	const response = `<?xml version="1.0"?>
+<methodResponse>
+  <params>
+    <param>
+      <value><struct>
+        <member><name>name</name><value><string>Pikachu &amp; friends</string></value></member>
+        <member><name>id</name><value><i4>25</i4></value></member>
+        <member><name>weight</name><value><double>6.5</double></value></member>
+        <member><name>legendary</name><value><boolean>0</boolean></value></member>
+        <member><name>caught</name><value><dateTime.iso8601>19960227T10:30:00</dateTime.iso8601></value></member>
+        <member><name>sprite</name><value><base64>iVBORw==</base64></value></member>
+        <member><name>types</name><value><array><data><value>electric</value></data></array></value></member>
+      </struct></value>
+    </param>
+  </params>
+</methodResponse>`
+
+	var request string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request = string(body)
+		if r.Header.Get("Content-Type") != "text/xml" {
+			t.Errorf("expected text/xml, got %s", r.Header.Get("Content-Type"))
+		}
+		if strings.Contains(request, "pokedex.release") {
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><methodResponse><fault><value><struct>
+				<member><name>faultCode</name><value><int>403</int></value></member>
+				<member><name>faultString</name><value><string>Cannot release legendary</string></value></member>
+			</struct></value></fault></methodResponse>`))
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client := &Client{}
+	ctx := context.Background()
+
+	t.Run("call", func(t *testing.T) {
+		type filter struct {
+			Region string `xmlrpc:"region"`
+			Limit  int
+			secret string
+		}
+		var result struct {
+			Name      string    `xmlrpc:"name"`
+			ID        int       `xmlrpc:"id"`
+			Weight    float64   `xmlrpc:"weight"`
+			Legendary bool      `xmlrpc:"legendary"`
+			Caught    time.Time `xmlrpc:"caught"`
+			Sprite    []byte    `xmlrpc:"sprite"`
+			Types     []string
+		}
+		params := []interface{}{25, "pikachu", true, []byte{1, 2}, filter{Region: "kanto", Limit: 1}}
+		if err := client.XMLRPC(ctx, server.URL, "pokedex.get", params, &result); err != nil {
+			t.Fatalf("XML-RPC call failed: %v", err)
+		}
+
+		wantRequest := `<?xml version="1.0"?><methodCall><methodName>pokedex.get</methodName><params>` +
+			`<param><value><int>25</int></value></param>` +
+			`<param><value><string>pikachu</string></value></param>` +
+			`<param><value><boolean>1</boolean></value></param>` +
+			`<param><value><base64>AQI=</base64></value></param>` +
+			`<param><value><struct><member><name>region</name><value><string>kanto</string></value></member>` +
+			`<member><name>Limit</name><value><int>1</int></value></member></struct></value></param>` +
+			`</params></methodCall>`
+		if request != wantRequest {
+			t.Errorf("expected request %s, got %s", wantRequest, request)
+		}
+
+		if result.Name != "Pikachu & friends" || result.ID != 25 || result.Weight != 6.5 || result.Legendary {
+			t.Errorf("unexpected scalars %+v", result)
+		}
+		if !result.Caught.Equal(time.Date(1996, 2, 27, 10, 30, 0, 0, time.UTC)) || string(result.Sprite) != "\x89PNG" {
+			t.Errorf("unexpected dateTime %v or base64 %q", result.Caught, result.Sprite)
+		}
+		if !reflect.DeepEqual(result.Types, []string{"electric"}) {
+			t.Errorf("expected [electric], got %v", result.Types)
+		}
+	})
+
+	t.Run("generic result", func(t *testing.T) {
+		var result map[string]interface{}
+		if err := client.XMLRPC(ctx, server.URL, "pokedex.get", nil, &result); err != nil {
+			t.Fatalf("XML-RPC call failed: %v", err)
+		}
+		if result["id"] != int64(25) || !reflect.DeepEqual(result["types"], []interface{}{"electric"}) {
+			t.Errorf("unexpected result %v", result)
+		}
+	})
+
+	t.Run("fault", func(t *testing.T) {
+		err := client.XMLRPC(ctx, server.URL, "pokedex.release", []interface{}{150}, nil)
+		var fault *XMLRPCFault
+		if !errors.As(err, &fault) || fault.Code != 403 || fault.String != "Cannot release legendary" {
+			t.Errorf("expected fault 403, got %v", err)
+		}
+	})
+}